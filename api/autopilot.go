@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/hostdb"
@@ -18,21 +19,72 @@ const (
 	DefaultAutopilotID = "autopilot"
 )
 
+const (
+	// AutopilotPhaseIdle indicates the autopilot isn't performing any
+	// maintenance.
+	AutopilotPhaseIdle = "idle"
+
+	// AutopilotPhaseForming indicates the autopilot is forming contracts.
+	AutopilotPhaseForming = "forming"
+
+	// AutopilotPhaseRenewing indicates the autopilot is renewing or
+	// refreshing contracts.
+	AutopilotPhaseRenewing = "renewing"
+
+	// AutopilotPhaseRepairing indicates the autopilot is migrating slabs.
+	AutopilotPhaseRepairing = "repairing"
+)
+
+const (
+	// ContractSetDiffReasonRenewed indicates a host was retained in the
+	// contract set and had its contract renewed in between the diffed periods.
+	ContractSetDiffReasonRenewed = "renewed"
+)
+
 var (
 	// ErrAutopilotNotFound is returned when an autopilot can't be found.
 	ErrAutopilotNotFound = errors.New("couldn't find autopilot")
 
+	// ErrInvalidAutopilotID is returned when an autopilot is updated with an
+	// identifier that is empty, too long or contains characters other than
+	// alphanumerics, dashes and underscores.
+	ErrInvalidAutopilotID = errors.New("invalid autopilot ID")
+
 	// ErrMaxDowntimeHoursTooHigh is returned if the autopilot config is updated
 	// with a value that exceeds the maximum of 99 years.
 	ErrMaxDowntimeHoursTooHigh = errors.New("MaxDowntimeHours is too high, exceeds max value of 99 years")
+
+	// ErrMinDurationTooHigh is returned if the autopilot config is updated
+	// with a minimum contract duration that exceeds the period plus the renew
+	// window, the maximum duration of a contract formed by the autopilot.
+	ErrMinDurationTooHigh = errors.New("MinDuration is too high, exceeds the period plus the renew window")
+
+	// ErrIncumbentBonusTooLow is returned if the autopilot config is updated
+	// with an incumbent bonus that would penalize incumbent hosts.
+	ErrIncumbentBonusTooLow = errors.New("IncumbentBonus is too low, must be either 0 or at least 1")
+
+	// ErrInvalidPeriodRange is returned when diffing a contract set between a
+	// period that comes after the period it is diffed against.
+	ErrInvalidPeriodRange = errors.New("fromPeriod can't be greater than toPeriod")
 )
 
 type (
-	// Autopilot contains the autopilot's config and current period.
+	// Autopilot contains the autopilot's config and current period. A
+	// disabled autopilot keeps its config and period but doesn't perform any
+	// maintenance. Autopilots are enabled unless explicitly disabled.
 	Autopilot struct {
 		ID            string          `json:"id"`
 		Config        AutopilotConfig `json:"config"`
 		CurrentPeriod uint64          `json:"currentPeriod"`
+		Disabled      bool            `json:"disabled,omitempty"`
+	}
+
+	// AutopilotConfigChange contains an autopilot config and the period and
+	// time at which it took effect.
+	AutopilotConfigChange struct {
+		Config    AutopilotConfig `json:"config"`
+		Period    uint64          `json:"period"`
+		Timestamp time.Time       `json:"timestamp"`
 	}
 
 	// AutopilotConfig contains all autopilot configuration.
@@ -52,6 +104,16 @@ type (
 		Upload      uint64         `json:"upload"`
 		Storage     uint64         `json:"storage"`
 		Prune       bool           `json:"prune"`
+
+		// AlignEndHeight rounds the end height of formed and renewed
+		// contracts up to the next multiple of the period, causing contracts
+		// to expire, and thus be renewed, together.
+		AlignEndHeight bool `json:"alignEndHeight"`
+
+		// MinDuration is the minimum duration, in blocks, of formed
+		// contracts. Contracts that would be shorter are extended and hosts
+		// that don't allow contracts of this duration are not used.
+		MinDuration uint64 `json:"minDuration"`
 	}
 
 	// HostsConfig contains all hosts settings used in the autopilot.
@@ -60,6 +122,15 @@ type (
 		MaxDowntimeHours      uint64                      `json:"maxDowntimeHours"`
 		MinRecentScanFailures uint64                      `json:"minRecentScanFailures"`
 		ScoreOverrides        map[types.PublicKey]float64 `json:"scoreOverrides"`
+
+		// IncumbentBonus is applied to the score of hosts we already have
+		// contracts with when deciding whether to keep and renew those
+		// contracts, e.g. 1.1 gives them a 10% edge over newcomers. This
+		// avoids churning through hosts over marginal score differences. A
+		// value of 0 or 1 disables the bonus. The bonus can only lower the
+		// minimum score incumbents need to reach, it never raises it and it
+		// isn't reflected in the host's reported score.
+		IncumbentBonus float64 `json:"incumbentBonus"`
 	}
 )
 
@@ -79,19 +150,37 @@ type (
 	// AutopilotStateResponse is the response type for the /autopilot/state
 	// endpoint.
 	AutopilotStateResponse struct {
-		Configured         bool        `json:"configured"`
-		Migrating          bool        `json:"migrating"`
-		MigratingLastStart TimeRFC3339 `json:"migratingLastStart"`
-		Pruning            bool        `json:"pruning"`
-		PruningLastStart   TimeRFC3339 `json:"pruningLastStart"`
-		Scanning           bool        `json:"scanning"`
-		ScanningLastStart  TimeRFC3339 `json:"scanningLastStart"`
-		UptimeMS           DurationMS  `json:"uptimeMs"`
+		Configured           bool        `json:"configured"`
+		Migrating            bool        `json:"migrating"`
+		MigratingLastStart   TimeRFC3339 `json:"migratingLastStart"`
+		MigrationsInProgress uint64      `json:"migrationsInProgress"`
+		FormationsInProgress uint64      `json:"formationsInProgress"`
+		FormationsRemaining  uint64      `json:"formationsRemaining"`
+		Pruning              bool        `json:"pruning"`
+		PruningLastStart     TimeRFC3339 `json:"pruningLastStart"`
+		Scanning             bool        `json:"scanning"`
+		ScanningLastStart    TimeRFC3339 `json:"scanningLastStart"`
+		UptimeMS             DurationMS  `json:"uptimeMs"`
+
+		// Phase is the current phase of the autopilot, LastRun and NextRun
+		// are the times the last maintenance run completed and the next one
+		// is scheduled.
+		Phase          string              `json:"phase"`
+		LastRun        TimeRFC3339         `json:"lastRun"`
+		LastRunSummary AutopilotRunSummary `json:"lastRunSummary"`
+		NextRun        TimeRFC3339         `json:"nextRun"`
 
 		StartTime TimeRFC3339 `json:"startTime"`
 		BuildState
 	}
 
+	// AutopilotRunSummary summarizes a maintenance run of the autopilot.
+	AutopilotRunSummary struct {
+		Duration           DurationMS `json:"duration"`
+		ContractSetChanged bool       `json:"contractSetChanged"`
+		Error              string     `json:"error,omitempty"`
+	}
+
 	ConfigEvaluationRequest struct {
 		AutopilotConfig    AutopilotConfig    `json:"autopilotConfig"`
 		GougingSettings    GougingSettings    `json:"gougingSettings"`
@@ -117,15 +206,58 @@ type (
 			} `json:"gouging"`
 			NotAcceptingContracts uint64 `json:"notAcceptingContracts"`
 			NotScanned            uint64 `json:"notScanned"`
+			LowMaxDuration        uint64 `json:"lowMaxDuration"`
 			Unknown               uint64 `json:"unknown"`
 		}
 		Recommendation *ConfigRecommendation `json:"recommendation,omitempty"`
 	}
 
+	// AtRiskHost is a host with a contract that is up for renewal but which
+	// won't be renewed by the autopilot, its data has to be migrated.
+	AtRiskHost struct {
+		HostKey    types.PublicKey      `json:"hostKey"`
+		ContractID types.FileContractID `json:"contractID"`
+		EndHeight  uint64               `json:"endHeight"`
+		Reasons    []string             `json:"reasons"`
+	}
+
+	// ContractSetDiffHost is a host that was added to, removed from or retained
+	// in a contract set between two periods.
+	ContractSetDiffHost struct {
+		HostKey types.PublicKey `json:"hostKey"`
+		Reason  string          `json:"reason,omitempty"`
+	}
+
+	// ContractSetDiffResponse is the response type for the
+	// /contractset/:set/diff endpoint. It describes how the hosts in a contract
+	// set changed between the end of two periods.
+	ContractSetDiffResponse struct {
+		Set        string                `json:"set"`
+		FromPeriod uint64                `json:"fromPeriod"`
+		ToPeriod   uint64                `json:"toPeriod"`
+		Added      []ContractSetDiffHost `json:"added"`
+		Removed    []ContractSetDiffHost `json:"removed"`
+		Retained   []ContractSetDiffHost `json:"retained"`
+	}
+
+	// AutopilotDryRunResponse is the response type for the /dryrun endpoint.
+	// It describes the actions the autopilot would take in its next iteration
+	// without actually performing them.
+	AutopilotDryRunResponse struct {
+		ToArchive   map[types.FileContractID]string `json:"toArchive"`
+		ToStopUsing map[types.FileContractID]string `json:"toStopUsing"`
+		ToRefresh   []types.FileContractID          `json:"toRefresh"`
+		ToRenew     []types.FileContractID          `json:"toRenew"`
+		ToForm      uint64                          `json:"toForm"`
+		Candidates  []types.PublicKey               `json:"candidates"`
+		ToMigrate   []UnhealthySlab                 `json:"toMigrate"`
+	}
+
 	// HostHandlerResponse is the response type for the /host/:hostkey endpoint.
 	HostHandlerResponse struct {
 		Host   hostdb.Host                `json:"host"`
 		Checks *HostHandlerResponseChecks `json:"checks,omitempty"`
+		RTT    *hostdb.RTTPercentiles     `json:"rtt,omitempty"`
 	}
 
 	HostHandlerResponseChecks struct {
@@ -145,6 +277,26 @@ type (
 		UploadErr   string `json:"uploadErr"`
 	}
 
+	// GougingReason explains why a host is considered to be gouging. If one
+	// of the host's prices exceeds one of the configured gouging limits, the
+	// reason contains the setting, the price and by what factor it exceeds
+	// the limit.
+	GougingReason struct {
+		Message string         `json:"message"`
+		Setting string         `json:"setting,omitempty"`
+		Price   types.Currency `json:"price"`
+		Limit   types.Currency `json:"limit"`
+		Factor  float64        `json:"factor,omitempty"`
+	}
+
+	// HostGougingResponse is the response type for the /host/:hostkey/gouging
+	// endpoint.
+	HostGougingResponse struct {
+		Gouging   bool                 `json:"gouging"`
+		Breakdown HostGougingBreakdown `json:"breakdown"`
+		Reasons   []GougingReason      `json:"reasons"`
+	}
+
 	HostScoreBreakdown struct {
 		Age              float64 `json:"age"`
 		Collateral       float64 `json:"collateral"`
@@ -160,6 +312,13 @@ func (sb HostScoreBreakdown) String() string {
 	return fmt.Sprintf("Age: %v, Col: %v, Int: %v, SR: %v, UT: %v, V: %v, Pr: %v", sb.Age, sb.Collateral, sb.Interactions, sb.StorageRemaining, sb.Uptime, sb.Version, sb.Prices)
 }
 
+func (gr GougingReason) String() string {
+	if gr.Setting == "" {
+		return gr.Message
+	}
+	return fmt.Sprintf("%s: %v is %.2fx the limit of %v", gr.Setting, gr.Price, gr.Factor, gr.Limit)
+}
+
 func (hgb HostGougingBreakdown) Gouging() bool {
 	for _, err := range []string{
 		hgb.ContractErr,
@@ -199,5 +358,11 @@ func (c AutopilotConfig) Validate() error {
 	if c.Hosts.MaxDowntimeHours > 99*365*24 {
 		return ErrMaxDowntimeHoursTooHigh
 	}
+	if c.Contracts.MinDuration > c.Contracts.Period+c.Contracts.RenewWindow {
+		return ErrMinDurationTooHigh
+	}
+	if c.Hosts.IncumbentBonus != 0 && c.Hosts.IncumbentBonus < 1 {
+		return ErrIncumbentBonusTooLow
+	}
 	return nil
 }
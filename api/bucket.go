@@ -2,6 +2,7 @@ package api
 
 import (
 	"errors"
+	"fmt"
 )
 
 const (
@@ -31,6 +32,11 @@ type (
 
 	BucketPolicy struct {
 		PublicReadAccess bool `json:"publicReadAccess"`
+
+		// Redundancy and Gouging override the global settings for uploads
+		// into the bucket, if set.
+		Redundancy *RedundancySettings `json:"redundancy,omitempty"`
+		Gouging    *GougingSettings    `json:"gouging,omitempty"`
 	}
 
 	CreateBucketOptions struct {
@@ -48,3 +54,31 @@ type (
 		Policy BucketPolicy `json:"policy"`
 	}
 )
+
+// Validate returns an error if the policy's overrides are not considered valid.
+func (p BucketPolicy) Validate() error {
+	if p.Redundancy != nil {
+		if err := p.Redundancy.Validate(); err != nil {
+			return fmt.Errorf("invalid redundancy settings: %w", err)
+		}
+	}
+	if p.Gouging != nil {
+		if err := p.Gouging.Validate(); err != nil {
+			return fmt.Errorf("invalid gouging settings: %w", err)
+		}
+	}
+	return nil
+}
+
+// ApplyBucketPolicy overrides the redundancy and gouging settings of the upload
+// params with the ones set on the given bucket policy, falling back to the
+// global settings for the ones it doesn't override.
+func (up UploadParams) ApplyBucketPolicy(p BucketPolicy) UploadParams {
+	if p.Redundancy != nil {
+		up.RedundancySettings = *p.Redundancy
+	}
+	if p.Gouging != nil {
+		up.GougingSettings = *p.Gouging
+	}
+	return up
+}
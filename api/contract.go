@@ -18,6 +18,7 @@ const (
 
 const (
 	ContractArchivalReasonHostPruned = "hostpruned"
+	ContractArchivalReasonRefreshed  = "refreshed"
 	ContractArchivalReasonRemoved    = "removed"
 	ContractArchivalReasonRenewed    = "renewed"
 )
@@ -98,10 +99,12 @@ type (
 	// An ArchivedContract contains all information about a contract with a host
 	// that has been moved to the archive either due to expiring or being renewed.
 	ArchivedContract struct {
-		ID        types.FileContractID `json:"id"`
-		HostKey   types.PublicKey      `json:"hostKey"`
-		RenewedTo types.FileContractID `json:"renewedTo"`
-		Spending  ContractSpending     `json:"spending"`
+		ID          types.FileContractID `json:"id"`
+		HostKey     types.PublicKey      `json:"hostKey"`
+		Reason      string               `json:"reason"`
+		RenewedFrom types.FileContractID `json:"renewedFrom"`
+		RenewedTo   types.FileContractID `json:"renewedTo"`
+		Spending    ContractSpending     `json:"spending"`
 
 		ProofHeight    uint64 `json:"proofHeight"`
 		RevisionHeight uint64 `json:"revisionHeight"`
@@ -182,6 +185,13 @@ type (
 	ContractsOpts struct {
 		ContractSet string `json:"contractset"`
 	}
+
+	// ArchivedContractsOpts filters the archived contracts, zero values
+	// match all contracts.
+	ArchivedContractsOpts struct {
+		HostKey types.PublicKey `json:"hostKey"`
+		Reason  string          `json:"reason"`
+	}
 )
 
 // Add returns the sum of the current and given contract spending.
@@ -17,12 +17,30 @@ const (
 	UsabilityFilterModeAll      = "all"
 	UsabilityFilterModeUsable   = "usable"
 	UsabilityFilterModeUnusable = "unusable"
+
+	HostSortByCollateral             = "collateral"
+	HostSortByContractPrice          = "contractPrice"
+	HostSortByDownloadBandwidthPrice = "downloadBandwidthPrice"
+	HostSortByLastScan               = "lastScan"
+	HostSortByLastScanLatency        = "lastScanLatency"
+	HostSortByLastSeen               = "lastSeen"
+	HostSortByPrice                  = "price"
+	HostSortByRemainingStorage       = "remainingStorage"
+	HostSortByScore                  = "score"
+	HostSortBySuccessRatio           = "successRatio"
+	HostSortByUploadBandwidthPrice   = "uploadBandwidthPrice"
+	HostSortByUptime                 = "uptime"
+	HostSortByVersion                = "version"
 )
 
 var (
 	// ErrHostNotFound is returned when a host can't be retrieved from the
 	// database.
 	ErrHostNotFound = errors.New("host doesn't exist in hostdb")
+
+	// ErrInvalidHostSortField is returned when hosts are sorted by a field
+	// that isn't supported.
+	ErrInvalidHostSortField = errors.New("invalid host sort field")
 )
 
 type (
@@ -36,12 +54,35 @@ type (
 		PriceTableUpdates []hostdb.PriceTableUpdate `json:"priceTableUpdates"`
 	}
 
+	// HostsInteractionsRequest is the request type for the /hosts/interactions
+	// endpoint.
+	HostsInteractionsRequest struct {
+		Interactions []hostdb.Interaction `json:"interactions"`
+	}
+
+	// HostsScoresRequest is the request type for the /hosts/scores endpoint.
+	HostsScoresRequest struct {
+		Scores map[types.PublicKey]float64 `json:"scores"`
+	}
+
+	// HostsPruneRequest is the request type for the /hosts/prune endpoint.
+	HostsPruneRequest struct {
+		NotSeenSince TimeRFC3339 `json:"notSeenSince"`
+	}
+
 	// HostsRemoveRequest is the request type for the /hosts/remove endpoint.
 	HostsRemoveRequest struct {
 		MaxDowntimeHours      DurationH `json:"maxDowntimeHours"`
 		MinRecentScanFailures uint64    `json:"minRecentScanFailures"`
 	}
 
+	// HostsSortedResponse is the response type for the /hosts/sorted
+	// endpoint.
+	HostsSortedResponse struct {
+		Hosts []hostdb.Host `json:"hosts"`
+		Total int64         `json:"total"`
+	}
+
 	SearchHostsRequest struct {
 		Offset          int               `json:"offset"`
 		Limit           int               `json:"limit"`
@@ -49,6 +90,24 @@ type (
 		UsabilityMode   string            `json:"usabilityMode"`
 		AddressContains string            `json:"addressContains"`
 		KeyIn           []types.PublicKey `json:"keyIn"`
+
+		// AcceptingContracts limits the search to hosts that were accepting
+		// contracts when they were last scanned successfully.
+		AcceptingContracts bool `json:"acceptingContracts"`
+
+		// MinSuccessRatio limits the search to hosts whose ratio of
+		// successful interactions is at least the given value.
+		MinSuccessRatio float64 `json:"minSuccessRatio"`
+
+		// MinRemainingStorage limits the search to hosts that reported at
+		// least the given amount of remaining storage, in bytes, when they
+		// were last scanned successfully.
+		MinRemainingStorage uint64 `json:"minRemainingStorage"`
+
+		// MaxStoragePrice limits the search to hosts whose storage price
+		// was at most the given value when they were last scanned
+		// successfully, a zero value disables the filter.
+		MaxStoragePrice types.Currency `json:"maxStoragePrice"`
 	}
 )
 
@@ -60,6 +119,15 @@ type (
 		Clear  bool              `json:"clear"`
 	}
 
+	// HostBlocklistEntry is a blocklist entry alongside its expiry. Entries
+	// without an expiry never lapse, for all other entries Remaining holds the
+	// time left until they do.
+	HostBlocklistEntry struct {
+		Entry     string      `json:"entry"`
+		ExpiresAt TimeRFC3339 `json:"expiresAt"`
+		Remaining DurationMS  `json:"remaining"`
+	}
+
 	// UpdateBlocklistRequest is the request type for /hosts/blocklist endpoint.
 	UpdateBlocklistRequest struct {
 		Add    []string `json:"add"`
@@ -74,17 +142,28 @@ type (
 		Offset int
 		Limit  int
 	}
+	HostsSortedOptions struct {
+		SortBy    string
+		Ascending bool
+		MinScore  float64
+		Offset    int
+		Limit     int
+	}
 	HostsForScanningOptions struct {
 		MaxLastScan TimeRFC3339
 		Limit       int
 		Offset      int
 	}
 	SearchHostOptions struct {
-		AddressContains string
-		FilterMode      string
-		KeyIn           []types.PublicKey
-		Limit           int
-		Offset          int
+		AcceptingContracts  bool
+		AddressContains     string
+		FilterMode          string
+		KeyIn               []types.PublicKey
+		Limit               int
+		MaxStoragePrice     types.Currency
+		MinRemainingStorage uint64
+		MinSuccessRatio     float64
+		Offset              int
 	}
 )
 
@@ -104,6 +183,22 @@ func (opts GetHostsOptions) Apply(values url.Values) {
 	}
 }
 
+func (opts HostsSortedOptions) Apply(values url.Values) {
+	values.Set("sortBy", opts.SortBy)
+	if opts.Ascending {
+		values.Set("asc", "true")
+	}
+	if opts.MinScore != 0 {
+		values.Set("minScore", fmt.Sprint(opts.MinScore))
+	}
+	if opts.Offset != 0 {
+		values.Set("offset", fmt.Sprint(opts.Offset))
+	}
+	if opts.Limit != 0 {
+		values.Set("limit", fmt.Sprint(opts.Limit))
+	}
+}
+
 func (opts HostsForScanningOptions) Apply(values url.Values) {
 	if opts.Offset != 0 {
 		values.Set("offset", fmt.Sprint(opts.Offset))
@@ -18,6 +18,7 @@ const (
 	ChurnDirRemoved = "removed"
 
 	MetricContractPrune    = "contractprune"
+	MetricContractRenewal  = "contractrenewal"
 	MetricContractSet      = "contractset"
 	MetricContractSetChurn = "churn"
 	MetricContract         = "contract"
@@ -39,7 +40,9 @@ type (
 	ContractSetChurnMetric struct {
 		Direction  string               `json:"direction"`
 		ContractID types.FileContractID `json:"contractID"`
+		HostKey    types.PublicKey      `json:"hostKey"`
 		Name       string               `json:"name"`
+		Period     uint64               `json:"period"`
 		Reason     string               `json:"reason,omitempty"`
 		Timestamp  TimeRFC3339          `json:"timestamp"`
 	}
@@ -104,6 +107,24 @@ type (
 		HostVersion string
 	}
 
+	// ContractRenewalMetric is recorded whenever a contract is renewed, the
+	// kind is either ContractArchivalReasonRenewed or
+	// ContractArchivalReasonRefreshed depending on whether the renewal
+	// extended the contract or only added funds to it.
+	ContractRenewalMetric struct {
+		Timestamp TimeRFC3339 `json:"timestamp"`
+
+		ContractID  types.FileContractID `json:"contractID"`
+		RenewedFrom types.FileContractID `json:"renewedFrom"`
+		HostKey     types.PublicKey      `json:"hostKey"`
+		Kind        string               `json:"kind"`
+	}
+
+	ContractRenewalMetricsQueryOpts struct {
+		HostKey types.PublicKey
+		Kind    string
+	}
+
 	WalletMetric struct {
 		Timestamp TimeRFC3339 `json:"timestamp"`
 
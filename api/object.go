@@ -25,6 +25,22 @@ const (
 
 	ObjectSortDirAsc  = "asc"
 	ObjectSortDirDesc = "desc"
+
+	// ObjectsHealthSafeThreshold is the health below which an object is
+	// considered at risk, it matches the default migration health cutoff.
+	ObjectsHealthSafeThreshold = 0.75
+
+	// UploadModeOverwrite replaces an existing object at the upload's path,
+	// it's the default mode.
+	UploadModeOverwrite = "overwrite"
+
+	// UploadModeFailIfExists fails the upload with ErrObjectExists if an
+	// object already exists at the upload's path.
+	UploadModeFailIfExists = "failifexists"
+
+	// UploadModeSkipIfExists leaves an existing object at the upload's path
+	// untouched and reports the upload as successful.
+	UploadModeSkipIfExists = "skipifexists"
 )
 
 var (
@@ -44,9 +60,17 @@ var (
 	// were provided
 	ErrInvalidObjectSortParameters = errors.New("invalid sort parameters")
 
+	// ErrInvalidUploadMode is returned when an unknown upload mode was
+	// provided.
+	ErrInvalidUploadMode = errors.New("invalid upload mode")
+
 	// ErrSlabNotFound is returned when a slab can't be retrieved from the
 	// database.
 	ErrSlabNotFound = errors.New("slab not found")
+
+	// ErrIncompatibleSlab is returned when the slices of a slab can't be
+	// repointed to another slab.
+	ErrIncompatibleSlab = errors.New("incompatible slab")
 )
 
 type (
@@ -115,6 +139,38 @@ type (
 		Objects    []ObjectMetadata `json:"objects"`
 	}
 
+	// ObjectsMoveRequest is the request type for the /bus/objects/move endpoint.
+	ObjectsMoveRequest struct {
+		SourceBucket string `json:"sourceBucket"`
+		SourcePath   string `json:"sourcePath"`
+
+		DestinationBucket string `json:"destinationBucket"`
+		DestinationPath   string `json:"destinationPath"`
+
+		Overwrite bool `json:"overwrite"`
+	}
+
+	// ObjectsRemoveRequest is the request type for the /bus/objects/remove
+	// endpoint.
+	ObjectsRemoveRequest struct {
+		Bucket string `json:"bucket"`
+		Prefix string `json:"prefix"`
+	}
+
+	// ObjectsRemoveResponse is the response type for the /bus/objects/remove
+	// endpoint. Objects that couldn't be removed are reported individually,
+	// all other objects with the given prefix were removed.
+	ObjectsRemoveResponse struct {
+		Removed int64                 `json:"removed"`
+		Failed  []ObjectRemoveFailure `json:"failed,omitempty"`
+	}
+
+	// ObjectRemoveFailure describes why an object couldn't be removed.
+	ObjectRemoveFailure struct {
+		Path  string `json:"path"`
+		Error string `json:"error"`
+	}
+
 	// ObjectsRenameRequest is the request type for the /bus/objects/rename endpoint.
 	ObjectsRenameRequest struct {
 		Bucket string `json:"bucket"`
@@ -124,6 +180,22 @@ type (
 		Mode   string `json:"mode"`
 	}
 
+	// ObjectHealth contains the health of an object, which is the minimum
+	// health of its slabs.
+	ObjectHealth struct {
+		Bucket string  `json:"bucket"`
+		Name   string  `json:"name"`
+		Health float64 `json:"health"`
+		Size   int64   `json:"size"`
+	}
+
+	// ObjectsHealthResponse is the response type for the
+	// /bus/stats/objects/health endpoint.
+	ObjectsHealthResponse struct {
+		Objects   []ObjectHealth `json:"objects"`
+		NumUnsafe int64          `json:"numUnsafe"` // number of objects below the safe threshold
+	}
+
 	ObjectsStatsOpts struct {
 		Bucket string
 	}
@@ -177,6 +249,7 @@ type (
 		ETag     string
 		MimeType string
 		Metadata ObjectUserMetadata
+		Mode     string
 	}
 
 	// AddObjectRequest is the request type for the /bus/object/*key endpoint.
@@ -187,6 +260,23 @@ type (
 		ETag        string             `json:"eTag"`
 		MimeType    string             `json:"mimeType"`
 		Metadata    ObjectUserMetadata `json:"metadata"`
+		Mode        string             `json:"mode,omitempty"`
+	}
+
+	// AddObjectsRequest is the request type for the /objects/add endpoint.
+	AddObjectsRequest struct {
+		Bucket      string                     `json:"bucket"`
+		ContractSet string                     `json:"contractSet"`
+		Objects     map[string]AddObjectsEntry `json:"objects"`
+		Mode        string                     `json:"mode,omitempty"`
+	}
+
+	// AddObjectsEntry is an object in an AddObjectsRequest.
+	AddObjectsEntry struct {
+		Object   object.Object      `json:"object"`
+		ETag     string             `json:"eTag"`
+		MimeType string             `json:"mimeType"`
+		Metadata ObjectUserMetadata `json:"metadata"`
 	}
 
 	// CopyObjectOptions is the options type for the bus client.
@@ -252,6 +342,7 @@ type (
 		ContentLength int64
 		MimeType      string
 		Metadata      ObjectUserMetadata
+		Mode          string
 	}
 
 	UploadMultipartUploadPartOptions struct {
@@ -276,6 +367,9 @@ func (opts UploadObjectOptions) ApplyValues(values url.Values) {
 	if opts.MimeType != "" {
 		values.Set("mimetype", opts.MimeType)
 	}
+	if opts.Mode != "" {
+		values.Set("mode", opts.Mode)
+	}
 }
 
 func (opts UploadObjectOptions) ApplyHeaders(h http.Header) {
@@ -366,3 +460,14 @@ func FormatETag(ETag string) string {
 func ObjectPathEscape(path string) string {
 	return url.PathEscape(strings.TrimPrefix(path, "/"))
 }
+
+// ValidateUploadMode returns ErrInvalidUploadMode if the given mode is not
+// one of the known upload modes, an empty mode is treated as overwrite.
+func ValidateUploadMode(mode string) error {
+	switch mode {
+	case "", UploadModeOverwrite, UploadModeFailIfExists, UploadModeSkipIfExists:
+		return nil
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidUploadMode, mode)
+	}
+}
@@ -21,6 +21,20 @@ type (
 		Locked      bool   `json:"locked"`      // whether the slab buffer is locked for uploading
 	}
 
+	// SlabInfo contains a slab's sector layout together with its computed
+	// health and the objects that reference it.
+	SlabInfo struct {
+		Slab    object.Slab  `json:"slab"`
+		Health  float64      `json:"health"`
+		Objects []SlabObject `json:"objects"`
+	}
+
+	// SlabObject identifies an object that references a slab.
+	SlabObject struct {
+		Bucket string `json:"bucket"`
+		Name   string `json:"name"`
+	}
+
 	UnhealthySlab struct {
 		Key    object.EncryptionKey `json:"key"`
 		Health float64              `json:"health"`
@@ -45,6 +59,18 @@ type (
 		Limit        int     `json:"limit"`
 	}
 
+	// RepointSlicesRequest is the request type for the /slabs/repoint endpoint.
+	RepointSlicesRequest struct {
+		OldKey object.EncryptionKey `json:"oldKey"`
+		NewKey object.EncryptionKey `json:"newKey"`
+	}
+
+	// RepointSlicesResponse is the response type for the /slabs/repoint
+	// endpoint.
+	RepointSlicesResponse struct {
+		Repointed int `json:"repointed"`
+	}
+
 	PackedSlabsRequestGET struct {
 		LockingDuration DurationMS `json:"lockingDuration"`
 		MinShards       uint8      `json:"minShards"`
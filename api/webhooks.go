@@ -1,8 +1,54 @@
 package api
 
-import "go.sia.tech/renterd/webhooks"
+import (
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/webhooks"
+)
+
+const (
+	// WebhookModuleContractSet is the module of the events that are broadcast
+	// when a contract set is updated or removed.
+	WebhookModuleContractSet = "contractset"
+
+	WebhookEventContractSetUpdate = "update"
+	WebhookEventContractSetDelete = "delete"
+
+	// WebhookModuleContract is the module of the events that are broadcast
+	// when a contract is renewed or archived.
+	WebhookModuleContract = "contract"
+
+	WebhookEventContractRenew   = "renew"
+	WebhookEventContractArchive = "archive"
+)
 
 type WebHookResponse struct {
 	Webhooks []webhooks.Webhook          `json:"webhooks"`
 	Queues   []webhooks.WebhookQueueInfo `json:"queues"`
 }
+
+// EventContractSetUpdate is the payload of the event that is broadcast when
+// the contracts in a contract set change.
+type EventContractSetUpdate struct {
+	Name        string                 `json:"name"`
+	ContractIDs []types.FileContractID `json:"contractIDs"`
+}
+
+// EventContractRenew is the payload of the event that is broadcast when a
+// contract is renewed.
+type EventContractRenew struct {
+	ContractID  types.FileContractID `json:"contractID"`
+	RenewedFrom types.FileContractID `json:"renewedFrom"`
+}
+
+// EventContractArchive is the payload of the event that is broadcast when
+// contracts are archived, if no contract IDs are given all contracts were
+// archived.
+type EventContractArchive struct {
+	ContractIDs []types.FileContractID `json:"contractIDs,omitempty"`
+}
+
+// EventContractSetDelete is the payload of the event that is broadcast when a
+// contract set is removed.
+type EventContractSetDelete struct {
+	Name string `json:"name"`
+}
@@ -9,6 +9,7 @@ import (
 	rhpv2 "go.sia.tech/core/rhp/v2"
 	rhpv3 "go.sia.tech/core/rhp/v3"
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/object"
 )
 
 var (
@@ -20,12 +21,23 @@ var (
 	// need a contract set to be able to upload data.
 	ErrContractSetNotSpecified = errors.New("contract set is not specified")
 
+	// ErrInsufficientContracts is returned by the worker API when an upload
+	// is started with fewer contracts than the number of shards it requires.
+	ErrInsufficientContracts = errors.New("not enough contracts to support requested redundancy")
+
 	// ErrHostOnPrivateNetwork is returned by the worker API when a host can't
 	// be scanned since it is on a private network.
 	ErrHostOnPrivateNetwork = errors.New("host is on a private network")
 )
 
 type (
+	// InsufficientContractsError is returned by the worker API when an upload
+	// is started with fewer contracts than the number of shards it requires.
+	InsufficientContractsError struct {
+		Contracts   int
+		TotalShards int
+	}
+
 	// AccountsLockHandlerRequest is the request type for the /accounts/:id/lock
 	// endpoint.
 	AccountsLockHandlerRequest struct {
@@ -204,8 +216,14 @@ type (
 		BuildState
 	}
 
+	// UploadObjectResponse is the response type for the /worker/objects
+	// endpoint, it describes the object that was created by the upload.
 	UploadObjectResponse struct {
-		ETag string `json:"etag"`
+		ETag       string                 `json:"etag"`
+		Path       string                 `json:"path"`
+		Size       int64                  `json:"size"`
+		Redundancy RedundancySettings     `json:"redundancy"`
+		SlabKeys   []object.EncryptionKey `json:"slabKeys"`
 	}
 
 	UploadMultipartUploadPartResponse struct {
@@ -213,6 +231,31 @@ type (
 	}
 )
 
+// Error implements the error interface.
+func (e *InsufficientContractsError) Error() string {
+	return fmt.Sprintf("%v, %d contracts < %d total shards", ErrInsufficientContracts, e.Contracts, e.TotalShards)
+}
+
+// Unwrap returns ErrInsufficientContracts.
+func (e *InsufficientContractsError) Unwrap() error {
+	return ErrInsufficientContracts
+}
+
+// ParseInsufficientContractsError parses an InsufficientContractsError from
+// the given error message, false is returned if the message doesn't contain
+// one.
+func ParseInsufficientContractsError(msg string) (*InsufficientContractsError, bool) {
+	i := strings.Index(msg, ErrInsufficientContracts.Error()+", ")
+	if i == -1 {
+		return nil, false
+	}
+	var e InsufficientContractsError
+	if _, err := fmt.Sscanf(msg[i+len(ErrInsufficientContracts.Error())+2:], "%d contracts < %d total shards", &e.Contracts, &e.TotalShards); err != nil {
+		return nil, false
+	}
+	return &e, true
+}
+
 type DownloadRange struct {
 	Offset int64
 	Length int64
@@ -104,6 +104,10 @@ func (a *accounts) refillWorkersAccountsLoop(ctx context.Context) {
 func (a *accounts) refillWorkerAccounts(ctx context.Context, w Worker) {
 	// fetch config
 	state := a.ap.State()
+	if !state.enabled {
+		a.l.Debug("autopilot is disabled, skipping account refills")
+		return
+	}
 
 	// fetch worker id
 	workerID, err := w.ID(ctx)
@@ -18,11 +18,11 @@ import (
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/build"
 	"go.sia.tech/renterd/hostdb"
+	"go.sia.tech/renterd/internal/gouging"
 	"go.sia.tech/renterd/internal/prometheus"
 	"go.sia.tech/renterd/object"
 	"go.sia.tech/renterd/wallet"
 	"go.sia.tech/renterd/webhooks"
-	"go.sia.tech/renterd/worker"
 	"go.uber.org/zap"
 )
 
@@ -46,6 +46,7 @@ type Bus interface {
 	AddRenewedContract(ctx context.Context, c rhpv2.ContractRevision, contractPrice, totalCost types.Currency, startHeight uint64, renewedFrom types.FileContractID, state string) (api.ContractMetadata, error)
 	AncestorContracts(ctx context.Context, id types.FileContractID, minStartHeight uint64) ([]api.ArchivedContract, error)
 	ArchiveContracts(ctx context.Context, toArchive map[types.FileContractID]string) error
+	ArchivedContracts(ctx context.Context, opts api.ArchivedContractsOpts) ([]api.ArchivedContract, error)
 	Contract(ctx context.Context, id types.FileContractID) (api.ContractMetadata, error)
 	Contracts(ctx context.Context, opts api.ContractsOpts) (contracts []api.ContractMetadata, err error)
 	FileContractTax(ctx context.Context, payout types.Currency) (types.Currency, error)
@@ -56,10 +57,12 @@ type Bus interface {
 	Host(ctx context.Context, hostKey types.PublicKey) (hostdb.HostInfo, error)
 	Hosts(ctx context.Context, opts api.GetHostsOptions) ([]hostdb.Host, error)
 	HostsForScanning(ctx context.Context, opts api.HostsForScanningOptions) ([]hostdb.HostAddress, error)
+	RecordHostScores(ctx context.Context, scores map[types.PublicKey]float64) error
 	RemoveOfflineHosts(ctx context.Context, minRecentScanFailures uint64, maxDowntime time.Duration) (uint64, error)
 	SearchHosts(ctx context.Context, opts api.SearchHostOptions) ([]hostdb.Host, error)
 
 	// metrics
+	ContractSetChurnHistory(ctx context.Context, set string, maxPeriod uint64) ([]api.ContractSetChurnMetric, error)
 	RecordContractSetChurnMetric(ctx context.Context, metrics ...api.ContractSetChurnMetric) error
 	RecordContractPruneMetric(ctx context.Context, metrics ...api.ContractPruneMetric) error
 
@@ -108,6 +111,12 @@ type Autopilot struct {
 	stateMu sync.Mutex
 	state   state
 
+	runMu          sync.Mutex
+	phase          string
+	lastRun        time.Time
+	lastRunSummary api.AutopilotRunSummary
+	tickerStart    time.Time
+
 	startStopMu       sync.Mutex
 	startTime         time.Time
 	shutdownCtx       context.Context
@@ -122,13 +131,14 @@ type state struct {
 	rs  api.RedundancySettings
 	cfg api.AutopilotConfig
 
+	enabled bool
 	address types.Address
 	fee     types.Currency
 	period  uint64
 }
 
 // New initializes an Autopilot.
-func New(id string, bus Bus, workers []Worker, logger *zap.Logger, heartbeat time.Duration, scannerScanInterval time.Duration, scannerBatchSize, scannerNumThreads uint64, migrationHealthCutoff float64, accountsRefillInterval time.Duration, revisionSubmissionBuffer, migratorParallelSlabsPerWorker uint64, revisionBroadcastInterval time.Duration) (*Autopilot, error) {
+func New(id string, bus Bus, workers []Worker, logger *zap.Logger, heartbeat time.Duration, scannerScanInterval time.Duration, scannerBatchSize, scannerNumThreads uint64, migrationHealthCutoff float64, accountsRefillInterval time.Duration, revisionSubmissionBuffer, migratorParallelSlabsPerWorker, migratorMaxConcurrentMigrations, contractFormationConcurrency uint64, revisionBroadcastInterval time.Duration) (*Autopilot, error) {
 	shutdownCtx, shutdownCtxCancel := context.WithCancel(context.Background())
 
 	ap := &Autopilot{
@@ -156,8 +166,8 @@ func New(id string, bus Bus, workers []Worker, logger *zap.Logger, heartbeat tim
 	}
 
 	ap.s = scanner
-	ap.c = newContractor(ap, revisionSubmissionBuffer, revisionBroadcastInterval)
-	ap.m = newMigrator(ap, migrationHealthCutoff, migratorParallelSlabsPerWorker)
+	ap.c = newContractor(ap, revisionSubmissionBuffer, revisionBroadcastInterval, contractFormationConcurrency)
+	ap.m = newMigrator(ap, migrationHealthCutoff, migratorParallelSlabsPerWorker, migratorMaxConcurrentMigrations)
 	ap.a = newAccounts(ap, ap.bus, ap.bus, ap.workers, ap.logger, accountsRefillInterval)
 
 	return ap, nil
@@ -166,13 +176,16 @@ func New(id string, bus Bus, workers []Worker, logger *zap.Logger, heartbeat tim
 // Handler returns an HTTP handler that serves the autopilot api.
 func (ap *Autopilot) Handler() http.Handler {
 	return jape.Mux(map[string]jape.Handler{
-		"GET    /config":        ap.configHandlerGET,
-		"PUT    /config":        ap.configHandlerPUT,
-		"POST   /config":        ap.configHandlerPOST,
-		"POST   /hosts":         ap.hostsHandlerPOST,
-		"GET    /host/:hostKey": ap.hostHandlerGET,
-		"GET    /state":         ap.stateHandlerGET,
-		"POST   /trigger":       ap.triggerHandlerPOST,
+		"GET    /config":                ap.configHandlerGET,
+		"GET    /contractset/:set/diff": ap.contractSetDiffHandlerGET,
+		"GET    /dryrun":                ap.dryRunHandlerGET,
+		"PUT    /config":                ap.configHandlerPUT,
+		"POST   /config":                ap.configHandlerPOST,
+		"POST   /hosts":                 ap.hostsHandlerPOST,
+		"GET    /hosts/atrisk":          ap.hostsAtRiskHandlerGET,
+		"GET    /host/:hostKey":         ap.hostHandlerGET,
+		"GET    /state":                 ap.stateHandlerGET,
+		"POST   /trigger":               ap.triggerHandlerPOST,
 	})
 }
 
@@ -242,6 +255,9 @@ func (ap *Autopilot) Run() error {
 	ap.startTime = time.Now()
 	ap.triggerChan = make(chan bool, 1)
 	ap.ticker = time.NewTicker(ap.tickerDuration)
+	ap.runMu.Lock()
+	ap.tickerStart = ap.startTime
+	ap.runMu.Unlock()
 
 	ap.wg.Add(1)
 	defer ap.wg.Done()
@@ -269,6 +285,12 @@ func (ap *Autopilot) Run() error {
 		ap.workers.withWorker(func(w Worker) {
 			defer ap.logger.Info("autopilot iteration ended")
 
+			// skip the iteration if the autopilot was disabled
+			if !ap.updateEnabled(ap.shutdownCtx) {
+				ap.logger.Info("autopilot is disabled, skipping iteration")
+				return
+			}
+
 			// initiate a host scan - no need to be synced or configured for scanning
 			ap.s.tryUpdateTimeout()
 			ap.s.tryPerformHostScan(ap.shutdownCtx, w, forceScan)
@@ -320,14 +342,19 @@ func (ap *Autopilot) Run() error {
 				return
 			}
 
+			// keep track of the maintenance run, runs that are skipped aren't
+			// recorded
+			var setChanged bool
+			runStart := time.Now()
+			defer func() { ap.finishRun(runStart, setChanged, err) }()
+
 			// perform wallet maintenance
-			err = ap.c.performWalletMaintenance(ap.shutdownCtx)
-			if err != nil {
+			if err := ap.c.performWalletMaintenance(ap.shutdownCtx); err != nil {
 				ap.logger.Errorf("wallet maintenance failed, err: %v", err)
 			}
 
 			// perform maintenance
-			setChanged, err := ap.c.performContractMaintenance(ap.shutdownCtx, w)
+			setChanged, err = ap.c.performContractMaintenance(ap.shutdownCtx, w)
 			if err != nil && isErr(err, context.Canceled) {
 				return
 			} else if err != nil {
@@ -349,6 +376,10 @@ func (ap *Autopilot) Run() error {
 				})
 			}
 
+			// contract maintenance is done, the phase reflects the migrator
+			// from here on out
+			ap.setPhase(api.AutopilotPhaseIdle)
+
 			// migration
 			ap.m.tryPerformMigrations(ap.shutdownCtx, ap.workers)
 
@@ -365,7 +396,7 @@ func (ap *Autopilot) Run() error {
 			return nil
 		case forceScan = <-ap.triggerChan:
 			ap.logger.Info("autopilot iteration triggered")
-			ap.ticker.Reset(ap.tickerDuration)
+			ap.resetTicker()
 		case <-ap.ticker.C:
 		case <-tickerFired:
 		}
@@ -384,10 +415,49 @@ func (ap *Autopilot) Shutdown(_ context.Context) error {
 		close(ap.triggerChan)
 		ap.wg.Wait()
 		ap.startTime = time.Time{}
+
+		ap.runMu.Lock()
+		ap.tickerStart = time.Time{}
+		ap.runMu.Unlock()
 	}
 	return nil
 }
 
+// LastRun returns the time the last maintenance run completed together with
+// a summary of that run.
+func (ap *Autopilot) LastRun() (time.Time, api.AutopilotRunSummary) {
+	ap.runMu.Lock()
+	defer ap.runMu.Unlock()
+	return ap.lastRun, ap.lastRunSummary
+}
+
+// NextRun returns the time the next maintenance run is scheduled, it's derived
+// from the ticker that schedules the runs. If the autopilot isn't running the
+// zero time is returned.
+func (ap *Autopilot) NextRun() time.Time {
+	ap.runMu.Lock()
+	defer ap.runMu.Unlock()
+	if ap.tickerStart.IsZero() || ap.tickerDuration <= 0 {
+		return time.Time{}
+	}
+	ticks := time.Since(ap.tickerStart)/ap.tickerDuration + 1
+	return ap.tickerStart.Add(ticks * ap.tickerDuration)
+}
+
+// Phase returns the current phase of the autopilot.
+func (ap *Autopilot) Phase() string {
+	ap.runMu.Lock()
+	phase := ap.phase
+	ap.runMu.Unlock()
+
+	if phase != "" && phase != api.AutopilotPhaseIdle {
+		return phase
+	} else if migrating, _ := ap.m.Status(); migrating {
+		return api.AutopilotPhaseRepairing
+	}
+	return api.AutopilotPhaseIdle
+}
+
 func (ap *Autopilot) StartTime() time.Time {
 	ap.startStopMu.Lock()
 	defer ap.startStopMu.Unlock()
@@ -576,6 +646,50 @@ func (ap *Autopilot) isRunning() bool {
 	return !ap.startTime.IsZero()
 }
 
+func (ap *Autopilot) finishRun(start time.Time, setChanged bool, err error) {
+	ap.runMu.Lock()
+	defer ap.runMu.Unlock()
+	ap.phase = api.AutopilotPhaseIdle
+	ap.lastRun = time.Now()
+	ap.lastRunSummary = api.AutopilotRunSummary{
+		Duration:           api.DurationMS(ap.lastRun.Sub(start)),
+		ContractSetChanged: setChanged,
+	}
+	if err != nil {
+		ap.lastRunSummary.Error = err.Error()
+	}
+}
+
+func (ap *Autopilot) resetTicker() {
+	ap.runMu.Lock()
+	defer ap.runMu.Unlock()
+	ap.ticker.Reset(ap.tickerDuration)
+	ap.tickerStart = time.Now()
+}
+
+func (ap *Autopilot) setPhase(phase string) {
+	ap.runMu.Lock()
+	defer ap.runMu.Unlock()
+	ap.phase = phase
+}
+
+// updateEnabled fetches the autopilot from the bus and updates whether it's
+// enabled. An autopilot that can't be fetched is considered enabled, whether
+// it's configured is checked later on in the iteration.
+func (ap *Autopilot) updateEnabled(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	autopilot, err := ap.bus.Autopilot(ctx, ap.id)
+	if err != nil {
+		return true
+	}
+
+	ap.stateMu.Lock()
+	defer ap.stateMu.Unlock()
+	ap.state.enabled = !autopilot.Disabled
+	return ap.state.enabled
+}
+
 func (ap *Autopilot) updateState(ctx context.Context) error {
 	// fetch the autopilot from the bus
 	autopilot, err := ap.bus.Autopilot(ctx, ap.id)
@@ -641,6 +755,7 @@ func (ap *Autopilot) updateState(ctx context.Context) error {
 		rs:  rs,
 		cfg: autopilot.Config,
 
+		enabled: !autopilot.Disabled,
 		address: address,
 		fee:     fee,
 		period:  autopilot.CurrentPeriod,
@@ -724,6 +839,8 @@ func (ap *Autopilot) stateHandlerGET(jc jape.Context) {
 	pruning, pLastStart := ap.c.Status()
 	migrating, mLastStart := ap.m.Status()
 	scanning, sLastStart := ap.s.Status()
+	formationsInProgress, formationsRemaining := ap.c.FormationProgress()
+	lastRun, lastRunSummary := ap.LastRun()
 	_, err := ap.bus.Autopilot(jc.Request.Context(), ap.id)
 	if err != nil && !strings.Contains(err.Error(), api.ErrAutopilotNotFound.Error()) {
 		jc.Error(err, http.StatusInternalServerError)
@@ -731,14 +848,22 @@ func (ap *Autopilot) stateHandlerGET(jc jape.Context) {
 	}
 
 	ap.writeResponse(jc, http.StatusOK, AutopilotStateResp(api.AutopilotStateResponse{
-		Configured:         err == nil,
-		Migrating:          migrating,
-		MigratingLastStart: api.TimeRFC3339(mLastStart),
-		Pruning:            pruning,
-		PruningLastStart:   api.TimeRFC3339(pLastStart),
-		Scanning:           scanning,
-		ScanningLastStart:  api.TimeRFC3339(sLastStart),
-		UptimeMS:           api.DurationMS(ap.Uptime()),
+		Configured:           err == nil,
+		Migrating:            migrating,
+		MigratingLastStart:   api.TimeRFC3339(mLastStart),
+		MigrationsInProgress: ap.m.InProgress(),
+		FormationsInProgress: formationsInProgress,
+		FormationsRemaining:  formationsRemaining,
+		Pruning:              pruning,
+		PruningLastStart:     api.TimeRFC3339(pLastStart),
+		Scanning:             scanning,
+		ScanningLastStart:    api.TimeRFC3339(sLastStart),
+		UptimeMS:             api.DurationMS(ap.Uptime()),
+
+		Phase:          ap.Phase(),
+		LastRun:        api.TimeRFC3339(lastRun),
+		LastRunSummary: lastRunSummary,
+		NextRun:        api.TimeRFC3339(ap.NextRun()),
 
 		StartTime: api.TimeRFC3339(ap.StartTime()),
 		BuildState: api.BuildState{
@@ -763,8 +888,39 @@ func (ap *Autopilot) hostsHandlerPOST(jc jape.Context) {
 	jc.Encode(hosts)
 }
 
+func (ap *Autopilot) hostsAtRiskHandlerGET(jc jape.Context) {
+	hosts, err := ap.c.AtRiskHosts(jc.Request.Context())
+	if jc.Check("failed to get hosts at risk", err) != nil {
+		return
+	}
+	jc.Encode(hosts)
+}
+
+func (ap *Autopilot) contractSetDiffHandlerGET(jc jape.Context) {
+	var fromPeriod, toPeriod uint64
+	if jc.DecodeForm("fromPeriod", &fromPeriod) != nil || jc.DecodeForm("toPeriod", &toPeriod) != nil {
+		return
+	} else if fromPeriod > toPeriod {
+		jc.Error(api.ErrInvalidPeriodRange, http.StatusBadRequest)
+		return
+	}
+	resp, err := ap.ContractSetDiff(jc.Request.Context(), jc.PathParam("set"), fromPeriod, toPeriod)
+	if jc.Check("failed to diff contract set", err) != nil {
+		return
+	}
+	jc.Encode(resp)
+}
+
+func (ap *Autopilot) dryRunHandlerGET(jc jape.Context) {
+	resp, err := ap.DryRun(jc.Request.Context())
+	if jc.Check("failed to perform dry run", err) != nil {
+		return
+	}
+	jc.Encode(resp)
+}
+
 func countUsableHosts(cfg api.AutopilotConfig, cs api.ConsensusState, fee types.Currency, currentPeriod uint64, rs api.RedundancySettings, gs api.GougingSettings, hosts []hostdb.Host) (usables uint64) {
-	gc := worker.NewGougingChecker(gs, cs, fee, currentPeriod, cfg.Contracts.RenewWindow)
+	gc := gouging.NewChecker(gs, cs, fee, currentPeriod, cfg.Contracts.RenewWindow)
 	for _, host := range hosts {
 		usable, _ := isUsableHost(cfg, rs, gc, host, smallestValidScore, 0)
 		if usable {
@@ -778,7 +934,7 @@ func countUsableHosts(cfg api.AutopilotConfig, cs api.ConsensusState, fee types.
 // are too strict for the number of contracts required by 'cfg', it will provide
 // a recommendation on how to loosen it.
 func evaluateConfig(cfg api.AutopilotConfig, cs api.ConsensusState, fee types.Currency, currentPeriod uint64, rs api.RedundancySettings, gs api.GougingSettings, hosts []hostdb.Host) (resp api.ConfigEvaluationResponse) {
-	gc := worker.NewGougingChecker(gs, cs, fee, currentPeriod, cfg.Contracts.RenewWindow)
+	gc := gouging.NewChecker(gs, cs, fee, currentPeriod, cfg.Contracts.RenewWindow)
 
 	resp.Hosts = uint64(len(hosts))
 	for _, host := range hosts {
@@ -796,6 +952,9 @@ func evaluateConfig(cfg api.AutopilotConfig, cs api.ConsensusState, fee types.Cu
 		if usableBreakdown.notcompletingscan > 0 {
 			resp.Unusable.NotScanned++
 		}
+		if usableBreakdown.lowmaxduration > 0 {
+			resp.Unusable.LowMaxDuration++
+		}
 		if usableBreakdown.unknown > 0 {
 			resp.Unusable.Unknown++
 		}
@@ -113,3 +113,45 @@ func TestOptimiseGougingSetting(t *testing.T) {
 		t.Fatal("unexpected storage price", gs.MaxStoragePrice.ExactString())
 	}
 }
+
+func TestAutopilotRunStatus(t *testing.T) {
+	ap := &Autopilot{tickerDuration: time.Minute}
+	ap.m = &migrator{ap: ap}
+
+	// assert the next run is unknown if the autopilot isn't running
+	if nextRun := ap.NextRun(); !nextRun.IsZero() {
+		t.Fatal("expected no next run", nextRun)
+	}
+
+	// assert the next run is derived from the ticker
+	ap.tickerStart = time.Now().Add(-90 * time.Second)
+	if nextRun := ap.NextRun(); !nextRun.Equal(ap.tickerStart.Add(2 * time.Minute)) {
+		t.Fatal("unexpected next run", nextRun)
+	}
+
+	// assert the phase
+	if phase := ap.Phase(); phase != api.AutopilotPhaseIdle {
+		t.Fatal("unexpected phase", phase)
+	}
+	ap.setPhase(api.AutopilotPhaseForming)
+	if phase := ap.Phase(); phase != api.AutopilotPhaseForming {
+		t.Fatal("unexpected phase", phase)
+	}
+
+	// assert finishing a run resets the phase and records the run
+	start := time.Now().Add(-time.Second)
+	ap.finishRun(start, true, nil)
+	if phase := ap.Phase(); phase != api.AutopilotPhaseIdle {
+		t.Fatal("unexpected phase", phase)
+	} else if lastRun, summary := ap.LastRun(); lastRun.Before(start) {
+		t.Fatal("unexpected last run", lastRun)
+	} else if !summary.ContractSetChanged || summary.Duration < api.DurationMS(time.Second) || summary.Error != "" {
+		t.Fatal("unexpected summary", summary)
+	}
+
+	// assert the autopilot is repairing while idle and migrating
+	ap.m.migrating = true
+	if phase := ap.Phase(); phase != api.AutopilotPhaseRepairing {
+		t.Fatal("unexpected phase", phase)
+	}
+}
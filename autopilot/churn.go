@@ -1,10 +1,15 @@
 package autopilot
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
 )
 
 type (
@@ -66,3 +71,136 @@ func (c *accumulatedChurn) Reset() {
 	c.additions = make(map[types.FileContractID]contractSetAdditions)
 	c.removals = make(map[types.FileContractID]contractSetRemovals)
 }
+
+// ContractSetDiff describes how the hosts in the given contract set changed
+// between the end of fromPeriod and the end of toPeriod. The set's membership
+// is reconstructed from the churn metrics recorded by the autopilot, metrics
+// that weren't stamped with a host key are ignored. Hosts that were retained
+// are marked as renewed if one of their contracts was renewed within that same
+// window.
+func (ap *Autopilot) ContractSetDiff(ctx context.Context, set string, fromPeriod, toPeriod uint64) (api.ContractSetDiffResponse, error) {
+	if fromPeriod > toPeriod {
+		return api.ContractSetDiffResponse{}, api.ErrInvalidPeriodRange
+	}
+
+	// fetch the churn history
+	history, err := ap.bus.ContractSetChurnHistory(ctx, set, toPeriod)
+	if err != nil {
+		return api.ContractSetDiffResponse{}, fmt.Errorf("failed to fetch contract set churn history: %w", err)
+	}
+	resp := diffContractSet(set, fromPeriod, toPeriod, history)
+	if len(resp.Retained) == 0 {
+		return resp, nil
+	}
+
+	// fetch the active and archived contracts to find the renewals
+	contracts, err := ap.bus.Contracts(ctx, api.ContractsOpts{})
+	if err != nil {
+		return api.ContractSetDiffResponse{}, fmt.Errorf("failed to fetch contracts: %w", err)
+	}
+	archived, err := ap.bus.ArchivedContracts(ctx, api.ArchivedContractsOpts{})
+	if err != nil {
+		return api.ContractSetDiffResponse{}, fmt.Errorf("failed to fetch archived contracts: %w", err)
+	}
+
+	// a period ends where the next one starts
+	periodLength := ap.State().cfg.Contracts.Period
+	renewed := renewedHosts(contracts, archived, fromPeriod+periodLength, toPeriod+periodLength)
+	for i, h := range resp.Retained {
+		if renewed[h.HostKey] {
+			resp.Retained[i].Reason = api.ContractSetDiffReasonRenewed
+		}
+	}
+	return resp, nil
+}
+
+// diffContractSet replays the given churn history to compute the hosts that
+// were added to, removed from or retained in the set between the end of
+// fromPeriod and the end of toPeriod. The history is replayed per contract, a
+// host is in the set as long as one of its contracts is, that way a host whose
+// contract is swapped for a new one is not reported as removed. Removed hosts
+// are annotated with the reason of their last removal.
+func diffContractSet(set string, fromPeriod, toPeriod uint64, history []api.ContractSetChurnMetric) api.ContractSetDiffResponse {
+	beforeContracts := make(map[types.FileContractID]types.PublicKey)
+	afterContracts := make(map[types.FileContractID]types.PublicKey)
+	reasons := make(map[types.PublicKey]string)
+	for _, m := range history {
+		if m.HostKey == (types.PublicKey{}) || m.Period > toPeriod {
+			continue
+		}
+		switch m.Direction {
+		case api.ChurnDirAdded:
+			afterContracts[m.ContractID] = m.HostKey
+			if m.Period <= fromPeriod {
+				beforeContracts[m.ContractID] = m.HostKey
+			}
+		case api.ChurnDirRemoved:
+			delete(afterContracts, m.ContractID)
+			if m.Period <= fromPeriod {
+				delete(beforeContracts, m.ContractID)
+			} else {
+				reasons[m.HostKey] = m.Reason
+			}
+		}
+	}
+
+	// convert the contracts to hosts
+	before := make(map[types.PublicKey]struct{})
+	for _, hk := range beforeContracts {
+		before[hk] = struct{}{}
+	}
+	after := make(map[types.PublicKey]struct{})
+	for _, hk := range afterContracts {
+		after[hk] = struct{}{}
+	}
+
+	resp := api.ContractSetDiffResponse{
+		Set:        set,
+		FromPeriod: fromPeriod,
+		ToPeriod:   toPeriod,
+		Added:      []api.ContractSetDiffHost{},
+		Removed:    []api.ContractSetDiffHost{},
+		Retained:   []api.ContractSetDiffHost{},
+	}
+	for hk := range after {
+		if _, ok := before[hk]; ok {
+			resp.Retained = append(resp.Retained, api.ContractSetDiffHost{HostKey: hk})
+		} else {
+			resp.Added = append(resp.Added, api.ContractSetDiffHost{HostKey: hk})
+		}
+	}
+	for hk := range before {
+		if _, ok := after[hk]; !ok {
+			resp.Removed = append(resp.Removed, api.ContractSetDiffHost{HostKey: hk, Reason: reasons[hk]})
+		}
+	}
+
+	// sort the hosts to make the response deterministic
+	for _, hosts := range [][]api.ContractSetDiffHost{resp.Added, resp.Removed, resp.Retained} {
+		sort.Slice(hosts, func(i, j int) bool {
+			return bytes.Compare(hosts[i].HostKey[:], hosts[j].HostKey[:]) < 0
+		})
+	}
+	return resp
+}
+
+// renewedHosts returns the hosts that had one of their contracts renewed
+// within [minHeight, maxHeight). A contract that was renewed from another one
+// was created by that renewal, so its start height is the renewal height.
+func renewedHosts(contracts []api.ContractMetadata, archived []api.ArchivedContract, minHeight, maxHeight uint64) map[types.PublicKey]bool {
+	renewed := make(map[types.PublicKey]bool)
+	isRenewal := func(renewedFrom types.FileContractID, startHeight uint64) bool {
+		return renewedFrom != (types.FileContractID{}) && startHeight >= minHeight && startHeight < maxHeight
+	}
+	for _, c := range contracts {
+		if isRenewal(c.RenewedFrom, c.StartHeight) {
+			renewed[c.HostKey] = true
+		}
+	}
+	for _, c := range archived {
+		if isRenewal(c.RenewedFrom, c.StartHeight) {
+			renewed[c.HostKey] = true
+		}
+	}
+	return renewed
+}
@@ -0,0 +1,95 @@
+package autopilot
+
+import (
+	"reflect"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+func TestDiffContractSet(t *testing.T) {
+	hk1, hk2, hk3, hk4, hk5 := types.PublicKey{1}, types.PublicKey{2}, types.PublicKey{3}, types.PublicKey{4}, types.PublicKey{5}
+	churn := func(hk types.PublicKey, fcid types.FileContractID, dir string, period uint64, reason string) api.ContractSetChurnMetric {
+		return api.ContractSetChurnMetric{HostKey: hk, ContractID: fcid, Direction: dir, Period: period, Reason: reason}
+	}
+	history := []api.ContractSetChurnMetric{
+		churn(hk1, types.FileContractID{1}, api.ChurnDirAdded, 100, ""),
+		churn(hk2, types.FileContractID{2}, api.ChurnDirAdded, 100, ""),
+		churn(hk3, types.FileContractID{3}, api.ChurnDirAdded, 100, ""),
+		churn(types.PublicKey{}, types.FileContractID{}, api.ChurnDirAdded, 100, ""), // legacy metric
+		churn(hk3, types.FileContractID{3}, api.ChurnDirRemoved, 100, "gouging"),
+		churn(hk2, types.FileContractID{2}, api.ChurnDirRemoved, 200, "low score"),
+		churn(hk4, types.FileContractID{4}, api.ChurnDirAdded, 200, ""),
+		churn(hk2, types.FileContractID{2}, api.ChurnDirAdded, 200, ""),
+		churn(hk1, types.FileContractID{6}, api.ChurnDirAdded, 200, ""), // hk1's contract is swapped
+		churn(hk1, types.FileContractID{1}, api.ChurnDirRemoved, 200, "refreshed"),
+		churn(hk2, types.FileContractID{2}, api.ChurnDirRemoved, 300, "offline"),
+		churn(hk5, types.FileContractID{5}, api.ChurnDirAdded, 300, ""),
+		churn(hk5, types.FileContractID{5}, api.ChurnDirRemoved, 300, "truncated"),
+	}
+
+	// diff the set against itself
+	resp := diffContractSet("foo", 100, 100, history)
+	if len(resp.Added) != 0 || len(resp.Removed) != 0 {
+		t.Fatal("expected no changes", resp)
+	} else if !reflect.DeepEqual(resp.Retained, []api.ContractSetDiffHost{{HostKey: hk1}, {HostKey: hk2}}) {
+		t.Fatal("unexpected retained hosts", resp.Retained)
+	}
+
+	// diff the set between the first and second period, hk2 was removed and
+	// re-added and hk1's contract was swapped so both are retained
+	resp = diffContractSet("foo", 100, 200, history)
+	if !reflect.DeepEqual(resp, api.ContractSetDiffResponse{
+		Set:        "foo",
+		FromPeriod: 100,
+		ToPeriod:   200,
+		Added:      []api.ContractSetDiffHost{{HostKey: hk4}},
+		Removed:    []api.ContractSetDiffHost{},
+		Retained:   []api.ContractSetDiffHost{{HostKey: hk1}, {HostKey: hk2}},
+	}) {
+		t.Fatal("unexpected diff", resp)
+	}
+
+	// diff the set between the first and last period, hk5 was added and
+	// removed in between so it's not part of the diff
+	resp = diffContractSet("foo", 100, 300, history)
+	if !reflect.DeepEqual(resp, api.ContractSetDiffResponse{
+		Set:        "foo",
+		FromPeriod: 100,
+		ToPeriod:   300,
+		Added:      []api.ContractSetDiffHost{{HostKey: hk4}},
+		Removed:    []api.ContractSetDiffHost{{HostKey: hk2, Reason: "offline"}},
+		Retained:   []api.ContractSetDiffHost{{HostKey: hk1}},
+	}) {
+		t.Fatal("unexpected diff", resp)
+	}
+}
+
+func TestRenewedHosts(t *testing.T) {
+	hk1, hk2, hk3 := types.PublicKey{1}, types.PublicKey{2}, types.PublicKey{3}
+	fcid1, fcid2, fcid3 := types.FileContractID{1}, types.FileContractID{2}, types.FileContractID{3}
+
+	// hk1 was renewed at 200 and again at 300, hk2 was renewed at 400 and hk3
+	// was never renewed
+	contracts := []api.ContractMetadata{
+		{ID: fcid3, HostKey: hk1, RenewedFrom: fcid2, StartHeight: 300},
+		{ID: types.FileContractID{4}, HostKey: hk2, RenewedFrom: types.FileContractID{5}, StartHeight: 400},
+		{ID: types.FileContractID{6}, HostKey: hk3, StartHeight: 250},
+	}
+	archived := []api.ArchivedContract{
+		{ID: fcid2, HostKey: hk1, RenewedFrom: fcid1, RenewedTo: fcid3, StartHeight: 200},
+		{ID: fcid1, HostKey: hk1, RenewedTo: fcid2, StartHeight: 100},
+	}
+
+	// only renewals within the window should count
+	if renewed := renewedHosts(contracts, archived, 200, 300); !reflect.DeepEqual(renewed, map[types.PublicKey]bool{hk1: true}) {
+		t.Fatal("unexpected renewed hosts", renewed)
+	} else if renewed := renewedHosts(contracts, archived, 300, 400); !reflect.DeepEqual(renewed, map[types.PublicKey]bool{hk1: true}) {
+		t.Fatal("unexpected renewed hosts", renewed)
+	} else if renewed := renewedHosts(contracts, archived, 100, 200); len(renewed) != 0 {
+		t.Fatal("expected no renewed hosts", renewed)
+	} else if renewed := renewedHosts(contracts, archived, 0, 500); !reflect.DeepEqual(renewed, map[types.PublicKey]bool{hk1: true, hk2: true}) {
+		t.Fatal("unexpected renewed hosts", renewed)
+	}
+}
@@ -3,6 +3,7 @@ package autopilot
 import (
 	"context"
 	"fmt"
+	"net/url"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/jape"
@@ -52,6 +53,30 @@ func (c *Client) HostInfos(ctx context.Context, filterMode, usabilityMode string
 	return
 }
 
+// AtRiskHosts returns all hosts with a contract that is up for renewal but
+// won't be renewed.
+func (c *Client) AtRiskHosts(ctx context.Context) (resp []api.AtRiskHost, err error) {
+	err = c.c.WithContext(ctx).GET("/hosts/atrisk", &resp)
+	return
+}
+
+// ContractSetDiff returns the hosts that were added to, removed from or
+// retained in the given contract set between the end of two periods.
+func (c *Client) ContractSetDiff(ctx context.Context, set string, fromPeriod, toPeriod uint64) (resp api.ContractSetDiffResponse, err error) {
+	values := url.Values{}
+	values.Set("fromPeriod", fmt.Sprint(fromPeriod))
+	values.Set("toPeriod", fmt.Sprint(toPeriod))
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/contractset/%s/diff?%s", set, values.Encode()), &resp)
+	return
+}
+
+// DryRun returns the actions the autopilot would take in its next iteration
+// without performing them.
+func (c *Client) DryRun(ctx context.Context) (resp api.AutopilotDryRunResponse, err error) {
+	err = c.c.WithContext(ctx).GET("/dryrun", &resp)
+	return
+}
+
 // State returns the current state of the autopilot.
 func (c *Client) State() (state api.AutopilotStateResponse, err error) {
 	err = c.c.GET("/state", &state)
@@ -16,8 +16,8 @@ import (
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/hostdb"
+	"go.sia.tech/renterd/internal/gouging"
 	"go.sia.tech/renterd/wallet"
-	"go.sia.tech/renterd/worker"
 	"go.uber.org/zap"
 )
 
@@ -95,14 +95,20 @@ type (
 		revisionLastBroadcast     map[types.FileContractID]time.Time
 		revisionSubmissionBuffer  uint64
 
+		formationConcurrency uint64
+
 		mu sync.Mutex
 
+		formationsInProgress uint64
+		formationsRemaining  uint64
+
 		pruning          bool
 		pruningLastStart time.Time
 
 		cachedHostInfo   map[types.PublicKey]hostInfo
 		cachedDataStored map[types.PublicKey]uint64
 		cachedMinScore   float64
+		cachedRevisions  map[types.FileContractID]*types.FileContractRevision
 	}
 
 	hostInfo struct {
@@ -151,7 +157,7 @@ type (
 	}
 )
 
-func newContractor(ap *Autopilot, revisionSubmissionBuffer uint64, revisionBroadcastInterval time.Duration) *contractor {
+func newContractor(ap *Autopilot, revisionSubmissionBuffer uint64, revisionBroadcastInterval time.Duration, formationConcurrency uint64) *contractor {
 	return &contractor{
 		ap:     ap,
 		churn:  newAccumulatedChurn(),
@@ -161,6 +167,8 @@ func newContractor(ap *Autopilot, revisionSubmissionBuffer uint64, revisionBroad
 		revisionLastBroadcast:     make(map[types.FileContractID]time.Time),
 		revisionSubmissionBuffer:  revisionSubmissionBuffer,
 
+		formationConcurrency: formationConcurrency,
+
 		resolver: newIPResolver(ap.shutdownCtx, resolverLookupTimeout, ap.logger.Named("resolver")),
 	}
 }
@@ -171,6 +179,21 @@ func (c *contractor) Status() (bool, time.Time) {
 	return c.pruning, c.pruningLastStart
 }
 
+// FormationProgress returns the number of contract formations in progress and
+// the number of contracts that remain to be formed.
+func (c *contractor) FormationProgress() (inProgress, remaining uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.formationsInProgress, c.formationsRemaining
+}
+
+func (c *contractor) updateFormationProgress(inProgress, remaining uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.formationsInProgress = inProgress
+	c.formationsRemaining = remaining
+}
+
 func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (bool, error) {
 	// skip contract maintenance if we're stopped or not synced
 	if c.ap.isStopped() {
@@ -181,36 +204,20 @@ func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (
 	// convenience variables
 	state := c.ap.State()
 
-	// no maintenance if no hosts are requested
+	// no maintenance if the config is invalid
 	//
 	// NOTE: this is an important check because we assume Contracts.Amount is
 	// not zero in several places
-	if state.cfg.Contracts.Amount == 0 {
-		c.logger.Warn("contracts is set to zero, skipping contract maintenance")
-		return false, nil
-	}
-
-	// no maintenance if no allowance was set
-	if state.cfg.Contracts.Allowance.IsZero() {
-		c.logger.Warn("allowance is set to zero, skipping contract maintenance")
-		return false, nil
-	}
-
-	// no maintenance if no period was set
-	if state.cfg.Contracts.Period == 0 {
-		c.logger.Warn("period is set to zero, skipping contract maintenance")
+	if err := validateMaintenanceConfig(state.cfg); err != nil {
+		c.logger.Warnf("%v, skipping contract maintenance", err)
 		return false, nil
 	}
 
 	// fetch current contract set
-	currentSet, err := c.ap.bus.Contracts(ctx, api.ContractsOpts{ContractSet: state.cfg.Contracts.Set})
-	if err != nil && !strings.Contains(err.Error(), api.ErrContractSetNotFound.Error()) {
+	currentSet, isInCurrentSet, err := c.fetchCurrentSet(ctx, state.cfg.Contracts.Set)
+	if err != nil {
 		return false, err
 	}
-	isInCurrentSet := make(map[types.FileContractID]struct{})
-	for _, c := range currentSet {
-		isInCurrentSet[c.ID] = struct{}{}
-	}
 	c.logger.Debugf("contract set '%s' holds %d contracts", state.cfg.Contracts.Set, len(currentSet))
 
 	// fetch all contracts from the worker.
@@ -225,6 +232,15 @@ func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (
 	contracts := resp.Contracts
 	c.logger.Debugf("fetched %d contracts from the worker, took %v", len(resp.Contracts), time.Since(start))
 
+	// cache the contracts' revisions
+	revisions := make(map[types.FileContractID]*types.FileContractRevision, len(contracts))
+	for _, contract := range contracts {
+		revisions[contract.ID] = contract.Revision
+	}
+	c.mu.Lock()
+	c.cachedRevisions = revisions
+	c.mu.Unlock()
+
 	// run revision broadcast
 	c.runRevisionBroadcast(ctx, w, contracts, isInCurrentSet)
 
@@ -233,25 +249,19 @@ func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (
 		return contracts[i].FileSize() > contracts[j].FileSize()
 	})
 
-	// get used hosts
-	usedHosts := make(map[types.PublicKey]struct{})
-	for _, contract := range contracts {
-		usedHosts[contract.HostKey] = struct{}{}
-	}
-
-	// compile map of stored data per host
+	// compile map of stored data per contract
 	contractData := make(map[types.FileContractID]uint64)
-	hostData := make(map[types.PublicKey]uint64)
 	for _, c := range contracts {
 		contractData[c.ID] = c.FileSize()
-		hostData[c.HostKey] += c.FileSize()
 	}
 
-	// fetch all hosts
-	hosts, err := c.ap.bus.Hosts(ctx, api.GetHostsOptions{})
+	// fetch hosts and candidates
+	hs, err := c.fetchMaintenanceHosts(ctx, contracts)
 	if err != nil {
 		return false, err
 	}
+	hosts, usedHosts, hostData := hs.hosts, hs.usedHosts, hs.hostData
+	candidates, unusableHosts, minScore := hs.candidates, hs.unusableHosts, hs.minScore
 
 	// check if any used hosts have lost data to warn the user
 	var toDismiss []types.Hash256
@@ -266,18 +276,13 @@ func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (
 		c.ap.DismissAlert(ctx, toDismiss...)
 	}
 
-	// fetch candidate hosts
-	candidates, unusableHosts, err := c.candidateHosts(ctx, hosts, usedHosts, hostData, smallestValidScore) // avoid 0 score hosts
-	if err != nil {
-		return false, err
+	// record the candidates' scores
+	scores := make(map[types.PublicKey]float64, len(candidates))
+	for _, h := range candidates {
+		scores[h.host.PublicKey] = h.score
 	}
-
-	// min score to pass checks
-	var minScore float64
-	if len(hosts) > 0 {
-		minScore = c.calculateMinScore(ctx, candidates, state.cfg.Contracts.Amount)
-	} else {
-		c.logger.Warn("could not calculate min score, no hosts found")
+	if err := c.ap.bus.RecordHostScores(ctx, scores); err != nil {
+		c.logger.Errorf("failed to record host scores, err: %v", err) // continue
 	}
 
 	// fetch consensus state
@@ -287,7 +292,7 @@ func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (
 	}
 
 	// create gouging checker
-	gc := worker.NewGougingChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
+	gc := gouging.NewChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
 
 	// prepare hosts for cache
 	hostInfos := make(map[types.PublicKey]hostInfo)
@@ -329,31 +334,14 @@ func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (
 	}
 
 	// calculate 'limit' amount of contracts we want to renew
-	var limit int
-	if len(toRenew) > 0 {
-		// when renewing, prioritise contracts that have already been in the set
-		// before and out of those prefer the largest ones.
-		sort.Slice(toRenew, func(i, j int) bool {
-			_, icsI := isInCurrentSet[toRenew[i].contract.ID]
-			_, icsJ := isInCurrentSet[toRenew[j].contract.ID]
-			if icsI && !icsJ {
-				return true
-			} else if !icsI && icsJ {
-				return false
-			}
-			return toRenew[i].contract.FileSize() > toRenew[j].contract.FileSize()
-		})
-		for len(updatedSet)+limit < int(state.cfg.Contracts.Amount) && limit < len(toRenew) {
-			// as long as we're missing contracts, increase the renewal limit
-			limit++
-		}
-	}
+	limit := renewalLimit(toRenew, isInCurrentSet, len(updatedSet), state.cfg.Contracts.Amount)
 
 	// run renewals on contracts that are not in updatedSet yet. We only renew
 	// up to 'limit' of those to avoid having too many contracts in the updated
 	// set afterwards
 	var renewed []renewal
 	if limit > 0 {
+		c.ap.setPhase(api.AutopilotPhaseRenewing)
 		var toKeep []api.ContractMetadata
 		renewed, toKeep = c.runContractRenewals(ctx, w, toRenew, &remaining, limit)
 		for _, ri := range renewed {
@@ -366,6 +354,9 @@ func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (
 	}
 
 	// run contract refreshes
+	if len(toRefresh) > 0 {
+		c.ap.setPhase(api.AutopilotPhaseRenewing)
+	}
 	refreshed, err := c.runContractRefreshes(ctx, w, toRefresh, &remaining)
 	if err != nil {
 		c.logger.Errorf("failed to refresh contracts, err: %v", err) // continue
@@ -378,17 +369,9 @@ func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (
 		}
 	}
 
-	// to avoid forming new contracts as soon as we dip below
-	// 'Contracts.Amount', we define a threshold but only if we have more
-	// contracts than 'Contracts.Amount' already
-	threshold := state.cfg.Contracts.Amount
-	if uint64(len(contracts)) > state.cfg.Contracts.Amount {
-		threshold = addLeeway(threshold, leewayPctRequiredContracts)
-	}
-
 	// check if we need to form contracts and add them to the contract set
 	var formed []api.ContractMetadata
-	if uint64(len(updatedSet)) < threshold {
+	if uint64(len(updatedSet)) < formationThreshold(state.cfg, len(contracts)) {
 		// no need to try and form contracts if wallet is completely empty
 		wallet, err := c.ap.bus.Wallet(ctx)
 		if err != nil {
@@ -397,6 +380,7 @@ func (c *contractor) performContractMaintenance(ctx context.Context, w Worker) (
 		} else if wallet.Confirmed.IsZero() && wallet.Unconfirmed.IsZero() {
 			c.logger.Warn("contract formations skipped, wallet is empty")
 		} else {
+			c.ap.setPhase(api.AutopilotPhaseForming)
 			formed, err = c.runContractFormations(ctx, w, candidates, usedHosts, unusableHosts, state.cfg.Contracts.Amount-uint64(len(updatedSet)), &remaining)
 			if err != nil {
 				c.logger.Errorf("failed to form contracts, err: %v", err) // continue
@@ -527,11 +511,14 @@ func (c *contractor) computeContractSetChanged(ctx context.Context, name string,
 
 	// record churn metrics
 	var metrics []api.ContractSetChurnMetric
-	for fcid := range setAdditions {
+	period := c.ap.State().period
+	for fcid, addition := range setAdditions {
 		metrics = append(metrics, api.ContractSetChurnMetric{
 			Name:       c.ap.state.cfg.Contracts.Set,
 			ContractID: fcid,
+			HostKey:    addition.HostKey,
 			Direction:  api.ChurnDirAdded,
+			Period:     period,
 			Timestamp:  now,
 		})
 	}
@@ -539,7 +526,9 @@ func (c *contractor) computeContractSetChanged(ctx context.Context, name string,
 		metrics = append(metrics, api.ContractSetChurnMetric{
 			Name:       c.ap.state.cfg.Contracts.Set,
 			ContractID: fcid,
+			HostKey:    removal.HostKey,
 			Direction:  api.ChurnDirRemoved,
+			Period:     period,
 			Reason:     removal.Removals[0].Reason,
 			Timestamp:  now,
 		})
@@ -661,6 +650,126 @@ func (c *contractor) performWalletMaintenance(ctx context.Context) error {
 	return nil
 }
 
+// maintenanceHosts holds the hosts information the contract maintenance is
+// based on.
+type maintenanceHosts struct {
+	hosts         []hostdb.Host
+	usedHosts     map[types.PublicKey]struct{}
+	hostData      map[types.PublicKey]uint64
+	candidates    scoredHosts
+	unusableHosts unusableHostResult
+	minScore      float64
+}
+
+// validateMaintenanceConfig returns an error if the given config doesn't allow
+// for contract maintenance.
+func validateMaintenanceConfig(cfg api.AutopilotConfig) error {
+	if cfg.Contracts.Amount == 0 {
+		return errors.New("contracts is set to zero")
+	} else if cfg.Contracts.Allowance.IsZero() {
+		return errors.New("allowance is set to zero")
+	} else if cfg.Contracts.Period == 0 {
+		return errors.New("period is set to zero")
+	}
+	return nil
+}
+
+// fetchCurrentSet fetches the contracts in the given set from the bus and
+// returns them alongside a lookup map. A set that doesn't exist is empty.
+func (c *contractor) fetchCurrentSet(ctx context.Context, set string) ([]api.ContractMetadata, map[types.FileContractID]struct{}, error) {
+	currentSet, err := c.ap.bus.Contracts(ctx, api.ContractsOpts{ContractSet: set})
+	if err != nil && !strings.Contains(err.Error(), api.ErrContractSetNotFound.Error()) {
+		return nil, nil, err
+	}
+	isInCurrentSet := make(map[types.FileContractID]struct{})
+	for _, c := range currentSet {
+		isInCurrentSet[c.ID] = struct{}{}
+	}
+	return currentSet, isInCurrentSet, nil
+}
+
+// fetchMaintenanceHosts fetches all hosts from the bus and compiles the hosts
+// used by the given contracts, the data stored on them, the candidate hosts and
+// the minimum score hosts need to pass the checks.
+func (c *contractor) fetchMaintenanceHosts(ctx context.Context, contracts []api.Contract) (hs maintenanceHosts, err error) {
+	// compile used hosts and the data stored per host
+	hs.usedHosts = make(map[types.PublicKey]struct{})
+	hs.hostData = make(map[types.PublicKey]uint64)
+	for _, c := range contracts {
+		hs.usedHosts[c.HostKey] = struct{}{}
+		hs.hostData[c.HostKey] += c.FileSize()
+	}
+
+	// fetch all hosts
+	hs.hosts, err = c.ap.bus.Hosts(ctx, api.GetHostsOptions{})
+	if err != nil {
+		return maintenanceHosts{}, err
+	}
+
+	// fetch candidate hosts
+	hs.candidates, hs.unusableHosts, err = c.candidateHosts(ctx, hs.hosts, hs.usedHosts, hs.hostData, smallestValidScore) // avoid 0 score hosts
+	if err != nil {
+		return maintenanceHosts{}, err
+	}
+
+	// min score to pass checks
+	if len(hs.hosts) > 0 {
+		hs.minScore = c.calculateMinScore(ctx, hs.candidates, c.ap.State().cfg.Contracts.Amount)
+	} else {
+		c.logger.Warn("could not calculate min score, no hosts found")
+	}
+	return hs, nil
+}
+
+// cacheRevision caches the revision of a contract the autopilot formed, renewed
+// or refreshed itself so the dry run doesn't have to wait for the next
+// maintenance to know about it.
+func (c *contractor) cacheRevision(rev rhpv2.ContractRevision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cachedRevisions == nil {
+		c.cachedRevisions = make(map[types.FileContractID]*types.FileContractRevision)
+	}
+	c.cachedRevisions[rev.ID()] = &rev.Revision
+}
+
+// renewalLimit sorts the given contracts by renewal priority and returns how
+// many of them have to be renewed to fill up a contract set that already holds
+// 'numKept' contracts. Contracts that have already been in the set before are
+// prioritised and out of those we prefer the largest ones.
+func renewalLimit(toRenew []contractInfo, inCurrentSet map[types.FileContractID]struct{}, numKept int, amount uint64) (limit int) {
+	sort.Slice(toRenew, func(i, j int) bool {
+		_, icsI := inCurrentSet[toRenew[i].contract.ID]
+		_, icsJ := inCurrentSet[toRenew[j].contract.ID]
+		if icsI && !icsJ {
+			return true
+		} else if !icsI && icsJ {
+			return false
+		}
+		return toRenew[i].contract.FileSize() > toRenew[j].contract.FileSize()
+	})
+	for numKept+limit < int(amount) && limit < len(toRenew) {
+		// as long as we're missing contracts, increase the renewal limit
+		limit++
+	}
+	return
+}
+
+// formationThreshold returns the size of the contract set below which we form
+// new contracts. To avoid forming new contracts as soon as we dip below
+// 'Contracts.Amount', we apply some leeway but only if we have more contracts
+// than 'Contracts.Amount' already.
+func formationThreshold(cfg api.AutopilotConfig, numContracts int) uint64 {
+	threshold := cfg.Contracts.Amount
+	if uint64(numContracts) > cfg.Contracts.Amount {
+		threshold = addLeeway(threshold, leewayPctRequiredContracts)
+	}
+	return threshold
+}
+
+// runContractChecks decides which contracts to keep, archive, refresh or renew.
+// If no worker is passed, the checks are read-only and use the hosts' cached
+// price tables instead of refreshing them.
 func (c *contractor) runContractChecks(ctx context.Context, w Worker, contracts []api.Contract, inCurrentSet map[types.FileContractID]struct{}, minScore float64) (toKeep []api.ContractMetadata, toArchive, toStopUsing map[types.FileContractID]string, toRefresh, toRenew []contractInfo, _ error) {
 	if c.ap.isStopped() {
 		return
@@ -753,9 +862,9 @@ func (c *contractor) runContractChecks(ctx context.Context, w Worker, contracts
 		}
 
 		// if the host doesn't have a valid pricetable, update it if we were
-		// able to obtain a revision
+		// able to obtain a revision, without a worker we use the cached one
 		invalidPT := contract.Revision == nil
-		if contract.Revision != nil {
+		if contract.Revision != nil && w != nil {
 			if err := refreshPriceTable(ctx, w, &host.Host); err != nil {
 				c.logger.Errorf("could not fetch price table for host %v: %v", host.PublicKey, err)
 				invalidPT = true
@@ -770,7 +879,7 @@ func (c *contractor) runContractChecks(ctx context.Context, w Worker, contracts
 		}
 
 		// use a new gouging checker for every contract
-		gc := worker.NewGougingChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
+		gc := gouging.NewChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
 
 		// set the host's block height to ours to disable the height check in
 		// the gouging checks, in certain edge cases the renter might unsync and
@@ -778,8 +887,8 @@ func (c *contractor) runContractChecks(ctx context.Context, w Worker, contracts
 		// whole new set of contracts with new hosts
 		host.PriceTable.HostBlockHeight = cs.BlockHeight
 
-		// decide whether the host is still good
-		usable, unusableResult := isUsableHost(state.cfg, state.rs, gc, host.Host, minScore, contract.FileSize())
+		// decide whether the host is still good, favouring incumbents
+		usable, unusableResult := isUsableHost(state.cfg, state.rs, gc, host.Host, incumbentMinScore(state.cfg, minScore), contract.FileSize())
 		if !usable {
 			reasons := unusableResult.reasons()
 			toStopUsing[fcid] = strings.Join(reasons, ",")
@@ -896,7 +1005,7 @@ func (c *contractor) runContractFormations(ctx context.Context, w Worker, candid
 	lastStateUpdate := time.Now()
 
 	// prepare a gouging checker
-	gc := worker.NewGougingChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
+	gc := gouging.NewChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
 
 	// prepare an IP filter that contains all used hosts
 	ipFilter := c.newIPFilter()
@@ -911,7 +1020,27 @@ func (c *contractor) runContractFormations(ctx context.Context, w Worker, candid
 	// calculate min/max contract funds
 	minInitialContractFunds, maxInitialContractFunds := initialContractFundingMinMax(state.cfg)
 
-	for h := 0; missing > 0 && h < len(selected); h++ {
+	// determine how many contracts we can form concurrently without the
+	// formations competing for the same wallet outputs, dust outputs can't
+	// fund a formation so we only count the ones that can
+	concurrency := c.formationConcurrency
+	if concurrency > 1 {
+		if outputs, err := c.ap.bus.WalletOutputs(ctx); err != nil {
+			c.logger.Errorf("failed to fetch wallet outputs, forming contracts sequentially, err: %v", err)
+			concurrency = 1
+		} else {
+			txnFee := state.fee.Mul64(estimatedFileContractTransactionSetSize)
+			concurrency = formationConcurrency(concurrency, fundingOutputs(outputs, maxInitialContractFunds.Add(txnFee)))
+		}
+	}
+
+	// prepare a budget that is shared by the formations
+	fb := newFormationBudget(budget)
+
+	// launch the formations
+	fs := newFormationScheduler(concurrency, missing, c.updateFormationProgress)
+	defer c.updateFormationProgress(0, 0)
+	for h := 0; h < len(selected) && fs.Next(); h++ {
 		host := selected[h].host
 
 		// break if the autopilot is stopped
@@ -933,7 +1062,7 @@ func (c *contractor) runContractFormations(ctx context.Context, w Worker, candid
 				c.logger.Errorf("could not fetch consensus state, err: %v", err)
 			} else {
 				cs = css
-				gc = worker.NewGougingChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
+				gc = gouging.NewChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
 			}
 		}
 
@@ -948,18 +1077,12 @@ func (c *contractor) runContractFormations(ctx context.Context, w Worker, candid
 			continue
 		}
 
-		formedContract, proceed, err := c.formContract(ctx, w, host, minInitialContractFunds, maxInitialContractFunds, budget)
-		if err == nil {
-			// add contract to contract set
-			formed = append(formed, formedContract)
-			missing--
-		}
-		if !proceed {
-			break
-		}
+		fs.Launch(func() (api.ContractMetadata, bool, error) {
+			return c.formContract(ctx, w, host, minInitialContractFunds, maxInitialContractFunds, fb)
+		})
 	}
 
-	return formed, nil
+	return fs.Wait(), nil
 }
 
 // runRevisionBroadcast broadcasts contract revisions from the current set of
@@ -1194,10 +1317,11 @@ func (c *contractor) renewFundingEstimate(ctx context.Context, ci contractInfo,
 
 	// estimate the
 	// - upload cost: previous uploads + prev storage
-	// - download cost: assumed to be the same
 	// - fund acount cost: assumed to be the same
+	//
+	// NOTE: downloads are paid for using ephemeral accounts, their spending is
+	// already part of the fund account spending so we don't add it again
 	newUploadsCost := prevSpending.Uploads.Add(sectorUploadCost.Mul(prevUploadDataEstimate.Div64(rhpv2.SectorSize)))
-	newDownloadsCost := prevSpending.Downloads
 	newFundAccountCost := prevSpending.FundAccount
 
 	// estimate the siafund fees
@@ -1206,7 +1330,7 @@ func (c *contractor) renewFundingEstimate(ctx context.Context, ci contractInfo,
 	// because users are not charged siafund fees on money that doesn't go into
 	// the file contract (and the transaction fee goes to the miners, not the
 	// file contract).
-	subTotal := storageCost.Add(newUploadsCost).Add(newDownloadsCost).Add(newFundAccountCost).Add(ci.settings.ContractPrice)
+	subTotal := storageCost.Add(newUploadsCost).Add(newFundAccountCost).Add(ci.settings.ContractPrice)
 	siaFundFeeEstimate, err := c.ap.bus.FileContractTax(ctx, subTotal)
 	if err != nil {
 		return types.ZeroCurrency, err
@@ -1235,7 +1359,6 @@ func (c *contractor) renewFundingEstimate(ctx context.Context, ci contractInfo,
 			"dataStored", dataStored,
 			"storageCost", storageCost.String(),
 			"newUploadsCost", newUploadsCost.String(),
-			"newDownloadsCost", newDownloadsCost.String(),
 			"newFundAccountCost", newFundAccountCost.String(),
 			"contractPrice", ci.settings.ContractPrice.String(),
 			"prevUploadDataEstimate", prevUploadDataEstimate.String(),
@@ -1310,7 +1433,7 @@ func (c *contractor) candidateHosts(ctx context.Context, hosts []hostdb.Host, us
 
 	// create a gouging checker
 	state := c.ap.State()
-	gc := worker.NewGougingChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
+	gc := gouging.NewChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
 
 	// select unused hosts that passed a scan
 	var unused []hostdb.Host
@@ -1440,6 +1563,7 @@ func (c *contractor) renewContract(ctx context.Context, w Worker, ci contractInf
 		c.logger.Errorw(fmt.Sprintf("renewal failed to persist, err: %v", err), "hk", hk, "fcid", fcid)
 		return api.ContractMetadata{}, false, err
 	}
+	c.cacheRevision(resp.Contract)
 
 	newCollateral := resp.Contract.Revision.MissedHostPayout().Sub(resp.ContractPrice)
 	c.logger.Debugw(
@@ -1523,6 +1647,7 @@ func (c *contractor) refreshContract(ctx context.Context, w Worker, ci contractI
 		c.logger.Errorw("adding refreshed contract failed", zap.Error(err), "hk", hk, "fcid", fcid)
 		return api.ContractMetadata{}, false, err
 	}
+	c.cacheRevision(resp.Contract)
 
 	// add to renewed set
 	newCollateral := resp.Contract.Revision.MissedHostPayout().Sub(resp.ContractPrice)
@@ -1536,7 +1661,7 @@ func (c *contractor) refreshContract(ctx context.Context, w Worker, ci contractI
 	return refreshedContract, true, nil
 }
 
-func (c *contractor) formContract(ctx context.Context, w Worker, host hostdb.Host, minInitialContractFunds, maxInitialContractFunds types.Currency, budget *types.Currency) (cm api.ContractMetadata, proceed bool, err error) {
+func (c *contractor) formContract(ctx context.Context, w Worker, host hostdb.Host, minInitialContractFunds, maxInitialContractFunds types.Currency, budget *formationBudget) (cm api.ContractMetadata, proceed bool, err error) {
 	// convenience variables
 	state := c.ap.State()
 	hk := host.PublicKey
@@ -1546,6 +1671,12 @@ func (c *contractor) formContract(ctx context.Context, w Worker, host hostdb.Hos
 	if err != nil {
 		c.logger.Debugw(err.Error(), "hk", hk)
 		return api.ContractMetadata{}, true, err
+	} else if scan.ScanError != "" {
+		// the host isn't reachable on its announced address, the failed scan
+		// was recorded by the worker so we skip it without forming a contract
+		err = fmt.Errorf("failed to scan host: %v", scan.ScanError)
+		c.logger.Debugw(err.Error(), "hk", hk)
+		return api.ContractMetadata{}, true, err
 	}
 
 	// fetch consensus state
@@ -1557,19 +1688,21 @@ func (c *contractor) formContract(ctx context.Context, w Worker, host hostdb.Hos
 	// check our budget
 	txnFee := state.fee.Mul64(estimatedFileContractTransactionSetSize)
 	renterFunds := initialContractFunding(scan.Settings, txnFee, minInitialContractFunds, maxInitialContractFunds)
-	if budget.Cmp(renterFunds) < 0 {
-		c.logger.Debugw("insufficient budget", "budget", budget, "needed", renterFunds)
+	if !budget.Reserve(renterFunds) {
+		c.logger.Debugw("insufficient budget", "budget", budget.Current(), "needed", renterFunds)
 		return api.ContractMetadata{}, false, errors.New("insufficient budget")
 	}
 
 	// calculate the host collateral
-	endHeight := endHeight(state.cfg, state.period)
+	endHeight := formationEndHeight(state.cfg, state.period, cs.BlockHeight, scan.Settings.MaxDuration)
 	expectedStorage := renterFundsToExpectedStorage(renterFunds, endHeight-cs.BlockHeight, scan.PriceTable)
 	hostCollateral := rhpv2.ContractFormationCollateral(state.cfg.Contracts.Period, expectedStorage, scan.Settings)
 
 	// form contract
 	contract, _, err := w.RHPForm(ctx, endHeight, hk, host.NetAddress, state.address, renterFunds, hostCollateral)
 	if err != nil {
+		budget.Release(renterFunds)
+
 		// TODO: keep track of consecutive failures and break at some point
 		c.logger.Errorw(fmt.Sprintf("contract formation failed, err: %v", err), "hk", hk)
 		if strings.Contains(err.Error(), wallet.ErrInsufficientBalance.Error()) {
@@ -1578,9 +1711,6 @@ func (c *contractor) formContract(ctx context.Context, w Worker, host hostdb.Hos
 		return api.ContractMetadata{}, true, err
 	}
 
-	// update the budget
-	*budget = budget.Sub(renterFunds)
-
 	// persist contract in store
 	contractPrice := contract.Revision.MissedHostPayout().Sub(hostCollateral)
 	formedContract, err := c.ap.bus.AddContract(ctx, contract, contractPrice, renterFunds, cs.BlockHeight, api.ContractStatePending)
@@ -1588,6 +1718,7 @@ func (c *contractor) formContract(ctx context.Context, w Worker, host hostdb.Hos
 		c.logger.Errorw(fmt.Sprintf("contract formation failed, err: %v", err), "hk", hk)
 		return api.ContractMetadata{}, true, err
 	}
+	c.cacheRevision(contract)
 
 	c.logger.Debugw("formation succeeded",
 		"hk", hk,
@@ -1638,7 +1769,28 @@ func addLeeway(n uint64, pct float64) uint64 {
 }
 
 func endHeight(cfg api.AutopilotConfig, currentPeriod uint64) uint64 {
-	return currentPeriod + cfg.Contracts.Period + cfg.Contracts.RenewWindow
+	eh := currentPeriod + cfg.Contracts.Period + cfg.Contracts.RenewWindow
+	if cfg.Contracts.AlignEndHeight && cfg.Contracts.Period > 0 {
+		if rem := eh % cfg.Contracts.Period; rem != 0 {
+			eh += cfg.Contracts.Period - rem
+		}
+	}
+	return eh
+}
+
+// formationEndHeight returns the end height for newly formed contracts, which
+// is extended if the contract would otherwise be shorter than the configured
+// minimum duration. The end height is capped by the host's max duration since
+// the host would reject the contract otherwise.
+func formationEndHeight(cfg api.AutopilotConfig, currentPeriod, bh, maxDuration uint64) uint64 {
+	eh := endHeight(cfg, currentPeriod)
+	if eh < bh+cfg.Contracts.MinDuration {
+		eh = bh + cfg.Contracts.MinDuration
+	}
+	if eh > bh+maxDuration {
+		eh = bh + maxDuration
+	}
+	return eh
 }
 
 func initialContractFunding(settings rhpv2.HostSettings, txnFee, min, max types.Currency) types.Currency {
@@ -1675,6 +1827,8 @@ func refreshPriceTable(ctx context.Context, w Worker, host *hostdb.Host) error {
 		scan, err := w.RHPScan(ctx, host.PublicKey, host.NetAddress, timeoutHostScan)
 		if err != nil {
 			return fmt.Errorf("failed to scan host %v: %w", host.PublicKey, err)
+		} else if scan.ScanError != "" {
+			return fmt.Errorf("failed to scan host %v: %v", host.PublicKey, scan.ScanError)
 		}
 		host.Settings = scan.Settings
 	}
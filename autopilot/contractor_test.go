@@ -4,7 +4,12 @@ import (
 	"context"
 	"math"
 	"testing"
+	"time"
 
+	rhpv2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/hostdb"
 	"go.uber.org/zap"
 )
 
@@ -36,3 +41,123 @@ func TestCalculateMinScore(t *testing.T) {
 		t.Fatalf("expected minScore to be math.SmallestNonzeroFLoat64 but was %v", minScore)
 	}
 }
+
+// unreachableHostWorker is a worker that fails to scan hosts and fails the
+// test if it's used to form a contract.
+type unreachableHostWorker struct {
+	Worker
+	t *testing.T
+}
+
+func (w *unreachableHostWorker) RHPScan(context.Context, types.PublicKey, string, time.Duration) (api.RHPScanResponse, error) {
+	return api.RHPScanResponse{ScanError: "connection refused"}, nil
+}
+
+func (w *unreachableHostWorker) RHPForm(context.Context, uint64, types.PublicKey, string, types.Address, types.Currency, types.Currency) (rhpv2.ContractRevision, []types.Transaction, error) {
+	w.t.Fatal("unexpected contract formation")
+	return rhpv2.ContractRevision{}, nil, nil
+}
+
+func TestFormContractUnreachableHost(t *testing.T) {
+	c := &contractor{
+		ap:     &Autopilot{},
+		logger: zap.NewNop().Sugar(),
+	}
+
+	// assert we don't form a contract with a host that we can't scan but we
+	// do proceed with the other formations
+	budget := types.Siacoins(1)
+	w := &unreachableHostWorker{t: t}
+	_, proceed, err := c.formContract(context.Background(), w, hostdb.Host{PublicKey: types.PublicKey{1}}, types.ZeroCurrency, types.Siacoins(1), newFormationBudget(&budget))
+	if err == nil {
+		t.Fatal("expected formation to fail")
+	} else if !proceed {
+		t.Fatal("expected to proceed with other formations")
+	} else if !budget.Equals(types.Siacoins(1)) {
+		t.Fatal("unexpected budget", budget)
+	}
+}
+
+func TestEndHeight(t *testing.T) {
+	var cfg api.AutopilotConfig
+	cfg.Contracts.Period = 100
+	cfg.Contracts.RenewWindow = 20
+
+	// without alignment the end height is the end of the period plus the
+	// renew window
+	if eh := endHeight(cfg, 55); eh != 175 {
+		t.Fatal("unexpected end height", eh)
+	}
+
+	// with alignment it's rounded up to the next period boundary
+	cfg.Contracts.AlignEndHeight = true
+	for _, period := range []uint64{55, 60, 79} {
+		if eh := endHeight(cfg, period); eh != 200 {
+			t.Fatal("unexpected end height", period, eh)
+		}
+	}
+
+	// heights that are already aligned aren't changed
+	if eh := endHeight(cfg, 80); eh != 200 {
+		t.Fatal("unexpected end height", eh)
+	} else if eh := endHeight(cfg, 180); eh != 300 {
+		t.Fatal("unexpected end height", eh)
+	}
+}
+
+func TestFormationEndHeight(t *testing.T) {
+	var cfg api.AutopilotConfig
+	cfg.Contracts.Period = 100
+	cfg.Contracts.RenewWindow = 20
+
+	// without a minimum duration the end height isn't extended
+	if eh := formationEndHeight(cfg, 100, 190, math.MaxUint64/2); eh != 220 {
+		t.Fatal("unexpected end height", eh)
+	}
+
+	// contracts that are long enough aren't extended
+	cfg.Contracts.MinDuration = 50
+	if eh := formationEndHeight(cfg, 100, 150, math.MaxUint64/2); eh != 220 {
+		t.Fatal("unexpected end height", eh)
+	}
+
+	// contracts that would be too short are extended
+	if eh := formationEndHeight(cfg, 100, 190, math.MaxUint64/2); eh != 240 {
+		t.Fatal("unexpected end height", eh)
+	}
+
+	// contracts that exceed the host's max duration are capped
+	if eh := formationEndHeight(cfg, 100, 150, 60); eh != 210 {
+		t.Fatal("unexpected end height", eh)
+	}
+}
+
+func TestRenewalLimit(t *testing.T) {
+	newContract := func(id byte, size uint64) contractInfo {
+		return contractInfo{contract: api.Contract{
+			ContractMetadata: api.ContractMetadata{ID: types.FileContractID{id}, Size: size},
+		}}
+	}
+	toRenew := []contractInfo{
+		newContract(1, 10),
+		newContract(2, 30),
+		newContract(3, 20),
+	}
+	inSet := map[types.FileContractID]struct{}{{3}: {}}
+
+	// contracts in the set come first, the rest is sorted by size
+	if limit := renewalLimit(toRenew, inSet, 0, 5); limit != 3 {
+		t.Fatal("unexpected limit", limit)
+	} else if toRenew[0].contract.ID != (types.FileContractID{3}) ||
+		toRenew[1].contract.ID != (types.FileContractID{2}) ||
+		toRenew[2].contract.ID != (types.FileContractID{1}) {
+		t.Fatal("unexpected order", toRenew)
+	}
+
+	// only renew what's missing
+	if limit := renewalLimit(toRenew, inSet, 4, 5); limit != 1 {
+		t.Fatal("unexpected limit", limit)
+	} else if limit := renewalLimit(toRenew, inSet, 5, 5); limit != 0 {
+		t.Fatal("unexpected limit", limit)
+	}
+}
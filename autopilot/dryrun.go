@@ -0,0 +1,119 @@
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.sia.tech/renterd/api"
+)
+
+// DryRun computes the actions the autopilot would take in its next iteration
+// without performing them. It reuses the decisions made by the contractor and
+// the migrator but never forms, renews, refreshes or archives contracts and
+// never migrates any slabs. It is read-only, it doesn't perform any RPCs with
+// hosts and uses the cached price tables, revisions and slab health instead.
+func (ap *Autopilot) DryRun(ctx context.Context) (resp api.AutopilotDryRunResponse, err error) {
+	resp, err = ap.c.dryRun(ctx)
+	if err != nil {
+		return api.AutopilotDryRunResponse{}, err
+	}
+
+	resp.ToMigrate, err = ap.m.dryRun(ctx)
+	if err != nil {
+		return api.AutopilotDryRunResponse{}, err
+	}
+	return
+}
+
+func (c *contractor) dryRun(ctx context.Context) (resp api.AutopilotDryRunResponse, _ error) {
+	state := c.ap.State()
+	if err := validateMaintenanceConfig(state.cfg); err != nil {
+		return resp, err
+	}
+
+	// fetch current contract set
+	_, isInCurrentSet, err := c.fetchCurrentSet(ctx, state.cfg.Contracts.Set)
+	if err != nil {
+		return resp, fmt.Errorf("failed to fetch contract set from bus: %w", err)
+	}
+
+	// fetch all contracts from the bus, fetching their revisions from the
+	// hosts isn't free so we use the ones of the last maintenance instead
+	metadata, err := c.ap.bus.Contracts(ctx, api.ContractsOpts{})
+	if err != nil {
+		return resp, fmt.Errorf("failed to fetch contracts from bus: %w", err)
+	}
+	c.mu.Lock()
+	contracts := make([]api.Contract, 0, len(metadata))
+	for _, md := range metadata {
+		contracts = append(contracts, api.Contract{
+			ContractMetadata: md,
+			Revision:         c.cachedRevisions[md.ID],
+		})
+	}
+	c.mu.Unlock()
+
+	// fetch hosts and candidates
+	hs, err := c.fetchMaintenanceHosts(ctx, contracts)
+	if err != nil {
+		return resp, fmt.Errorf("failed to fetch hosts from bus: %w", err)
+	}
+	candidates := hs.candidates
+
+	// run checks
+	updatedSet, toArchive, toStopUsing, toRefresh, toRenew, err := c.runContractChecks(ctx, nil, contracts, isInCurrentSet, hs.minScore)
+	if err != nil {
+		return resp, fmt.Errorf("failed to run contract checks, err: %v", err)
+	}
+	resp.ToArchive = toArchive
+	resp.ToStopUsing = toStopUsing
+
+	// assume all renewals and refreshes succeed
+	numContracts := len(updatedSet)
+	limit := renewalLimit(toRenew, isInCurrentSet, len(updatedSet), state.cfg.Contracts.Amount)
+	for _, ci := range toRenew[:limit] {
+		resp.ToRenew = append(resp.ToRenew, ci.contract.ID)
+		if ci.usable || ci.recoverable {
+			numContracts++
+		}
+	}
+	for _, ci := range toRefresh {
+		resp.ToRefresh = append(resp.ToRefresh, ci.contract.ID)
+		if ci.usable || ci.recoverable {
+			numContracts++
+		}
+	}
+
+	// check if we need to form contracts
+	if uint64(numContracts) < formationThreshold(state.cfg, len(contracts)) {
+		resp.ToForm = state.cfg.Contracts.Amount - uint64(numContracts)
+
+		// the formations select hosts randomly, weighted by score, so we
+		// return the best candidates instead
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].score > candidates[j].score
+		})
+		wanted := int(addLeeway(resp.ToForm, leewayPctCandidateHosts))
+		for i := 0; i < len(candidates) && i < wanted; i++ {
+			resp.Candidates = append(resp.Candidates, candidates[i].host.PublicKey)
+		}
+	}
+	return resp, nil
+}
+
+func (m *migrator) dryRun(ctx context.Context) ([]api.UnhealthySlab, error) {
+	set := m.ap.State().cfg.Contracts.Set
+	if set == "" {
+		return nil, errors.New("no contract set configured")
+	}
+
+	// fetch slabs for migration based on their stored health, recomputing
+	// it would write to the database
+	toMigrate, err := m.ap.bus.SlabsForMigration(ctx, m.healthCutoff, set, migratorBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch slabs for migration: %w", err)
+	}
+	return toMigrate, nil
+}
@@ -0,0 +1,158 @@
+package autopilot
+
+import (
+	"sync"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/wallet"
+)
+
+type (
+	// formationBudget is the budget shared by concurrent contract formations,
+	// funds are reserved before a contract is formed and released again if
+	// the formation fails.
+	formationBudget struct {
+		mu     sync.Mutex
+		budget *types.Currency
+	}
+
+	// formationScheduler bounds the number of contract formations that run
+	// concurrently. It only launches formations for contracts that are still
+	// missing and not in progress, and stops launching formations once a
+	// formation indicates we shouldn't proceed.
+	formationScheduler struct {
+		concurrency uint64
+		missing     uint64
+		onProgress  func(inProgress, remaining uint64)
+
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		cond       *sync.Cond
+		formed     []api.ContractMetadata
+		inProgress uint64
+		stopped    bool
+	}
+)
+
+func newFormationBudget(budget *types.Currency) *formationBudget {
+	return &formationBudget{budget: budget}
+}
+
+// Current returns the remaining budget.
+func (b *formationBudget) Current() types.Currency {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return *b.budget
+}
+
+// Reserve subtracts the given amount from the budget, it returns false if the
+// budget is insufficient.
+func (b *formationBudget) Reserve(amount types.Currency) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.budget.Cmp(amount) < 0 {
+		return false
+	}
+	*b.budget = b.budget.Sub(amount)
+	return true
+}
+
+// Release adds the given amount back to the budget.
+func (b *formationBudget) Release(amount types.Currency) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	*b.budget = b.budget.Add(amount)
+}
+
+func newFormationScheduler(concurrency, missing uint64, onProgress func(inProgress, remaining uint64)) *formationScheduler {
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	s := &formationScheduler{
+		concurrency: concurrency,
+		missing:     missing,
+		onProgress:  onProgress,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Next blocks until another formation can be launched, it returns false if no
+// more formations should be launched because enough contracts were formed or
+// a formation indicated we shouldn't proceed.
+func (s *formationScheduler) Next() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for !s.stopped && s.inProgress > 0 && (s.inProgress >= s.concurrency || uint64(len(s.formed))+s.inProgress >= s.missing) {
+		s.cond.Wait()
+	}
+	return !s.stopped && uint64(len(s.formed)) < s.missing
+}
+
+// Launch runs the given formation in a separate goroutine, it should only be
+// called after Next returned true.
+func (s *formationScheduler) Launch(form func() (api.ContractMetadata, bool, error)) {
+	s.mu.Lock()
+	s.inProgress++
+	s.progress()
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		formed, proceed, err := form()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.inProgress--
+		if err == nil {
+			s.formed = append(s.formed, formed)
+		}
+		if !proceed {
+			s.stopped = true
+		}
+		s.progress()
+		s.cond.Broadcast()
+	}()
+}
+
+// Wait blocks until all launched formations are done and returns the formed
+// contracts.
+func (s *formationScheduler) Wait() []api.ContractMetadata {
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.formed
+}
+
+func (s *formationScheduler) progress() {
+	if s.onProgress != nil {
+		s.onProgress(s.inProgress, s.missing-uint64(len(s.formed)))
+	}
+}
+
+// formationConcurrency returns the number of contracts to form concurrently.
+// Every formation locks at least one wallet output, so the concurrency is
+// bounded by the number of outputs that can fund a formation on their own to
+// avoid formations failing because the outputs they need are locked by other
+// formations.
+func formationConcurrency(concurrency uint64, spendableOutputs int) uint64 {
+	if concurrency == 0 || spendableOutputs <= 1 {
+		return 1
+	} else if uint64(spendableOutputs) < concurrency {
+		return uint64(spendableOutputs)
+	}
+	return concurrency
+}
+
+// fundingOutputs returns the number of outputs that can fund a formation on
+// their own, i.e. outputs whose value is at least the given amount.
+func fundingOutputs(outputs []wallet.SiacoinElement, amount types.Currency) (n int) {
+	for _, sce := range outputs {
+		if sce.Value.Cmp(amount) >= 0 {
+			n++
+		}
+	}
+	return
+}
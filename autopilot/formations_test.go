@@ -0,0 +1,159 @@
+package autopilot
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/wallet"
+)
+
+func TestFormationScheduler(t *testing.T) {
+	// helper to run n formations with the given concurrency, the formations
+	// fail right away if the fail func returns true for their index, the
+	// second return value indicates whether to proceed after the failure
+	run := func(n int, concurrency, missing uint64, fail func(i int) (bool, bool)) (formed []api.ContractMetadata, launched, maxInProgress int) {
+		t.Helper()
+
+		var remaining []uint64
+		fs := newFormationScheduler(concurrency, missing, func(inProgress, r uint64) {
+			if int(inProgress) > maxInProgress {
+				maxInProgress = int(inProgress)
+			}
+			remaining = append(remaining, r)
+		})
+
+		for i := 0; i < n && fs.Next(); i++ {
+			i := i
+			launched++
+			fs.Launch(func() (api.ContractMetadata, bool, error) {
+				if failed, proceed := fail(i); failed {
+					return api.ContractMetadata{}, proceed, errors.New("formation failed")
+				}
+				time.Sleep(10 * time.Millisecond)
+				return api.ContractMetadata{ID: types.FileContractID{byte(i)}}, true, nil
+			})
+		}
+		formed = fs.Wait()
+
+		if r := remaining[len(remaining)-1]; r != missing-uint64(len(formed)) {
+			t.Fatalf("expected %v remaining formations, got %v", missing-uint64(len(formed)), r)
+		}
+		return
+	}
+	succeed := func(int) (bool, bool) { return false, true }
+
+	// form N contracts concurrently
+	formed, launched, maxInProgress := run(20, 5, 10, succeed)
+	if len(formed) != 10 || launched != 10 {
+		t.Fatalf("expected 10 formed and launched formations, got %v and %v", len(formed), launched)
+	} else if maxInProgress != 5 {
+		t.Fatalf("expected 5 concurrent formations, got %v", maxInProgress)
+	}
+
+	// assert formations are performed sequentially without concurrency
+	formed, _, maxInProgress = run(20, 1, 10, succeed)
+	if len(formed) != 10 {
+		t.Fatalf("expected 10 formed contracts, got %v", len(formed))
+	} else if maxInProgress != 1 {
+		t.Fatalf("expected 1 concurrent formation, got %v", maxInProgress)
+	}
+
+	// assert we never form more contracts than are missing
+	formed, launched, maxInProgress = run(20, 10, 3, succeed)
+	if len(formed) != 3 || launched != 3 || maxInProgress != 3 {
+		t.Fatalf("unexpected formations %v %v %v", len(formed), launched, maxInProgress)
+	}
+
+	// assert failed formations are replaced by other candidates
+	formed, launched, _ = run(20, 4, 10, func(i int) (bool, bool) { return i%2 == 0, true })
+	if len(formed) != 10 {
+		t.Fatalf("expected 10 formed contracts, got %v", len(formed))
+	} else if launched < 20 {
+		t.Fatalf("expected all candidates to be launched, got %v", launched)
+	}
+
+	// assert no new formations are launched once a formation indicates we
+	// shouldn't proceed
+	formed, launched, _ = run(20, 4, 10, func(i int) (bool, bool) { return i == 0, i != 0 })
+	if launched > 4 {
+		t.Fatalf("expected at most 4 formations to be launched, got %v", launched)
+	} else if len(formed) != launched-1 {
+		t.Fatalf("expected %v formed contracts, got %v", launched-1, len(formed))
+	}
+}
+
+func TestFormationBudget(t *testing.T) {
+	budget := types.Siacoins(10)
+	fb := newFormationBudget(&budget)
+
+	// reserve the budget concurrently
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var reserved int
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if fb.Reserve(types.Siacoins(1)) {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 10 {
+		t.Fatalf("expected 10 reservations, got %v", reserved)
+	} else if !budget.IsZero() {
+		t.Fatal("expected budget to be depleted", budget)
+	}
+
+	// release some funds
+	fb.Release(types.Siacoins(2))
+	if !fb.Current().Equals(types.Siacoins(2)) {
+		t.Fatal("unexpected budget", fb.Current())
+	} else if fb.Reserve(types.Siacoins(3)) {
+		t.Fatal("expected reservation to fail")
+	}
+}
+
+func TestFormationConcurrency(t *testing.T) {
+	tests := []struct {
+		concurrency uint64
+		outputs     int
+		expected    uint64
+	}{
+		{0, 10, 1},
+		{1, 10, 1},
+		{5, 10, 5},
+		{5, 3, 3},
+		{5, 1, 1},
+		{5, 0, 1},
+	}
+	for _, test := range tests {
+		if got := formationConcurrency(test.concurrency, test.outputs); got != test.expected {
+			t.Errorf("formationConcurrency(%v, %v) = %v, expected %v", test.concurrency, test.outputs, got, test.expected)
+		}
+	}
+}
+
+func TestFundingOutputs(t *testing.T) {
+	output := func(sc uint32) wallet.SiacoinElement {
+		return wallet.SiacoinElement{SiacoinOutput: types.SiacoinOutput{Value: types.Siacoins(sc)}}
+	}
+	outputs := []wallet.SiacoinElement{output(1), output(10), output(9), output(100), output(0)}
+
+	// only the outputs that can fund a formation should be counted
+	if n := fundingOutputs(outputs, types.Siacoins(10)); n != 2 {
+		t.Fatalf("expected 2 funding outputs, got %v", n)
+	} else if n := fundingOutputs(outputs, types.Siacoins(1000)); n != 0 {
+		t.Fatalf("expected no funding outputs, got %v", n)
+	} else if n := formationConcurrency(5, fundingOutputs(outputs, types.Siacoins(10))); n != 2 {
+		t.Fatalf("expected a concurrency of 2, got %v", n)
+	}
+}
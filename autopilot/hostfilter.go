@@ -12,7 +12,7 @@ import (
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/hostdb"
-	"go.sia.tech/renterd/worker"
+	"go.sia.tech/renterd/internal/gouging"
 )
 
 const (
@@ -41,6 +41,7 @@ var (
 	errHostNotAcceptingContracts = errors.New("host is not accepting contracts")
 	errHostNotCompletingScan     = errors.New("host is not completing scan")
 	errHostNotAnnounced          = errors.New("host is not announced")
+	errHostLowMaxDuration        = errors.New("host's max duration is below the minimum contract duration")
 
 	errContractOutOfCollateral   = errors.New("contract is out of collateral")
 	errContractOutOfFunds        = errors.New("contract is out of funds")
@@ -49,6 +50,7 @@ var (
 	errContractNoRevision        = errors.New("contract has no revision")
 	errContractExpired           = errors.New("contract has expired")
 	errContractNotConfirmed      = errors.New("contract hasn't been confirmed on chain in time")
+	errContractNotInSet          = errors.New("contract is not in the contract set")
 )
 
 type unusableHostResult struct {
@@ -60,6 +62,7 @@ type unusableHostResult struct {
 	notacceptingcontracts uint64
 	notannounced          uint64
 	notcompletingscan     uint64
+	lowmaxduration        uint64
 	unknown               uint64
 
 	// gougingBreakdown is mostly ignored, we overload the unusableHostResult
@@ -91,6 +94,8 @@ func newUnusableHostResult(errs []error, gougingBreakdown api.HostGougingBreakdo
 			u.notannounced++
 		} else if errors.Is(err, errHostNotCompletingScan) {
 			u.notcompletingscan++
+		} else if errors.Is(err, errHostLowMaxDuration) {
+			u.lowmaxduration++
 		} else {
 			u.unknown++
 		}
@@ -131,6 +136,9 @@ func (u unusableHostResult) reasons() []string {
 	if u.notcompletingscan > 0 {
 		reasons = append(reasons, errHostNotCompletingScan.Error())
 	}
+	if u.lowmaxduration > 0 {
+		reasons = append(reasons, errHostLowMaxDuration.Error())
+	}
 	if u.unknown > 0 {
 		reasons = append(reasons, "unknown")
 	}
@@ -146,6 +154,7 @@ func (u *unusableHostResult) merge(other unusableHostResult) {
 	u.notacceptingcontracts += other.notacceptingcontracts
 	u.notannounced += other.notannounced
 	u.notcompletingscan += other.notcompletingscan
+	u.lowmaxduration += other.lowmaxduration
 	u.unknown += other.unknown
 
 	// scoreBreakdown is not merged
@@ -163,6 +172,7 @@ func (u *unusableHostResult) keysAndValues() []interface{} {
 		"notacceptingcontracts", u.notacceptingcontracts,
 		"notcompletingscan", u.notcompletingscan,
 		"notannounced", u.notannounced,
+		"lowmaxduration", u.lowmaxduration,
 		"unknown", u.unknown,
 	}
 	for i := 0; i < len(values); i += 2 {
@@ -176,7 +186,7 @@ func (u *unusableHostResult) keysAndValues() []interface{} {
 
 // isUsableHost returns whether the given host is usable along with a list of
 // reasons why it was deemed unusable.
-func isUsableHost(cfg api.AutopilotConfig, rs api.RedundancySettings, gc worker.GougingChecker, h hostdb.Host, minScore float64, storedData uint64) (bool, unusableHostResult) {
+func isUsableHost(cfg api.AutopilotConfig, rs api.RedundancySettings, gc gouging.Checker, h hostdb.Host, minScore float64, storedData uint64) (bool, unusableHostResult) {
 	if rs.Validate() != nil {
 		panic("invalid redundancy settings were supplied - developer error")
 	}
@@ -200,6 +210,11 @@ func isUsableHost(cfg api.AutopilotConfig, rs api.RedundancySettings, gc worker.
 			errs = append(errs, errHostNotAcceptingContracts)
 		}
 
+		// max duration check
+		if h.Settings.MaxDuration < cfg.Contracts.MinDuration {
+			errs = append(errs, fmt.Errorf("%w: %v < %v", errHostLowMaxDuration, h.Settings.MaxDuration, cfg.Contracts.MinDuration))
+		}
+
 		// perform gouging checks
 		gougingBreakdown = gc.Check(&h.Settings, &h.PriceTable.HostPriceTable)
 		if gougingBreakdown.Gouging() {
@@ -86,3 +86,64 @@ func TestMinRemainingCollateral(t *testing.T) {
 		}
 	}
 }
+
+func TestIsUsableContractOutOfFunds(t *testing.T) {
+	t.Parallel()
+
+	cfg := api.AutopilotConfig{
+		Contracts: api.ContractsConfig{
+			Amount:      5,
+			Period:      100,
+			RenewWindow: 10,
+		},
+		Hosts: api.HostsConfig{
+			AllowRedundantIPs: true,
+		},
+	}
+	rs := api.RedundancySettings{MinShards: 1, TotalShards: 2}
+	one := types.NewCurrency64(1)
+	pt := rhpv3.HostPriceTable{
+		InitBaseCost:    one,
+		WriteBaseCost:   one,
+		ReadBaseCost:    one,
+		WriteLengthCost: one,
+		WriteStoreCost:  one,
+		ReadLengthCost:  one,
+	}
+
+	// helper to create a contract with the given remaining renter funds
+	newContract := func(renterFunds types.Currency) api.Contract {
+		return api.Contract{
+			ContractMetadata: api.ContractMetadata{
+				TotalCost:   types.Siacoins(100),
+				WindowStart: 1000,
+			},
+			Revision: &types.FileContractRevision{
+				FileContract: types.FileContract{
+					WindowStart:        1000,
+					ValidProofOutputs:  []types.SiacoinOutput{{Value: renterFunds}, {}},
+					MissedProofOutputs: []types.SiacoinOutput{{Value: renterFunds}, {}, {}},
+				},
+			},
+		}
+	}
+
+	c := &contractor{}
+	s := state{cfg: cfg, rs: rs}
+
+	// a contract with plenty of funds remains usable
+	ci := contractInfo{contract: newContract(types.Siacoins(50)), priceTable: pt}
+	usable, recoverable, refresh, renew, reasons := c.isUsableContract(cfg, s, ci, 100, nil)
+	if !usable || refresh || renew {
+		t.Fatal("unexpected", usable, recoverable, refresh, renew, reasons)
+	}
+
+	// a contract below the fund threshold gets refreshed instead of renewed
+	ci = contractInfo{contract: newContract(types.Siacoins(4)), priceTable: pt}
+	usable, recoverable, refresh, renew, reasons = c.isUsableContract(cfg, s, ci, 100, nil)
+	if usable || !recoverable || !refresh || renew {
+		t.Fatal("unexpected", usable, recoverable, refresh, renew)
+	} else if len(reasons) != 1 || reasons[0] != errContractOutOfFunds.Error() {
+		t.Fatal("unexpected reasons", reasons)
+	}
+}
@@ -3,11 +3,12 @@ package autopilot
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/hostdb"
-	"go.sia.tech/renterd/worker"
+	"go.sia.tech/renterd/internal/gouging"
 )
 
 func (c *contractor) HostInfo(ctx context.Context, hostKey types.PublicKey) (api.HostHandlerResponse, error) {
@@ -48,13 +49,13 @@ func (c *contractor) HostInfo(ctx context.Context, hostKey types.PublicKey) (api
 	minScore := c.cachedMinScore
 	c.mu.Unlock()
 
-	gc := worker.NewGougingChecker(gs, cs, fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
+	gc := gouging.NewChecker(gs, cs, fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
 
 	// ignore the pricetable's HostBlockHeight by setting it to our own blockheight
 	host.Host.PriceTable.HostBlockHeight = cs.BlockHeight
 
 	isUsable, unusableResult := isUsableHost(state.cfg, rs, gc, host.Host, minScore, storedData)
-	return api.HostHandlerResponse{
+	resp := api.HostHandlerResponse{
 		Host: host.Host,
 		Checks: &api.HostHandlerResponseChecks{
 			Gouging:          unusableResult.gougingBreakdown.Gouging(),
@@ -64,7 +65,14 @@ func (c *contractor) HostInfo(ctx context.Context, hostKey types.PublicKey) (api
 			Usable:           isUsable,
 			UnusableReasons:  unusableResult.reasons(),
 		},
-	}, nil
+	}
+
+	// add the host's RTT percentiles if we have any samples
+	if host.Interactions.RTT.Count() > 0 {
+		percentiles := host.Interactions.RTT.Percentiles()
+		resp.RTT = &percentiles
+	}
+	return resp, nil
 }
 
 func (c *contractor) hostInfoFromCache(ctx context.Context, host hostdb.Host) (hi hostInfo, found bool) {
@@ -89,7 +97,7 @@ func (c *contractor) hostInfoFromCache(ctx context.Context, host hostdb.Host) (h
 			c.logger.Error("failed to fetch consensus state from bus: %v", err)
 		} else {
 			state := c.ap.State()
-			gc := worker.NewGougingChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
+			gc := gouging.NewChecker(state.gs, cs, state.fee, state.cfg.Contracts.Period, state.cfg.Contracts.RenewWindow)
 			isUsable, unusableResult := isUsableHost(state.cfg, state.rs, gc, host, minScore, storedData)
 			hi = hostInfo{
 				Usable:         isUsable,
@@ -193,6 +201,70 @@ func (c *contractor) HostInfos(ctx context.Context, filterMode, usabilityMode, a
 	}
 }
 
+// AtRiskHosts returns all hosts with a contract in the renew window that won't
+// be renewed, either because the contract was dropped from the set or because
+// the host is no longer usable.
+func (c *contractor) AtRiskHosts(ctx context.Context) ([]api.AtRiskHost, error) {
+	cs, err := c.ap.bus.ConsensusState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch consensus state from bus: %w", err)
+	}
+	state := c.ap.State()
+	contracts, err := c.ap.bus.Contracts(ctx, api.ContractsOpts{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contracts from bus: %w", err)
+	}
+	setContracts, err := c.ap.bus.Contracts(ctx, api.ContractsOpts{ContractSet: state.cfg.Contracts.Set})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract set from bus: %w", err)
+	}
+	inSet := make(map[types.FileContractID]struct{}, len(setContracts))
+	for _, c := range setContracts {
+		inSet[c.ID] = struct{}{}
+	}
+
+	c.mu.Lock()
+	hostInfos := make(map[types.PublicKey]hostInfo, len(c.cachedHostInfo))
+	for hk, hi := range c.cachedHostInfo {
+		hostInfos[hk] = hi
+	}
+	c.mu.Unlock()
+
+	return atRiskHosts(state.cfg, cs.BlockHeight, contracts, inSet, hostInfos), nil
+}
+
+func atRiskHosts(cfg api.AutopilotConfig, bh uint64, contracts []api.ContractMetadata, inSet map[types.FileContractID]struct{}, hostInfos map[types.PublicKey]hostInfo) []api.AtRiskHost {
+	var hosts []api.AtRiskHost
+	for _, c := range contracts {
+		// ignore contracts outside of the renew window
+		if bh+cfg.Contracts.RenewWindow < c.WindowStart || bh >= c.WindowEnd {
+			continue
+		}
+
+		var reasons []string
+		if _, ok := inSet[c.ID]; !ok {
+			reasons = append(reasons, errContractNotInSet.Error())
+		}
+		if hi, ok := hostInfos[c.HostKey]; ok && !hi.Usable {
+			reasons = append(reasons, hi.UnusableResult.reasons()...)
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		hosts = append(hosts, api.AtRiskHost{
+			HostKey:    c.HostKey,
+			ContractID: c.ID,
+			EndHeight:  c.WindowStart,
+			Reasons:    reasons,
+		})
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].EndHeight < hosts[j].EndHeight
+	})
+	return hosts
+}
+
 func isValidUsabilityFilterMode(usabilityMode string) bool {
 	switch usabilityMode {
 	case api.UsabilityFilterModeUsable:
@@ -0,0 +1,38 @@
+package autopilot
+
+import (
+	"reflect"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+func TestAtRiskHosts(t *testing.T) {
+	var cfg api.AutopilotConfig
+	cfg.Contracts.RenewWindow = 10
+
+	hk1, hk2, hk3, hk4 := types.PublicKey{1}, types.PublicKey{2}, types.PublicKey{3}, types.PublicKey{4}
+	fcid1, fcid2, fcid3, fcid4 := types.FileContractID{1}, types.FileContractID{2}, types.FileContractID{3}, types.FileContractID{4}
+	contracts := []api.ContractMetadata{
+		{ID: fcid1, HostKey: hk1, WindowStart: 108, WindowEnd: 120}, // renewed
+		{ID: fcid2, HostKey: hk2, WindowStart: 105, WindowEnd: 120}, // not in set
+		{ID: fcid3, HostKey: hk3, WindowStart: 110, WindowEnd: 120}, // blocked
+		{ID: fcid4, HostKey: hk4, WindowStart: 150, WindowEnd: 160}, // not in renew window
+	}
+	inSet := map[types.FileContractID]struct{}{fcid1: {}, fcid3: {}}
+	hostInfos := map[types.PublicKey]hostInfo{
+		hk1: {Usable: true},
+		hk3: {UnusableResult: unusableHostResult{blocked: 1}},
+		hk4: {UnusableResult: unusableHostResult{offline: 1}},
+	}
+
+	hosts := atRiskHosts(cfg, 100, contracts, inSet, hostInfos)
+	expected := []api.AtRiskHost{
+		{HostKey: hk2, ContractID: fcid2, EndHeight: 105, Reasons: []string{errContractNotInSet.Error()}},
+		{HostKey: hk3, ContractID: fcid3, EndHeight: 110, Reasons: []string{errHostBlocked.Error()}},
+	}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Fatalf("unexpected hosts, %+v != %+v", hosts, expected)
+	}
+}
@@ -42,6 +42,18 @@ func hostScore(cfg api.AutopilotConfig, h hostdb.Host, storedData uint64, expect
 	}
 }
 
+// incumbentMinScore returns the min score hosts we already have contracts with
+// need to reach to be kept. Lowering the min score by the incumbent bonus is
+// equivalent to applying the bonus to their score. The bonus only ever lowers
+// the min score, incumbents are never held to a higher standard than
+// newcomers, and it doesn't change the score incumbents are ranked by.
+func incumbentMinScore(cfg api.AutopilotConfig, minScore float64) float64 {
+	if cfg.Hosts.IncumbentBonus <= 1 {
+		return minScore
+	}
+	return minScore / cfg.Hosts.IncumbentBonus
+}
+
 // priceAdjustmentScore computes a score between 0 and 1 for a host giving its
 // price settings and the autopilot's configuration.
 //   - 0.5 is returned if the host's costs exactly match the settings.
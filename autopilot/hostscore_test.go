@@ -1,6 +1,7 @@
 package autopilot
 
 import (
+	"errors"
 	"math"
 	"testing"
 	"time"
@@ -105,6 +106,47 @@ func TestHostScore(t *testing.T) {
 	}
 }
 
+func TestIncumbentMinScore(t *testing.T) {
+	newHost := func() hostdb.Host {
+		return newTestHost(randomHostKey(), newTestHostPriceTable(), newTestHostSettings())
+	}
+
+	// the newcomer scores marginally better than the incumbent, the min
+	// score is such that only the newcomer passes
+	redundancy := 3.0
+	incumbent, newcomer := newHost(), newHost()
+	newcomer.Interactions.SuccessfulInteractions++
+	minScore := hostScore(cfg, newcomer, 0, redundancy).Score()
+	score := hostScore(cfg, incumbent, 0, redundancy).Score()
+	if score >= minScore {
+		t.Fatal("expected incumbent to score lower than the newcomer")
+	}
+
+	// without a bonus the incumbent doesn't pass
+	if incumbentMinScore(cfg, minScore) != minScore {
+		t.Fatal("unexpected min score")
+	}
+
+	// with a bonus the incumbent is preferred at the margin
+	cfg := cfg
+	cfg.Hosts.IncumbentBonus = 1.1
+	if score < incumbentMinScore(cfg, minScore) {
+		t.Fatal("expected incumbent to pass with the bonus")
+	}
+
+	// but not if it's a lot worse
+	incumbent.PriceTable.WriteBaseCost = types.Siacoins(1)
+	if hostScore(cfg, incumbent, 0, redundancy).Score() >= incumbentMinScore(cfg, minScore) {
+		t.Fatal("expected expensive incumbent to fail despite the bonus")
+	}
+
+	// a bonus below 1 is rejected
+	cfg.Hosts.IncumbentBonus = 0.9
+	if err := cfg.Validate(); !errors.Is(err, api.ErrIncumbentBonusTooLow) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
 func TestPriceAdjustmentScore(t *testing.T) {
 	score := func(cpp uint32) float64 {
 		t.Helper()
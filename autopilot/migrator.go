@@ -25,12 +25,14 @@ type (
 		logger                    *zap.SugaredLogger
 		healthCutoff              float64
 		parallelSlabsPerWorker    uint64
+		maxConcurrentMigrations   uint64
 		signalMaintenanceFinished chan struct{}
 		statsSlabMigrationSpeedMS *stats.DataPoints
 
 		mu                 sync.Mutex
 		migrating          bool
 		migratingLastStart time.Time
+		inProgress         uint64
 	}
 
 	job struct {
@@ -59,12 +61,13 @@ func (j *job) execute(ctx context.Context, w Worker) (_ api.MigrateSlabResponse,
 	return res, nil
 }
 
-func newMigrator(ap *Autopilot, healthCutoff float64, parallelSlabsPerWorker uint64) *migrator {
+func newMigrator(ap *Autopilot, healthCutoff float64, parallelSlabsPerWorker, maxConcurrentMigrations uint64) *migrator {
 	return &migrator{
 		ap:                        ap,
 		logger:                    ap.logger.Named("migrator"),
 		healthCutoff:              healthCutoff,
 		parallelSlabsPerWorker:    parallelSlabsPerWorker,
+		maxConcurrentMigrations:   maxConcurrentMigrations,
 		signalMaintenanceFinished: make(chan struct{}, 1),
 		statsSlabMigrationSpeedMS: stats.New(time.Hour),
 	}
@@ -83,6 +86,24 @@ func (m *migrator) Status() (bool, time.Time) {
 	return m.migrating, m.migratingLastStart
 }
 
+// InProgress returns the number of slab migrations that are currently being
+// performed.
+func (m *migrator) InProgress() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inProgress
+}
+
+func (m *migrator) trackInProgress(delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if delta < 0 {
+		m.inProgress -= uint64(-delta)
+	} else {
+		m.inProgress += uint64(delta)
+	}
+}
+
 func (m *migrator) slabMigrationEstimate(remaining int) time.Duration {
 	// recompute p90
 	m.statsSlabMigrationSpeedMS.Recompute()
@@ -93,7 +114,11 @@ func (m *migrator) slabMigrationEstimate(remaining int) time.Duration {
 		return 0
 	}
 
-	totalNumMS := float64(remaining) * p90 / float64(m.parallelSlabsPerWorker)
+	parallel := m.parallelSlabsPerWorker
+	if m.maxConcurrentMigrations > 0 && m.maxConcurrentMigrations < parallel {
+		parallel = m.maxConcurrentMigrations
+	}
+	totalNumMS := float64(remaining) * p90 / float64(parallel)
 	return time.Duration(totalNumMS) * time.Millisecond
 }
 
@@ -123,7 +148,7 @@ func (m *migrator) performMigrations(p *workerPool) {
 
 	// prepare a channel to push work to the workers
 	jobs := make(chan job)
-	var wg sync.WaitGroup
+	var wg *sync.WaitGroup
 	defer func() {
 		close(jobs)
 		wg.Wait()
@@ -131,54 +156,7 @@ func (m *migrator) performMigrations(p *workerPool) {
 
 	// launch workers
 	p.withWorkers(func(workers []Worker) {
-		for _, w := range workers {
-			for i := uint64(0); i < m.parallelSlabsPerWorker; i++ {
-				wg.Add(1)
-				go func(w Worker) {
-					defer wg.Done()
-
-					// derive ctx from shutdown ctx
-					ctx, cancel := context.WithCancel(m.ap.shutdownCtx)
-					defer cancel()
-
-					// fetch worker id once
-					id, err := w.ID(ctx)
-					if err != nil {
-						m.logger.Errorf("failed to fetch worker id: %v", err)
-						return
-					}
-
-					// process jobs
-					for j := range jobs {
-						start := time.Now()
-						res, err := j.execute(ctx, w)
-						m.statsSlabMigrationSpeedMS.Track(float64(time.Since(start).Milliseconds()))
-
-						if err != nil {
-							m.logger.Errorf("%v: migration %d/%d failed, key: %v, health: %v, overpaid: %v, err: %v", id, j.slabIdx+1, j.batchSize, j.Key, j.Health, res.SurchargeApplied, err)
-							skipAlert := isErr(err, api.ErrSlabNotFound)
-							if !skipAlert {
-								if res.SurchargeApplied {
-									m.ap.RegisterAlert(ctx, newCriticalMigrationFailedAlert(j.Key, j.Health, err))
-								} else {
-									m.ap.RegisterAlert(ctx, newMigrationFailedAlert(j.Key, j.Health, err))
-								}
-							}
-						} else {
-							m.logger.Infof("%v: migration %d/%d succeeded, key: %v, health: %v, overpaid: %v, shards migrated: %v", id, j.slabIdx+1, j.batchSize, j.Key, j.Health, res.SurchargeApplied, res.NumShardsMigrated)
-							m.ap.DismissAlert(ctx, alertIDForSlab(alertMigrationID, j.Key))
-							if res.SurchargeApplied {
-								// this alert confirms the user his gouging
-								// settings are working, it will be dismissed
-								// automatically the next time this slab is
-								// successfully migrated
-								m.ap.RegisterAlert(ctx, newCriticalMigrationSucceededAlert(j.Key))
-							}
-						}
-					}
-				}(w)
-			}
-		}
+		wg = m.launchMigrationThreads(workers, jobs)
 	})
 	var toMigrate []api.UnhealthySlab
 
@@ -273,3 +251,79 @@ OUTER:
 		return
 	}
 }
+
+// launchMigrationThreads launches the threads that process the migration jobs.
+// Every worker gets up to parallelSlabsPerWorker threads, the total number of
+// threads is capped by maxConcurrentMigrations if set. The threads are spread
+// across the workers evenly. The returned WaitGroup is done once the jobs
+// channel is closed and all threads have returned.
+func (m *migrator) launchMigrationThreads(workers []Worker, jobs <-chan job) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for _, w := range migrationThreads(workers, m.parallelSlabsPerWorker, m.maxConcurrentMigrations) {
+		wg.Add(1)
+		go func(w Worker) {
+			defer wg.Done()
+			m.processJobs(w, jobs)
+		}(w)
+	}
+	return &wg
+}
+
+func (m *migrator) processJobs(w Worker, jobs <-chan job) {
+	// derive ctx from shutdown ctx
+	ctx, cancel := context.WithCancel(m.ap.shutdownCtx)
+	defer cancel()
+
+	// fetch worker id once
+	id, err := w.ID(ctx)
+	if err != nil {
+		m.logger.Errorf("failed to fetch worker id: %v", err)
+		return
+	}
+
+	// process jobs
+	for j := range jobs {
+		start := time.Now()
+		m.trackInProgress(1)
+		res, err := j.execute(ctx, w)
+		m.trackInProgress(-1)
+		m.statsSlabMigrationSpeedMS.Track(float64(time.Since(start).Milliseconds()))
+
+		if err != nil {
+			m.logger.Errorf("%v: migration %d/%d failed, key: %v, health: %v, overpaid: %v, err: %v", id, j.slabIdx+1, j.batchSize, j.Key, j.Health, res.SurchargeApplied, err)
+			skipAlert := isErr(err, api.ErrSlabNotFound)
+			if !skipAlert {
+				if res.SurchargeApplied {
+					m.ap.RegisterAlert(ctx, newCriticalMigrationFailedAlert(j.Key, j.Health, err))
+				} else {
+					m.ap.RegisterAlert(ctx, newMigrationFailedAlert(j.Key, j.Health, err))
+				}
+			}
+		} else {
+			m.logger.Infof("%v: migration %d/%d succeeded, key: %v, health: %v, overpaid: %v, shards migrated: %v", id, j.slabIdx+1, j.batchSize, j.Key, j.Health, res.SurchargeApplied, res.NumShardsMigrated)
+			m.ap.DismissAlert(ctx, alertIDForSlab(alertMigrationID, j.Key))
+			if res.SurchargeApplied {
+				// this alert confirms the user his gouging
+				// settings are working, it will be dismissed
+				// automatically the next time this slab is
+				// successfully migrated
+				m.ap.RegisterAlert(ctx, newCriticalMigrationSucceededAlert(j.Key))
+			}
+		}
+	}
+}
+
+// migrationThreads returns the worker for every migration thread that should
+// be launched, threads are assigned to the workers in a round-robin fashion so
+// that a cap on the number of threads is spread evenly across the workers.
+func migrationThreads(workers []Worker, parallelSlabsPerWorker, maxConcurrentMigrations uint64) (threads []Worker) {
+	for i := uint64(0); i < parallelSlabsPerWorker; i++ {
+		for _, w := range workers {
+			if maxConcurrentMigrations > 0 && uint64(len(threads)) >= maxConcurrentMigrations {
+				return
+			}
+			threads = append(threads, w)
+		}
+	}
+	return
+}
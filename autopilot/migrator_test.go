@@ -0,0 +1,158 @@
+package autopilot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/object"
+	"go.uber.org/zap"
+)
+
+type mockMigrationBus struct {
+	Bus
+}
+
+func (b *mockMigrationBus) Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error) {
+	return object.Slab{Key: key}, nil
+}
+
+// migrationTracker tracks the max number of concurrent migrations, migrations
+// signal they started and block until they are released.
+type migrationTracker struct {
+	started chan struct{}
+	release chan struct{}
+
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+type mockMigrationWorker struct {
+	Worker
+	tracker *migrationTracker
+}
+
+func (w *mockMigrationWorker) ID(ctx context.Context) (string, error) {
+	return "worker", nil
+}
+
+func (w *mockMigrationWorker) MigrateSlab(ctx context.Context, s object.Slab, set string) (api.MigrateSlabResponse, error) {
+	w.tracker.mu.Lock()
+	w.tracker.active++
+	if w.tracker.active > w.tracker.maxActive {
+		w.tracker.maxActive = w.tracker.active
+	}
+	w.tracker.mu.Unlock()
+
+	w.tracker.started <- struct{}{}
+	<-w.tracker.release
+
+	w.tracker.mu.Lock()
+	w.tracker.active--
+	w.tracker.mu.Unlock()
+	return api.MigrateSlabResponse{}, nil
+}
+
+func TestMigrationThreads(t *testing.T) {
+	w1, w2, w3 := &mockMigrationWorker{}, &mockMigrationWorker{}, &mockMigrationWorker{}
+	workers := []Worker{w1, w2, w3}
+
+	count := func(threads []Worker) map[Worker]int {
+		cnt := make(map[Worker]int)
+		for _, w := range threads {
+			cnt[w]++
+		}
+		return cnt
+	}
+
+	// no cap
+	if threads := migrationThreads(workers, 2, 0); len(threads) != 6 {
+		t.Fatal("unexpected number of threads", len(threads))
+	} else if cnt := count(threads); cnt[w1] != 2 || cnt[w2] != 2 || cnt[w3] != 2 {
+		t.Fatal("unexpected distribution", cnt)
+	}
+
+	// cap exceeding the number of threads
+	if threads := migrationThreads(workers, 2, 10); len(threads) != 6 {
+		t.Fatal("unexpected number of threads", len(threads))
+	}
+
+	// cap spread evenly across the workers
+	if threads := migrationThreads(workers, 2, 4); len(threads) != 4 {
+		t.Fatal("unexpected number of threads", len(threads))
+	} else if cnt := count(threads); cnt[w1] != 2 || cnt[w2] != 1 || cnt[w3] != 1 {
+		t.Fatal("unexpected distribution", cnt)
+	}
+}
+
+func TestMigrationConcurrencyCap(t *testing.T) {
+	ap := &Autopilot{
+		alerts:      alerts.NewManager(),
+		logger:      zap.NewNop().Sugar(),
+		shutdownCtx: context.Background(),
+	}
+
+	assertCap := func(parallelSlabsPerWorker, maxConcurrentMigrations uint64, expected int) {
+		t.Helper()
+
+		// create workers that track the max number of concurrent migrations
+		const numJobs = 50
+		tracker := &migrationTracker{
+			started: make(chan struct{}, numJobs),
+			release: make(chan struct{}),
+		}
+		var workers []Worker
+		for i := 0; i < 3; i++ {
+			workers = append(workers, &mockMigrationWorker{tracker: tracker})
+		}
+
+		// launch the threads and push jobs
+		m := newMigrator(ap, 0.5, parallelSlabsPerWorker, maxConcurrentMigrations)
+		jobs := make(chan job)
+		wg := m.launchMigrationThreads(workers, jobs)
+		go func() {
+			for i := 0; i < numJobs; i++ {
+				jobs <- job{UnhealthySlab: api.UnhealthySlab{Key: object.GenerateEncryptionKey()}, slabIdx: i, batchSize: numJobs, b: &mockMigrationBus{}}
+			}
+			close(jobs)
+		}()
+
+		// wait until the cap is reached, migrations block until released
+		for i := 0; i < expected; i++ {
+			select {
+			case <-tracker.started:
+			case <-time.After(10 * time.Second):
+				t.Fatalf("only %v out of %v migrations started", i, expected)
+			}
+		}
+
+		// assert the cap is respected while the migrations are held
+		tracker.mu.Lock()
+		maxActive := tracker.maxActive
+		tracker.mu.Unlock()
+		if maxActive > expected {
+			t.Fatalf("expected at most %v concurrent migrations, got %v", expected, maxActive)
+		} else if n := m.InProgress(); n > uint64(expected) {
+			t.Fatalf("unexpected number of migrations in progress %v", n)
+		}
+
+		// release the migrations and wait for all jobs to be processed
+		close(tracker.release)
+		wg.Wait()
+
+		// assert the cap was respected throughout
+		if tracker.maxActive > expected {
+			t.Fatalf("expected at most %v concurrent migrations, got %v", expected, tracker.maxActive)
+		} else if n := m.InProgress(); n != 0 {
+			t.Fatalf("expected no migrations in progress, got %v", n)
+		}
+	}
+
+	assertCap(2, 0, 6)
+	assertCap(2, 4, 4)
+	assertCap(2, 1, 1)
+}
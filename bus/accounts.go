@@ -254,6 +254,25 @@ func (a *accounts) ResetDrift(id rhpv3.Account) error {
 	return nil
 }
 
+// FlagOrphaned marks all accounts whose host is not in the given set of hosts
+// as requiring a sync and returns them. This prevents the balance of an
+// account from being trusted after the contract with its host is gone.
+func (a *accounts) FlagOrphaned(hosts map[types.PublicKey]struct{}) []api.Account {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var orphaned []api.Account
+	for _, acc := range a.byID {
+		acc.mu.Lock()
+		if _, ok := hosts[acc.HostKey]; !ok {
+			acc.RequiresSync = true
+			acc.requiresSyncTime = time.Now()
+			orphaned = append(orphaned, acc.convert())
+		}
+		acc.mu.Unlock()
+	}
+	return orphaned
+}
+
 // ToPersist returns all known accounts to be persisted by the storage backend.
 // Called once on shutdown.
 func (a *accounts) ToPersist() []api.Account {
@@ -2,11 +2,13 @@ package bus
 
 import (
 	"context"
+	"math/big"
 	"testing"
 	"time"
 
 	rhpv3 "go.sia.tech/core/rhp/v3"
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
 	"go.uber.org/zap"
 	"lukechampine.com/frand"
 )
@@ -85,3 +87,33 @@ func TestAccountLocking(t *testing.T) {
 		t.Fatal("should not have any locks", len(acc.locks))
 	}
 }
+
+func TestFlagOrphanedAccounts(t *testing.T) {
+	var hk1, hk2 types.PublicKey
+	frand.Read(hk1[:])
+	frand.Read(hk2[:])
+
+	var id1, id2 rhpv3.Account
+	frand.Read(id1[:])
+	frand.Read(id2[:])
+
+	accounts := newAccounts([]api.Account{
+		{ID: id1, HostKey: hk1, Balance: big.NewInt(1), Drift: big.NewInt(0)},
+		{ID: id2, HostKey: hk2, Balance: big.NewInt(2), Drift: big.NewInt(0)},
+	}, zap.NewNop().Sugar())
+
+	// flag accounts with only hk1 having an active contract
+	orphaned := accounts.FlagOrphaned(map[types.PublicKey]struct{}{hk1: {}})
+	if len(orphaned) != 1 {
+		t.Fatal("expected 1 orphaned account, got", len(orphaned))
+	} else if orphaned[0].ID != id2 || !orphaned[0].RequiresSync {
+		t.Fatal("unexpected orphaned account", orphaned[0])
+	}
+
+	// the account with an active contract should be untouched
+	if acc, err := accounts.Account(id1, hk1); err != nil {
+		t.Fatal(err)
+	} else if acc.RequiresSync {
+		t.Fatal("account shouldn't require a sync")
+	}
+}
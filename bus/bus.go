@@ -11,6 +11,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"go.sia.tech/core/consensus"
@@ -24,14 +25,22 @@ import (
 	"go.sia.tech/renterd/build"
 	"go.sia.tech/renterd/bus/client"
 	"go.sia.tech/renterd/hostdb"
+	"go.sia.tech/renterd/internal/gouging"
 	"go.sia.tech/renterd/internal/prometheus"
 	"go.sia.tech/renterd/object"
 	"go.sia.tech/renterd/wallet"
 	"go.sia.tech/renterd/webhooks"
 	"go.sia.tech/siad/modules"
 	"go.uber.org/zap"
+	"lukechampine.com/frand"
 )
 
+// blocklistPruneInterval is the interval at which expired blocklist entries
+// are removed from the blocklist.
+const blocklistPruneInterval = time.Hour
+
+var alertOrphanedAccountsID = frand.Entropy256() // constant until restarted
+
 // Client re-exports the client from the client package.
 type Client struct {
 	*client.Client
@@ -93,18 +102,26 @@ type (
 	// A HostDB stores information about hosts.
 	HostDB interface {
 		Host(ctx context.Context, hostKey types.PublicKey) (hostdb.HostInfo, error)
+		HostAnnouncements(ctx context.Context, hostKey types.PublicKey, limit int) ([]hostdb.Announcement, error)
 		Hosts(ctx context.Context, offset, limit int) ([]hostdb.Host, error)
+		HostsSorted(ctx context.Context, sortBy string, ascending bool, minScore float64, offset, limit int) ([]hostdb.Host, int64, error)
 		HostsForScanning(ctx context.Context, maxLastScan time.Time, offset, limit int) ([]hostdb.HostAddress, error)
+		NewHosts(ctx context.Context, sinceHeight uint64) ([]hostdb.Host, error)
+		RecordHostInteractions(ctx context.Context, interactions []hostdb.Interaction) error
 		RecordHostScans(ctx context.Context, scans []hostdb.HostScan) error
+		RecordHostScores(ctx context.Context, scores map[types.PublicKey]float64) error
 		RecordPriceTables(ctx context.Context, priceTableUpdate []hostdb.PriceTableUpdate) error
+		PruneHosts(ctx context.Context, notSeenSince time.Time) (uint64, error)
+		RemoveHost(ctx context.Context, hk types.PublicKey) error
 		RemoveOfflineHosts(ctx context.Context, minRecentScanFailures uint64, maxDowntime time.Duration) (uint64, error)
 		ResetLostSectors(ctx context.Context, hk types.PublicKey) error
-		SearchHosts(ctx context.Context, filterMode, addressContains string, keyIn []types.PublicKey, offset, limit int) ([]hostdb.Host, error)
+		SearchHosts(ctx context.Context, filterMode, addressContains string, keyIn []types.PublicKey, acceptingContracts bool, minSuccessRatio float64, minRemainingStorage uint64, maxStoragePrice types.Currency, offset, limit int) ([]hostdb.Host, error)
 
 		HostAllowlist(ctx context.Context) ([]types.PublicKey, error)
 		HostBlocklist(ctx context.Context) ([]string, error)
 		UpdateHostAllowlistEntries(ctx context.Context, add, remove []types.PublicKey, clear bool) error
 		UpdateHostBlocklistEntries(ctx context.Context, add, remove []string, clear bool) error
+		PruneExpiredHostBlocklistEntries(ctx context.Context) (uint64, error)
 	}
 
 	// A MetadataStore stores information about contracts and objects.
@@ -113,11 +130,14 @@ type (
 		AddRenewedContract(ctx context.Context, c rhpv2.ContractRevision, contractPrice, totalCost types.Currency, startHeight uint64, renewedFrom types.FileContractID, state string) (api.ContractMetadata, error)
 		AncestorContracts(ctx context.Context, fcid types.FileContractID, minStartHeight uint64) ([]api.ArchivedContract, error)
 		ArchiveContract(ctx context.Context, id types.FileContractID, reason string) error
+		ArchivedContracts(ctx context.Context, opts api.ArchivedContractsOpts) ([]api.ArchivedContract, error)
 		ArchiveContracts(ctx context.Context, toArchive map[types.FileContractID]string) error
 		ArchiveAllContracts(ctx context.Context, reason string) error
 		Contract(ctx context.Context, id types.FileContractID) (api.ContractMetadata, error)
+		ContractForHost(ctx context.Context, hk types.PublicKey) (api.ContractMetadata, error)
 		Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error)
 		ContractSets(ctx context.Context) ([]string, error)
+		LockedFunds(ctx context.Context) (types.Currency, error)
 		RecordContractSpending(ctx context.Context, records []api.ContractSpendingRecord) error
 		RemoveContractSet(ctx context.Context, name string) error
 		RenewedContract(ctx context.Context, renewedFrom types.FileContractID) (api.ContractMetadata, error)
@@ -141,13 +161,16 @@ type (
 		ObjectMetadata(ctx context.Context, bucketName, path string) (api.Object, error)
 		ObjectEntries(ctx context.Context, bucketName, path, prefix, sortBy, sortDir, marker string, offset, limit int) ([]api.ObjectMetadata, bool, error)
 		ObjectsBySlabKey(ctx context.Context, bucketName string, slabKey object.EncryptionKey) ([]api.ObjectMetadata, error)
+		ObjectsHealth(ctx context.Context, offset, limit int) (api.ObjectsHealthResponse, error)
 		ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (api.ObjectsStatsResponse, error)
 		RemoveObject(ctx context.Context, bucketName, path string) error
-		RemoveObjects(ctx context.Context, bucketName, prefix string) error
+		RemoveObjects(ctx context.Context, bucketName, prefix string) (api.ObjectsRemoveResponse, error)
+		MoveObject(ctx context.Context, srcBucket, srcPath, dstBucket, dstPath string, overwrite bool) error
 		RenameObject(ctx context.Context, bucketName, from, to string, force bool) error
 		RenameObjects(ctx context.Context, bucketName, from, to string, force bool) error
 		SearchObjects(ctx context.Context, bucketName, substring string, offset, limit int) ([]api.ObjectMetadata, error)
-		UpdateObject(ctx context.Context, bucketName, path, contractSet, ETag, mimeType string, metadata api.ObjectUserMetadata, o object.Object) error
+		UpdateObject(ctx context.Context, bucketName, path, contractSet, ETag, mimeType, mode string, metadata api.ObjectUserMetadata, o object.Object) error
+		UpdateObjects(ctx context.Context, bucket, contractSet, mode string, objs map[string]api.AddObjectsEntry) error
 
 		AbortMultipartUpload(ctx context.Context, bucketName, path string, uploadID string) (err error)
 		AddMultipartPart(ctx context.Context, bucketName, path, contractSet, eTag, uploadID string, partNumber int, slices []object.SlabSlice) (err error)
@@ -164,7 +187,9 @@ type (
 		AddPartialSlab(ctx context.Context, data []byte, minShards, totalShards uint8, contractSet string) (slabs []object.SlabSlice, bufferSize int64, err error)
 		FetchPartialSlab(ctx context.Context, key object.EncryptionKey, offset, length uint32) ([]byte, error)
 		Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error)
+		SlabInfo(ctx context.Context, key object.EncryptionKey) (api.SlabInfo, error)
 		RefreshHealth(ctx context.Context) error
+		RepointSlices(ctx context.Context, oldKey, newKey object.EncryptionKey) (int, error)
 		UnhealthySlabs(ctx context.Context, healthCutoff float64, set string, limit int) ([]api.UnhealthySlab, error)
 		UpdateSlab(ctx context.Context, s object.Slab, contractSet string) error
 	}
@@ -174,6 +199,7 @@ type (
 		Autopilot(ctx context.Context, id string) (api.Autopilot, error)
 		Autopilots(ctx context.Context) ([]api.Autopilot, error)
 		UpdateAutopilot(ctx context.Context, ap api.Autopilot) error
+		DeleteAutopilot(ctx context.Context, id string) error
 	}
 
 	// A SettingStore stores settings.
@@ -199,11 +225,14 @@ type (
 		ContractPruneMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractPruneMetricsQueryOpts) ([]api.ContractPruneMetric, error)
 		RecordContractPruneMetric(ctx context.Context, metrics ...api.ContractPruneMetric) error
 
+		ContractRenewalMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractRenewalMetricsQueryOpts) ([]api.ContractRenewalMetric, error)
+
 		ContractMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractMetricsQueryOpts) ([]api.ContractMetric, error)
 		RecordContractMetric(ctx context.Context, metrics ...api.ContractMetric) error
 
 		PruneMetrics(ctx context.Context, metric string, cutoff time.Time) error
 		ContractSetChurnMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractSetChurnMetricsQueryOpts) ([]api.ContractSetChurnMetric, error)
+		ContractSetChurnHistory(ctx context.Context, name string, maxPeriod uint64) ([]api.ContractSetChurnMetric, error)
 		RecordContractSetChurnMetric(ctx context.Context, metrics ...api.ContractSetChurnMetric) error
 
 		WalletMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.WalletMetricsQueryOpts) ([]api.WalletMetric, error)
@@ -233,6 +262,10 @@ type bus struct {
 	alertMgr *alerts.Manager
 	hooks    *webhooks.Manager
 	logger   *zap.SugaredLogger
+
+	shutdownCtx       context.Context
+	shutdownCtxCancel context.CancelFunc
+	wg                sync.WaitGroup
 }
 
 // Handler returns an HTTP handler that serves the bus API.
@@ -254,6 +287,7 @@ func (b *bus) Handler() http.Handler {
 		"GET    /autopilots":    b.autopilotsListHandlerGET,
 		"GET    /autopilot/:id": b.autopilotsHandlerGET,
 		"PUT    /autopilot/:id": b.autopilotsHandlerPUT,
+		"DELETE /autopilot/:id": b.autopilotsHandlerDELETE,
 
 		"GET    /buckets":             b.bucketsHandlerGET,
 		"POST   /buckets":             b.bucketsHandlerPOST,
@@ -266,36 +300,48 @@ func (b *bus) Handler() http.Handler {
 		"GET    /consensus/siafundfee/:payout": b.contractTaxHandlerGET,
 		"GET    /consensus/state":              b.consensusStateHandler,
 
-		"GET    /contracts":              b.contractsHandlerGET,
-		"DELETE /contracts/all":          b.contractsAllHandlerDELETE,
-		"POST   /contracts/archive":      b.contractsArchiveHandlerPOST,
-		"GET    /contracts/prunable":     b.contractsPrunableDataHandlerGET,
-		"GET    /contracts/renewed/:id":  b.contractsRenewedIDHandlerGET,
-		"GET    /contracts/sets":         b.contractsSetsHandlerGET,
-		"PUT    /contracts/set/:set":     b.contractsSetHandlerPUT,
-		"DELETE /contracts/set/:set":     b.contractsSetHandlerDELETE,
-		"POST   /contracts/spending":     b.contractsSpendingHandlerPOST,
-		"GET    /contract/:id":           b.contractIDHandlerGET,
-		"POST   /contract/:id":           b.contractIDHandlerPOST,
-		"DELETE /contract/:id":           b.contractIDHandlerDELETE,
-		"POST   /contract/:id/acquire":   b.contractAcquireHandlerPOST,
-		"GET    /contract/:id/ancestors": b.contractIDAncestorsHandler,
-		"POST   /contract/:id/keepalive": b.contractKeepaliveHandlerPOST,
-		"POST   /contract/:id/renewed":   b.contractIDRenewedHandlerPOST,
-		"POST   /contract/:id/release":   b.contractReleaseHandlerPOST,
-		"GET    /contract/:id/roots":     b.contractIDRootsHandlerGET,
-		"GET    /contract/:id/size":      b.contractSizeHandlerGET,
+		"GET    /contracts":                b.contractsHandlerGET,
+		"DELETE /contracts/all":            b.contractsAllHandlerDELETE,
+		"POST   /contracts/archive":        b.contractsArchiveHandlerPOST,
+		"GET    /contracts/archived":       b.contractsArchivedHandlerGET,
+		"GET    /contracts/lockedfunds":    b.contractsLockedFundsHandlerGET,
+		"GET    /contracts/prunable":       b.contractsPrunableDataHandlerGET,
+		"GET    /contracts/renewed/:id":    b.contractsRenewedIDHandlerGET,
+		"GET    /contracts/sets":           b.contractsSetsHandlerGET,
+		"PUT    /contracts/set/:set":       b.contractsSetHandlerPUT,
+		"DELETE /contracts/set/:set":       b.contractsSetHandlerDELETE,
+		"GET    /contracts/set/:set/churn": b.contractsSetChurnHandlerGET,
+		"POST   /contracts/spending":       b.contractsSpendingHandlerPOST,
+		"GET    /contract/:id":             b.contractIDHandlerGET,
+		"POST   /contract/:id":             b.contractIDHandlerPOST,
+		"DELETE /contract/:id":             b.contractIDHandlerDELETE,
+		"POST   /contract/:id/acquire":     b.contractAcquireHandlerPOST,
+		"GET    /contract/:id/ancestors":   b.contractIDAncestorsHandler,
+		"POST   /contract/:id/keepalive":   b.contractKeepaliveHandlerPOST,
+		"POST   /contract/:id/renewed":     b.contractIDRenewedHandlerPOST,
+		"POST   /contract/:id/release":     b.contractReleaseHandlerPOST,
+		"GET    /contract/:id/roots":       b.contractIDRootsHandlerGET,
+		"GET    /contract/:id/size":        b.contractSizeHandlerGET,
 
 		"GET    /hosts":                          b.hostsHandlerGET,
 		"GET    /hosts/allowlist":                b.hostsAllowlistHandlerGET,
 		"PUT    /hosts/allowlist":                b.hostsAllowlistHandlerPUT,
 		"GET    /hosts/blocklist":                b.hostsBlocklistHandlerGET,
 		"PUT    /hosts/blocklist":                b.hostsBlocklistHandlerPUT,
+		"POST   /hosts/interactions":             b.hostsInteractionsHandlerPOST,
+		"GET    /hosts/new":                      b.hostsNewHandlerGET,
 		"POST   /hosts/pricetables":              b.hostsPricetableHandlerPOST,
+		"POST   /hosts/prune":                    b.hostsPruneHandlerPOST,
 		"POST   /hosts/remove":                   b.hostsRemoveHandlerPOST,
 		"POST   /hosts/scans":                    b.hostsScanHandlerPOST,
+		"POST   /hosts/scores":                   b.hostsScoresHandlerPOST,
 		"GET    /hosts/scanning":                 b.hostsScanningHandlerGET,
+		"GET    /hosts/sorted":                   b.hostsSortedHandlerGET,
 		"GET    /host/:hostkey":                  b.hostsPubkeyHandlerGET,
+		"DELETE /host/:hostkey":                  b.hostsPubkeyHandlerDELETE,
+		"GET    /host/:hostkey/announcements":    b.hostsAnnouncementsHandlerGET,
+		"GET    /host/:hostkey/contract":         b.hostsContractHandlerGET,
+		"GET    /host/:hostkey/gouging":          b.hostsGougingHandlerGET,
 		"POST   /host/:hostkey/resetlostsectors": b.hostsResetLostSectorsPOST,
 
 		"PUT    /metric/:key": b.metricsHandlerPUT,
@@ -313,8 +359,11 @@ func (b *bus) Handler() http.Handler {
 		"GET    /objects/*path":  b.objectsHandlerGET,
 		"PUT    /objects/*path":  b.objectsHandlerPUT,
 		"DELETE /objects/*path":  b.objectsHandlerDELETE,
+		"POST   /objects/add":    b.objectsAddHandlerPOST,
 		"POST   /objects/copy":   b.objectsCopyHandlerPOST,
+		"POST   /objects/move":   b.objectsMoveHandlerPOST,
 		"POST   /objects/rename": b.objectsRenameHandlerPOST,
+		"POST   /objects/remove": b.objectsRemoveHandlerPOST,
 		"POST   /objects/list":   b.objectsListHandlerPOST,
 
 		"GET    /params/gouging": b.paramsHandlerGougingGET,
@@ -338,12 +387,15 @@ func (b *bus) Handler() http.Handler {
 		"GET    /slabs/partial/:key":  b.slabsPartialHandlerGET,
 		"POST   /slabs/partial":       b.slabsPartialHandlerPOST,
 		"POST   /slabs/refreshhealth": b.slabsRefreshHealthHandlerPOST,
+		"POST   /slabs/repoint":       b.slabsRepointHandlerPOST,
 		"GET    /slab/:key":           b.slabHandlerGET,
+		"GET    /slab/:key/info":      b.slabInfoHandlerGET,
 		"GET    /slab/:key/objects":   b.slabObjectsHandlerGET,
 		"PUT    /slab":                b.slabHandlerPUT,
 
-		"GET    /state":         b.stateHandlerGET,
-		"GET    /stats/objects": b.objectsStatshandlerGET,
+		"GET    /state":                b.stateHandlerGET,
+		"GET    /stats/objects":        b.objectsStatshandlerGET,
+		"GET    /stats/objects/health": b.objectsHealthHandlerGET,
 
 		"GET    /syncer/address": b.syncerAddrHandler,
 		"POST   /syncer/connect": b.syncerConnectHandler,
@@ -405,6 +457,8 @@ func (b *bus) writeResponse(c jape.Context, code int, resp any) {
 
 // Shutdown shuts down the bus.
 func (b *bus) Shutdown(ctx context.Context) error {
+	b.shutdownCtxCancel()
+	b.wg.Wait()
 	b.hooks.Close()
 	accounts := b.accounts.ToPersist()
 	err := b.eas.SaveAccounts(ctx, accounts)
@@ -416,6 +470,66 @@ func (b *bus) Shutdown(ctx context.Context) error {
 	return err
 }
 
+// pruneExpiredBlocklistEntries removes expired entries from the blocklist at the
+// given interval until the bus is shut down.
+func (b *bus) pruneExpiredBlocklistEntries(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if pruned, err := b.hdb.PruneExpiredHostBlocklistEntries(b.shutdownCtx); err != nil {
+			b.logger.Errorf("failed to prune expired blocklist entries: %v", err)
+		} else if pruned > 0 {
+			b.logger.Debugf("pruned %d expired blocklist entries", pruned)
+		}
+
+		select {
+		case <-b.shutdownCtx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// flagOrphanedAccounts flags all accounts for hosts we don't have an active
+// contract with, these accounts require a sync before they can be used again.
+func (b *bus) flagOrphanedAccounts(ctx context.Context) {
+	contracts, err := b.ms.Contracts(ctx, api.ContractsOpts{})
+	if err != nil {
+		b.logger.Errorf("failed to fetch contracts to reconcile accounts: %v", err)
+		return
+	}
+	hosts := make(map[types.PublicKey]struct{})
+	for _, c := range contracts {
+		hosts[c.HostKey] = struct{}{}
+	}
+
+	orphaned := b.accounts.FlagOrphaned(hosts)
+	if len(orphaned) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(orphaned))
+	for _, acc := range orphaned {
+		ids = append(ids, acc.ID.String())
+		b.logger.Warnw("account has no active contract with its host",
+			"account", acc.ID,
+			"host", acc.HostKey,
+			"balance", acc.Balance.String())
+	}
+	if err := b.alerts.RegisterAlert(ctx, alerts.Alert{
+		ID:       alertOrphanedAccountsID,
+		Severity: alerts.SeverityWarning,
+		Message:  "Found accounts without an active contract",
+		Data: map[string]interface{}{
+			"accounts": ids,
+		},
+		Timestamp: time.Now(),
+	}); err != nil {
+		b.logger.Errorf("failed to register alert: %v", err)
+	}
+}
+
 func (b *bus) fetchSetting(ctx context.Context, key string, value interface{}) error {
 	if val, err := b.ss.Setting(ctx, key); err != nil {
 		return fmt.Errorf("could not get contract set settings: %w", err)
@@ -495,6 +609,9 @@ func (b *bus) bucketsHandlerPOST(jc jape.Context) {
 	} else if bucket.Name == "" {
 		jc.Error(errors.New("no name provided"), http.StatusBadRequest)
 		return
+	} else if err := bucket.Policy.Validate(); err != nil {
+		jc.Error(fmt.Errorf("invalid bucket policy: %w", err), http.StatusBadRequest)
+		return
 	} else if jc.Check("failed to create bucket", b.ms.CreateBucket(jc.Request.Context(), bucket.Name, bucket.Policy)) != nil {
 		return
 	}
@@ -507,6 +624,9 @@ func (b *bus) bucketsHandlerPolicyPUT(jc jape.Context) {
 	} else if bucket := jc.PathParam("name"); bucket == "" {
 		jc.Error(errors.New("no bucket name provided"), http.StatusBadRequest)
 		return
+	} else if err := req.Policy.Validate(); err != nil {
+		jc.Error(fmt.Errorf("invalid bucket policy: %w", err), http.StatusBadRequest)
+		return
 	} else if jc.Check("failed to create bucket", b.ms.UpdateBucketPolicy(jc.Request.Context(), bucket, req.Policy)) != nil {
 		return
 	}
@@ -797,12 +917,34 @@ func (b *bus) hostsHandlerGET(jc jape.Context) {
 	b.writeResponse(jc, http.StatusOK, HostsResp(hosts))
 }
 
+func (b *bus) hostsSortedHandlerGET(jc jape.Context) {
+	var sortBy string
+	var ascending bool
+	var minScore float64
+	offset := 0
+	limit := -1
+	if jc.DecodeForm("sortBy", &sortBy) != nil || jc.DecodeForm("asc", &ascending) != nil || jc.DecodeForm("minScore", &minScore) != nil || jc.DecodeForm("offset", &offset) != nil || jc.DecodeForm("limit", &limit) != nil {
+		return
+	}
+	hosts, total, err := b.hdb.HostsSorted(jc.Request.Context(), sortBy, ascending, minScore, offset, limit)
+	if errors.Is(err, api.ErrInvalidHostSortField) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if jc.Check(fmt.Sprintf("couldn't fetch hosts %d-%d", offset, offset+limit), err) != nil {
+		return
+	}
+	jc.Encode(api.HostsSortedResponse{
+		Hosts: hosts,
+		Total: total,
+	})
+}
+
 func (b *bus) searchHostsHandlerPOST(jc jape.Context) {
 	var req api.SearchHostsRequest
 	if jc.Decode(&req) != nil {
 		return
 	}
-	hosts, err := b.hdb.SearchHosts(jc.Request.Context(), req.FilterMode, req.AddressContains, req.KeyIn, req.Offset, req.Limit)
+	hosts, err := b.hdb.SearchHosts(jc.Request.Context(), req.FilterMode, req.AddressContains, req.KeyIn, req.AcceptingContracts, req.MinSuccessRatio, req.MinRemainingStorage, req.MaxStoragePrice, req.Offset, req.Limit)
 	if jc.Check(fmt.Sprintf("couldn't fetch hosts %d-%d", req.Offset, req.Offset+req.Limit), err) != nil {
 		return
 	}
@@ -829,6 +971,22 @@ func (b *bus) hostsRemoveHandlerPOST(jc jape.Context) {
 	jc.Encode(removed)
 }
 
+func (b *bus) hostsPruneHandlerPOST(jc jape.Context) {
+	var req api.HostsPruneRequest
+	if jc.Decode(&req) != nil {
+		return
+	}
+	if req.NotSeenSince.IsZero() {
+		jc.Error(errors.New("notSeenSince must be set"), http.StatusBadRequest)
+		return
+	}
+	pruned, err := b.hdb.PruneHosts(jc.Request.Context(), req.NotSeenSince.Std())
+	if jc.Check("couldn't prune hosts", err) != nil {
+		return
+	}
+	jc.Encode(pruned)
+}
+
 func (b *bus) hostsScanningHandlerGET(jc jape.Context) {
 	offset := 0
 	limit := -1
@@ -843,6 +1001,18 @@ func (b *bus) hostsScanningHandlerGET(jc jape.Context) {
 	b.writeResponse(jc, http.StatusOK, HostsScanningResp(hosts))
 }
 
+func (b *bus) hostsNewHandlerGET(jc jape.Context) {
+	var sinceHeight uint64
+	if jc.DecodeForm("sinceHeight", &sinceHeight) != nil {
+		return
+	}
+	hosts, err := b.hdb.NewHosts(jc.Request.Context(), sinceHeight)
+	if jc.Check(fmt.Sprintf("couldn't fetch hosts announced since height %d", sinceHeight), err) != nil {
+		return
+	}
+	b.writeResponse(jc, http.StatusOK, HostsResp(hosts))
+}
+
 func (b *bus) hostsPubkeyHandlerGET(jc jape.Context) {
 	var hostKey types.PublicKey
 	if jc.DecodeParam("hostkey", &hostKey) != nil {
@@ -854,6 +1024,75 @@ func (b *bus) hostsPubkeyHandlerGET(jc jape.Context) {
 	}
 }
 
+func (b *bus) hostsAnnouncementsHandlerGET(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+	limit := -1
+	if jc.DecodeForm("limit", &limit) != nil {
+		return
+	}
+	announcements, err := b.hdb.HostAnnouncements(jc.Request.Context(), hostKey, limit)
+	if jc.Check("couldn't load announcements", err) == nil {
+		jc.Encode(announcements)
+	}
+}
+
+func (b *bus) hostsPubkeyHandlerDELETE(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+	err := b.hdb.RemoveHost(jc.Request.Context(), hostKey)
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("couldn't remove host", err)
+}
+
+func (b *bus) hostsContractHandlerGET(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+	c, err := b.ms.ContractForHost(jc.Request.Context(), hostKey)
+	if errors.Is(err, api.ErrContractNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't load contract", err) == nil {
+		jc.Encode(c)
+	}
+}
+
+func (b *bus) hostsGougingHandlerGET(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+	host, err := b.hdb.Host(jc.Request.Context(), hostKey)
+	if jc.Check("couldn't load host", err) != nil {
+		return
+	}
+	gp, err := b.gougingParams(jc.Request.Context())
+	if jc.Check("could not get gouging parameters", err) != nil {
+		return
+	}
+
+	// ignore the pricetable's HostBlockHeight by setting it to our own
+	// blockheight, the price table might be outdated
+	host.PriceTable.HostBlockHeight = gp.ConsensusState.BlockHeight
+
+	gc := gouging.NewChecker(gp.GougingSettings, gp.ConsensusState, gp.TransactionFee, 0, 0)
+	breakdown := gc.Check(&host.Settings, &host.PriceTable.HostPriceTable)
+	jc.Encode(api.HostGougingResponse{
+		Gouging:   breakdown.Gouging(),
+		Breakdown: breakdown,
+		Reasons:   gc.Reasons(&host.Settings, &host.PriceTable.HostPriceTable),
+	})
+}
+
 func (b *bus) hostsResetLostSectorsPOST(jc jape.Context) {
 	var hostKey types.PublicKey
 	if jc.DecodeParam("hostkey", &hostKey) != nil {
@@ -875,6 +1114,26 @@ func (b *bus) hostsScanHandlerPOST(jc jape.Context) {
 	}
 }
 
+func (b *bus) hostsScoresHandlerPOST(jc jape.Context) {
+	var req api.HostsScoresRequest
+	if jc.Decode(&req) != nil {
+		return
+	}
+	if jc.Check("failed to record scores", b.hdb.RecordHostScores(jc.Request.Context(), req.Scores)) != nil {
+		return
+	}
+}
+
+func (b *bus) hostsInteractionsHandlerPOST(jc jape.Context) {
+	var req api.HostsInteractionsRequest
+	if jc.Decode(&req) != nil {
+		return
+	}
+	if jc.Check("failed to record interactions", b.hdb.RecordHostInteractions(jc.Request.Context(), req.Interactions)) != nil {
+		return
+	}
+}
+
 func (b *bus) hostsPricetableHandlerPOST(jc jape.Context) {
 	var req api.HostsPriceTablesRequest
 	if jc.Decode(&req) != nil {
@@ -948,6 +1207,19 @@ func (b *bus) contractsHandlerGET(jc jape.Context) {
 	}
 }
 
+func (b *bus) contractsArchivedHandlerGET(jc jape.Context) {
+	var opts api.ArchivedContractsOpts
+	if jc.DecodeForm("hostKey", &opts.HostKey) != nil {
+		return
+	} else if jc.DecodeForm("reason", &opts.Reason) != nil {
+		return
+	}
+	contracts, err := b.ms.ArchivedContracts(jc.Request.Context(), opts)
+	if jc.Check("couldn't load archived contracts", err) == nil {
+		jc.Encode(contracts)
+	}
+}
+
 func (b *bus) contractsRenewedIDHandlerGET(jc jape.Context) {
 	var id types.FileContractID
 	if jc.DecodeParam("id", &id) != nil {
@@ -966,7 +1238,21 @@ func (b *bus) contractsArchiveHandlerPOST(jc jape.Context) {
 		return
 	}
 
-	jc.Check("failed to archive contracts", b.ms.ArchiveContracts(jc.Request.Context(), toArchive))
+	if jc.Check("failed to archive contracts", b.ms.ArchiveContracts(jc.Request.Context(), toArchive)) != nil {
+		return
+	}
+	fcids := make([]types.FileContractID, 0, len(toArchive))
+	for fcid := range toArchive {
+		fcids = append(fcids, fcid)
+	}
+	b.broadcastContractArchive(jc.Request.Context(), fcids...)
+}
+
+func (b *bus) contractsLockedFundsHandlerGET(jc jape.Context) {
+	locked, err := b.ms.LockedFunds(jc.Request.Context())
+	if jc.Check("couldn't fetch locked funds", err) == nil {
+		jc.Encode(locked)
+	}
 }
 
 func (b *bus) contractsSetsHandlerGET(jc jape.Context) {
@@ -981,14 +1267,48 @@ func (b *bus) contractsSetHandlerPUT(jc jape.Context) {
 	if set := jc.PathParam("set"); set == "" {
 		jc.Error(errors.New("path parameter 'set' can not be empty"), http.StatusBadRequest)
 	} else if jc.Decode(&contractIds) == nil {
-		jc.Check("could not add contracts to set", b.ms.SetContractSet(jc.Request.Context(), set, contractIds))
+		if jc.Check("could not add contracts to set", b.ms.SetContractSet(jc.Request.Context(), set, contractIds)) != nil {
+			return
+		}
+		b.hooks.BroadcastAction(jc.Request.Context(), webhooks.Event{
+			Module: api.WebhookModuleContractSet,
+			Event:  api.WebhookEventContractSetUpdate,
+			Payload: api.EventContractSetUpdate{
+				Name:        set,
+				ContractIDs: contractIds,
+			},
+		})
 	}
 }
 
 func (b *bus) contractsSetHandlerDELETE(jc jape.Context) {
 	if set := jc.PathParam("set"); set != "" {
-		jc.Check("could not remove contract set", b.ms.RemoveContractSet(jc.Request.Context(), set))
+		if jc.Check("could not remove contract set", b.ms.RemoveContractSet(jc.Request.Context(), set)) != nil {
+			return
+		}
+		b.hooks.BroadcastAction(jc.Request.Context(), webhooks.Event{
+			Module:  api.WebhookModuleContractSet,
+			Event:   api.WebhookEventContractSetDelete,
+			Payload: api.EventContractSetDelete{Name: set},
+		})
+	}
+}
+
+func (b *bus) contractsSetChurnHandlerGET(jc jape.Context) {
+	maxPeriod := uint64(math.MaxUint64)
+	if jc.DecodeForm("maxPeriod", &maxPeriod) != nil {
+		return
+	}
+	set := jc.PathParam("set")
+	if set == "" {
+		jc.Error(errors.New("path parameter 'set' can not be empty"), http.StatusBadRequest)
+		return
+	}
+	history, err := b.mtrcs.ContractSetChurnHistory(jc.Request.Context(), set, maxPeriod)
+	if jc.Check("failed to fetch contract set churn history", err) != nil {
+		return
 	}
+	jc.Encode(history)
 }
 
 func (b *bus) contractAcquireHandlerPOST(jc jape.Context) {
@@ -1162,9 +1482,18 @@ func (b *bus) contractIDRenewedHandlerPOST(jc jape.Context) {
 		req.State = api.ContractStatePending
 	}
 	r, err := b.ms.AddRenewedContract(jc.Request.Context(), req.Contract, req.ContractPrice, req.TotalCost, req.StartHeight, req.RenewedFrom, req.State)
-	if jc.Check("couldn't store contract", err) == nil {
-		jc.Encode(r)
+	if jc.Check("couldn't store contract", err) != nil {
+		return
 	}
+	b.hooks.BroadcastAction(jc.Request.Context(), webhooks.Event{
+		Module: api.WebhookModuleContract,
+		Event:  api.WebhookEventContractRenew,
+		Payload: api.EventContractRenew{
+			ContractID:  r.ID,
+			RenewedFrom: req.RenewedFrom,
+		},
+	})
+	jc.Encode(r)
 }
 
 func (b *bus) contractIDRootsHandlerGET(jc jape.Context) {
@@ -1187,11 +1516,25 @@ func (b *bus) contractIDHandlerDELETE(jc jape.Context) {
 	if jc.DecodeParam("id", &id) != nil {
 		return
 	}
-	jc.Check("couldn't remove contract", b.ms.ArchiveContract(jc.Request.Context(), id, api.ContractArchivalReasonRemoved))
+	if jc.Check("couldn't remove contract", b.ms.ArchiveContract(jc.Request.Context(), id, api.ContractArchivalReasonRemoved)) == nil {
+		b.broadcastContractArchive(jc.Request.Context(), id)
+	}
 }
 
 func (b *bus) contractsAllHandlerDELETE(jc jape.Context) {
-	jc.Check("couldn't remove contracts", b.ms.ArchiveAllContracts(jc.Request.Context(), api.ContractArchivalReasonRemoved))
+	if jc.Check("couldn't remove contracts", b.ms.ArchiveAllContracts(jc.Request.Context(), api.ContractArchivalReasonRemoved)) == nil {
+		b.broadcastContractArchive(jc.Request.Context())
+	}
+}
+
+// broadcastContractArchive broadcasts that the given contracts were archived,
+// if no contracts are given all contracts were archived.
+func (b *bus) broadcastContractArchive(ctx context.Context, fcids ...types.FileContractID) {
+	b.hooks.BroadcastAction(ctx, webhooks.Event{
+		Module:  api.WebhookModuleContract,
+		Event:   api.WebhookEventContractArchive,
+		Payload: api.EventContractArchive{ContractIDs: fcids},
+	})
 }
 
 func (b *bus) searchObjectsHandlerGET(jc jape.Context) {
@@ -1298,7 +1641,35 @@ func (b *bus) objectsHandlerPUT(jc jape.Context) {
 	} else if aor.Bucket == "" {
 		aor.Bucket = api.DefaultBucketName
 	}
-	jc.Check("couldn't store object", b.ms.UpdateObject(jc.Request.Context(), aor.Bucket, jc.PathParam("path"), aor.ContractSet, aor.ETag, aor.MimeType, aor.Metadata, aor.Object))
+	if err := api.ValidateUploadMode(aor.Mode); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	err := b.ms.UpdateObject(jc.Request.Context(), aor.Bucket, jc.PathParam("path"), aor.ContractSet, aor.ETag, aor.MimeType, aor.Mode, aor.Metadata, aor.Object)
+	if errors.Is(err, api.ErrObjectExists) {
+		jc.Error(err, http.StatusConflict)
+		return
+	}
+	jc.Check("couldn't store object", err)
+}
+
+func (b *bus) objectsAddHandlerPOST(jc jape.Context) {
+	var aor api.AddObjectsRequest
+	if jc.Decode(&aor) != nil {
+		return
+	} else if aor.Bucket == "" {
+		aor.Bucket = api.DefaultBucketName
+	}
+	if err := api.ValidateUploadMode(aor.Mode); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	err := b.ms.UpdateObjects(jc.Request.Context(), aor.Bucket, aor.ContractSet, aor.Mode, aor.Objects)
+	if errors.Is(err, api.ErrObjectExists) {
+		jc.Error(err, http.StatusConflict)
+		return
+	}
+	jc.Check("couldn't store objects", err)
 }
 
 func (b *bus) objectsCopyHandlerPOST(jc jape.Context) {
@@ -1331,6 +1702,32 @@ func (b *bus) objectsListHandlerPOST(jc jape.Context) {
 	jc.Encode(resp)
 }
 
+func (b *bus) objectsMoveHandlerPOST(jc jape.Context) {
+	var omr api.ObjectsMoveRequest
+	if jc.Decode(&omr) != nil {
+		return
+	} else if strings.HasSuffix(omr.SourcePath, "/") || strings.HasSuffix(omr.DestinationPath, "/") {
+		jc.Error(errors.New("can't move dirs"), http.StatusBadRequest)
+		return
+	}
+	if omr.SourceBucket == "" {
+		omr.SourceBucket = api.DefaultBucketName
+	}
+	if omr.DestinationBucket == "" {
+		omr.DestinationBucket = api.DefaultBucketName
+	}
+
+	err := b.ms.MoveObject(jc.Request.Context(), omr.SourceBucket, omr.SourcePath, omr.DestinationBucket, omr.DestinationPath, omr.Overwrite)
+	if errors.Is(err, api.ErrObjectNotFound) || errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if errors.Is(err, api.ErrObjectExists) {
+		jc.Error(err, http.StatusConflict)
+		return
+	}
+	jc.Check("couldn't move object", err)
+}
+
 func (b *bus) objectsRenameHandlerPOST(jc jape.Context) {
 	var orr api.ObjectsRenameRequest
 	if jc.Decode(&orr) != nil {
@@ -1372,7 +1769,11 @@ func (b *bus) objectsHandlerDELETE(jc jape.Context) {
 	}
 	var err error
 	if batch {
-		err = b.ms.RemoveObjects(jc.Request.Context(), bucket, jc.PathParam("path"))
+		var res api.ObjectsRemoveResponse
+		res, err = b.ms.RemoveObjects(jc.Request.Context(), bucket, jc.PathParam("path"))
+		if err == nil && len(res.Failed) > 0 {
+			err = fmt.Errorf("failed to delete %d objects, e.g. '%s': %s", len(res.Failed), res.Failed[0].Path, res.Failed[0].Error)
+		}
 	} else {
 		err = b.ms.RemoveObject(jc.Request.Context(), bucket, jc.PathParam("path"))
 	}
@@ -1383,6 +1784,23 @@ func (b *bus) objectsHandlerDELETE(jc jape.Context) {
 	jc.Check("couldn't delete object", err)
 }
 
+func (b *bus) objectsRemoveHandlerPOST(jc jape.Context) {
+	var orr api.ObjectsRemoveRequest
+	if jc.Decode(&orr) != nil {
+		return
+	} else if orr.Bucket == "" {
+		orr.Bucket = api.DefaultBucketName
+	}
+	res, err := b.ms.RemoveObjects(jc.Request.Context(), orr.Bucket, orr.Prefix)
+	if errors.Is(err, api.ErrObjectNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't remove objects", err) != nil {
+		return
+	}
+	jc.Encode(res)
+}
+
 func (b *bus) slabbuffersHandlerGET(jc jape.Context) {
 	buffers, err := b.ms.SlabBuffers(jc.Request.Context())
 	if jc.Check("couldn't get slab buffers info", err) != nil {
@@ -1403,6 +1821,22 @@ func (b *bus) objectsStatshandlerGET(jc jape.Context) {
 	b.writeResponse(jc, http.StatusOK, ObjectsStatsResp(info))
 }
 
+func (b *bus) objectsHealthHandlerGET(jc jape.Context) {
+	offset := 0
+	limit := -1
+	if jc.DecodeForm("offset", &offset) != nil || jc.DecodeForm("limit", &limit) != nil {
+		return
+	} else if offset < 0 {
+		jc.Error(errors.New("offset must be non-negative"), http.StatusBadRequest)
+		return
+	}
+	resp, err := b.ms.ObjectsHealth(jc.Request.Context(), offset, limit)
+	if jc.Check("couldn't get objects health", err) != nil {
+		return
+	}
+	jc.Encode(resp)
+}
+
 func (b *bus) packedSlabsHandlerFetchPOST(jc jape.Context) {
 	var psrg api.PackedSlabsRequestGET
 	if jc.Decode(&psrg) != nil {
@@ -1483,6 +1917,22 @@ func (b *bus) slabHandlerGET(jc jape.Context) {
 	jc.Encode(slab)
 }
 
+func (b *bus) slabInfoHandlerGET(jc jape.Context) {
+	var key object.EncryptionKey
+	if jc.DecodeParam("key", &key) != nil {
+		return
+	}
+	info, err := b.ms.SlabInfo(jc.Request.Context(), key)
+	if errors.Is(err, api.ErrSlabNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		jc.Error(err, http.StatusInternalServerError)
+		return
+	}
+	jc.Encode(info)
+}
+
 func (b *bus) slabHandlerPUT(jc jape.Context) {
 	var usr api.UpdateSlabRequest
 	if jc.Decode(&usr) == nil {
@@ -1494,6 +1944,24 @@ func (b *bus) slabsRefreshHealthHandlerPOST(jc jape.Context) {
 	jc.Check("failed to recompute health", b.ms.RefreshHealth(jc.Request.Context()))
 }
 
+func (b *bus) slabsRepointHandlerPOST(jc jape.Context) {
+	var req api.RepointSlicesRequest
+	if jc.Decode(&req) != nil {
+		return
+	}
+	repointed, err := b.ms.RepointSlices(jc.Request.Context(), req.OldKey, req.NewKey)
+	if errors.Is(err, api.ErrSlabNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if errors.Is(err, api.ErrIncompatibleSlab) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if jc.Check("couldn't repoint slices", err) != nil {
+		return
+	}
+	jc.Encode(api.RepointSlicesResponse{Repointed: repointed})
+}
+
 func (b *bus) slabsMigrationHandlerPOST(jc jape.Context) {
 	var msr api.MigrationSlabsRequest
 	if jc.Decode(&msr) == nil {
@@ -1989,7 +2457,25 @@ func (b *bus) autopilotsHandlerPUT(jc jape.Context) {
 		return
 	}
 
-	jc.Check("failed to update autopilot", b.as.UpdateAutopilot(jc.Request.Context(), ap))
+	err := b.as.UpdateAutopilot(jc.Request.Context(), ap)
+	if errors.Is(err, api.ErrInvalidAutopilotID) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	jc.Check("failed to update autopilot", err)
+}
+
+func (b *bus) autopilotsHandlerDELETE(jc jape.Context) {
+	var id string
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+	err := b.as.DeleteAutopilot(jc.Request.Context(), id)
+	if errors.Is(err, api.ErrAutopilotNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("failed to delete autopilot", err)
 }
 
 func (b *bus) contractTaxHandlerGET(jc jape.Context) {
@@ -2189,6 +2675,14 @@ func (b *bus) metricsHandlerGET(jc jape.Context) {
 			return
 		}
 		metrics, err = b.metrics(jc.Request.Context(), key, start, n, interval, opts)
+	case api.MetricContractRenewal:
+		var opts api.ContractRenewalMetricsQueryOpts
+		if jc.DecodeForm("hostKey", &opts.HostKey) != nil {
+			return
+		} else if jc.DecodeForm("kind", &opts.Kind) != nil {
+			return
+		}
+		metrics, err = b.metrics(jc.Request.Context(), key, start, n, interval, opts)
 	case api.MetricContractSet:
 		var opts api.ContractSetMetricsQueryOpts
 		if jc.DecodeForm("name", &opts.Name) != nil {
@@ -2227,6 +2721,8 @@ func (b *bus) metrics(ctx context.Context, key string, start time.Time, n uint64
 		return b.mtrcs.ContractMetrics(ctx, start, n, interval, opts.(api.ContractMetricsQueryOpts))
 	case api.MetricContractPrune:
 		return b.mtrcs.ContractPruneMetrics(ctx, start, n, interval, opts.(api.ContractPruneMetricsQueryOpts))
+	case api.MetricContractRenewal:
+		return b.mtrcs.ContractRenewalMetrics(ctx, start, n, interval, opts.(api.ContractRenewalMetricsQueryOpts))
 	case api.MetricContractSet:
 		return b.mtrcs.ContractSetMetrics(ctx, start, n, interval, opts.(api.ContractSetMetricsQueryOpts))
 	case api.MetricContractSetChurn:
@@ -2362,6 +2858,7 @@ func New(s Syncer, am *alerts.Manager, hm *webhooks.Manager, cm ChainManager, tp
 
 		startTime: time.Now(),
 	}
+	b.shutdownCtx, b.shutdownCtxCancel = context.WithCancel(context.Background())
 
 	// ensure we don't hang indefinitely
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
@@ -2439,10 +2936,20 @@ func New(s Syncer, am *alerts.Manager, hm *webhooks.Manager, cm ChainManager, tp
 	}
 	b.accounts = newAccounts(accounts, b.logger)
 
+	// flag accounts for hosts we no longer have an active contract with
+	b.flagOrphanedAccounts(ctx)
+
 	// mark the shutdown as unclean, this will be overwritten when/if the
 	// accounts are saved on shutdown
 	if err := eas.SetUncleanShutdown(); err != nil {
 		return nil, fmt.Errorf("failed to mark account shutdown as unclean: %w", err)
 	}
+
+	// periodically remove expired entries from the blocklist
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.pruneExpiredBlocklistEntries(blocklistPruneInterval)
+	}()
 	return b, nil
 }
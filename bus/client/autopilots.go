@@ -24,3 +24,9 @@ func (c *Client) UpdateAutopilot(ctx context.Context, autopilot api.Autopilot) (
 	err = c.c.WithContext(ctx).PUT(fmt.Sprintf("/autopilot/%s", autopilot.ID), autopilot)
 	return
 }
+
+// DeleteAutopilot removes the autopilot with the given ID from the store.
+func (c *Client) DeleteAutopilot(ctx context.Context, id string) (err error) {
+	err = c.c.WithContext(ctx).DELETE(fmt.Sprintf("/autopilot/%s", id))
+	return
+}
@@ -68,6 +68,12 @@ func (c *Client) Contract(ctx context.Context, id types.FileContractID) (contrac
 	return
 }
 
+// ContractForHost returns the active contract with the given host.
+func (c *Client) ContractForHost(ctx context.Context, hostKey types.PublicKey) (contract api.ContractMetadata, err error) {
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/host/%s/contract", hostKey), &contract)
+	return
+}
+
 // ContractRoots returns the sector roots, as well as the ones that are still
 // uploading, for the contract with given id.
 func (c *Client) ContractRoots(ctx context.Context, contractID types.FileContractID) (roots, uploading []types.Hash256, err error) {
@@ -90,6 +96,20 @@ func (c *Client) ContractSize(ctx context.Context, contractID types.FileContract
 	return
 }
 
+// ArchivedContracts retrieves the archived contracts that match the given
+// filter from the metadata store.
+func (c *Client) ArchivedContracts(ctx context.Context, opts api.ArchivedContractsOpts) (contracts []api.ArchivedContract, err error) {
+	values := url.Values{}
+	if opts.HostKey != (types.PublicKey{}) {
+		values.Set("hostKey", opts.HostKey.String())
+	}
+	if opts.Reason != "" {
+		values.Set("reason", opts.Reason)
+	}
+	err = c.c.WithContext(ctx).GET("/contracts/archived?"+values.Encode(), &contracts)
+	return
+}
+
 // Contracts retrieves contracts from the metadata store. If no filter is set,
 // all contracts are returned.
 func (c *Client) Contracts(ctx context.Context, opts api.ContractsOpts) (contracts []api.ContractMetadata, err error) {
@@ -140,6 +160,13 @@ func (c *Client) KeepaliveContract(ctx context.Context, contractID types.FileCon
 	return
 }
 
+// LockedFunds returns the funds that are still locked in pending and active
+// contracts.
+func (c *Client) LockedFunds(ctx context.Context) (locked types.Currency, err error) {
+	err = c.c.WithContext(ctx).GET("/contracts/lockedfunds", &locked)
+	return
+}
+
 // PrunableData returns an overview of all contract sizes, the total size and
 // the amount of data that can be pruned.
 func (c *Client) PrunableData(ctx context.Context) (prunableData api.ContractsPrunableDataResponse, err error) {
@@ -167,6 +194,15 @@ func (c *Client) ReleaseContract(ctx context.Context, contractID types.FileContr
 	return
 }
 
+// ContractSetChurnHistory returns all churn metrics recorded for the given
+// contract set up until and including the given period.
+func (c *Client) ContractSetChurnHistory(ctx context.Context, set string, maxPeriod uint64) (history []api.ContractSetChurnMetric, err error) {
+	values := url.Values{}
+	values.Set("maxPeriod", fmt.Sprint(maxPeriod))
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/contracts/set/%s/churn?%s", set, values.Encode()), &history)
+	return
+}
+
 // SetContractSet adds the given contracts to the given set.
 func (c *Client) SetContractSet(ctx context.Context, set string, contracts []types.FileContractID) (err error) {
 	err = c.c.WithContext(ctx).PUT(fmt.Sprintf("/contracts/set/%s", set), contracts)
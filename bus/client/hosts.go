@@ -11,12 +11,29 @@ import (
 	"go.sia.tech/renterd/hostdb"
 )
 
+// CheckGouging returns whether the host with the given host key is gouging,
+// explaining which of its prices exceed which configured limit.
+func (c *Client) CheckGouging(ctx context.Context, hostKey types.PublicKey) (resp api.HostGougingResponse, err error) {
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/host/%s/gouging", hostKey), &resp)
+	return
+}
+
 // Host returns information about a particular host known to the server.
 func (c *Client) Host(ctx context.Context, hostKey types.PublicKey) (h hostdb.HostInfo, err error) {
 	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/host/%s", hostKey), &h)
 	return
 }
 
+// HostAnnouncements returns up to 'limit' announcements of the host with the
+// given host key, ordered from most to least recent. A limit of -1 returns all
+// announcements.
+func (c *Client) HostAnnouncements(ctx context.Context, hostKey types.PublicKey, limit int) (announcements []hostdb.Announcement, err error) {
+	values := url.Values{}
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/host/%s/announcements?%s", hostKey, values.Encode()), &announcements)
+	return
+}
+
 // HostAllowlist returns the allowlist.
 func (c *Client) HostAllowlist(ctx context.Context) (allowlist []types.PublicKey, err error) {
 	err = c.c.WithContext(ctx).GET("/hosts/allowlist", &allowlist)
@@ -37,6 +54,15 @@ func (c *Client) Hosts(ctx context.Context, opts api.GetHostsOptions) (hosts []h
 	return
 }
 
+// HostsSorted returns a page of hosts sorted by the given field, alongside the
+// total number of hosts.
+func (c *Client) HostsSorted(ctx context.Context, opts api.HostsSortedOptions) (resp api.HostsSortedResponse, err error) {
+	values := url.Values{}
+	opts.Apply(values)
+	err = c.c.WithContext(ctx).GET("/hosts/sorted?"+values.Encode(), &resp)
+	return
+}
+
 // HostsForScanning returns 'limit' host addresses at given 'offset' which
 // haven't been scanned after lastScan.
 func (c *Client) HostsForScanning(ctx context.Context, opts api.HostsForScanningOptions) (hosts []hostdb.HostAddress, err error) {
@@ -46,6 +72,23 @@ func (c *Client) HostsForScanning(ctx context.Context, opts api.HostsForScanning
 	return
 }
 
+// NewHosts returns all hosts that were first announced at or after the given
+// block height, ordered by the height of their first announcement.
+func (c *Client) NewHosts(ctx context.Context, sinceHeight uint64) (hosts []hostdb.Host, err error) {
+	values := url.Values{}
+	values.Set("sinceHeight", fmt.Sprint(sinceHeight))
+	err = c.c.WithContext(ctx).GET("/hosts/new?"+values.Encode(), &hosts)
+	return
+}
+
+// RecordHostInteractions records the given interactions with hosts.
+func (c *Client) RecordHostInteractions(ctx context.Context, interactions []hostdb.Interaction) (err error) {
+	err = c.c.WithContext(ctx).POST("/hosts/interactions", api.HostsInteractionsRequest{
+		Interactions: interactions,
+	}, nil)
+	return
+}
+
 // RecordHostInteraction records an interaction for the supplied host.
 func (c *Client) RecordHostScans(ctx context.Context, scans []hostdb.HostScan) (err error) {
 	err = c.c.WithContext(ctx).POST("/hosts/scans", api.HostsScanRequest{
@@ -54,6 +97,14 @@ func (c *Client) RecordHostScans(ctx context.Context, scans []hostdb.HostScan) (
 	return
 }
 
+// RecordHostScores records the scores of the given hosts.
+func (c *Client) RecordHostScores(ctx context.Context, scores map[types.PublicKey]float64) (err error) {
+	err = c.c.WithContext(ctx).POST("/hosts/scores", api.HostsScoresRequest{
+		Scores: scores,
+	}, nil)
+	return
+}
+
 // RecordHostInteraction records an interaction for the supplied host.
 func (c *Client) RecordPriceTables(ctx context.Context, priceTableUpdates []hostdb.PriceTableUpdate) (err error) {
 	err = c.c.WithContext(ctx).POST("/hosts/pricetables", api.HostsPriceTablesRequest{
@@ -62,6 +113,21 @@ func (c *Client) RecordPriceTables(ctx context.Context, priceTableUpdates []host
 	return
 }
 
+// PruneHosts removes all hosts that neither announced nor were scanned since
+// the given time.
+func (c *Client) PruneHosts(ctx context.Context, notSeenSince time.Time) (pruned uint64, err error) {
+	err = c.c.WithContext(ctx).POST("/hosts/prune", api.HostsPruneRequest{
+		NotSeenSince: api.TimeRFC3339(notSeenSince),
+	}, &pruned)
+	return
+}
+
+// RemoveHost removes the host with given key from the hostdb.
+func (c *Client) RemoveHost(ctx context.Context, hostKey types.PublicKey) (err error) {
+	err = c.c.WithContext(ctx).DELETE(fmt.Sprintf("/host/%s", hostKey))
+	return
+}
+
 // RemoveOfflineHosts removes all hosts that have been offline for longer than the given max downtime.
 func (c *Client) RemoveOfflineHosts(ctx context.Context, minRecentScanFailures uint64, maxDowntime time.Duration) (removed uint64, err error) {
 	err = c.c.WithContext(ctx).POST("/hosts/remove", api.HostsRemoveRequest{
@@ -80,11 +146,15 @@ func (c *Client) ResetLostSectors(ctx context.Context, hostKey types.PublicKey)
 // SearchHosts returns all hosts that match certain search criteria.
 func (c *Client) SearchHosts(ctx context.Context, opts api.SearchHostOptions) (hosts []hostdb.Host, err error) {
 	err = c.c.WithContext(ctx).POST("/search/hosts", api.SearchHostsRequest{
-		Offset:          opts.Offset,
-		Limit:           opts.Limit,
-		FilterMode:      opts.FilterMode,
-		AddressContains: opts.AddressContains,
-		KeyIn:           opts.KeyIn,
+		Offset:              opts.Offset,
+		Limit:               opts.Limit,
+		FilterMode:          opts.FilterMode,
+		AddressContains:     opts.AddressContains,
+		KeyIn:               opts.KeyIn,
+		AcceptingContracts:  opts.AcceptingContracts,
+		MinSuccessRatio:     opts.MinSuccessRatio,
+		MinRemainingStorage: opts.MinRemainingStorage,
+		MaxStoragePrice:     opts.MaxStoragePrice,
 	}, &hosts)
 	return
 }
@@ -56,6 +56,25 @@ func (c *Client) ContractPruneMetrics(ctx context.Context, start time.Time, n ui
 	return resp, nil
 }
 
+func (c *Client) ContractRenewalMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractRenewalMetricsQueryOpts) ([]api.ContractRenewalMetric, error) {
+	values := url.Values{}
+	values.Set("start", api.TimeRFC3339(start).String())
+	values.Set("n", fmt.Sprint(n))
+	values.Set("interval", api.DurationMS(interval).String())
+	if opts.HostKey != (types.PublicKey{}) {
+		values.Set("hostKey", opts.HostKey.String())
+	}
+	if opts.Kind != "" {
+		values.Set("kind", opts.Kind)
+	}
+
+	var resp []api.ContractRenewalMetric
+	if err := c.metric(ctx, api.MetricContractRenewal, values, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (c *Client) ContractSetChurnMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractSetChurnMetricsQueryOpts) ([]api.ContractSetChurnMetric, error) {
 	values := url.Values{}
 	values.Set("start", api.TimeRFC3339(start).String())
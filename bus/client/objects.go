@@ -9,6 +9,18 @@ import (
 	"go.sia.tech/renterd/object"
 )
 
+// AddObjects stores the provided objects under their paths, the objects are
+// added in batches rather than one transaction per object.
+func (c *Client) AddObjects(ctx context.Context, bucket, contractSet string, objs map[string]api.AddObjectsEntry, mode string) (err error) {
+	err = c.c.WithContext(ctx).POST("/objects/add", api.AddObjectsRequest{
+		Bucket:      bucket,
+		ContractSet: contractSet,
+		Objects:     objs,
+		Mode:        mode,
+	}, nil)
+	return
+}
+
 // AddObject stores the provided object under the given path.
 func (c *Client) AddObject(ctx context.Context, bucket, path, contractSet string, o object.Object, opts api.AddObjectOptions) (err error) {
 	path = api.ObjectPathEscape(path)
@@ -19,6 +31,7 @@ func (c *Client) AddObject(ctx context.Context, bucket, path, contractSet string
 		ETag:        opts.ETag,
 		MimeType:    opts.MimeType,
 		Metadata:    opts.Metadata,
+		Mode:        opts.Mode,
 	})
 	return
 }
@@ -91,6 +104,39 @@ func (c *Client) ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (o
 	return
 }
 
+// ObjectsHealth returns the objects ordered by their health, least healthy
+// first, together with the number of objects below the safe threshold.
+func (c *Client) ObjectsHealth(ctx context.Context, offset, limit int) (resp api.ObjectsHealthResponse, err error) {
+	values := url.Values{}
+	values.Set("offset", fmt.Sprint(offset))
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.c.WithContext(ctx).GET("/stats/objects/health?"+values.Encode(), &resp)
+	return
+}
+
+// MoveObject moves an object from the source bucket and path to the
+// destination bucket and path without re-uploading its data.
+func (c *Client) MoveObject(ctx context.Context, srcBucket, srcPath, dstBucket, dstPath string, overwrite bool) (err error) {
+	err = c.c.WithContext(ctx).POST("/objects/move", api.ObjectsMoveRequest{
+		SourceBucket:      srcBucket,
+		SourcePath:        srcPath,
+		DestinationBucket: dstBucket,
+		DestinationPath:   dstPath,
+		Overwrite:         overwrite,
+	}, nil)
+	return
+}
+
+// RemoveObjects removes all objects with the given prefix. Objects that can't
+// be removed are reported in the response, all others are removed.
+func (c *Client) RemoveObjects(ctx context.Context, bucket, prefix string) (resp api.ObjectsRemoveResponse, err error) {
+	err = c.c.WithContext(ctx).POST("/objects/remove", api.ObjectsRemoveRequest{
+		Bucket: bucket,
+		Prefix: prefix,
+	}, &resp)
+	return
+}
+
 // RenameObject renames a single object.
 func (c *Client) RenameObject(ctx context.Context, bucket, from, to string, force bool) (err error) {
 	return c.renameObjects(ctx, bucket, from, to, api.ObjectsRenameModeSingle, force)
@@ -106,12 +106,31 @@ func (c *Client) RefreshHealth(ctx context.Context) error {
 	return c.c.WithContext(ctx).POST("/slabs/refreshhealth", nil, nil)
 }
 
+// RepointSlices updates all slices referencing the slab with the old key to
+// reference the slab with the new key and returns the number of slices that
+// were updated.
+func (c *Client) RepointSlices(ctx context.Context, oldKey, newKey object.EncryptionKey) (int, error) {
+	var resp api.RepointSlicesResponse
+	err := c.c.WithContext(ctx).POST("/slabs/repoint", api.RepointSlicesRequest{
+		OldKey: oldKey,
+		NewKey: newKey,
+	}, &resp)
+	return resp.Repointed, err
+}
+
 // Slab returns the slab with the given key from the bus.
 func (c *Client) Slab(ctx context.Context, key object.EncryptionKey) (slab object.Slab, err error) {
 	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/slab/%s", key), &slab)
 	return
 }
 
+// SlabInfo returns the slab with the given key along with its computed health
+// and the objects that reference it.
+func (c *Client) SlabInfo(ctx context.Context, key object.EncryptionKey) (info api.SlabInfo, err error) {
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/slab/%s/info", key), &info)
+	return
+}
+
 // SlabBuffers returns information about the number of objects and their size.
 func (c *Client) SlabBuffers() (buffers []api.SlabBuffer, err error) {
 	err = c.c.GET("/slabbuffers", &buffers)
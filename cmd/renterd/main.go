@@ -26,6 +26,7 @@ import (
 	"go.sia.tech/renterd/internal/node"
 	"go.sia.tech/renterd/s3"
 	"go.sia.tech/renterd/stores"
+	"go.sia.tech/renterd/webhooks"
 	"go.sia.tech/renterd/worker"
 	"go.sia.tech/web/renterd"
 	"go.uber.org/zap"
@@ -87,6 +88,9 @@ var (
 		},
 		Bus: config.Bus{
 			AnnouncementMaxAgeHours:       24 * 7 * 52, // 1 year
+			AnnouncementBatchSoftLimit:    1000,
+			AnnouncementBatchHardLimit:    10000,
+			AnnouncementCatchUpBatchSize:  100,
 			Bootstrap:                     true,
 			GatewayAddr:                   build.DefaultGatewayAddress,
 			PersistInterval:               time.Minute,
@@ -99,6 +103,9 @@ var (
 			ID:                  "worker",
 			ContractLockTimeout: 30 * time.Second,
 			BusFlushInterval:    5 * time.Second,
+			ShutdownTimeout:     time.Minute,
+
+			InteractionsFlushSize: 1000,
 
 			DownloadMaxOverdrive:     5,
 			DownloadOverdriveTimeout: 3 * time.Second,
@@ -106,19 +113,22 @@ var (
 			DownloadMaxMemory:      1 << 30, // 1 GiB
 			UploadMaxMemory:        1 << 30, // 1 GiB
 			UploadMaxOverdrive:     5,
+			UploadMaxConcurrency:   256,
 			UploadOverdriveTimeout: 3 * time.Second,
 		},
 		Autopilot: config.Autopilot{
-			Enabled:                        true,
-			RevisionSubmissionBuffer:       144,
-			AccountsRefillInterval:         defaultAccountRefillInterval,
-			Heartbeat:                      30 * time.Minute,
-			MigrationHealthCutoff:          0.75,
-			RevisionBroadcastInterval:      7 * 24 * time.Hour,
-			ScannerBatchSize:               1000,
-			ScannerInterval:                24 * time.Hour,
-			ScannerNumThreads:              100,
-			MigratorParallelSlabsPerWorker: 1,
+			Enabled:                         true,
+			RevisionSubmissionBuffer:        144,
+			AccountsRefillInterval:          defaultAccountRefillInterval,
+			Heartbeat:                       30 * time.Minute,
+			MigrationHealthCutoff:           0.75,
+			RevisionBroadcastInterval:       7 * 24 * time.Hour,
+			ScannerBatchSize:                1000,
+			ScannerInterval:                 24 * time.Hour,
+			ScannerNumThreads:               100,
+			MigratorParallelSlabsPerWorker:  1,
+			MigratorMaxConcurrentMigrations: 10,
+			ContractFormationConcurrency:    1,
 		},
 		S3: config.S3{
 			Address:     build.DefaultS3Address,
@@ -256,6 +266,10 @@ func main() {
 	flag.StringVar(&cfg.Database.MySQL.User, "db.user", cfg.Database.MySQL.User, "Database username for the bus (overrides with RENTERD_DB_USER)")
 	flag.StringVar(&cfg.Database.MySQL.Database, "db.name", cfg.Database.MySQL.Database, "Database name for the bus (overrides with RENTERD_DB_NAME)")
 	flag.StringVar(&cfg.Database.MySQL.MetricsDatabase, "db.metricsName", cfg.Database.MySQL.MetricsDatabase, "Database for metrics (overrides with RENTERD_DB_METRICS_NAME)")
+	flag.IntVar(&cfg.Database.MySQL.MaxOpenConns, "db.maxOpenConns", cfg.Database.MySQL.MaxOpenConns, "Maximum number of open connections to the MySQL database, 0 means unlimited (overrides with RENTERD_DB_MAX_OPEN_CONNS)")
+	flag.IntVar(&cfg.Database.MySQL.MaxIdleConns, "db.maxIdleConns", cfg.Database.MySQL.MaxIdleConns, "Maximum number of idle connections to the MySQL database, 0 uses the default (overrides with RENTERD_DB_MAX_IDLE_CONNS)")
+	flag.DurationVar(&cfg.Database.MySQL.ConnMaxLifetime, "db.connMaxLifetime", cfg.Database.MySQL.ConnMaxLifetime, "Maximum amount of time a MySQL connection may be reused, 0 means forever (overrides with RENTERD_DB_CONN_MAX_LIFETIME)")
+	flag.StringVar(&cfg.Database.SQLite.JournalMode, "db.sqlite.journalMode", cfg.Database.SQLite.JournalMode, "Journal mode of the SQLite database, use TRUNCATE or DELETE on network drives (overrides with RENTERD_DB_SQLITE_JOURNAL_MODE)")
 
 	// db logger
 	flag.BoolVar(&cfg.Database.Log.IgnoreRecordNotFoundError, "db.logger.ignoreNotFoundError", cfg.Database.Log.IgnoreRecordNotFoundError, "Ignores 'not found' errors in logger (overrides with RENTERD_DB_LOGGER_IGNORE_NOT_FOUND_ERROR)")
@@ -264,6 +278,9 @@ func main() {
 
 	// bus
 	flag.Uint64Var(&cfg.Bus.AnnouncementMaxAgeHours, "bus.announcementMaxAgeHours", cfg.Bus.AnnouncementMaxAgeHours, "Max age for announcements")
+	flag.IntVar(&cfg.Bus.AnnouncementBatchSoftLimit, "bus.announcementBatchSoftLimit", cfg.Bus.AnnouncementBatchSoftLimit, "Number of buffered announcements that triggers a persist on the next consensus change")
+	flag.IntVar(&cfg.Bus.AnnouncementBatchHardLimit, "bus.announcementBatchHardLimit", cfg.Bus.AnnouncementBatchHardLimit, "Number of buffered announcements that triggers a persist once the current consensus change is processed, bounds memory usage while syncing")
+	flag.IntVar(&cfg.Bus.AnnouncementCatchUpBatchSize, "bus.announcementCatchUpBatchSize", cfg.Bus.AnnouncementCatchUpBatchSize, "Number of blocks after which buffered announcements are persisted while syncing")
 	flag.BoolVar(&cfg.Bus.Bootstrap, "bus.bootstrap", cfg.Bus.Bootstrap, "Bootstraps gateway and consensus modules")
 	flag.StringVar(&cfg.Bus.GatewayAddr, "bus.gatewayAddr", cfg.Bus.GatewayAddr, "Address for Sia peer connections (overrides with RENTERD_BUS_GATEWAY_ADDR)")
 	flag.DurationVar(&cfg.Bus.PersistInterval, "bus.persistInterval", cfg.Bus.PersistInterval, "Interval for persisting consensus updates")
@@ -273,11 +290,15 @@ func main() {
 	// worker
 	flag.BoolVar(&cfg.Worker.AllowPrivateIPs, "worker.allowPrivateIPs", cfg.Worker.AllowPrivateIPs, "Allows hosts with private IPs")
 	flag.DurationVar(&cfg.Worker.BusFlushInterval, "worker.busFlushInterval", cfg.Worker.BusFlushInterval, "Interval for flushing data to bus")
+	flag.Uint64Var(&cfg.Worker.InteractionsFlushSize, "worker.interactionsFlushSize", cfg.Worker.InteractionsFlushSize, "Number of buffered host interactions that triggers a flush to the bus")
+	flag.DurationVar(&cfg.Worker.ShutdownTimeout, "worker.shutdownTimeout", cfg.Worker.ShutdownTimeout, "Max time to wait for in-flight RHP calls on shutdown before their connections are forcibly closed")
+	flag.BoolVar(&cfg.Worker.UploadTriggerAutopilot, "worker.uploadTriggerAutopilot", cfg.Worker.UploadTriggerAutopilot, "Triggers the autopilot and waits for it to form contracts when an upload has insufficient contracts, requires the autopilot to be enabled")
 	flag.Uint64Var(&cfg.Worker.DownloadMaxOverdrive, "worker.downloadMaxOverdrive", cfg.Worker.DownloadMaxOverdrive, "Max overdrive workers for downloads")
 	flag.StringVar(&cfg.Worker.ID, "worker.id", cfg.Worker.ID, "Unique ID for worker (overrides with RENTERD_WORKER_ID)")
 	flag.DurationVar(&cfg.Worker.DownloadOverdriveTimeout, "worker.downloadOverdriveTimeout", cfg.Worker.DownloadOverdriveTimeout, "Timeout for overdriving slab downloads")
 	flag.Uint64Var(&cfg.Worker.UploadMaxMemory, "worker.uploadMaxMemory", cfg.Worker.UploadMaxMemory, "Max amount of RAM the worker allocates for slabs when uploading (overrides with RENTERD_WORKER_UPLOAD_MAX_MEMORY)")
 	flag.Uint64Var(&cfg.Worker.UploadMaxOverdrive, "worker.uploadMaxOverdrive", cfg.Worker.UploadMaxOverdrive, "Max overdrive workers for uploads")
+	flag.Uint64Var(&cfg.Worker.UploadMaxConcurrency, "worker.uploadMaxConcurrency", cfg.Worker.UploadMaxConcurrency, "Max number of sectors the worker uploads concurrently across all hosts")
 	flag.DurationVar(&cfg.Worker.UploadOverdriveTimeout, "worker.uploadOverdriveTimeout", cfg.Worker.UploadOverdriveTimeout, "Timeout for overdriving slab uploads")
 	flag.BoolVar(&cfg.Worker.Enabled, "worker.enabled", cfg.Worker.Enabled, "Enables/disables worker (overrides with RENTERD_WORKER_ENABLED)")
 	flag.BoolVar(&cfg.Worker.AllowUnauthenticatedDownloads, "worker.unauthenticatedDownloads", cfg.Worker.AllowUnauthenticatedDownloads, "Allows unauthenticated downloads (overrides with RENTERD_WORKER_UNAUTHENTICATED_DOWNLOADS)")
@@ -291,6 +312,8 @@ func main() {
 	flag.DurationVar(&cfg.Autopilot.ScannerInterval, "autopilot.scannerInterval", cfg.Autopilot.ScannerInterval, "Interval for scanning hosts")
 	flag.Uint64Var(&cfg.Autopilot.ScannerNumThreads, "autopilot.scannerNumThreads", cfg.Autopilot.ScannerNumThreads, "Number of threads for scanning hosts")
 	flag.Uint64Var(&cfg.Autopilot.MigratorParallelSlabsPerWorker, "autopilot.migratorParallelSlabsPerWorker", cfg.Autopilot.MigratorParallelSlabsPerWorker, "Parallel slab migrations per worker (overrides with RENTERD_MIGRATOR_PARALLEL_SLABS_PER_WORKER)")
+	flag.Uint64Var(&cfg.Autopilot.MigratorMaxConcurrentMigrations, "autopilot.migratorMaxConcurrentMigrations", cfg.Autopilot.MigratorMaxConcurrentMigrations, "Max number of concurrent slab migrations across all workers, 0 disables the limit (overrides with RENTERD_MIGRATOR_MAX_CONCURRENT_MIGRATIONS)")
+	flag.Uint64Var(&cfg.Autopilot.ContractFormationConcurrency, "autopilot.contractFormationConcurrency", cfg.Autopilot.ContractFormationConcurrency, "Max number of contracts formed concurrently, bounded by the number of spendable wallet outputs (overrides with RENTERD_CONTRACT_FORMATION_CONCURRENCY)")
 	flag.BoolVar(&cfg.Autopilot.Enabled, "autopilot.enabled", cfg.Autopilot.Enabled, "Enables/disables autopilot (overrides with RENTERD_AUTOPILOT_ENABLED)")
 	flag.DurationVar(&cfg.ShutdownTimeout, "node.shutdownTimeout", cfg.ShutdownTimeout, "Timeout for node shutdown")
 
@@ -346,6 +369,10 @@ func main() {
 	parseEnvVar("RENTERD_DB_PASSWORD", &cfg.Database.MySQL.Password)
 	parseEnvVar("RENTERD_DB_NAME", &cfg.Database.MySQL.Database)
 	parseEnvVar("RENTERD_DB_METRICS_NAME", &cfg.Database.MySQL.MetricsDatabase)
+	parseEnvVar("RENTERD_DB_MAX_OPEN_CONNS", &cfg.Database.MySQL.MaxOpenConns)
+	parseEnvVar("RENTERD_DB_MAX_IDLE_CONNS", &cfg.Database.MySQL.MaxIdleConns)
+	parseEnvVar("RENTERD_DB_CONN_MAX_LIFETIME", &cfg.Database.MySQL.ConnMaxLifetime)
+	parseEnvVar("RENTERD_DB_SQLITE_JOURNAL_MODE", &cfg.Database.SQLite.JournalMode)
 
 	parseEnvVar("RENTERD_DB_LOGGER_IGNORE_NOT_FOUND_ERROR", &cfg.Database.Log.IgnoreRecordNotFoundError)
 	parseEnvVar("RENTERD_DB_LOGGER_LOG_LEVEL", &cfg.Log.Level)
@@ -363,6 +390,8 @@ func main() {
 	parseEnvVar("RENTERD_AUTOPILOT_ENABLED", &cfg.Autopilot.Enabled)
 	parseEnvVar("RENTERD_AUTOPILOT_REVISION_BROADCAST_INTERVAL", &cfg.Autopilot.RevisionBroadcastInterval)
 	parseEnvVar("RENTERD_MIGRATOR_PARALLEL_SLABS_PER_WORKER", &cfg.Autopilot.MigratorParallelSlabsPerWorker)
+	parseEnvVar("RENTERD_MIGRATOR_MAX_CONCURRENT_MIGRATIONS", &cfg.Autopilot.MigratorMaxConcurrentMigrations)
+	parseEnvVar("RENTERD_CONTRACT_FORMATION_CONCURRENCY", &cfg.Autopilot.ContractFormationConcurrency)
 
 	parseEnvVar("RENTERD_S3_ADDRESS", &cfg.S3.Address)
 	parseEnvVar("RENTERD_S3_ENABLED", &cfg.S3.Enabled)
@@ -407,6 +436,11 @@ func main() {
 			cfg.Database.MySQL.URI,
 			cfg.Database.MySQL.MetricsDatabase,
 		)
+		busCfg.DBMaxOpenConns = cfg.Database.MySQL.MaxOpenConns
+		busCfg.DBMaxIdleConns = cfg.Database.MySQL.MaxIdleConns
+		busCfg.DBConnMaxLifetime = cfg.Database.MySQL.ConnMaxLifetime
+	} else if cfg.Database.SQLite.JournalMode != "" {
+		busCfg.DBSQLiteOptions = append(busCfg.DBSQLiteOptions, stores.WithJournalMode(cfg.Database.SQLite.JournalMode))
 	}
 
 	var level logger.LogLevel
@@ -481,8 +515,14 @@ func main() {
 		logger.Fatal("failed to create directory: " + err.Error())
 	}
 
+	// events is only set if the bus runs in the same process, it allows the
+	// worker to subscribe to changes in the bus
+	var events webhooks.Subscriber
+
 	busAddr, busPassword := cfg.Bus.RemoteAddr, cfg.Bus.RemotePassword
 	if cfg.Bus.RemoteAddr == "" {
+		relay := webhooks.NewRelay()
+		busCfg.Events = relay
 		b, fn, err := node.NewBus(busCfg, cfg.Directory, getSeed(), logger)
 		if err != nil {
 			logger.Fatal("failed to create bus, err: " + err.Error())
@@ -491,6 +531,7 @@ func main() {
 			name: "Bus",
 			fn:   fn,
 		})
+		events = relay
 
 		mux.sub["/api/bus"] = treeMux{h: auth(b)}
 		busAddr = cfg.HTTP.Address + "/api/bus"
@@ -508,7 +549,14 @@ func main() {
 	var workers []autopilot.Worker
 	if len(cfg.Worker.Remotes) == 0 {
 		if cfg.Worker.Enabled {
-			w, fn, err := node.NewWorker(cfg.Worker, bc, getSeed(), logger)
+			var apt worker.AutopilotTrigger
+			if cfg.Worker.UploadTriggerAutopilot && cfg.Autopilot.Enabled {
+				apt = autopilot.NewClient(cfg.HTTP.Address+"/api/autopilot", cfg.HTTP.Password)
+			} else if cfg.Worker.UploadTriggerAutopilot {
+				logger.Warn("worker.uploadTriggerAutopilot is ignored since the autopilot is not enabled")
+			}
+
+			w, fn, err := node.NewWorker(node.WorkerConfig{Worker: cfg.Worker, Events: events}, bc, apt, getSeed(), logger)
 			if err != nil {
 				logger.Fatal("failed to create worker: " + err.Error())
 			}
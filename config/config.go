@@ -37,12 +37,16 @@ type (
 	Database struct {
 		Log DatabaseLog `yaml:"log,omitempty"`
 		// optional fields depending on backend
-		MySQL MySQL `yaml:"mysql,omitempty"`
+		MySQL  MySQL  `yaml:"mysql,omitempty"`
+		SQLite SQLite `yaml:"sqlite,omitempty"`
 	}
 
 	// Bus contains the configuration for a bus.
 	Bus struct {
 		AnnouncementMaxAgeHours       uint64        `yaml:"announcementMaxAgeHours,omitempty"`
+		AnnouncementBatchSoftLimit    int           `yaml:"announcementBatchSoftLimit,omitempty"`
+		AnnouncementBatchHardLimit    int           `yaml:"announcementBatchHardLimit,omitempty"`
+		AnnouncementCatchUpBatchSize  int           `yaml:"announcementCatchUpBatchSize,omitempty"`
 		Bootstrap                     bool          `yaml:"bootstrap,omitempty"`
 		GatewayAddr                   string        `yaml:"gatewayAddr,omitempty"`
 		RemoteAddr                    string        `yaml:"remoteAddr,omitempty"`
@@ -60,11 +64,19 @@ type (
 
 	// MySQL contains the configuration for an optional MySQL database.
 	MySQL struct {
-		URI             string `yaml:"uri,omitempty"`
-		User            string `yaml:"user,omitempty"`
-		Password        string `yaml:"password,omitempty"`
-		Database        string `yaml:"database,omitempty"`
-		MetricsDatabase string `yaml:"metricsDatabase,omitempty"`
+		URI             string        `yaml:"uri,omitempty"`
+		User            string        `yaml:"user,omitempty"`
+		Password        string        `yaml:"password,omitempty"`
+		Database        string        `yaml:"database,omitempty"`
+		MetricsDatabase string        `yaml:"metricsDatabase,omitempty"`
+		MaxOpenConns    int           `yaml:"maxOpenConns,omitempty"`
+		MaxIdleConns    int           `yaml:"maxIdleConns,omitempty"`
+		ConnMaxLifetime time.Duration `yaml:"connMaxLifetime,omitempty"`
+	}
+
+	// SQLite contains the configuration for the default SQLite database.
+	SQLite struct {
+		JournalMode string `yaml:"journalMode,omitempty"`
 	}
 
 	RemoteWorker struct {
@@ -87,6 +99,8 @@ type (
 		Remotes                       []RemoteWorker `yaml:"remotes,omitempty"`
 		AllowPrivateIPs               bool           `yaml:"allowPrivateIPs,omitempty"`
 		BusFlushInterval              time.Duration  `yaml:"busFlushInterval,omitempty"`
+		InteractionsFlushSize         uint64         `yaml:"interactionsFlushSize,omitempty"`
+		ShutdownTimeout               time.Duration  `yaml:"shutdownTimeout,omitempty"`
 		ContractLockTimeout           time.Duration  `yaml:"contractLockTimeout,omitempty"`
 		DownloadOverdriveTimeout      time.Duration  `yaml:"downloadOverdriveTimeout,omitempty"`
 		UploadOverdriveTimeout        time.Duration  `yaml:"uploadOverdriveTimeout,omitempty"`
@@ -94,20 +108,24 @@ type (
 		DownloadMaxMemory             uint64         `yaml:"downloadMaxMemory,omitempty"`
 		UploadMaxMemory               uint64         `yaml:"uploadMaxMemory,omitempty"`
 		UploadMaxOverdrive            uint64         `yaml:"uploadMaxOverdrive,omitempty"`
+		UploadMaxConcurrency          uint64         `yaml:"uploadMaxConcurrency,omitempty"`
+		UploadTriggerAutopilot        bool           `yaml:"uploadTriggerAutopilot,omitempty"`
 		AllowUnauthenticatedDownloads bool           `yaml:"allowUnauthenticatedDownloads,omitempty"`
 	}
 
 	// Autopilot contains the configuration for an autopilot.
 	Autopilot struct {
-		Enabled                        bool          `yaml:"enabled,omitempty"`
-		AccountsRefillInterval         time.Duration `yaml:"accountsRefillInterval,omitempty"`
-		Heartbeat                      time.Duration `yaml:"heartbeat,omitempty"`
-		MigrationHealthCutoff          float64       `yaml:"migrationHealthCutoff,omitempty"`
-		RevisionBroadcastInterval      time.Duration `yaml:"revisionBroadcastInterval,omitempty"`
-		RevisionSubmissionBuffer       uint64        `yaml:"revisionSubmissionBuffer,omitempty"`
-		ScannerInterval                time.Duration `yaml:"scannerInterval,omitempty"`
-		ScannerBatchSize               uint64        `yaml:"scannerBatchSize,omitempty"`
-		ScannerNumThreads              uint64        `yaml:"scannerNumThreads,omitempty"`
-		MigratorParallelSlabsPerWorker uint64        `yaml:"migratorParallelSlabsPerWorker,omitempty"`
+		Enabled                         bool          `yaml:"enabled,omitempty"`
+		AccountsRefillInterval          time.Duration `yaml:"accountsRefillInterval,omitempty"`
+		Heartbeat                       time.Duration `yaml:"heartbeat,omitempty"`
+		MigrationHealthCutoff           float64       `yaml:"migrationHealthCutoff,omitempty"`
+		RevisionBroadcastInterval       time.Duration `yaml:"revisionBroadcastInterval,omitempty"`
+		RevisionSubmissionBuffer        uint64        `yaml:"revisionSubmissionBuffer,omitempty"`
+		ScannerInterval                 time.Duration `yaml:"scannerInterval,omitempty"`
+		ScannerBatchSize                uint64        `yaml:"scannerBatchSize,omitempty"`
+		ScannerNumThreads               uint64        `yaml:"scannerNumThreads,omitempty"`
+		MigratorParallelSlabsPerWorker  uint64        `yaml:"migratorParallelSlabsPerWorker,omitempty"`
+		MigratorMaxConcurrentMigrations uint64        `yaml:"migratorMaxConcurrentMigrations,omitempty"`
+		ContractFormationConcurrency    uint64        `yaml:"contractFormationConcurrency,omitempty"`
 	}
 )
@@ -1,6 +1,8 @@
 package hostdb
 
 import (
+	"math"
+	"sort"
 	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -13,9 +15,9 @@ import (
 
 // Announcement represents a host announcement in a given block.
 type Announcement struct {
-	Index      types.ChainIndex
-	Timestamp  time.Time
-	NetAddress string
+	Index      types.ChainIndex `json:"index"`
+	Timestamp  time.Time        `json:"timestamp"`
+	NetAddress string           `json:"netAddress"`
 }
 
 type hostAnnouncement struct {
@@ -62,30 +64,123 @@ func ForEachAnnouncement(b types.Block, height uint64, fn func(types.PublicKey,
 
 // Interactions contains metadata about a host's interactions.
 type Interactions struct {
-	TotalScans              uint64        `json:"totalScans"`
-	LastScan                time.Time     `json:"lastScan"`
-	LastScanSuccess         bool          `json:"lastScanSuccess"`
-	LostSectors             uint64        `json:"lostSectors"`
-	SecondToLastScanSuccess bool          `json:"secondToLastScanSuccess"`
-	Uptime                  time.Duration `json:"uptime"`
-	Downtime                time.Duration `json:"downtime"`
+	TotalScans               uint64        `json:"totalScans"`
+	LastScan                 time.Time     `json:"lastScan"`
+	LastScanSuccess          bool          `json:"lastScanSuccess"`
+	LastScanLatency          time.Duration `json:"lastScanLatency"`
+	LastScanResolutionFailed bool          `json:"lastScanResolutionFailed"`
+	LostSectors              uint64        `json:"lostSectors"`
+	SecondToLastScanSuccess  bool          `json:"secondToLastScanSuccess"`
+	Uptime                   time.Duration `json:"uptime"`
+	Downtime                 time.Duration `json:"downtime"`
+	UptimeRatio              float64       `json:"uptimeRatio"`
 
 	SuccessfulInteractions float64 `json:"successfulInteractions"`
 	FailedInteractions     float64 `json:"failedInteractions"`
+	SuccessRatio           float64 `json:"successRatio"`
+
+	RTT RTTHistogram `json:"rtt"`
+}
+
+// RTTBuckets are the upper bounds of the buckets of an RTTHistogram, samples
+// exceeding the last bound are counted in an additional overflow bucket.
+var RTTBuckets = [...]time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// RTTHistogram is a compact histogram of the round-trip times of a host's
+// RPCs, it holds the number of samples per bucket in RTTBuckets.
+type RTTHistogram [len(RTTBuckets) + 1]uint64
+
+// RTTPercentiles contains the upper bounds of the buckets the 50th, 95th and
+// 99th percentile of a host's round-trip times fall into.
+type RTTPercentiles struct {
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+}
+
+// Add adds the given round-trip time to the histogram.
+func (h *RTTHistogram) Add(rtt time.Duration) {
+	h[sort.Search(len(RTTBuckets), func(i int) bool { return rtt <= RTTBuckets[i] })]++
+}
+
+// Count returns the number of samples in the histogram.
+func (h RTTHistogram) Count() (n uint64) {
+	for _, c := range h {
+		n += c
+	}
+	return
+}
+
+// Percentile returns the upper bound of the bucket the p-th percentile falls
+// into, p has to be in the range (0, 1]. Percentiles that fall into the
+// overflow bucket are reported as the largest bound in RTTBuckets.
+func (h RTTHistogram) Percentile(p float64) time.Duration {
+	total := h.Count()
+	if total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(p * float64(total)))
+	var n uint64
+	for i, c := range h[:len(RTTBuckets)] {
+		n += c
+		if n >= target {
+			return RTTBuckets[i]
+		}
+	}
+	return RTTBuckets[len(RTTBuckets)-1]
+}
+
+// Percentiles returns the 50th, 95th and 99th percentile of the histogram.
+func (h RTTHistogram) Percentiles() RTTPercentiles {
+	return RTTPercentiles{
+		P50: h.Percentile(0.5),
+		P95: h.Percentile(0.95),
+		P99: h.Percentile(0.99),
+	}
 }
 
 type HostScan struct {
 	HostKey    types.PublicKey `json:"hostKey"`
 	Success    bool
 	Timestamp  time.Time
+	Duration   time.Duration
 	Settings   rhpv2.HostSettings
 	PriceTable rhpv3.HostPriceTable
+
+	// ResolutionFailed indicates the scan failed because the host's net
+	// address couldn't be resolved.
+	ResolutionFailed bool
+}
+
+// InteractionTypeDownload is the type of an interaction that downloaded a
+// sector from a host.
+const InteractionTypeDownload = "download"
+
+// Interaction describes an RPC with a host that doesn't warrant its own type,
+// like a sector download.
+type Interaction struct {
+	HostKey   types.PublicKey `json:"hostKey"`
+	Type      string          `json:"type"`
+	Success   bool            `json:"success"`
+	Timestamp time.Time       `json:"timestamp"`
 }
 
 type PriceTableUpdate struct {
 	HostKey    types.PublicKey `json:"hostKey"`
 	Success    bool
 	Timestamp  time.Time
+	Duration   time.Duration
 	PriceTable HostPriceTable
 }
 
@@ -98,14 +193,17 @@ type HostAddress struct {
 
 // A Host pairs a host's public key with a set of interactions.
 type Host struct {
-	KnownSince       time.Time          `json:"knownSince"`
-	LastAnnouncement time.Time          `json:"lastAnnouncement"`
-	PublicKey        types.PublicKey    `json:"publicKey"`
-	NetAddress       string             `json:"netAddress"`
-	PriceTable       HostPriceTable     `json:"priceTable"`
-	Settings         rhpv2.HostSettings `json:"settings"`
-	Interactions     Interactions       `json:"interactions"`
-	Scanned          bool               `json:"scanned"`
+	KnownSince        time.Time          `json:"knownSince"`
+	LastAnnouncement  time.Time          `json:"lastAnnouncement"`
+	LastSeen          time.Time          `json:"lastSeen"`
+	PublicKey         types.PublicKey    `json:"publicKey"`
+	NetAddress        string             `json:"netAddress"`
+	NetAddressChanges uint64             `json:"netAddressChanges"`
+	PriceTable        HostPriceTable     `json:"priceTable"`
+	Settings          rhpv2.HostSettings `json:"settings"`
+	Interactions      Interactions       `json:"interactions"`
+	Scanned           bool               `json:"scanned"`
+	Score             float64            `json:"score"`
 }
 
 // A HostPriceTable extends the host price table with its expiry.
@@ -0,0 +1,569 @@
+package gouging
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	rhpv2 "go.sia.tech/core/rhp/v2"
+	rhpv3 "go.sia.tech/core/rhp/v3"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+const (
+	// maxBaseRPCPriceVsBandwidth is the max ratio for sane pricing between the
+	// MinBaseRPCPrice and the MinDownloadBandwidthPrice. This ensures that 1
+	// million base RPC charges are at most 1% of the cost to download 4TB. This
+	// ratio should be used by checking that the MinBaseRPCPrice is less than or
+	// equal to the MinDownloadBandwidthPrice multiplied by this constant
+	maxBaseRPCPriceVsBandwidth = uint64(40e3)
+
+	// maxSectorAccessPriceVsBandwidth is the max ratio for sane pricing between
+	// the MinSectorAccessPrice and the MinDownloadBandwidthPrice. This ensures
+	// that 1 million base accesses are at most 10% of the cost to download 4TB.
+	// This ratio should be used by checking that the MinSectorAccessPrice is
+	// less than or equal to the MinDownloadBandwidthPrice multiplied by this
+	// constant
+	maxSectorAccessPriceVsBandwidth = uint64(400e3)
+)
+
+var (
+	ErrHostSettingsGouging = errors.New("host settings gouging detected")
+	ErrPriceTableGouging   = errors.New("price table gouging detected")
+)
+
+type (
+	Checker interface {
+		Check(_ *rhpv2.HostSettings, _ *rhpv3.HostPriceTable) api.HostGougingBreakdown
+		Reasons(_ *rhpv2.HostSettings, _ *rhpv3.HostPriceTable) []api.GougingReason
+		BlocksUntilBlockHeightGouging(hostHeight uint64) int64
+	}
+
+	checker struct {
+		consensusState api.ConsensusState
+		settings       api.GougingSettings
+		txFee          types.Currency
+
+		period      *uint64
+		renewWindow *uint64
+	}
+
+	// limitError is returned by the gouging checks if one of the host's prices
+	// exceeds one of the configured limits.
+	limitError struct {
+		reason api.GougingReason
+	}
+)
+
+var _ Checker = checker{}
+
+func NewChecker(gs api.GougingSettings, cs api.ConsensusState, txnFee types.Currency, period, renewWindow uint64) Checker {
+	return checker{
+		consensusState: cs,
+		settings:       gs,
+		txFee:          txnFee,
+
+		period:      &period,
+		renewWindow: &renewWindow,
+	}
+}
+
+func (gc checker) BlocksUntilBlockHeightGouging(hostHeight uint64) int64 {
+	blockHeight := gc.consensusState.BlockHeight
+	leeway := gc.settings.HostBlockHeightLeeway
+	var min uint64
+	if blockHeight >= uint64(leeway) {
+		min = blockHeight - uint64(leeway)
+	}
+	return int64(hostHeight) - int64(min)
+}
+
+func (gc checker) Check(hs *rhpv2.HostSettings, pt *rhpv3.HostPriceTable) api.HostGougingBreakdown {
+	contractErr, downloadErr, gougingErr, pruneErr, uploadErr := gc.check(hs, pt, false)
+	return api.HostGougingBreakdown{
+		ContractErr: errsToStr(contractErr),
+		DownloadErr: errsToStr(downloadErr),
+		GougingErr:  errsToStr(gougingErr),
+		PruneErr:    errsToStr(pruneErr),
+		UploadErr:   errsToStr(uploadErr),
+	}
+}
+
+// Reasons returns a reason for every gouging check the host fails. If the
+// check failed because one of the host's prices exceeds a configured limit,
+// the reason includes the price and by what factor it exceeds the limit.
+func (gc checker) Reasons(hs *rhpv2.HostSettings, pt *rhpv3.HostPriceTable) (reasons []api.GougingReason) {
+	contractErr, downloadErr, gougingErr, pruneErr, uploadErr := gc.check(hs, pt, true)
+	for _, err := range unjoin(contractErr, downloadErr, gougingErr, pruneErr, uploadErr) {
+		var le *limitError
+		if errors.As(err, &le) {
+			reason := le.reason
+			reason.Message = err.Error()
+			reasons = append(reasons, reason)
+		} else {
+			reasons = append(reasons, api.GougingReason{Message: err.Error()})
+		}
+	}
+	return
+}
+
+// check performs all gouging checks, the price checks only return their first
+// failure unless 'all' is set, in which case every failure is returned.
+func (gc checker) check(hs *rhpv2.HostSettings, pt *rhpv3.HostPriceTable, all bool) (contractErr, downloadErr, gougingErr, pruneErr, uploadErr error) {
+	if hs == nil && pt == nil {
+		panic("gouging checker needs to be provided with at least host settings or a price table") // developer error
+	}
+
+	contractErr = errors.Join(
+		checkContractGougingRHPv2(gc.period, gc.renewWindow, hs),
+		checkContractGougingRHPv3(gc.period, gc.renewWindow, pt),
+	)
+	downloadErr = checkDownloadGougingRHPv3(gc.settings, pt)
+	ptErrs := checkPriceGougingPT(gc.settings, gc.consensusState, gc.txFee, pt)
+	hsErrs := checkPriceGougingHS(gc.settings, hs)
+	if all {
+		gougingErr = errors.Join(errors.Join(ptErrs...), errors.Join(hsErrs...))
+	} else {
+		gougingErr = errors.Join(firstErr(ptErrs...), firstErr(hsErrs...))
+	}
+	pruneErr = checkPruneGougingRHPv2(gc.settings, hs)
+	uploadErr = checkUploadGougingRHPv3(gc.settings, pt)
+	return
+}
+
+func (e *limitError) Error() string {
+	return e.reason.Message
+}
+
+// exceedsMax returns a limitError if the price exceeds the given max, a max of
+// zero means there is no limit.
+func exceedsMax(setting, desc string, price, max types.Currency) error {
+	if max.IsZero() || price.Cmp(max) <= 0 {
+		return nil
+	}
+	return &limitError{api.GougingReason{
+		Message: fmt.Sprintf("%s exceeds max: %v > %v", desc, price, max),
+		Setting: setting,
+		Price:   price,
+		Limit:   max,
+		Factor:  currencyRatio(price, max),
+	}}
+}
+
+// belowMin returns a limitError if the price is below the given min.
+func belowMin(setting, desc string, price, min types.Currency) error {
+	if price.Cmp(min) >= 0 {
+		return nil
+	}
+	return &limitError{api.GougingReason{
+		Message: fmt.Sprintf("%s is below minimum: %v < %v", desc, price, min),
+		Setting: setting,
+		Price:   price,
+		Limit:   min,
+		Factor:  currencyRatio(price, min),
+	}}
+}
+
+// currencyRatio returns a / b as a float, 0 is returned if b is zero.
+func currencyRatio(a, b types.Currency) float64 {
+	if b.IsZero() {
+		return 0
+	}
+	f, _ := new(big.Rat).SetFrac(a.Big(), b.Big()).Float64()
+	return f
+}
+
+// unjoin flattens the given errors, errors created using errors.Join are
+// split into the errors they were joined from.
+func unjoin(errs ...error) (flattened []error) {
+	for _, err := range errs {
+		if err == nil {
+			continue
+		} else if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			flattened = append(flattened, unjoin(joined.Unwrap()...)...)
+		} else {
+			flattened = append(flattened, err)
+		}
+	}
+	return
+}
+
+// firstErr returns the first non-nil error.
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkPriceGougingHS(gs api.GougingSettings, hs *rhpv2.HostSettings) (errs []error) {
+	// check if we have settings
+	if hs == nil {
+		return nil
+	}
+
+	// check base rpc price
+	errs = append(errs, exceedsMax("maxRPCPrice", "rpc price", hs.BaseRPCPrice, gs.MaxRPCPrice))
+	maxBaseRPCPrice := hs.DownloadBandwidthPrice.Mul64(maxBaseRPCPriceVsBandwidth)
+	if hs.BaseRPCPrice.Cmp(maxBaseRPCPrice) > 0 {
+		errs = append(errs, fmt.Errorf("rpc price too high, %v > %v", hs.BaseRPCPrice, maxBaseRPCPrice))
+	}
+
+	// check sector access price
+	if hs.DownloadBandwidthPrice.IsZero() {
+		hs.DownloadBandwidthPrice = types.NewCurrency64(1)
+	}
+	maxSectorAccessPrice := hs.DownloadBandwidthPrice.Mul64(maxSectorAccessPriceVsBandwidth)
+	if hs.SectorAccessPrice.Cmp(maxSectorAccessPrice) > 0 {
+		errs = append(errs, fmt.Errorf("sector access price too high, %v > %v", hs.SectorAccessPrice, maxSectorAccessPrice))
+	}
+
+	// check max storage price
+	errs = append(errs, exceedsMax("maxStoragePrice", "storage price", hs.StoragePrice, gs.MaxStoragePrice))
+
+	// check contract price
+	errs = append(errs, exceedsMax("maxContractPrice", "contract price", hs.ContractPrice, gs.MaxContractPrice))
+
+	// check max collateral
+	if hs.MaxCollateral.IsZero() {
+		errs = append(errs, errors.New("MaxCollateral of host is 0"))
+	} else {
+		errs = append(errs, belowMin("minMaxCollateral", "MaxCollateral", hs.MaxCollateral, gs.MinMaxCollateral))
+	}
+
+	// check max EA balance
+	if hs.MaxEphemeralAccountBalance.Cmp(gs.MinMaxEphemeralAccountBalance) < 0 {
+		errs = append(errs, fmt.Errorf("'MaxEphemeralAccountBalance' is less than the allowed minimum value, %v < %v", hs.MaxEphemeralAccountBalance, gs.MinMaxEphemeralAccountBalance))
+	}
+
+	// check EA expiry
+	if hs.EphemeralAccountExpiry < gs.MinAccountExpiry {
+		errs = append(errs, fmt.Errorf("'EphemeralAccountExpiry' is less than the allowed minimum value, %v < %v", hs.EphemeralAccountExpiry, gs.MinAccountExpiry))
+	}
+
+	return
+}
+
+// TODO: if we ever stop assuming that certain prices in the pricetable are
+// always set to 1H we should account for those fields in
+// `hostPeriodCostForScore` as well.
+func checkPriceGougingPT(gs api.GougingSettings, cs api.ConsensusState, txnFee types.Currency, pt *rhpv3.HostPriceTable) (errs []error) {
+	// check if we have a price table
+	if pt == nil {
+		return nil
+	}
+
+	// check base rpc price
+	errs = append(errs, exceedsMax("maxRPCPrice", "init base cost", pt.InitBaseCost, gs.MaxRPCPrice))
+
+	// check contract price
+	errs = append(errs, exceedsMax("maxContractPrice", "contract price", pt.ContractPrice, gs.MaxContractPrice))
+
+	// check max storage
+	errs = append(errs, exceedsMax("maxStoragePrice", "storage price", pt.WriteStoreCost, gs.MaxStoragePrice))
+
+	// check max collateral
+	if pt.MaxCollateral.IsZero() {
+		errs = append(errs, errors.New("MaxCollateral of host is 0"))
+	} else {
+		errs = append(errs, belowMin("minMaxCollateral", "MaxCollateral", pt.MaxCollateral, gs.MinMaxCollateral))
+	}
+
+	// check ReadLengthCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.ReadLengthCost) < 0 {
+		errs = append(errs, fmt.Errorf("ReadLengthCost of host is %v but should be %v", pt.ReadLengthCost, types.NewCurrency64(1)))
+	}
+
+	// check WriteLengthCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.WriteLengthCost) < 0 {
+		errs = append(errs, fmt.Errorf("WriteLengthCost of %v exceeds 1H", pt.WriteLengthCost))
+	}
+
+	// check AccountBalanceCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.AccountBalanceCost) < 0 {
+		errs = append(errs, fmt.Errorf("AccountBalanceCost of %v exceeds 1H", pt.AccountBalanceCost))
+	}
+
+	// check FundAccountCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.FundAccountCost) < 0 {
+		errs = append(errs, fmt.Errorf("FundAccountCost of %v exceeds 1H", pt.FundAccountCost))
+	}
+
+	// check UpdatePriceTableCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.UpdatePriceTableCost) < 0 {
+		errs = append(errs, fmt.Errorf("UpdatePriceTableCost of %v exceeds 1H", pt.UpdatePriceTableCost))
+	}
+
+	// check HasSectorBaseCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.HasSectorBaseCost) < 0 {
+		errs = append(errs, fmt.Errorf("HasSectorBaseCost of %v exceeds 1H", pt.HasSectorBaseCost))
+	}
+
+	// check MemoryTimeCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.MemoryTimeCost) < 0 {
+		errs = append(errs, fmt.Errorf("MemoryTimeCost of %v exceeds 1H", pt.MemoryTimeCost))
+	}
+
+	// check DropSectorsBaseCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.DropSectorsBaseCost) < 0 {
+		errs = append(errs, fmt.Errorf("DropSectorsBaseCost of %v exceeds 1H", pt.DropSectorsBaseCost))
+	}
+
+	// check DropSectorsUnitCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.DropSectorsUnitCost) < 0 {
+		errs = append(errs, fmt.Errorf("DropSectorsUnitCost of %v exceeds 1H", pt.DropSectorsUnitCost))
+	}
+
+	// check SwapSectorBaseCost - should be 1H as it's unused by hosts
+	if types.NewCurrency64(1).Cmp(pt.SwapSectorBaseCost) < 0 {
+		errs = append(errs, fmt.Errorf("SwapSectorBaseCost of %v exceeds 1H", pt.SwapSectorBaseCost))
+	}
+
+	// check SubscriptionMemoryCost - expect 1H default
+	if types.NewCurrency64(1).Cmp(pt.SubscriptionMemoryCost) < 0 {
+		errs = append(errs, fmt.Errorf("SubscriptionMemoryCost of %v exceeds 1H", pt.SubscriptionMemoryCost))
+	}
+
+	// check SubscriptionNotificationCost - expect 1H default
+	if types.NewCurrency64(1).Cmp(pt.SubscriptionNotificationCost) < 0 {
+		errs = append(errs, fmt.Errorf("SubscriptionNotificationCost of %v exceeds 1H", pt.SubscriptionNotificationCost))
+	}
+
+	// check LatestRevisionCost - expect sane value
+	maxRevisionCost := gs.MaxDownloadPrice.Div64(1 << 40).Mul64(4096)
+	if pt.LatestRevisionCost.Cmp(maxRevisionCost) > 0 {
+		errs = append(errs, fmt.Errorf("LatestRevisionCost of %v exceeds maximum cost of %v", pt.LatestRevisionCost, maxRevisionCost))
+	}
+
+	// check RenewContractCost - expect 100nS default
+	if types.Siacoins(1).Mul64(100).Div64(1e9).Cmp(pt.RenewContractCost) < 0 {
+		errs = append(errs, fmt.Errorf("RenewContractCost of %v exceeds 100nS", pt.RenewContractCost))
+	}
+
+	// check RevisionBaseCost - expect 0H default
+	if types.ZeroCurrency.Cmp(pt.RevisionBaseCost) < 0 {
+		errs = append(errs, fmt.Errorf("RevisionBaseCost of %v exceeds 0H", pt.RevisionBaseCost))
+	}
+
+	// check block height - if too much time has passed since the last block
+	// there is a chance we are not up-to-date anymore. So we only check whether
+	// the host's height is at least equal to ours.
+	if !cs.Synced || time.Since(cs.LastBlockTime.Std()) > time.Hour {
+		if pt.HostBlockHeight < cs.BlockHeight {
+			errs = append(errs, fmt.Errorf("consensus not synced and host block height is lower, %v < %v", pt.HostBlockHeight, cs.BlockHeight))
+		}
+	} else {
+		var min uint64
+		if cs.BlockHeight >= uint64(gs.HostBlockHeightLeeway) {
+			min = cs.BlockHeight - uint64(gs.HostBlockHeightLeeway)
+		}
+		max := cs.BlockHeight + uint64(gs.HostBlockHeightLeeway)
+		if !(min <= pt.HostBlockHeight && pt.HostBlockHeight <= max) {
+			errs = append(errs, fmt.Errorf("consensus is synced and host block height is not within range, %v-%v %v", min, max, pt.HostBlockHeight))
+		}
+	}
+
+	// check TxnFeeMaxRecommended - expect at most a multiple of our fee
+	if !txnFee.IsZero() && pt.TxnFeeMaxRecommended.Cmp(txnFee.Mul64(5)) > 0 {
+		errs = append(errs, fmt.Errorf("TxnFeeMaxRecommended %v exceeds %v", pt.TxnFeeMaxRecommended, txnFee.Mul64(5)))
+	}
+
+	// check TxnFeeMinRecommended - expect it to be lower or equal than the max
+	if pt.TxnFeeMinRecommended.Cmp(pt.TxnFeeMaxRecommended) > 0 {
+		errs = append(errs, fmt.Errorf("TxnFeeMinRecommended is greater than TxnFeeMaxRecommended, %v > %v", pt.TxnFeeMinRecommended, pt.TxnFeeMaxRecommended))
+	}
+
+	// check Validity
+	if pt.Validity < gs.MinPriceTableValidity {
+		errs = append(errs, fmt.Errorf("'Validity' is less than the allowed minimum value, %v < %v", pt.Validity, gs.MinPriceTableValidity))
+	}
+
+	return
+}
+
+func checkContractGougingRHPv2(period, renewWindow *uint64, hs *rhpv2.HostSettings) (err error) {
+	// period and renew window might be nil since we don't always have access to
+	// these settings when performing gouging checks
+	if hs == nil || period == nil || renewWindow == nil {
+		return nil
+	}
+
+	err = checkContractGouging(*period, *renewWindow, hs.MaxDuration, hs.WindowSize)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrHostSettingsGouging, err)
+	}
+	return
+}
+
+func checkContractGougingRHPv3(period, renewWindow *uint64, pt *rhpv3.HostPriceTable) (err error) {
+	// period and renew window might be nil since we don't always have access to
+	// these settings when performing gouging checks
+	if pt == nil || period == nil || renewWindow == nil {
+		return nil
+	}
+	err = checkContractGouging(*period, *renewWindow, pt.MaxDuration, pt.WindowSize)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrPriceTableGouging, err)
+	}
+	return
+}
+
+func checkContractGouging(period, renewWindow, maxDuration, windowSize uint64) error {
+	// check MaxDuration
+	if period != 0 && period > maxDuration {
+		return fmt.Errorf("MaxDuration %v is lower than the period %v", maxDuration, period)
+	}
+
+	// check WindowSize
+	if renewWindow != 0 && renewWindow < windowSize {
+		return fmt.Errorf("minimum WindowSize %v is greater than the renew window %v", windowSize, renewWindow)
+	}
+
+	return nil
+}
+
+func checkPruneGougingRHPv2(gs api.GougingSettings, hs *rhpv2.HostSettings) error {
+	if hs == nil {
+		return nil
+	}
+	// pruning costs are similar to sector read costs in a way because they
+	// include base costs and download bandwidth costs, to avoid re-adding all
+	// RHPv2 cost calculations we reuse download gouging checks to cover pruning
+	sectorDownloadPrice, overflow := sectorReadCost(
+		types.NewCurrency64(1), // 1H
+		hs.SectorAccessPrice,
+		hs.BaseRPCPrice,
+		hs.DownloadBandwidthPrice,
+		hs.UploadBandwidthPrice,
+	)
+	if overflow {
+		return fmt.Errorf("%w: overflow detected when computing sector download price", ErrHostSettingsGouging)
+	}
+	dpptb, overflow := sectorDownloadPrice.Mul64WithOverflow(1 << 40 / rhpv2.SectorSize) // sectors per TiB
+	if overflow {
+		return fmt.Errorf("%w: overflow detected when computing download price per TiB", ErrHostSettingsGouging)
+	}
+	if err := exceedsMax("maxDownloadPrice", "download cost per TiB", dpptb, gs.MaxDownloadPrice); err != nil {
+		return fmt.Errorf("%w: %w", ErrHostSettingsGouging, err)
+	}
+	return nil
+}
+
+func checkDownloadGougingRHPv3(gs api.GougingSettings, pt *rhpv3.HostPriceTable) error {
+	if pt == nil {
+		return nil
+	}
+	sectorDownloadPrice, overflow := sectorReadCostRHPv3(*pt)
+	if overflow {
+		return fmt.Errorf("%w: overflow detected when computing sector download price", ErrPriceTableGouging)
+	}
+	dpptb, overflow := sectorDownloadPrice.Mul64WithOverflow(1 << 40 / rhpv2.SectorSize) // sectors per TiB
+	if overflow {
+		return fmt.Errorf("%w: overflow detected when computing download price per TiB", ErrPriceTableGouging)
+	}
+	if err := exceedsMax("maxDownloadPrice", "download cost per TiB", dpptb, gs.MaxDownloadPrice); err != nil {
+		return fmt.Errorf("%w: %w", ErrPriceTableGouging, err)
+	}
+	return nil
+}
+
+func checkUploadGougingRHPv3(gs api.GougingSettings, pt *rhpv3.HostPriceTable) error {
+	if pt == nil {
+		return nil
+	}
+	sectorUploadPricePerMonth, overflow := sectorUploadCostRHPv3(*pt)
+	if overflow {
+		return fmt.Errorf("%w: overflow detected when computing sector price", ErrPriceTableGouging)
+	}
+	uploadPrice, overflow := sectorUploadPricePerMonth.Mul64WithOverflow(1 << 40 / rhpv2.SectorSize) // sectors per TiB
+	if overflow {
+		return fmt.Errorf("%w: overflow detected when computing upload price per TiB", ErrPriceTableGouging)
+	}
+	if err := exceedsMax("maxUploadPrice", "upload cost per TiB", uploadPrice, gs.MaxUploadPrice); err != nil {
+		return fmt.Errorf("%w: %w", ErrPriceTableGouging, err)
+	}
+	return nil
+}
+
+func sectorReadCostRHPv3(pt rhpv3.HostPriceTable) (types.Currency, bool) {
+	return sectorReadCost(
+		pt.ReadLengthCost,
+		pt.ReadBaseCost,
+		pt.InitBaseCost,
+		pt.UploadBandwidthCost,
+		pt.DownloadBandwidthCost,
+	)
+}
+
+func sectorReadCost(readLengthCost, readBaseCost, initBaseCost, ulBWCost, dlBWCost types.Currency) (types.Currency, bool) {
+	// base
+	base, overflow := readLengthCost.Mul64WithOverflow(rhpv2.SectorSize)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	base, overflow = base.AddWithOverflow(readBaseCost)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	base, overflow = base.AddWithOverflow(initBaseCost)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	// bandwidth
+	ingress, overflow := ulBWCost.Mul64WithOverflow(32)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	egress, overflow := dlBWCost.Mul64WithOverflow(rhpv2.SectorSize)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	// total
+	total, overflow := base.AddWithOverflow(ingress)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	total, overflow = total.AddWithOverflow(egress)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	return total, false
+}
+
+func sectorUploadCostRHPv3(pt rhpv3.HostPriceTable) (types.Currency, bool) {
+	// write
+	writeCost, overflow := pt.WriteLengthCost.Mul64WithOverflow(rhpv2.SectorSize)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	writeCost, overflow = writeCost.AddWithOverflow(pt.WriteBaseCost)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	writeCost, overflow = writeCost.AddWithOverflow(pt.InitBaseCost)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	// bandwidth
+	ingress, overflow := pt.UploadBandwidthCost.Mul64WithOverflow(rhpv2.SectorSize)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	// total
+	total, overflow := writeCost.AddWithOverflow(ingress)
+	if overflow {
+		return types.ZeroCurrency, true
+	}
+	return total, false
+}
+
+func errsToStr(errs ...error) string {
+	if err := errors.Join(errs...); err != nil {
+		return err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package gouging
+
+import (
+	"testing"
+
+	rhpv2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+func TestGougingReasons(t *testing.T) {
+	gs := api.GougingSettings{
+		MaxStoragePrice:  types.NewCurrency64(10),
+		MaxContractPrice: types.NewCurrency64(100),
+		MinMaxCollateral: types.NewCurrency64(50),
+	}
+	gc := NewChecker(gs, api.ConsensusState{}, types.ZeroCurrency, 0, 0)
+
+	// host within the limits
+	hs := rhpv2.HostSettings{
+		StoragePrice:  types.NewCurrency64(10),
+		ContractPrice: types.NewCurrency64(100),
+		MaxCollateral: types.NewCurrency64(50),
+	}
+	if reasons := gc.Reasons(&hs, nil); len(reasons) != 0 {
+		t.Fatal("unexpected reasons", reasons)
+	}
+
+	// host exceeding the storage price and below the min max collateral
+	hs.StoragePrice = types.NewCurrency64(23)
+	hs.MaxCollateral = types.NewCurrency64(25)
+	reasons := gc.Reasons(&hs, nil)
+	if len(reasons) != 2 {
+		t.Fatal("unexpected reasons", reasons)
+	} else if r := reasons[0]; r.Setting != "maxStoragePrice" || !r.Price.Equals(hs.StoragePrice) || !r.Limit.Equals(gs.MaxStoragePrice) || r.Factor != 2.3 {
+		t.Fatal("unexpected reason", r)
+	} else if r := reasons[1]; r.Setting != "minMaxCollateral" || !r.Price.Equals(hs.MaxCollateral) || !r.Limit.Equals(gs.MinMaxCollateral) || r.Factor != 0.5 {
+		t.Fatal("unexpected reason", r)
+	} else if breakdown := gc.Check(&hs, nil); breakdown.GougingErr != reasons[0].Message {
+		t.Fatal("expected breakdown to only contain the first failure", breakdown)
+	}
+
+	// host failing a check that isn't related to a limit
+	hs.StoragePrice = gs.MaxStoragePrice
+	hs.MaxCollateral = types.ZeroCurrency
+	reasons = gc.Reasons(&hs, nil)
+	if len(reasons) != 1 {
+		t.Fatal("unexpected reasons", reasons)
+	} else if r := reasons[0]; r.Setting != "" || r.Message != "MaxCollateral of host is 0" {
+		t.Fatal("unexpected reason", r)
+	} else if breakdown := gc.Check(&hs, nil); breakdown.GougingErr != r.Message {
+		t.Fatal("unexpected breakdown", breakdown)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"go.sia.tech/core/consensus"
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/autopilot"
 	"go.sia.tech/renterd/bus"
 	"go.sia.tech/renterd/config"
@@ -38,8 +39,25 @@ type BusConfig struct {
 	DBLoggerConfig      stores.LoggerConfig
 	DBDialector         gorm.Dialector
 	DBMetricsDialector  gorm.Dialector
+	DBSQLiteOptions     []stores.SQLiteOption
+	DBMaxOpenConns      int
+	DBMaxIdleConns      int
+	DBConnMaxLifetime   time.Duration
 	SlabPruningInterval time.Duration
 	SlabPruningCooldown time.Duration
+
+	// Events is optional, if set the bus relays its contract and contract
+	// set events to it which allows in-process subscribers like a worker to
+	// subscribe to them
+	Events *webhooks.Relay
+}
+
+type WorkerConfig struct {
+	config.Worker
+
+	// Events is optional, if set the worker caches the contracts used for
+	// uploads and invalidates them on contract and contract set events
+	Events webhooks.Subscriber
 }
 
 type AutopilotConfig struct {
@@ -94,7 +112,7 @@ func NewBus(cfg BusConfig, dir string, seed types.PrivateKey, l *zap.Logger) (ht
 		if err := os.MkdirAll(dbDir, 0700); err != nil {
 			return nil, nil, err
 		}
-		dbConn = stores.NewSQLiteConnection(filepath.Join(dbDir, "db.sqlite"))
+		dbConn = stores.NewSQLiteConnection(filepath.Join(dbDir, "db.sqlite"), cfg.DBSQLiteOptions...)
 	}
 	dbMetricsConn := cfg.DBMetricsDialector
 	if dbMetricsConn == nil {
@@ -102,7 +120,7 @@ func NewBus(cfg BusConfig, dir string, seed types.PrivateKey, l *zap.Logger) (ht
 		if err := os.MkdirAll(dbDir, 0700); err != nil {
 			return nil, nil, err
 		}
-		dbMetricsConn = stores.NewSQLiteConnection(filepath.Join(dbDir, "metrics.sqlite"))
+		dbMetricsConn = stores.NewSQLiteConnection(filepath.Join(dbDir, "metrics.sqlite"), cfg.DBSQLiteOptions...)
 	}
 
 	alertsMgr := alerts.NewManager()
@@ -117,12 +135,18 @@ func NewBus(cfg BusConfig, dir string, seed types.PrivateKey, l *zap.Logger) (ht
 		PartialSlabDir:                sqlStoreDir,
 		Migrate:                       true,
 		AnnouncementMaxAge:            announcementMaxAge,
+		AnnouncementBatchSoftLimit:    cfg.AnnouncementBatchSoftLimit,
+		AnnouncementBatchHardLimit:    cfg.AnnouncementBatchHardLimit,
+		AnnouncementCatchUpBatchSize:  cfg.AnnouncementCatchUpBatchSize,
 		PersistInterval:               cfg.PersistInterval,
 		WalletAddress:                 walletAddr,
 		SlabBufferCompletionThreshold: cfg.SlabBufferCompletionThreshold,
 		Logger:                        l.Sugar(),
 		GormLogger:                    sqlLogger,
 		RetryTransactionIntervals:     []time.Duration{200 * time.Millisecond, 500 * time.Millisecond, time.Second, 3 * time.Second, 10 * time.Second, 10 * time.Second},
+		MaxOpenConns:                  cfg.DBMaxOpenConns,
+		MaxIdleConns:                  cfg.DBMaxIdleConns,
+		ConnMaxLifetime:               cfg.DBConnMaxLifetime,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -135,6 +159,15 @@ func NewBus(cfg BusConfig, dir string, seed types.PrivateKey, l *zap.Logger) (ht
 	// Hook up webhooks to alerts.
 	alertsMgr.RegisterWebhookBroadcaster(hooksMgr)
 
+	// Relay contract events to in-process subscribers.
+	if cfg.Events != nil {
+		for _, module := range []string{api.WebhookModuleContract, api.WebhookModuleContractSet} {
+			hooksMgr.Subscribe(module, func(e webhooks.Event) {
+				cfg.Events.BroadcastAction(context.Background(), e)
+			})
+		}
+	}
+
 	cancelSubscribe := make(chan struct{})
 	go func() {
 		subscribeErr := cs.ConsensusSetSubscribe(sqlStore, ccid, cancelSubscribe)
@@ -149,7 +182,7 @@ func NewBus(cfg BusConfig, dir string, seed types.PrivateKey, l *zap.Logger) (ht
 			subscribeErr = cs.ConsensusSetSubscribe(sqlStore, modules.ConsensusChangeBeginning, cancelSubscribe)
 		}
 		if subscribeErr != nil && !errors.Is(subscribeErr, sync.ErrStopped) {
-			l.Fatal(fmt.Sprintf("ConsensusSetSubscribe returned an error: %v", err))
+			l.Fatal(fmt.Sprintf("ConsensusSetSubscribe returned an error: %v", subscribeErr))
 		}
 	}()
 
@@ -192,18 +225,21 @@ func NewBus(cfg BusConfig, dir string, seed types.PrivateKey, l *zap.Logger) (ht
 	return b.Handler(), shutdownFn, nil
 }
 
-func NewWorker(cfg config.Worker, b worker.Bus, seed types.PrivateKey, l *zap.Logger) (http.Handler, ShutdownFn, error) {
+func NewWorker(cfg WorkerConfig, b worker.Bus, apt worker.AutopilotTrigger, seed types.PrivateKey, l *zap.Logger) (http.Handler, ShutdownFn, error) {
 	workerKey := blake2b.Sum256(append([]byte("worker"), seed...))
-	w, err := worker.New(workerKey, cfg.ID, b, cfg.ContractLockTimeout, cfg.BusFlushInterval, cfg.DownloadOverdriveTimeout, cfg.UploadOverdriveTimeout, cfg.DownloadMaxOverdrive, cfg.UploadMaxOverdrive, cfg.DownloadMaxMemory, cfg.UploadMaxMemory, cfg.AllowPrivateIPs, l)
+	w, err := worker.New(workerKey, cfg.ID, b, apt, cfg.ContractLockTimeout, cfg.BusFlushInterval, cfg.DownloadOverdriveTimeout, cfg.UploadOverdriveTimeout, cfg.ShutdownTimeout, cfg.DownloadMaxOverdrive, cfg.UploadMaxOverdrive, cfg.DownloadMaxMemory, cfg.UploadMaxMemory, cfg.UploadMaxConcurrency, cfg.InteractionsFlushSize, cfg.AllowPrivateIPs, l)
 	if err != nil {
 		return nil, nil, err
 	}
+	if cfg.Events != nil {
+		w.SubscribeToContractChanges(cfg.Events)
+	}
 
 	return w.Handler(), w.Shutdown, nil
 }
 
 func NewAutopilot(cfg AutopilotConfig, b autopilot.Bus, workers []autopilot.Worker, l *zap.Logger) (http.Handler, RunFn, ShutdownFn, error) {
-	ap, err := autopilot.New(cfg.ID, b, workers, l, cfg.Heartbeat, cfg.ScannerInterval, cfg.ScannerBatchSize, cfg.ScannerNumThreads, cfg.MigrationHealthCutoff, cfg.AccountsRefillInterval, cfg.RevisionSubmissionBuffer, cfg.MigratorParallelSlabsPerWorker, cfg.RevisionBroadcastInterval)
+	ap, err := autopilot.New(cfg.ID, b, workers, l, cfg.Heartbeat, cfg.ScannerInterval, cfg.ScannerBatchSize, cfg.ScannerNumThreads, cfg.MigrationHealthCutoff, cfg.AccountsRefillInterval, cfg.RevisionSubmissionBuffer, cfg.MigratorParallelSlabsPerWorker, cfg.MigratorMaxConcurrentMigrations, cfg.ContractFormationConcurrency, cfg.RevisionBroadcastInterval)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -25,6 +25,7 @@ import (
 	"go.sia.tech/renterd/internal/test"
 	"go.sia.tech/renterd/s3"
 	"go.sia.tech/renterd/stores"
+	"go.sia.tech/renterd/webhooks"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gorm.io/gorm"
@@ -302,6 +303,8 @@ func newTestCluster(t *testing.T, opts testClusterOptions) *TestCluster {
 	busCfg.Miner = node.NewMiner(busClient)
 
 	// Create bus.
+	events := webhooks.NewRelay()
+	busCfg.Events = events
 	b, bStopFn, err := node.NewBus(busCfg, busDir, wk, logger)
 	tt.OK(err)
 
@@ -315,7 +318,7 @@ func newTestCluster(t *testing.T, opts testClusterOptions) *TestCluster {
 	busShutdownFns = append(busShutdownFns, bStopFn)
 
 	// Create worker.
-	w, wShutdownFn, err := node.NewWorker(workerCfg, busClient, wk, logger)
+	w, wShutdownFn, err := node.NewWorker(node.WorkerConfig{Worker: workerCfg, Events: events}, busClient, nil, wk, logger)
 	tt.OK(err)
 
 	workerAuth := jape.BasicAuth(workerPassword)
@@ -896,6 +899,8 @@ func testWorkerCfg() config.Worker {
 		DownloadMaxMemory:        1 << 28, // 256 MiB
 		UploadMaxMemory:          1 << 28, // 256 MiB
 		UploadMaxOverdrive:       5,
+		UploadMaxConcurrency:     64,
+		ShutdownTimeout:          5 * time.Second,
 	}
 }
 
@@ -904,6 +909,7 @@ func testApCfg() node.AutopilotConfig {
 		ID: api.DefaultAutopilotID,
 		Autopilot: config.Autopilot{
 			AccountsRefillInterval:         time.Second,
+			ContractFormationConcurrency:   5,
 			Heartbeat:                      time.Second,
 			MigrationHealthCutoff:          0.99,
 			MigratorParallelSlabsPerWorker: 1,
@@ -10,6 +10,8 @@ import (
 	"io"
 	"math"
 	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
@@ -539,11 +541,21 @@ func TestUploadDownloadBasic(t *testing.T) {
 
 	// upload the data
 	path := fmt.Sprintf("data_%v", len(data))
-	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(data), api.DefaultBucketName, path, api.UploadObjectOptions{}))
+	uor, err := w.UploadObject(context.Background(), bytes.NewReader(data), api.DefaultBucketName, path, api.UploadObjectOptions{})
+	tt.OK(err)
 
 	// fetch object and check its slabs
 	resp, err := cluster.Bus.Object(context.Background(), api.DefaultBucketName, path, api.GetObjectOptions{})
 	tt.OK(err)
+
+	// assert the upload response describes the object
+	if uor.Path != "/"+path || uor.Size != int64(len(data)) || uor.Redundancy != test.RedundancySettings {
+		t.Fatal("unexpected upload response", uor.Path, uor.Size, uor.Redundancy)
+	} else if len(uor.SlabKeys) != len(resp.Object.Slabs) {
+		t.Fatal("unexpected number of slab keys", len(uor.SlabKeys), len(resp.Object.Slabs))
+	} else if uor.ETag != api.FormatETag(resp.Object.ETag) {
+		t.Fatal("unexpected etag", uor.ETag, resp.Object.ETag)
+	}
 	for _, slab := range resp.Object.Slabs {
 		hosts := make(map[types.PublicKey]struct{})
 		roots := make(map[types.Hash256]struct{})
@@ -924,25 +936,74 @@ func TestUploadDownloadSpending(t *testing.T) {
 			t.Fatal("no contracts found")
 		}
 
+		var downloads types.Currency
 		for _, c := range cms {
 			if c.Spending.Uploads.IsZero() {
 				t.Fatal("upload spending shouldn't be zero")
 			}
-			if !c.Spending.Downloads.IsZero() {
-				t.Fatal("download spending should be zero")
-			}
 			if c.RevisionNumber == 0 {
 				t.Fatalf("revision number for contract wasn't recorded: %v", c.RevisionNumber)
 			}
 			if c.Size == 0 {
 				t.Fatalf("size for contract wasn't recorded: %v", c.Size)
 			}
+			downloads = downloads.Add(c.Spending.Downloads)
+		}
+
+		// not every host is necessarily used for downloads, so we only
+		// assert the total download spending
+		if downloads.IsZero() {
+			return errors.New("download spending shouldn't be zero")
 		}
 		return nil
 	})
 	tt.OK(err)
 }
 
+// TestDownloadToFile is an integration test that verifies objects can be
+// downloaded to a local file and no file is left behind if the download fails.
+func TestDownloadToFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// create a test cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+
+	w := cluster.Worker
+	tt := cluster.tt
+
+	// upload an object
+	data := frand.Bytes(rhpv2.SectorSize + 1)
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(data), api.DefaultBucketName, "foo", api.UploadObjectOptions{}))
+
+	// download it to a file
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "foo")
+	n, err := w.DownloadToFile(context.Background(), api.DefaultBucketName, "foo", localPath)
+	tt.OK(err)
+	if n != int64(len(data)) {
+		t.Fatal("unexpected number of bytes written", n)
+	} else if b, err := os.ReadFile(localPath); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(b, data) {
+		t.Fatal("unexpected data")
+	}
+
+	// downloading an object that doesn't exist should fail and leave no
+	// files behind
+	if _, err := w.DownloadToFile(context.Background(), api.DefaultBucketName, "bar", filepath.Join(dir, "bar")); err == nil {
+		t.Fatal("expected error")
+	} else if entries, err := os.ReadDir(dir); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 || entries[0].Name() != "foo" {
+		t.Fatal("unexpected files in dir", entries)
+	}
+}
+
 // TestEphemeralAccounts tests the use of ephemeral accounts.
 func TestEphemeralAccounts(t *testing.T) {
 	if testing.Short() {
@@ -1159,6 +1220,18 @@ func TestParallelUpload(t *testing.T) {
 	if len(objects) != 0 {
 		t.Fatal("objects weren't deleted")
 	}
+
+	// Upload two objects and remove them, the response should report them.
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader([]byte("data")), api.DefaultBucketName, "/dir/foo", api.UploadObjectOptions{}))
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader([]byte("data")), api.DefaultBucketName, "/dir/bar", api.UploadObjectOptions{}))
+	res, err := cluster.Bus.RemoveObjects(context.Background(), api.DefaultBucketName, "/dir/")
+	tt.OK(err)
+	if res.Removed != 2 || len(res.Failed) != 0 {
+		t.Fatal("unexpected response", res)
+	}
+	if _, err := cluster.Bus.RemoveObjects(context.Background(), api.DefaultBucketName, "/dir/"); err == nil || !strings.Contains(err.Error(), api.ErrObjectNotFound.Error()) {
+		t.Fatal("expected object not found error, got", err)
+	}
 }
 
 // TestParallelDownload tests downloading a file in parallel.
@@ -2276,6 +2349,256 @@ func TestWalletFormUnconfirmed(t *testing.T) {
 	}
 }
 
+func TestAutopilotDryRun(t *testing.T) {
+	// create a test cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+	tt := cluster.tt
+
+	// fetch the contracts
+	contracts, err := cluster.Bus.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+
+	// perform a dry run, since the cluster is healthy there should be nothing
+	// to do
+	resp, err := cluster.Autopilot.DryRun(context.Background())
+	tt.OK(err)
+	if len(resp.ToArchive) != 0 || len(resp.ToStopUsing) != 0 {
+		t.Fatal("unexpected contracts to archive or stop using", resp.ToArchive, resp.ToStopUsing)
+	} else if len(resp.ToRefresh) != 0 || len(resp.ToRenew) != 0 {
+		t.Fatal("unexpected contracts to refresh or renew", resp.ToRefresh, resp.ToRenew)
+	} else if resp.ToForm != 0 {
+		t.Fatal("unexpected contracts to form", resp.ToForm)
+	} else if len(resp.ToMigrate) != 0 {
+		t.Fatal("unexpected slabs to migrate", resp.ToMigrate)
+	}
+
+	// assert the dry run didn't touch the contracts
+	after, err := cluster.Bus.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+	if !reflect.DeepEqual(contracts, after) {
+		t.Fatal("contracts changed during dry run")
+	}
+}
+
+func TestFormContractExplicitFunding(t *testing.T) {
+	// New cluster with autopilot disabled
+	cfg := clusterOptsDefault
+	cfg.skipSettingAutopilot = true
+	cluster := newTestCluster(t, cfg)
+	defer cluster.Shutdown()
+	b := cluster.Bus
+	w := cluster.Worker
+	tt := cluster.tt
+
+	// Add a host.
+	hosts := cluster.AddHosts(1)
+	hk := hosts[0].PublicKey()
+	hostIP := hosts[0].RHPv2Addr()
+
+	// Fetch the host's settings.
+	scan, err := w.RHPScan(context.Background(), hk, hostIP, 0)
+	tt.OK(err)
+	if scan.ScanError != "" {
+		t.Fatal("unexpected scan error", scan.ScanError)
+	}
+
+	// Fetch the consensus state and the wallet address.
+	cs, err := b.ConsensusState(context.Background())
+	tt.OK(err)
+	wr, err := b.Wallet(context.Background())
+	tt.OK(err)
+
+	// Form a contract with an explicit funding amount and collateral.
+	renterFunds := types.Siacoins(10)
+	hostCollateral := rhpv2.ContractFormationCollateral(test.AutopilotConfig.Contracts.Period, 1<<30, scan.Settings)
+	endHeight := cs.BlockHeight + test.AutopilotConfig.Contracts.Period
+	contract, _, err := w.RHPForm(context.Background(), endHeight, hk, hostIP, wr.Address, renterFunds, hostCollateral)
+	tt.OK(err)
+	if contract.Revision.ValidRenterPayout().Cmp(renterFunds) > 0 {
+		t.Fatal("renter payout exceeds the renter funds", contract.Revision.ValidRenterPayout())
+	} else if contract.Revision.WindowStart != endHeight {
+		t.Fatal("unexpected window start", contract.Revision.WindowStart)
+	}
+
+	// Persist the contract in the bus.
+	contractPrice := contract.Revision.MissedHostPayout().Sub(hostCollateral)
+	_, err = b.AddContract(context.Background(), contract, contractPrice, renterFunds, cs.BlockHeight, api.ContractStatePending)
+	tt.OK(err)
+
+	// Assert the contract is part of the active contracts.
+	contracts, err := b.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+	if len(contracts) != 1 {
+		t.Fatal("expected 1 contract", len(contracts))
+	} else if contracts[0].ID != contract.ID() {
+		t.Fatal("unexpected contract", contracts[0].ID)
+	} else if contracts[0].HostKey != hk {
+		t.Fatal("unexpected host key", contracts[0].HostKey)
+	} else if !contracts[0].TotalCost.Equals(renterFunds) {
+		t.Fatal("unexpected total cost", contracts[0].TotalCost)
+	}
+}
+
+func TestRenewContractCustomParameters(t *testing.T) {
+	// create a test cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: 1,
+	})
+	defer cluster.Shutdown()
+	b := cluster.Bus
+	w := cluster.Worker
+	tt := cluster.tt
+
+	// fetch the contract
+	contracts, err := b.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+	if len(contracts) != 1 {
+		t.Fatal("expected 1 contract", len(contracts))
+	}
+	c := contracts[0]
+
+	// fetch the host settings
+	scan, err := w.RHPScan(context.Background(), c.HostKey, c.HostIP, 0)
+	tt.OK(err)
+
+	// fetch the consensus state and the wallet address
+	cs, err := b.ConsensusState(context.Background())
+	tt.OK(err)
+	if cs.BlockHeight+test.AutopilotConfig.Contracts.RenewWindow >= c.WindowStart {
+		t.Fatal("contract is already in its renew window")
+	}
+	wr, err := b.Wallet(context.Background())
+	tt.OK(err)
+
+	// force-renew the contract before its renew window with new funding and
+	// an extended duration
+	renterFunds := types.Siacoins(5)
+	endHeight := c.WindowStart + 10
+	resp, err := w.RHPRenew(context.Background(), c.ID, endHeight, c.HostKey, c.SiamuxAddr, scan.Settings.Address, wr.Address, renterFunds, types.ZeroCurrency, 0, scan.Settings.WindowSize)
+	tt.OK(err)
+	if resp.Contract.Revision.WindowStart != endHeight {
+		t.Fatal("unexpected window start", resp.Contract.Revision.WindowStart)
+	}
+
+	// persist the renewed contract
+	renewed, err := b.AddRenewedContract(context.Background(), resp.Contract, resp.ContractPrice, renterFunds, cs.BlockHeight, c.ID, api.ContractStatePending)
+	tt.OK(err)
+	if renewed.RenewedFrom != c.ID {
+		t.Fatalf("expected renewed from %v, got %v", c.ID, renewed.RenewedFrom)
+	}
+
+	// assert the renewed contract replaced the original one
+	contracts, err = b.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+	if len(contracts) != 1 {
+		t.Fatal("expected 1 contract", len(contracts))
+	} else if contracts[0].ID != resp.ContractID {
+		t.Fatal("unexpected contract", contracts[0].ID)
+	} else if contracts[0].RenewedFrom != c.ID {
+		t.Fatal("unexpected renewed from", contracts[0].RenewedFrom)
+	}
+
+	// renewing the original contract again should fail
+	_, err = w.RHPRenew(context.Background(), c.ID, endHeight, c.HostKey, c.SiamuxAddr, scan.Settings.Address, wr.Address, renterFunds, types.ZeroCurrency, 0, scan.Settings.WindowSize)
+	if err == nil || !strings.Contains(err.Error(), api.ErrContractNotFound.Error()) {
+		t.Fatal("expected ErrContractNotFound", err)
+	}
+}
+
+func TestFormContractShortMaxDuration(t *testing.T) {
+	// New cluster with autopilot disabled
+	cfg := clusterOptsDefault
+	cfg.skipSettingAutopilot = true
+	cluster := newTestCluster(t, cfg)
+	defer cluster.Shutdown()
+	tt := cluster.tt
+
+	// Add a host and configure a max duration that is longer than the period
+	// but shorter than the period and renew window combined.
+	maxDuration := test.AutopilotConfig.Contracts.Period + test.AutopilotConfig.Contracts.RenewWindow/2
+	host := cluster.AddHosts(1)[0]
+	settings := host.settings.Settings()
+	settings.MaxContractDuration = maxDuration
+	tt.OK(host.UpdateSettings(settings))
+
+	// Enable autopilot by setting it.
+	cluster.UpdateAutopilotConfig(context.Background(), test.AutopilotConfig)
+
+	// Wait for a contract to form and assert it doesn't exceed the host's
+	// max duration.
+	contracts := cluster.WaitForContracts()
+	if len(contracts) != 1 {
+		t.Fatal("expected 1 contract", len(contracts))
+	} else if c := contracts[0]; c.WindowStart > c.StartHeight+maxDuration {
+		t.Fatalf("contract exceeds the host's max duration, %v > %v+%v", c.WindowStart, c.StartHeight, maxDuration)
+	}
+}
+
+func TestUploadBucketPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// create a test cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+	tt := cluster.tt
+	b := cluster.Bus
+	w := cluster.Worker
+
+	// invalid overrides should be rejected
+	tt.FailAll(b.CreateBucket(context.Background(), "invalid", api.CreateBucketOptions{
+		Policy: api.BucketPolicy{Redundancy: &api.RedundancySettings{MinShards: 0, TotalShards: 1}},
+	}))
+	gs := test.GougingSettings
+	gs.HostBlockHeightLeeway = 0
+	tt.FailAll(b.CreateBucket(context.Background(), "invalid", api.CreateBucketOptions{
+		Policy: api.BucketPolicy{Gouging: &gs},
+	}))
+
+	// create a bucket with higher redundancy and one without overrides
+	cold := api.RedundancySettings{MinShards: 1, TotalShards: test.RedundancySettings.TotalShards}
+	tt.OK(b.CreateBucket(context.Background(), "cold", api.CreateBucketOptions{
+		Policy: api.BucketPolicy{Redundancy: &cold},
+	}))
+	tt.OK(b.CreateBucket(context.Background(), "scratch", api.CreateBucketOptions{}))
+
+	// upload an object into both buckets and assert the redundancy
+	for bucket, rs := range map[string]api.RedundancySettings{
+		"cold":    cold,
+		"scratch": test.RedundancySettings,
+	} {
+		resp, err := w.UploadObject(context.Background(), bytes.NewReader(frand.Bytes(rhpv2.SectorSize)), bucket, "foo", api.UploadObjectOptions{})
+		tt.OK(err)
+		if resp.Redundancy != rs {
+			t.Fatalf("unexpected redundancy for bucket %v: %+v", bucket, resp.Redundancy)
+		}
+		res, err := b.Object(context.Background(), bucket, "foo", api.GetObjectOptions{})
+		tt.OK(err)
+		if slab := res.Object.Slabs[0]; int(slab.MinShards) != rs.MinShards || len(slab.Shards) != rs.TotalShards {
+			t.Fatalf("unexpected slab for bucket %v: %d-of-%d", bucket, slab.MinShards, len(slab.Shards))
+		}
+	}
+
+	// override the gouging settings of the bucket so that all hosts are
+	// considered to be gouging
+	gs = test.GougingSettings
+	gs.MaxUploadPrice = types.NewCurrency64(1)
+	tt.OK(b.UpdateBucketPolicy(context.Background(), "scratch", api.BucketPolicy{Gouging: &gs}))
+	_, err := w.UploadObject(context.Background(), bytes.NewReader(frand.Bytes(rhpv2.SectorSize)), "scratch", "bar", api.UploadObjectOptions{})
+	if err == nil || !strings.Contains(err.Error(), "gouging") {
+		t.Fatal("expected upload to fail due to gouging, got", err)
+	}
+
+	// the default bucket should not be affected
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(frand.Bytes(rhpv2.SectorSize)), api.DefaultBucketName, "bar", api.UploadObjectOptions{}))
+}
+
 func TestBusRecordedMetrics(t *testing.T) {
 	startTime := time.Now().UTC().Round(time.Second)
 
@@ -2318,12 +2641,29 @@ func TestBusRecordedMetrics(t *testing.T) {
 		t.Fatalf("expected added churn, got %v", m.Direction)
 	} else if m.ContractID == (types.FileContractID{}) {
 		t.Fatal("expected non-zero FCID")
+	} else if m.HostKey == (types.PublicKey{}) {
+		t.Fatal("expected non-zero host key")
 	} else if m.Name != test.ContractSet {
 		t.Fatalf("expected contract set %v, got %v", test.ContractSet, m.Name)
 	} else if m.Timestamp.Std().Before(startTime) {
 		t.Fatalf("expected time to be after start time %v, got %v", startTime, m.Timestamp.Std())
 	}
 
+	// Diff the contract set, the host should have been added in the current
+	// period.
+	ap, err := cluster.Bus.Autopilot(context.Background(), api.DefaultAutopilotID)
+	cluster.tt.OK(err)
+	if cscMetrics[0].Period != ap.CurrentPeriod {
+		t.Fatalf("expected period %v, got %v", ap.CurrentPeriod, cscMetrics[0].Period)
+	}
+	diff, err := cluster.Autopilot.ContractSetDiff(context.Background(), test.ContractSet, ap.CurrentPeriod-1, ap.CurrentPeriod)
+	cluster.tt.OK(err)
+	if len(diff.Added) != 1 || diff.Added[0].HostKey != cscMetrics[0].HostKey {
+		t.Fatalf("expected host %v to be added, got %+v", cscMetrics[0].HostKey, diff.Added)
+	} else if len(diff.Removed) != 0 || len(diff.Retained) != 0 {
+		t.Fatalf("expected no removed or retained hosts, got %+v", diff)
+	}
+
 	// Get contract metrics.
 	var cMetrics []api.ContractMetric
 	cluster.tt.Retry(100, 100*time.Millisecond, func() error {
@@ -2594,3 +2934,209 @@ func TestHostScan(t *testing.T) {
 		t.Fatalf("expected 1 hosts, got %v", len(toScan))
 	}
 }
+
+func TestUploadModes(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// create a test cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+	tt := cluster.tt
+	b := cluster.Bus
+	w := cluster.Worker
+
+	// helper to upload data with a given mode
+	upload := func(data []byte, mode string) (*api.UploadObjectResponse, error) {
+		return w.UploadObject(context.Background(), bytes.NewReader(data), api.DefaultBucketName, "foo", api.UploadObjectOptions{Mode: mode})
+	}
+
+	// helper to assert the object's content
+	assertData := func(data []byte) {
+		t.Helper()
+		var buf bytes.Buffer
+		tt.OK(w.DownloadObject(context.Background(), &buf, api.DefaultBucketName, "foo", api.DownloadObjectOptions{}))
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Fatal("unexpected data")
+		}
+	}
+
+	// an invalid mode should be rejected
+	if _, err := upload(frand.Bytes(64), "invalid"); err == nil || !strings.Contains(err.Error(), api.ErrInvalidUploadMode.Error()) {
+		t.Fatal("expected invalid mode to be rejected, got", err)
+	}
+
+	// upload the object
+	original := frand.Bytes(64)
+	resp, err := upload(original, api.UploadModeFailIfExists)
+	tt.OK(err)
+	assertData(original)
+
+	// uploading again should fail
+	if _, err := upload(frand.Bytes(64), api.UploadModeFailIfExists); err == nil || !strings.Contains(err.Error(), api.ErrObjectExists.Error()) {
+		t.Fatal("expected upload to fail, got", err)
+	}
+	assertData(original)
+
+	// skipping should succeed and return the existing object's etag
+	skipped, err := upload(frand.Bytes(64), api.UploadModeSkipIfExists)
+	tt.OK(err)
+	if skipped.ETag != resp.ETag {
+		t.Fatalf("unexpected etag %v, expected %v", skipped.ETag, resp.ETag)
+	}
+	assertData(original)
+
+	// the bus should enforce the mode as well
+	res, err := b.Object(context.Background(), api.DefaultBucketName, "foo", api.GetObjectOptions{})
+	tt.OK(err)
+	err = b.AddObject(context.Background(), api.DefaultBucketName, "foo", test.ContractSet, *res.Object.Object, api.AddObjectOptions{Mode: api.UploadModeFailIfExists})
+	if err == nil || !strings.Contains(err.Error(), api.ErrObjectExists.Error()) {
+		t.Fatal("expected adding the object to fail, got", err)
+	}
+
+	// overwriting should replace the object
+	overwritten := frand.Bytes(64)
+	tt.OKAll(upload(overwritten, api.UploadModeOverwrite))
+	assertData(overwritten)
+}
+
+func TestUploadConcurrency(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	for _, concurrency := range []uint64{1, 16} {
+		t.Run(fmt.Sprint(concurrency), func(t *testing.T) {
+			// create a test cluster with the given upload concurrency
+			workerCfg := testWorkerCfg()
+			workerCfg.UploadMaxConcurrency = concurrency
+			cluster := newTestCluster(t, testClusterOptions{
+				hosts:     test.RedundancySettings.TotalShards,
+				workerCfg: &workerCfg,
+			})
+			defer cluster.Shutdown()
+
+			w := cluster.Worker
+			tt := cluster.tt
+
+			// upload an object that spans multiple slabs
+			data := frand.Bytes(int(test.RedundancySettings.SlabSizeNoRedundancy())*2 + 1)
+			tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(data), api.DefaultBucketName, "foo", api.UploadObjectOptions{}))
+
+			// download the object and assert it matches
+			var buf bytes.Buffer
+			tt.OK(w.DownloadObject(context.Background(), &buf, api.DefaultBucketName, "foo", api.DownloadObjectOptions{}))
+			if !bytes.Equal(data, buf.Bytes()) {
+				t.Fatal("data mismatch")
+			}
+		})
+	}
+}
+
+func TestDownloadHostOffline(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// create a test cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts:  test.RedundancySettings.TotalShards,
+		logger: zap.NewNop(),
+	})
+	defer cluster.Shutdown()
+
+	w := cluster.Worker
+	tt := cluster.tt
+
+	// upload an object
+	data := frand.Bytes(128)
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(data), api.DefaultBucketName, "foo", api.UploadObjectOptions{}))
+
+	// assert the first host stores one of the shards
+	res, err := cluster.Bus.Object(context.Background(), api.DefaultBucketName, "foo", api.GetObjectOptions{})
+	tt.OK(err)
+	h := cluster.hosts[0]
+	var found bool
+	for _, shard := range res.Object.Slabs[0].Shards {
+		if shard.LatestHost == h.PublicKey() {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected host to store a shard")
+	}
+
+	// take the host offline
+	cluster.RemoveHost(h)
+
+	// assert the object can still be downloaded from the remaining hosts, we
+	// download multiple times to make sure we hit the offline host
+	for i := 0; i < 5; i++ {
+		var buf bytes.Buffer
+		tt.OK(w.DownloadObject(context.Background(), &buf, api.DefaultBucketName, "foo", api.DownloadObjectOptions{}))
+		if !bytes.Equal(data, buf.Bytes()) {
+			t.Fatal("data mismatch")
+		}
+	}
+}
+
+func TestDownloadInteractions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// create a test cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts:  test.RedundancySettings.TotalShards,
+		logger: zap.NewNop(),
+	})
+	defer cluster.Shutdown()
+
+	b := cluster.Bus
+	w := cluster.Worker
+	tt := cluster.tt
+
+	// upload an object
+	data := frand.Bytes(128)
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(data), api.DefaultBucketName, "foo", api.UploadObjectOptions{}))
+
+	// corrupt the data of the first host, we disable its sector cache to make
+	// sure the sector is read from disk
+	h := cluster.hosts[0]
+	h.storage.ResizeCache(0)
+	path := filepath.Join(h.dir, "volumes", "volume.dat")
+	fi, err := os.Stat(path)
+	tt.OK(err)
+	tt.OK(os.WriteFile(path, frand.Bytes(int(fi.Size())), 0666))
+
+	// download the object until the corrupt host is used and assert the failed
+	// download was recorded
+	tt.Retry(100, testBusFlushInterval, func() error {
+		var buf bytes.Buffer
+		tt.OK(w.DownloadObject(context.Background(), &buf, api.DefaultBucketName, "foo", api.DownloadObjectOptions{}))
+		if !bytes.Equal(data, buf.Bytes()) {
+			t.Fatal("data mismatch")
+		}
+
+		hi, err := b.Host(context.Background(), h.PublicKey())
+		tt.OK(err)
+		if hi.Interactions.FailedInteractions == 0 {
+			return errors.New("no failed interactions recorded")
+		}
+		return nil
+	})
+
+	// assert the healthy hosts didn't have failed interactions recorded
+	for _, h := range cluster.hosts[1:] {
+		hi, err := b.Host(context.Background(), h.PublicKey())
+		tt.OK(err)
+		if hi.Interactions.FailedInteractions != 0 {
+			t.Fatal("unexpected failed interactions", hi.Interactions.FailedInteractions)
+		} else if hi.Interactions.SuccessfulInteractions == 0 {
+			t.Fatal("expected successful interactions")
+		}
+	}
+}
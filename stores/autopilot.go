@@ -2,13 +2,22 @@ package stores
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
 
 	"go.sia.tech/renterd/api"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// maxAutopilotIDLen is the maximum length of an autopilot identifier.
+const maxAutopilotIDLen = 64
+
+// autopilotIDRegex matches the characters allowed in an autopilot identifier.
+var autopilotIDRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 type (
 	dbAutopilot struct {
 		Model
@@ -16,18 +25,31 @@ type (
 		Identifier    string              `gorm:"unique;NOT NULL;"`
 		Config        api.AutopilotConfig `gorm:"serializer:json"`
 		CurrentPeriod uint64              `gorm:"default:0"`
+		Disabled      bool                `gorm:"NOT NULL"`
+	}
+
+	dbAutopilotConfigHistory struct {
+		Model
+
+		DBAutopilotID uint                `gorm:"index;NOT NULL"`
+		Config        api.AutopilotConfig `gorm:"serializer:json"`
+		CurrentPeriod uint64              `gorm:"default:0"`
 	}
 )
 
 // TableName implements the gorm.Tabler interface.
 func (dbAutopilot) TableName() string { return "autopilots" }
 
+// TableName implements the gorm.Tabler interface.
+func (dbAutopilotConfigHistory) TableName() string { return "autopilot_config_history" }
+
 // convert converts a dbContract to a ContractMetadata.
 func (c dbAutopilot) convert() api.Autopilot {
 	return api.Autopilot{
 		ID:            c.Identifier,
 		Config:        c.Config,
 		CurrentPeriod: c.CurrentPeriod,
+		Disabled:      c.Disabled,
 	}
 }
 
@@ -66,19 +88,137 @@ func (s *SQLStore) Autopilot(ctx context.Context, id string) (api.Autopilot, err
 func (s *SQLStore) UpdateAutopilot(ctx context.Context, ap api.Autopilot) error {
 	// validate autopilot
 	if ap.ID == "" {
-		return errors.New("autopilot ID cannot be empty")
+		return fmt.Errorf("%w: autopilot ID cannot be empty", api.ErrInvalidAutopilotID)
 	}
 	if err := ap.Config.Validate(); err != nil {
 		return err
 	}
 
-	// upsert
-	return s.db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "identifier"}},
-		UpdateAll: true,
-	}).Create(&dbAutopilot{
-		Identifier:    ap.ID,
-		Config:        ap.Config,
-		CurrentPeriod: ap.CurrentPeriod,
-	}).Error
+	return s.retryTransaction(func(tx *gorm.DB) error {
+		// fetch the current config
+		var existing dbAutopilot
+		err := tx.
+			Where("identifier = ?", ap.ID).
+			Take(&existing).
+			Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		exists := err == nil
+
+		// validate the identifier of new autopilots, existing autopilots
+		// might predate the validation so we allow updating those
+		if !exists {
+			if err := validateAutopilotID(ap.ID); err != nil {
+				return err
+			}
+		}
+
+		// upsert
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "identifier"}},
+			UpdateAll: true,
+		}).Create(&dbAutopilot{
+			Identifier:    ap.ID,
+			Config:        ap.Config,
+			CurrentPeriod: ap.CurrentPeriod,
+			Disabled:      ap.Disabled,
+		}).Error; err != nil {
+			return err
+		}
+
+		// only record the config if it changed
+		if exists {
+			if changed, err := configChanged(existing.Config, ap.Config); err != nil {
+				return err
+			} else if !changed {
+				return nil
+			}
+		}
+
+		var id uint
+		if err := tx.
+			Model(&dbAutopilot{}).
+			Select("id").
+			Where("identifier = ?", ap.ID).
+			Take(&id).
+			Error; err != nil {
+			return fmt.Errorf("failed to fetch autopilot id: %w", err)
+		}
+		return tx.Create(&dbAutopilotConfigHistory{
+			DBAutopilotID: id,
+			Config:        ap.Config,
+			CurrentPeriod: ap.CurrentPeriod,
+		}).Error
+	})
+}
+
+// DeleteAutopilot removes the autopilot with given id, including its config
+// history.
+func (s *SQLStore) DeleteAutopilot(ctx context.Context, id string) error {
+	res := s.db.
+		WithContext(ctx).
+		Where("identifier = ?", id).
+		Delete(&dbAutopilot{})
+	if res.Error != nil {
+		return res.Error
+	} else if res.RowsAffected == 0 {
+		return api.ErrAutopilotNotFound
+	}
+	return nil
+}
+
+// AutopilotConfigHistory returns the config changes of the autopilot with
+// given id, ordered from oldest to newest.
+func (s *SQLStore) AutopilotConfigHistory(ctx context.Context, id string) ([]api.AutopilotConfigChange, error) {
+	if _, err := s.Autopilot(ctx, id); err != nil {
+		return nil, err
+	}
+
+	var entities []dbAutopilotConfigHistory
+	err := s.db.
+		WithContext(ctx).
+		Joins("INNER JOIN autopilots ap ON ap.id = autopilot_config_history.db_autopilot_id").
+		Where("ap.identifier = ?", id).
+		Order("autopilot_config_history.id ASC").
+		Find(&entities).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]api.AutopilotConfigChange, len(entities))
+	for i, e := range entities {
+		history[i] = api.AutopilotConfigChange{
+			Config:    e.Config,
+			Period:    e.CurrentPeriod,
+			Timestamp: e.CreatedAt.UTC(),
+		}
+	}
+	return history, nil
+}
+
+// configChanged returns true if the JSON encodings of the given configs differ,
+// the configs are stored as JSON so that's what we compare.
+func configChanged(old, new api.AutopilotConfig) (bool, error) {
+	oldJSON, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	newJSON, err := json.Marshal(new)
+	if err != nil {
+		return false, err
+	}
+	return string(oldJSON) != string(newJSON), nil
+}
+
+// validateAutopilotID returns an error if the given identifier is too long or
+// contains characters that aren't safe to use in a URL.
+func validateAutopilotID(id string) error {
+	if len(id) > maxAutopilotIDLen {
+		return fmt.Errorf("%w: autopilot ID can't be longer than %d characters", api.ErrInvalidAutopilotID, maxAutopilotIDLen)
+	} else if !autopilotIDRegex.MatchString(id) {
+		return fmt.Errorf("%w: autopilot ID can only contain alphanumerics, dashes and underscores", api.ErrInvalidAutopilotID)
+	}
+	return nil
 }
@@ -2,7 +2,9 @@ package stores
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
@@ -86,3 +88,190 @@ func TestAutopilotStore(t *testing.T) {
 		t.Fatal("expected amount to be 99")
 	}
 }
+
+func TestAutopilotConfigHistory(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// assert fetching the history of an unknown autopilot fails
+	_, err := ss.AutopilotConfigHistory(context.Background(), t.Name())
+	if !errors.Is(err, api.ErrAutopilotNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// add an autopilot
+	ap := api.Autopilot{ID: t.Name(), Config: api.AutopilotConfig{
+		Contracts: api.ContractsConfig{
+			Allowance:   types.Siacoins(1).Mul64(1e3),
+			Amount:      3,
+			Period:      144,
+			RenewWindow: 72,
+			Set:         testContractSet,
+		},
+	}}
+	if err := ss.UpdateAutopilot(context.Background(), ap); err != nil {
+		t.Fatal(err)
+	}
+
+	// update it a couple of times without changing the config
+	for i := 0; i < 3; i++ {
+		ap.CurrentPeriod++
+		if err := ss.UpdateAutopilot(context.Background(), ap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert there's only one entry
+	history, err := ss.AutopilotConfigHistory(context.Background(), t.Name())
+	if err != nil {
+		t.Fatal(err)
+	} else if len(history) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(history))
+	} else if !reflect.DeepEqual(history[0].Config, ap.Config) {
+		t.Fatal("unexpected config")
+	} else if history[0].Period != 0 {
+		t.Fatalf("expected period 0, got %d", history[0].Period)
+	} else if history[0].Timestamp.IsZero() {
+		t.Fatal("expected timestamp to be set")
+	}
+
+	// change the config
+	ap.Config.Contracts.Amount = 5
+	if err := ss.UpdateAutopilot(context.Background(), ap); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the change was recorded with the current period
+	history, err = ss.AutopilotConfigHistory(context.Background(), t.Name())
+	if err != nil {
+		t.Fatal(err)
+	} else if len(history) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(history))
+	} else if history[0].Config.Contracts.Amount != 3 || history[1].Config.Contracts.Amount != 5 {
+		t.Fatal("unexpected history", history)
+	} else if history[1].Period != 3 {
+		t.Fatalf("expected period 3, got %d", history[1].Period)
+	}
+}
+
+func TestAutopilotIDValidation(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	cfg := api.AutopilotConfig{
+		Contracts: api.ContractsConfig{
+			Allowance:   types.Siacoins(1).Mul64(1e3),
+			Amount:      3,
+			Period:      144,
+			RenewWindow: 72,
+			Set:         testContractSet,
+		},
+	}
+
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{api.DefaultAutopilotID, true},
+		{"autopilot-1_A", true},
+		{strings.Repeat("a", maxAutopilotIDLen), true},
+		{"", false},
+		{strings.Repeat("a", maxAutopilotIDLen+1), false},
+		{"auto/pilot", false},
+		{"auto pilot", false},
+		{"autopilot?", false},
+		{"autopilöt", false},
+	}
+	for _, test := range tests {
+		err := ss.UpdateAutopilot(context.Background(), api.Autopilot{ID: test.id, Config: cfg})
+		if test.valid && err != nil {
+			t.Fatalf("expected %q to be valid, got %v", test.id, err)
+		} else if !test.valid && !errors.Is(err, api.ErrInvalidAutopilotID) {
+			t.Fatalf("expected %q to be invalid, got %v", test.id, err)
+		}
+	}
+
+	// autopilots that predate the validation can still be updated
+	if err := ss.db.Create(&dbAutopilot{Identifier: "auto pilot", Config: cfg}).Error; err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateAutopilot(context.Background(), api.Autopilot{ID: "auto pilot", Config: cfg, CurrentPeriod: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAutopilotEnabledAndDelete(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add two autopilots
+	cfg := api.AutopilotConfig{
+		Contracts: api.ContractsConfig{
+			Allowance:   types.Siacoins(1).Mul64(1e3),
+			Amount:      3,
+			Period:      144,
+			RenewWindow: 72,
+			Set:         testContractSet,
+		},
+	}
+	for _, id := range []string{"foo", "bar"} {
+		if err := ss.UpdateAutopilot(context.Background(), api.Autopilot{ID: id, Config: cfg, CurrentPeriod: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertEnabled := func(id string, enabled bool) {
+		t.Helper()
+		ap, err := ss.Autopilot(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		} else if ap.Disabled == enabled {
+			t.Fatalf("expected enabled to be %v, got %v", enabled, !ap.Disabled)
+		} else if ap.CurrentPeriod != 10 || !reflect.DeepEqual(ap.Config, cfg) {
+			t.Fatal("unexpected autopilot", ap)
+		}
+	}
+	assertEnabled("foo", true)
+
+	// disable it and assert it's still returned by Autopilots
+	ap, err := ss.Autopilot(context.Background(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ap.Disabled = true
+	if err := ss.UpdateAutopilot(context.Background(), ap); err != nil {
+		t.Fatal(err)
+	}
+	assertEnabled("foo", false)
+	if aps, err := ss.Autopilots(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if len(aps) != 2 {
+		t.Fatalf("expected 2 autopilots, got %d", len(aps))
+	}
+
+	// re-enable it
+	ap.Disabled = false
+	if err := ss.UpdateAutopilot(context.Background(), ap); err != nil {
+		t.Fatal(err)
+	}
+	assertEnabled("foo", true)
+
+	// delete it
+	if err := ss.DeleteAutopilot(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.Autopilot(context.Background(), "foo"); !errors.Is(err, api.ErrAutopilotNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if _, err := ss.AutopilotConfigHistory(context.Background(), "foo"); !errors.Is(err, api.ErrAutopilotNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if err := ss.DeleteAutopilot(context.Background(), "foo"); !errors.Is(err, api.ErrAutopilotNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// assert the config history of the deleted autopilot is gone
+	var cnt int64
+	if err := ss.db.Model(&dbAutopilotConfigHistory{}).Count(&cnt).Error; err != nil {
+		t.Fatal(err)
+	} else if cnt != 1 {
+		t.Fatalf("expected 1 history entry, got %d", cnt)
+	}
+	assertEnabled("bar", true)
+}
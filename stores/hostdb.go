@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,10 +21,22 @@ import (
 )
 
 const (
-	// announcementBatchSoftLimit is the limit above which
-	// threadedProcessAnnouncements will stop merging batches of
-	// announcements and apply them to the db.
-	announcementBatchSoftLimit = 1000
+	// defaultAnnouncementBatchSoftLimit is the default limit above which
+	// buffered announcements are applied to the db on the next consensus
+	// change, regardless of the persist interval.
+	defaultAnnouncementBatchSoftLimit = 1000
+
+	// defaultAnnouncementBatchHardLimit is the default limit above which
+	// buffered announcements are applied to the db as soon as the consensus
+	// change that is being processed is done, regardless of the persist
+	// interval. This bounds the memory used when catching up with the chain.
+	defaultAnnouncementBatchHardLimit = 10000
+
+	// defaultAnnouncementCatchUpBatchSize is the default number of blocks
+	// after which buffered announcements are applied to the db while catching
+	// up with the chain, this keeps the transactions bounded regardless of the
+	// soft limit.
+	defaultAnnouncementCatchUpBatchSize = 100
 
 	// consensusInfoID defines the primary key of the entry in the consensusInfo
 	// table.
@@ -33,6 +46,31 @@ const (
 	// database per batch. Empirically tested to verify that this is a value
 	// that performs reasonably well.
 	hostRetrievalBatchSize = 10000
+
+	// announcementInsertionBatchSize is the number of announcements, and thus
+	// hosts, we insert per query. Every host column is a SQL variable so the
+	// batch size has to be small enough to stay below maxSQLVars.
+	announcementInsertionBatchSize = 500
+)
+
+var (
+	// hostSortColumns maps the fields hosts can be sorted by to their
+	// respective column, it doubles as an allow-list for the sort field.
+	hostSortColumns = map[string]string{
+		api.HostSortByCollateral:             "collateral",
+		api.HostSortByContractPrice:          "host_contract_price",
+		api.HostSortByDownloadBandwidthPrice: "download_bandwidth_price",
+		api.HostSortByLastScan:               "last_scan",
+		api.HostSortByLastScanLatency:        "last_scan_latency",
+		api.HostSortByLastSeen:               "last_seen",
+		api.HostSortByPrice:                  "storage_price",
+		api.HostSortByRemainingStorage:       "remaining_storage",
+		api.HostSortByScore:                  "score",
+		api.HostSortBySuccessRatio:           "success_ratio",
+		api.HostSortByUploadBandwidthPrice:   "upload_bandwidth_price",
+		api.HostSortByUptime:                 "uptime",
+		api.HostSortByVersion:                "version_sort",
+	}
 )
 
 var (
@@ -42,8 +80,9 @@ var (
 
 type (
 	// dbHost defines a hostdb.Interaction as persisted in the DB. Deleting a
-	// host from the db will cascade the deletion and also delete the
-	// corresponding announcements and interactions with that host.
+	// host from the db will cascade the deletion to its allowlist and
+	// blocklist entries, announcements aren't related to the host and have to
+	// be pruned separately.
 	//
 	// NOTE: updating the host entity requires an update to the field map passed
 	// to 'Update' when recording host interactions
@@ -71,11 +110,71 @@ type (
 		SuccessfulInteractions float64
 		FailedInteractions     float64
 
+		// SuccessRatio is derived from the successful and failed interactions
+		// and updated alongside them, it allows for sorting and filtering
+		// hosts by their success ratio.
+		SuccessRatio float64 `gorm:"index;NOT NULL;default:0"`
+
+		// Score is the host's score as last computed by the autopilot, it
+		// allows for sorting and filtering hosts without having to score them.
+		Score float64 `gorm:"index;NOT NULL;default:0"`
+
 		LostSectors uint64
 
 		LastAnnouncement time.Time
 		NetAddress       string `gorm:"index"`
 
+		// NetAddressChanges is the number of times the host re-announced
+		// itself with a different net address.
+		NetAddressChanges uint64 `gorm:"NOT NULL;default:0"`
+
+		// LastSeen is the most recent of the host's last successful scan and
+		// its last announcement.
+		LastSeen int64 `gorm:"index;NOT NULL;default:0"` // unix nano
+
+		// AcceptingContracts mirrors the setting of the same name, it's
+		// updated on every successful scan so hosts that aren't accepting
+		// contracts can be filtered out without decoding their settings.
+		AcceptingContracts bool `gorm:"index"`
+
+		// MaxDuration mirrors the setting of the same name and is updated on
+		// every successful scan.
+		MaxDuration uint64 `gorm:"index;NOT NULL;default:0"`
+
+		// StoragePrice, Collateral, RemainingStorage and Version mirror the
+		// settings of the same name, they are updated on every successful scan
+		// and allow for sorting hosts without decoding their settings.
+		StoragePrice     bCurrency `gorm:"index;NOT NULL;size:16"`
+		Collateral       bCurrency `gorm:"index;NOT NULL;size:16"`
+		RemainingStorage uint64    `gorm:"index;NOT NULL;default:0"`
+		Version          string    `gorm:"index;NOT NULL;default:''"`
+
+		// VersionSort encodes the version into an integer that sorts like a
+		// semantic version, sorting by the version string would put "1.10.0"
+		// before "1.9.0".
+		VersionSort uint64 `gorm:"index;NOT NULL;default:0"`
+
+		// HostContractPrice, UploadBandwidthPrice and DownloadBandwidthPrice
+		// mirror the settings of the same name, like the columns above they
+		// allow for sorting and filtering hosts by their prices in SQL. The
+		// contract price is prefixed since contracts have a column of the
+		// same name and both tables are selected together when joined.
+		HostContractPrice      bCurrency `gorm:"index;NOT NULL;size:16"`
+		UploadBandwidthPrice   bCurrency `gorm:"index;NOT NULL;size:16"`
+		DownloadBandwidthPrice bCurrency `gorm:"index;NOT NULL;size:16"`
+
+		// RTTHistogram holds the round-trip times of successful scans and
+		// price table updates.
+		RTTHistogram rttHistogram
+
+		// LastScanLatency is the round-trip time of the last successful scan,
+		// it allows for sorting hosts by their responsiveness.
+		LastScanLatency time.Duration `gorm:"index;NOT NULL;default:0"`
+
+		// LastScanResolutionFailed indicates whether the last scan failed
+		// because the host's net address couldn't be resolved.
+		LastScanResolutionFailed bool `gorm:"NOT NULL;default:false"`
+
 		Allowlist []dbAllowlistEntry `gorm:"many2many:host_allowlist_entry_hosts;constraint:OnDelete:CASCADE"`
 		Blocklist []dbBlocklistEntry `gorm:"many2many:host_blocklist_entry_hosts;constraint:OnDelete:CASCADE"`
 	}
@@ -98,6 +197,11 @@ type (
 		Model
 		Entry string   `gorm:"unique;index;NOT NULL"`
 		Hosts []dbHost `gorm:"many2many:host_blocklist_entry_hosts;constraint:OnDelete:CASCADE"`
+
+		// ExpiresAt is the time at which the entry lapses, entries without
+		// an expiry are permanent. Expired entries are ignored when
+		// filtering hosts until they are pruned.
+		ExpiresAt sql.NullTime `gorm:"index"`
 	}
 
 	// dbHostBlocklistEntryHost is a join table between dbBlocklistEntry and dbHost.
@@ -123,6 +227,11 @@ type (
 		BlockHeight uint64
 		BlockID     string
 		NetAddress  string
+
+		// Timestamp is the timestamp of the block the announcement was found
+		// in, it's not set for announcements that were inserted before it was
+		// added.
+		Timestamp sql.NullTime
 	}
 
 	// announcement describes an announcement for a single host.
@@ -269,6 +378,22 @@ func convertHostPriceTable(pt rhpv3.HostPriceTable) hostPriceTable {
 // TableName implements the gorm.Tabler interface.
 func (dbAnnouncement) TableName() string { return "host_announcements" }
 
+// convert converts an announcement into a hostdb.Announcement.
+func (a dbAnnouncement) convert() (hostdb.Announcement, error) {
+	var id types.BlockID
+	if err := id.UnmarshalText([]byte(a.BlockID)); err != nil {
+		return hostdb.Announcement{}, fmt.Errorf("failed to decode block id of announcement %d: %w", a.ID, err)
+	}
+	return hostdb.Announcement{
+		Index: types.ChainIndex{
+			Height: a.BlockHeight,
+			ID:     id,
+		},
+		Timestamp:  a.Timestamp.Time,
+		NetAddress: a.NetAddress,
+	}, nil
+}
+
 // TableName implements the gorm.Tabler interface.
 func (dbConsensusInfo) TableName() string { return "consensus_infos" }
 
@@ -289,24 +414,34 @@ func (dbHostBlocklistEntryHost) TableName() string { return "host_blocklist_entr
 
 // convert converts a host into a hostdb.Host.
 func (h dbHost) convert() hostdb.Host {
-	var lastScan time.Time
+	var lastScan, lastSeen time.Time
 	if h.LastScan > 0 {
 		lastScan = time.Unix(0, h.LastScan)
 	}
+	if h.LastSeen > 0 {
+		lastSeen = time.Unix(0, h.LastSeen)
+	}
 	return hostdb.Host{
-		KnownSince:       h.CreatedAt,
-		LastAnnouncement: h.LastAnnouncement,
-		NetAddress:       h.NetAddress,
+		KnownSince:        h.CreatedAt,
+		LastAnnouncement:  h.LastAnnouncement,
+		LastSeen:          lastSeen,
+		NetAddress:        h.NetAddress,
+		NetAddressChanges: h.NetAddressChanges,
 		Interactions: hostdb.Interactions{
-			TotalScans:              h.TotalScans,
-			LastScan:                lastScan,
-			LastScanSuccess:         h.LastScanSuccess,
-			SecondToLastScanSuccess: h.SecondToLastScanSuccess,
-			Uptime:                  h.Uptime,
-			Downtime:                h.Downtime,
-			SuccessfulInteractions:  h.SuccessfulInteractions,
-			FailedInteractions:      h.FailedInteractions,
-			LostSectors:             h.LostSectors,
+			TotalScans:               h.TotalScans,
+			LastScan:                 lastScan,
+			LastScanSuccess:          h.LastScanSuccess,
+			LastScanLatency:          h.LastScanLatency,
+			LastScanResolutionFailed: h.LastScanResolutionFailed,
+			SecondToLastScanSuccess:  h.SecondToLastScanSuccess,
+			Uptime:                   h.Uptime,
+			Downtime:                 h.Downtime,
+			UptimeRatio:              uptimeRatio(h.Uptime, h.Downtime),
+			SuccessfulInteractions:   h.SuccessfulInteractions,
+			FailedInteractions:       h.FailedInteractions,
+			SuccessRatio:             h.SuccessRatio,
+			LostSectors:              h.LostSectors,
+			RTT:                      hostdb.RTTHistogram(h.RTTHistogram),
 		},
 		PriceTable: hostdb.HostPriceTable{
 			HostPriceTable: h.PriceTable.convert(),
@@ -314,14 +449,53 @@ func (h dbHost) convert() hostdb.Host {
 		},
 		PublicKey: types.PublicKey(h.PublicKey),
 		Scanned:   h.Scanned,
+		Score:     h.Score,
 		Settings:  h.Settings.convert(),
 	}
 }
 
+// successRatio returns the ratio of successful interactions to all
+// interactions, hosts without any interactions have a ratio of 0.
+func successRatio(successful, failed float64) float64 {
+	if successful+failed == 0 {
+		return 0
+	}
+	return successful / (successful + failed)
+}
+
+// uptimeRatio returns the ratio of a host's uptime to its total uptime and
+// downtime, hosts that were never up or down have a ratio of 0.
+func uptimeRatio(uptime, downtime time.Duration) float64 {
+	if uptime+downtime == 0 {
+		return 0
+	}
+	return float64(uptime) / float64(uptime+downtime)
+}
+
 func (h *dbHost) BeforeCreate(tx *gorm.DB) (err error) {
+	// a host is only created through an announcement, if it exists already
+	// the last seen timestamp is only updated if the announcement is newer
+	lastSeen := gorm.Expr("GREATEST(last_seen, VALUES(last_seen))")
+	if isSQLite(tx) {
+		lastSeen = gorm.Expr("MAX(last_seen, excluded.last_seen)")
+	}
+
+	// count the net address changes, MySQL evaluates the assignments in order
+	// so the count has to be updated before the net address
+	netAddressChanges := gorm.Expr("CASE WHEN net_address <> VALUES(net_address) THEN net_address_changes + 1 ELSE net_address_changes END")
+	if isSQLite(tx) {
+		netAddressChanges = gorm.Expr("CASE WHEN net_address <> excluded.net_address THEN net_address_changes + 1 ELSE net_address_changes END")
+	}
+
 	tx.Statement.AddClause(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "public_key"}},
-		DoUpdates: clause.AssignmentColumns([]string{"last_announcement", "net_address"}),
+		Columns: []clause.Column{{Name: "public_key"}},
+		DoUpdates: append(
+			clause.Set{{Column: clause.Column{Name: "net_address_changes"}, Value: netAddressChanges}},
+			append(
+				clause.AssignmentColumns([]string{"last_announcement", "net_address"}),
+				clause.Assignment{Column: clause.Column{Name: "last_seen"}, Value: lastSeen},
+			)...,
+		),
 	})
 	return nil
 }
@@ -407,6 +581,10 @@ func (e *dbBlocklistEntry) BeforeCreate(tx *gorm.DB) (err error) {
 	return nil
 }
 
+func (e *dbBlocklistEntry) expired(now time.Time) bool {
+	return e.ExpiresAt.Valid && !e.ExpiresAt.Time.After(now)
+}
+
 func (e *dbBlocklistEntry) blocks(h dbHost) bool {
 	values := []string{h.NetAddress}
 	host, _, err := net.SplitHostPort(h.NetAddress)
@@ -443,41 +621,93 @@ func (ss *SQLStore) Host(ctx context.Context, hostKey types.PublicKey) (hostdb.H
 	}, nil
 }
 
-// HostsForScanning returns the address of hosts for scanning.
+// HostsForScanning returns the address of hosts for scanning. Only the public
+// key and net address of the hosts are fetched, ordered by last scan.
 func (ss *SQLStore) HostsForScanning(ctx context.Context, maxLastScan time.Time, offset, limit int) ([]hostdb.HostAddress, error) {
 	if offset < 0 {
 		return nil, ErrNegativeOffset
 	}
 
 	var hosts []struct {
-		PublicKey  publicKey `gorm:"unique;index;NOT NULL"`
+		PublicKey  publicKey
 		NetAddress string
 	}
-	var hostAddresses []hostdb.HostAddress
-
 	err := ss.db.
+		WithContext(ctx).
 		Model(&dbHost{}).
+		Select("public_key, net_address").
 		Where("last_scan < ?", maxLastScan.UnixNano()).
+		Order("last_scan ASC").
+		Order("id ASC").
 		Offset(offset).
 		Limit(limit).
-		Order("last_scan ASC").
-		FindInBatches(&hosts, hostRetrievalBatchSize, func(tx *gorm.DB, batch int) error {
-			for _, h := range hosts {
-				hostAddresses = append(hostAddresses, hostdb.HostAddress{
-					PublicKey:  types.PublicKey(h.PublicKey),
-					NetAddress: h.NetAddress,
-				})
-			}
-			return nil
-		}).
+		Find(&hosts).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	hostAddresses := make([]hostdb.HostAddress, len(hosts))
+	for i, h := range hosts {
+		hostAddresses[i] = hostdb.HostAddress{
+			PublicKey:  types.PublicKey(h.PublicKey),
+			NetAddress: h.NetAddress,
+		}
+	}
+	return hostAddresses, nil
+}
+
+// NewHosts returns all hosts that were first announced at or after the given
+// block height, ordered by the height of their first announcement.
+func (ss *SQLStore) NewHosts(ctx context.Context, sinceHeight uint64) ([]hostdb.Host, error) {
+	var fullHosts []dbHost
+	err := ss.db.
+		Model(&dbHost{}).
+		Select("hosts.*").
+		Joins("INNER JOIN (SELECT host_key, MIN(block_height) AS first_height FROM host_announcements GROUP BY host_key) ha ON ha.host_key = hosts.public_key").
+		Where("ha.first_height >= ?", sinceHeight).
+		Order("ha.first_height ASC").
+		Order("hosts.id ASC").
+		Find(&fullHosts).
 		Error
 	if err != nil {
 		return nil, err
 	}
-	return hostAddresses, err
+
+	hosts := make([]hostdb.Host, len(fullHosts))
+	for i, fh := range fullHosts {
+		hosts[i] = fh.convert()
+	}
+	return hosts, nil
 }
 
-func (ss *SQLStore) SearchHosts(ctx context.Context, filterMode, addressContains string, keyIn []types.PublicKey, offset, limit int) ([]hostdb.Host, error) {
+// HostAnnouncements returns up to 'limit' announcements of the host with the
+// given key, ordered from most to least recent. A limit of -1 returns all of
+// the host's announcements.
+func (ss *SQLStore) HostAnnouncements(ctx context.Context, hostKey types.PublicKey, limit int) ([]hostdb.Announcement, error) {
+	var dbAnnouncements []dbAnnouncement
+	err := ss.db.
+		WithContext(ctx).
+		Where("host_key = ?", publicKey(hostKey)).
+		Order("block_height DESC").
+		Order("id DESC").
+		Limit(limit).
+		Find(&dbAnnouncements).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	announcements := make([]hostdb.Announcement, len(dbAnnouncements))
+	for i, a := range dbAnnouncements {
+		if announcements[i], err = a.convert(); err != nil {
+			return nil, err
+		}
+	}
+	return announcements, nil
+}
+
+func (ss *SQLStore) SearchHosts(ctx context.Context, filterMode, addressContains string, keyIn []types.PublicKey, acceptingContracts bool, minSuccessRatio float64, minRemainingStorage uint64, maxStoragePrice types.Currency, offset, limit int) ([]hostdb.Host, error) {
 	if offset < 0 {
 		return nil, ErrNegativeOffset
 	}
@@ -505,6 +735,34 @@ func (ss *SQLStore) SearchHosts(ctx context.Context, filterMode, addressContains
 		})
 	}
 
+	// Only search for hosts that are accepting contracts.
+	if acceptingContracts {
+		query = query.Scopes(func(d *gorm.DB) *gorm.DB {
+			return d.Where("accepting_contracts = ?", true)
+		})
+	}
+
+	// Only search for hosts with a minimum success ratio.
+	if minSuccessRatio > 0 {
+		query = query.Scopes(func(d *gorm.DB) *gorm.DB {
+			return d.Where("success_ratio >= ?", minSuccessRatio)
+		})
+	}
+
+	// Only search for hosts with a minimum amount of remaining storage.
+	if minRemainingStorage > 0 {
+		query = query.Scopes(func(d *gorm.DB) *gorm.DB {
+			return d.Where("remaining_storage >= ?", minRemainingStorage)
+		})
+	}
+
+	// Only search for hosts with a maximum storage price.
+	if !maxStoragePrice.IsZero() {
+		query = query.Scopes(func(d *gorm.DB) *gorm.DB {
+			return d.Where("storage_price <= ?", bCurrency(maxStoragePrice))
+		})
+	}
+
 	// Only search for specific hosts.
 	if len(keyIn) > 0 {
 		pubKeys := make([]publicKey, len(keyIn))
@@ -532,9 +790,174 @@ func (ss *SQLStore) SearchHosts(ctx context.Context, filterMode, addressContains
 	return hosts, err
 }
 
+// HostsSorted returns non-blocked hosts sorted by the given field at given
+// offset and limit, alongside the total number of non-blocked hosts. Hosts with
+// a score below minScore are excluded.
+func (ss *SQLStore) HostsSorted(ctx context.Context, sortBy string, ascending bool, minScore float64, offset, limit int) ([]hostdb.Host, int64, error) {
+	if offset < 0 {
+		return nil, 0, ErrNegativeOffset
+	}
+
+	// only allow sorting by the promoted columns
+	column, ok := hostSortColumns[sortBy]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w '%s'", api.ErrInvalidHostSortField, sortBy)
+	}
+
+	// only include hosts with a minimum score
+	minScoreFilter := func(d *gorm.DB) *gorm.DB {
+		if minScore > 0 {
+			return d.Where("score >= ?", minScore)
+		}
+		return d
+	}
+
+	// count the hosts
+	var total int64
+	if err := ss.db.
+		WithContext(ctx).
+		Model(&dbHost{}).
+		Scopes(ss.excludeBlocked, minScoreFilter).
+		Count(&total).
+		Error; err != nil {
+		return nil, 0, err
+	}
+
+	// fetch the page, break ties using the id to ensure a stable order
+	var dbHosts []dbHost
+	if err := ss.db.
+		WithContext(ctx).
+		Scopes(ss.excludeBlocked, minScoreFilter).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: !ascending}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: !ascending}).
+		Offset(offset).
+		Limit(limit).
+		Find(&dbHosts).
+		Error; err != nil {
+		return nil, 0, err
+	}
+
+	hosts := make([]hostdb.Host, len(dbHosts))
+	for i, h := range dbHosts {
+		hosts[i] = h.convert()
+	}
+	return hosts, total, nil
+}
+
+// backfillHostLastSeen sets the last seen timestamp of existing hosts to the
+// most recent of their last announcement and their last scan, if it was
+// successful.
+func backfillHostLastSeen(tx *gorm.DB) error {
+	var batch []dbHost
+	return tx.
+		Model(&dbHost{}).
+		Select("id", "last_scan", "last_scan_success", "last_announcement").
+		FindInBatches(&batch, hostRetrievalBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, h := range batch {
+				var lastSeen int64
+				if !h.LastAnnouncement.IsZero() {
+					lastSeen = h.LastAnnouncement.UnixNano()
+				}
+				if h.LastScanSuccess && h.LastScan > lastSeen {
+					lastSeen = h.LastScan
+				}
+				if lastSeen <= 0 {
+					continue
+				}
+				if err := tx.
+					Model(&dbHost{}).
+					Where("id", h.ID).
+					Update("last_seen", lastSeen).
+					Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).
+		Error
+}
+
+// backfillHostSortColumns populates the columns hosts can be sorted by from the
+// settings of all hosts.
+func backfillHostSortColumns(tx *gorm.DB) error {
+	var batch []dbHost
+	return tx.
+		Model(&dbHost{}).
+		Select("id", "settings").
+		Where("settings IS NOT NULL").
+		FindInBatches(&batch, hostRetrievalBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, h := range batch {
+				if err := tx.
+					Model(&dbHost{}).
+					Where("id", h.ID).
+					Updates(map[string]interface{}{
+						"storage_price":     bCurrency(h.Settings.StoragePrice),
+						"collateral":        bCurrency(h.Settings.Collateral),
+						"remaining_storage": h.Settings.RemainingStorage,
+						"version":           h.Settings.Version,
+					}).
+					Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).
+		Error
+}
+
+// backfillHostVersionSort populates the version sort column of all hosts from
+// their version, it's used by the migration that introduced the column.
+func backfillHostVersionSort(tx *gorm.DB) error {
+	var batch []dbHost
+	return tx.
+		Model(&dbHost{}).
+		Select("id", "version").
+		Where("version <> ''").
+		FindInBatches(&batch, hostRetrievalBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, h := range batch {
+				if err := tx.
+					Model(&dbHost{}).
+					Where("id", h.ID).
+					Update("version_sort", versionSortKey(h.Version)).
+					Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).
+		Error
+}
+
+// backfillHostPriceColumns populates the price columns of all hosts from their
+// settings, it's used by the migration that introduced the columns.
+func backfillHostPriceColumns(tx *gorm.DB) error {
+	var batch []dbHost
+	return tx.
+		Model(&dbHost{}).
+		Select("id", "settings").
+		Where("settings IS NOT NULL").
+		FindInBatches(&batch, hostRetrievalBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, h := range batch {
+				if err := tx.
+					Model(&dbHost{}).
+					Where("id", h.ID).
+					Updates(map[string]interface{}{
+						"host_contract_price":      bCurrency(h.Settings.ContractPrice),
+						"upload_bandwidth_price":   bCurrency(h.Settings.UploadBandwidthPrice),
+						"download_bandwidth_price": bCurrency(h.Settings.DownloadBandwidthPrice),
+					}).
+					Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).
+		Error
+}
+
 // Hosts returns non-blocked hosts at given offset and limit.
 func (ss *SQLStore) Hosts(ctx context.Context, offset, limit int) ([]hostdb.Host, error) {
-	return ss.SearchHosts(ctx, api.HostFilterModeAllowed, "", nil, offset, limit)
+	return ss.SearchHosts(ctx, api.HostFilterModeAllowed, "", nil, false, 0, 0, types.ZeroCurrency, offset, limit)
 }
 
 func (ss *SQLStore) RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDowntime time.Duration) (removed uint64, err error) {
@@ -553,6 +976,49 @@ func (ss *SQLStore) RemoveOfflineHosts(ctx context.Context, minRecentFailures ui
 		return 0, err
 	}
 
+	return ss.removeHosts(ctx, hosts)
+}
+
+// RemoveHost removes the host with given key from the database, its contracts
+// are archived and its announcements are pruned.
+func (ss *SQLStore) RemoveHost(ctx context.Context, hk types.PublicKey) error {
+	var h dbHost
+	if err := ss.db.
+		WithContext(ctx).
+		Where(&dbHost{PublicKey: publicKey(hk)}).
+		Take(&h).
+		Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return api.ErrHostNotFound
+	} else if err != nil {
+		return err
+	}
+
+	_, err := ss.removeHosts(ctx, []dbHost{h})
+	return err
+}
+
+// PruneHosts removes all hosts that neither announced nor were scanned since
+// the given cutoff and returns the number of removed hosts.
+func (ss *SQLStore) PruneHosts(ctx context.Context, notSeenSince time.Time) (uint64, error) {
+	// fetch the ids of all hosts that were neither scanned nor seen since the
+	// cutoff, the last seen timestamp covers the host's last announcement
+	var hosts []dbHost
+	if err := ss.db.
+		WithContext(ctx).
+		Model(&dbHost{}).
+		Select("id").
+		Where("last_scan < ? AND last_seen < ?", notSeenSince.UnixNano(), notSeenSince.UnixNano()).
+		Find(&hosts).
+		Error; err != nil {
+		return 0, err
+	}
+	return ss.removeHosts(ctx, hosts)
+}
+
+// removeHosts removes the given hosts one by one, archiving their contracts,
+// and prunes the announcements of the removed hosts afterwards since
+// announcements aren't related to hosts and thus don't cascade.
+func (ss *SQLStore) removeHosts(ctx context.Context, hosts []dbHost) (removed uint64, err error) {
 	// return early
 	if len(hosts) == 0 {
 		return 0, nil
@@ -590,6 +1056,15 @@ func (ss *SQLStore) RemoveOfflineHosts(ctx context.Context, minRecentFailures ui
 		}
 	}
 
+	// prune the announcements of the removed hosts
+	if removed > 0 {
+		if pruned, err := ss.PruneHostAnnouncements(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to prune announcements: %w", err))
+		} else if pruned > 0 {
+			ss.logger.Debugf("pruned %d announcements of removed hosts", pruned)
+		}
+	}
+
 	if len(errs) > 0 {
 		var msgs []string
 		for _, err := range errs {
@@ -600,6 +1075,18 @@ func (ss *SQLStore) RemoveOfflineHosts(ctx context.Context, minRecentFailures ui
 	return
 }
 
+// PruneHostAnnouncements removes all announcements of hosts that no longer
+// exist, announcements aren't related to hosts so they aren't removed when a
+// host is deleted. It returns the number of announcements that were pruned.
+func (ss *SQLStore) PruneHostAnnouncements(ctx context.Context) (pruned int64, err error) {
+	err = ss.retryTransaction(func(tx *gorm.DB) error {
+		res := tx.Exec("DELETE FROM host_announcements WHERE NOT EXISTS (SELECT 1 FROM hosts WHERE hosts.public_key = host_announcements.host_key)")
+		pruned = res.RowsAffected
+		return res.Error
+	})
+	return
+}
+
 func (ss *SQLStore) UpdateHostAllowlistEntries(ctx context.Context, add, remove []types.PublicKey, clear bool) (err error) {
 	// nothing to do
 	if len(add)+len(remove) == 0 && !clear {
@@ -663,6 +1150,15 @@ func (ss *SQLStore) UpdateHostBlocklistEntries(ctx context.Context, add, remove
 			if err := tx.Create(&toInsert).Error; err != nil {
 				return err
 			}
+			// entries that already existed might be temporary, adding them
+			// again turns them into permanent entries
+			if err := tx.
+				Model(&dbBlocklistEntry{}).
+				Where("entry IN ? AND expires_at IS NOT NULL", add).
+				Update("expires_at", nil).
+				Error; err != nil {
+				return err
+			}
 		}
 		if len(remove) > 0 {
 			if err := tx.Delete(&dbBlocklistEntry{}, "entry IN ?", remove).Error; err != nil {
@@ -689,11 +1185,97 @@ func (ss *SQLStore) HostAllowlist(ctx context.Context) (allowlist []types.Public
 func (ss *SQLStore) HostBlocklist(ctx context.Context) (blocklist []string, err error) {
 	err = ss.db.
 		Model(&dbBlocklistEntry{}).
+		Where("expires_at IS NULL OR expires_at > ?", ss.clock.Now().UTC()).
 		Pluck("entry", &blocklist).
 		Error
 	return
 }
 
+// HostBlocklistEntries returns the entries of the blocklist that haven't
+// expired yet, alongside their expiry and the time remaining until they lapse.
+func (ss *SQLStore) HostBlocklistEntries(ctx context.Context) ([]api.HostBlocklistEntry, error) {
+	now := ss.clock.Now().UTC()
+
+	var entries []dbBlocklistEntry
+	if err := ss.db.
+		Model(&dbBlocklistEntry{}).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("id ASC").
+		Find(&entries).
+		Error; err != nil {
+		return nil, err
+	}
+
+	blocklist := make([]api.HostBlocklistEntry, len(entries))
+	for i, e := range entries {
+		blocklist[i] = api.HostBlocklistEntry{Entry: e.Entry}
+		if e.ExpiresAt.Valid {
+			blocklist[i].ExpiresAt = api.TimeRFC3339(e.ExpiresAt.Time)
+			blocklist[i].Remaining = api.DurationMS(e.ExpiresAt.Time.Sub(now))
+		}
+	}
+	return blocklist, nil
+}
+
+// AddHostBlocklistEntriesWithExpiry adds the given entries to the blocklist,
+// they lapse at the given time. Existing entries are only extended, a
+// permanent entry is never turned into a temporary one.
+func (ss *SQLStore) AddHostBlocklistEntriesWithExpiry(ctx context.Context, entries []string, expiresAt time.Time) (err error) {
+	// nothing to do
+	if len(entries) == 0 {
+		return nil
+	}
+	defer ss.updateHasBlocklist(&err)
+
+	now := ss.clock.Now()
+	expiry := sql.NullTime{Time: expiresAt.UTC(), Valid: true}
+	return ss.retryTransaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			var existing dbBlocklistEntry
+			err := tx.
+				Where("entry = ?", entry).
+				Take(&existing).
+				Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Create(&dbBlocklistEntry{Entry: entry, ExpiresAt: expiry}).Error; err != nil {
+					return err
+				}
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			// extend the entry if it's temporary and lapses earlier
+			if !existing.ExpiresAt.Valid || (!existing.expired(now) && existing.ExpiresAt.Time.After(expiresAt)) {
+				continue
+			}
+			if err := tx.
+				Model(&dbBlocklistEntry{}).
+				Where("id", existing.ID).
+				Update("expires_at", expiry).
+				Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PruneExpiredHostBlocklistEntries removes all blocklist entries that have
+// expired and returns the number of removed entries.
+func (ss *SQLStore) PruneExpiredHostBlocklistEntries(ctx context.Context) (pruned uint64, err error) {
+	defer ss.updateHasBlocklist(&err)
+
+	err = ss.retryTransaction(func(tx *gorm.DB) error {
+		res := tx.
+			Where("expires_at IS NOT NULL AND expires_at <= ?", ss.clock.Now().UTC()).
+			Delete(&dbBlocklistEntry{})
+		pruned = uint64(res.RowsAffected)
+		return res.Error
+	})
+	return
+}
+
 func (ss *SQLStore) RecordHostScans(ctx context.Context, scans []hostdb.HostScan) error {
 	if len(scans) == 0 {
 		return nil // nothing to do
@@ -732,6 +1314,7 @@ func (ss *SQLStore) RecordHostScans(ctx context.Context, scans []hostdb.HostScan
 
 	// Write the interactions and update to the hosts atomically within a single
 	// transaction.
+	now := ss.clock.Now()
 	return ss.retryTransaction(func(tx *gorm.DB) error {
 		// Handle scans
 		for _, scan := range scans {
@@ -739,6 +1322,9 @@ func (ss *SQLStore) RecordHostScans(ctx context.Context, scans []hostdb.HostScan
 			if !exists {
 				continue // host doesn't exist
 			}
+			if scan.Timestamp.IsZero() {
+				scan.Timestamp = now
+			}
 			lastScan := time.Unix(0, host.LastScan)
 
 			if scan.Success {
@@ -747,6 +1333,9 @@ func (ss *SQLStore) RecordHostScans(ctx context.Context, scans []hostdb.HostScan
 				if host.LastScan > 0 && lastScan.Before(scan.Timestamp) {
 					host.Uptime += scan.Timestamp.Sub(lastScan)
 				}
+				if scan.Timestamp.UnixNano() > host.LastSeen {
+					host.LastSeen = scan.Timestamp.UnixNano()
+				}
 				host.RecentDowntime = 0
 				host.RecentScanFailures = 0
 
@@ -754,16 +1343,30 @@ func (ss *SQLStore) RecordHostScans(ctx context.Context, scans []hostdb.HostScan
 				// received through the host announcement
 				scan.Settings.NetAddress = host.NetAddress
 				host.Settings = convertHostSettings(scan.Settings)
+				host.AcceptingContracts = scan.Settings.AcceptingContracts
+				host.MaxDuration = scan.Settings.MaxDuration
+				host.StoragePrice = bCurrency(scan.Settings.StoragePrice)
+				host.Collateral = bCurrency(scan.Settings.Collateral)
+				host.HostContractPrice = bCurrency(scan.Settings.ContractPrice)
+				host.UploadBandwidthPrice = bCurrency(scan.Settings.UploadBandwidthPrice)
+				host.DownloadBandwidthPrice = bCurrency(scan.Settings.DownloadBandwidthPrice)
+				host.RemainingStorage = scan.Settings.RemainingStorage
+				host.Version = scan.Settings.Version
+				host.VersionSort = versionSortKey(scan.Settings.Version)
+				if scan.Duration > 0 {
+					(*hostdb.RTTHistogram)(&host.RTTHistogram).Add(scan.Duration)
+					host.LastScanLatency = scan.Duration
+				}
 
 				// scans can only update the price table if the current
 				// pricetable is expired anyway, ensuring scans never
 				// overwrite a valid price table since the price table from
 				// scans are not paid for and thus not useful for anything
 				// aside from gouging checks
-				if time.Now().After(host.PriceTableExpiry.Time) {
+				if now.After(host.PriceTableExpiry.Time) {
 					host.PriceTable = convertHostPriceTable(scan.PriceTable)
 					host.PriceTableExpiry = sql.NullTime{
-						Time:  time.Now(),
+						Time:  now,
 						Valid: true,
 					}
 				}
@@ -777,10 +1380,12 @@ func (ss *SQLStore) RecordHostScans(ctx context.Context, scans []hostdb.HostScan
 				}
 			}
 
+			host.SuccessRatio = successRatio(host.SuccessfulInteractions, host.FailedInteractions)
 			host.TotalScans++
 			host.Scanned = host.Scanned || scan.Success
 			host.SecondToLastScanSuccess = host.LastScanSuccess
 			host.LastScanSuccess = scan.Success
+			host.LastScanResolutionFailed = !scan.Success && scan.ResolutionFailed
 			host.LastScan = scan.Timestamp.UnixNano()
 
 			// Save to map again.
@@ -801,11 +1406,133 @@ func (ss *SQLStore) RecordHostScans(ctx context.Context, scans []hostdb.HostScan
 					"downtime":                    h.Downtime,
 					"uptime":                      h.Uptime,
 					"last_scan":                   h.LastScan,
+					"last_scan_latency":           h.LastScanLatency,
+					"last_scan_resolution_failed": h.LastScanResolutionFailed,
+					"last_seen":                   h.LastSeen,
 					"settings":                    h.Settings,
+					"accepting_contracts":         h.AcceptingContracts,
+					"max_duration":                h.MaxDuration,
+					"storage_price":               h.StoragePrice,
+					"collateral":                  h.Collateral,
+					"host_contract_price":         h.HostContractPrice,
+					"upload_bandwidth_price":      h.UploadBandwidthPrice,
+					"download_bandwidth_price":    h.DownloadBandwidthPrice,
+					"remaining_storage":           h.RemainingStorage,
+					"version":                     h.Version,
+					"version_sort":                h.VersionSort,
+					"rtt_histogram":               h.RTTHistogram,
 					"price_table":                 h.PriceTable,
 					"price_table_expiry":          h.PriceTableExpiry,
 					"successful_interactions":     h.SuccessfulInteractions,
 					"failed_interactions":         h.FailedInteractions,
+					"success_ratio":               h.SuccessRatio,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RecordHostScores updates the score of the given hosts in a single
+// transaction, scores of unknown hosts are ignored. The scores are updated
+// using a single statement per batch of hosts.
+func (ss *SQLStore) RecordHostScores(ctx context.Context, scores map[types.PublicKey]float64) error {
+	if len(scores) == 0 {
+		return nil // nothing to do
+	}
+
+	hks := make([]publicKey, 0, len(scores))
+	for hk := range scores {
+		hks = append(hks, publicKey(hk))
+	}
+
+	return ss.retryTransaction(func(tx *gorm.DB) error {
+		for i := 0; i < len(hks); i += hostRetrievalBatchSize {
+			end := i + hostRetrievalBatchSize
+			if end > len(hks) {
+				end = len(hks)
+			}
+			batch := hks[i:end]
+
+			// build a CASE expression that maps every host to its score
+			var sb strings.Builder
+			args := make([]interface{}, 0, 2*len(batch)+1)
+			sb.WriteString("UPDATE hosts SET score = CASE public_key")
+			for _, hk := range batch {
+				sb.WriteString(" WHEN ? THEN ?")
+				args = append(args, hk, scores[types.PublicKey(hk)])
+			}
+			sb.WriteString(" END WHERE public_key IN ?")
+			args = append(args, batch)
+
+			if err := tx.Exec(sb.String(), args...).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ss *SQLStore) RecordHostInteractions(ctx context.Context, interactions []hostdb.Interaction) error {
+	if len(interactions) == 0 {
+		return nil // nothing to do
+	}
+
+	// Get keys from input.
+	keyMap := make(map[publicKey]struct{})
+	var hks []publicKey
+	for _, interaction := range interactions {
+		if _, exists := keyMap[publicKey(interaction.HostKey)]; !exists {
+			hks = append(hks, publicKey(interaction.HostKey))
+			keyMap[publicKey(interaction.HostKey)] = struct{}{}
+		}
+	}
+
+	// Fetch hosts for which to add interactions outside of the transaction.
+	var hosts []dbHost
+	for i := 0; i < len(hks); i += maxSQLVars {
+		end := i + maxSQLVars
+		if end > len(hks) {
+			end = len(hks)
+		}
+		var batchHosts []dbHost
+		if err := ss.db.Where("public_key IN (?)", hks[i:end]).
+			Find(&batchHosts).Error; err != nil {
+			return err
+		}
+		hosts = append(hosts, batchHosts...)
+	}
+	hostMap := make(map[publicKey]dbHost)
+	for _, h := range hosts {
+		hostMap[h.PublicKey] = h
+	}
+
+	// Update the hosts atomically within a single transaction.
+	return ss.retryTransaction(func(tx *gorm.DB) error {
+		for _, interaction := range interactions {
+			host, exists := hostMap[publicKey(interaction.HostKey)]
+			if !exists {
+				continue // host doesn't exist
+			}
+			if interaction.Success {
+				host.SuccessfulInteractions++
+			} else {
+				host.FailedInteractions++
+			}
+			host.SuccessRatio = successRatio(host.SuccessfulInteractions, host.FailedInteractions)
+			hostMap[host.PublicKey] = host
+		}
+
+		// Persist.
+		for _, h := range hostMap {
+			err := tx.Model(&dbHost{}).
+				Where("public_key", h.PublicKey).
+				Updates(map[string]interface{}{
+					"successful_interactions": h.SuccessfulInteractions,
+					"failed_interactions":     h.FailedInteractions,
+					"success_ratio":           h.SuccessRatio,
 				}).Error
 			if err != nil {
 				return err
@@ -866,6 +1593,9 @@ func (ss *SQLStore) RecordPriceTables(ctx context.Context, priceTableUpdate []ho
 				host.SuccessfulInteractions++
 				host.RecentDowntime = 0
 				host.RecentScanFailures = 0
+				if ptu.Duration > 0 {
+					(*hostdb.RTTHistogram)(&host.RTTHistogram).Add(ptu.Duration)
+				}
 
 				// Update pricetable.
 				host.PriceTable = convertHostPriceTable(ptu.PriceTable.HostPriceTable)
@@ -877,6 +1607,7 @@ func (ss *SQLStore) RecordPriceTables(ctx context.Context, priceTableUpdate []ho
 				// Handle failed update.
 				host.FailedInteractions++
 			}
+			host.SuccessRatio = successRatio(host.SuccessfulInteractions, host.FailedInteractions)
 
 			// Save to map again.
 			hostMap[host.PublicKey] = host
@@ -893,6 +1624,8 @@ func (ss *SQLStore) RecordPriceTables(ctx context.Context, priceTableUpdate []ho
 					"price_table_expiry":      h.PriceTableExpiry,
 					"successful_interactions": h.SuccessfulInteractions,
 					"failed_interactions":     h.FailedInteractions,
+					"success_ratio":           h.SuccessRatio,
+					"rtt_histogram":           h.RTTHistogram,
 				}).Error
 			if err != nil {
 				return err
@@ -908,15 +1641,14 @@ func (ss *SQLStore) processConsensusChangeHostDB(cc modules.ConsensusChange) {
 		height--
 	}
 
-	var newAnnouncements []announcement
 	for _, sb := range cc.AppliedBlocks {
 		var b types.Block
 		convertToCore(sb, (*types.V1Block)(&b))
 
 		// Process announcements, but only if they are not too old.
-		if b.Timestamp.After(time.Now().Add(-ss.announcementMaxAge)) {
+		if b.Timestamp.After(ss.clock.Now().Add(-ss.announcementMaxAge)) {
 			hostdb.ForEachAnnouncement(types.Block(b), height, func(hostKey types.PublicKey, ha hostdb.Announcement) {
-				newAnnouncements = append(newAnnouncements, announcement{
+				ss.unappliedAnnouncements = append(ss.unappliedAnnouncements, announcement{
 					hostKey:      publicKey(hostKey),
 					announcement: ha,
 				})
@@ -924,9 +1656,21 @@ func (ss *SQLStore) processConsensusChangeHostDB(cc modules.ConsensusChange) {
 			})
 		}
 		height++
+		ss.unappliedAnnouncementBlocks++
 	}
+}
 
-	ss.unappliedAnnouncements = append(ss.unappliedAnnouncements, newAnnouncements...)
+// announcementBatchLimitReached returns true if the buffered announcements have
+// to be applied right away, which is the case if the hard limit is reached or,
+// while catching up, if the announcements span enough blocks.
+//
+// NOTE: updates are only applied in between consensus changes, applying them
+// mid-change would persist them alongside the previous change's ID which
+// causes them to be inserted again if the change is replayed.
+func (ss *SQLStore) announcementBatchLimitReached(synced bool) bool {
+	hardLimitReached := len(ss.unappliedAnnouncements) >= ss.announcementBatchHardLimit
+	catchUpBatchReached := !synced && len(ss.unappliedAnnouncements) > 0 && ss.unappliedAnnouncementBlocks >= ss.announcementCatchUpBatchSize
+	return hardLimitReached || catchUpBatchReached
 }
 
 // excludeBlocked can be used as a scope for a db transaction to exclude blocked
@@ -939,7 +1683,7 @@ func (ss *SQLStore) excludeBlocked(db *gorm.DB) *gorm.DB {
 		db = db.Where("EXISTS (SELECT 1 FROM host_allowlist_entry_hosts hbeh WHERE hbeh.db_host_id = hosts.id)")
 	}
 	if ss.hasBlocklist {
-		db = db.Where("NOT EXISTS (SELECT 1 FROM host_blocklist_entry_hosts hbeh WHERE hbeh.db_host_id = hosts.id)")
+		db = db.Where("NOT EXISTS (SELECT 1 FROM host_blocklist_entry_hosts hbeh INNER JOIN host_blocklist_entries hbe ON hbe.id = hbeh.db_blocklist_entry_id WHERE hbeh.db_host_id = hosts.id AND (hbe.expires_at IS NULL OR hbe.expires_at > ?))", ss.clock.Now().UTC())
 	}
 	return db
 }
@@ -954,7 +1698,7 @@ func (ss *SQLStore) excludeAllowed(db *gorm.DB) *gorm.DB {
 		db = db.Where("NOT EXISTS (SELECT 1 FROM host_allowlist_entry_hosts hbeh WHERE hbeh.db_host_id = hosts.id)")
 	}
 	if ss.hasBlocklist {
-		db = db.Where("EXISTS (SELECT 1 FROM host_blocklist_entry_hosts hbeh WHERE hbeh.db_host_id = hosts.id)")
+		db = db.Where("EXISTS (SELECT 1 FROM host_blocklist_entry_hosts hbeh INNER JOIN host_blocklist_entries hbe ON hbe.id = hbeh.db_blocklist_entry_id WHERE hbeh.db_host_id = hosts.id AND (hbe.expires_at IS NULL OR hbe.expires_at > ?))", ss.clock.Now().UTC())
 	}
 	if !ss.hasAllowlist && !ss.hasBlocklist {
 		// if neither an allowlist nor a blocklist exist, all hosts are allowed
@@ -971,8 +1715,14 @@ func (ss *SQLStore) isBlocked(h dbHost) (blocked bool) {
 	if ss.hasAllowlist && len(h.Allowlist) == 0 {
 		blocked = true
 	}
-	if ss.hasBlocklist && len(h.Blocklist) > 0 {
-		blocked = true
+	if ss.hasBlocklist {
+		now := ss.clock.Now()
+		for _, e := range h.Blocklist {
+			if !e.expired(now) {
+				blocked = true
+				break
+			}
+		}
 	}
 	return
 }
@@ -996,6 +1746,7 @@ func insertAnnouncements(tx *gorm.DB, as []announcement) error {
 		hosts = append(hosts, dbHost{
 			PublicKey:        a.hostKey,
 			LastAnnouncement: a.announcement.Timestamp.UTC(),
+			LastSeen:         a.announcement.Timestamp.UnixNano(),
 			NetAddress:       a.announcement.NetAddress,
 		})
 		announcements = append(announcements, dbAnnouncement{
@@ -1003,12 +1754,16 @@ func insertAnnouncements(tx *gorm.DB, as []announcement) error {
 			BlockHeight: a.announcement.Index.Height,
 			BlockID:     a.announcement.Index.ID.String(),
 			NetAddress:  a.announcement.NetAddress,
+			Timestamp: sql.NullTime{
+				Time:  a.announcement.Timestamp.UTC(),
+				Valid: !a.announcement.Timestamp.IsZero(),
+			},
 		})
 	}
-	if err := tx.Create(&announcements).Error; err != nil {
+	if err := tx.CreateInBatches(&announcements, announcementInsertionBatchSize).Error; err != nil {
 		return err
 	}
-	return tx.Create(&hosts).Error
+	return tx.CreateInBatches(&hosts, announcementInsertionBatchSize).Error
 }
 
 func applyRevisionUpdate(db *gorm.DB, fcid types.FileContractID, rev revisionUpdate) error {
@@ -1093,3 +1848,26 @@ func (s *SQLStore) ResetLostSectors(ctx context.Context, hk types.PublicKey) err
 			Error
 	})
 }
+
+// versionSortKey encodes the given version into an integer that sorts like the
+// version itself. The major, minor and patch versions take up 20 bits each, a
+// leading 'v' and any pre-release or build suffix are ignored and components
+// that can't be parsed count as 0.
+func versionSortKey(version string) uint64 {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.SplitN(version, ".", 3)
+
+	var key uint64
+	for i := 0; i < 3; i++ {
+		key <<= 20
+		if i < len(parts) {
+			if n, err := strconv.ParseUint(parts[i], 10, 20); err == nil {
+				key |= n
+			}
+		}
+	}
+	return key
+}
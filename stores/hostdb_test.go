@@ -3,6 +3,8 @@ package stores
 import (
 	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -12,6 +14,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"gitlab.com/NebulousLabs/encoding"
 	rhpv2 "go.sia.tech/core/rhp/v2"
+	rhpv3 "go.sia.tech/core/rhp/v3"
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/hostdb"
@@ -191,6 +194,21 @@ func TestSQLHosts(t *testing.T) {
 		t.Fatal("unexpected error", err)
 	}
 
+	// assert paging through the hosts returns every host exactly once and in
+	// the same order, also when hosts share the same last scan timestamp
+	for i, hk := range hks {
+		if hosts, err := ss.Hosts(ctx, i, 1); err != nil || len(hosts) != 1 {
+			t.Fatal("unexpected", len(hosts), err)
+		} else if hosts[0].PublicKey != hk {
+			t.Fatal("unexpected host at offset", i, hosts[0].PublicKey)
+		}
+		if hostAddresses, err := ss.HostsForScanning(ctx, time.Now(), i, 1); err != nil || len(hostAddresses) != 1 {
+			t.Fatal("unexpected", len(hostAddresses), err)
+		} else if hostAddresses[0].PublicKey != hk {
+			t.Fatal("unexpected host at offset", i, hostAddresses[0].PublicKey)
+		}
+	}
+
 	// Add a scan for each host.
 	n := time.Now()
 	if err := ss.addTestScan(hk1, n.Add(-time.Minute), nil, rhpv2.HostSettings{}); err != nil {
@@ -240,6 +258,77 @@ func TestSQLHosts(t *testing.T) {
 	}
 }
 
+// TestHostsBatches asserts hosts are returned exactly once when fetching more
+// hosts than are retrieved per batch.
+func TestHostsBatches(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add more hosts than fit in two batches
+	n := 2*hostRetrievalBatchSize + hostRetrievalBatchSize/2
+	for i := 0; i < n; i++ {
+		hk := types.PublicKey(types.HashBytes([]byte(fmt.Sprint(i))))
+		ss.unappliedHostKeys[hk] = struct{}{}
+		ss.unappliedAnnouncements = append(ss.unappliedAnnouncements, announcement{
+			hostKey:      publicKey(hk),
+			announcement: newTestHostDBAnnouncement(fmt.Sprintf("host%d.com", i)),
+		})
+	}
+	if err := ss.applyUpdates(true); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert every host is returned exactly once, respecting offset and limit
+	ctx := context.Background()
+	for _, tc := range []struct {
+		offset, limit, expected int
+	}{
+		{0, -1, n},
+		{0, hostRetrievalBatchSize + 1, hostRetrievalBatchSize + 1},
+		{hostRetrievalBatchSize, -1, n - hostRetrievalBatchSize},
+		{2 * hostRetrievalBatchSize, hostRetrievalBatchSize, n - 2*hostRetrievalBatchSize},
+	} {
+		hosts, err := ss.Hosts(ctx, tc.offset, tc.limit)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(hosts) != tc.expected {
+			t.Fatalf("offset %d limit %d: expected %d hosts, got %d", tc.offset, tc.limit, tc.expected, len(hosts))
+		}
+		seen := make(map[types.PublicKey]struct{})
+		for _, h := range hosts {
+			if _, ok := seen[h.PublicKey]; ok {
+				t.Fatal("host returned twice", h.PublicKey)
+			}
+			seen[h.PublicKey] = struct{}{}
+		}
+	}
+
+	// assert the same holds for the hosts for scanning
+	for _, tc := range []struct {
+		offset, limit, expected int
+	}{
+		{0, -1, n},
+		{hostRetrievalBatchSize, hostRetrievalBatchSize + 1, hostRetrievalBatchSize + 1},
+	} {
+		hosts, err := ss.HostsForScanning(ctx, time.Now(), tc.offset, tc.limit)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(hosts) != tc.expected {
+			t.Fatalf("offset %d limit %d: expected %d hosts, got %d", tc.offset, tc.limit, tc.expected, len(hosts))
+		}
+		seen := make(map[types.PublicKey]struct{})
+		for _, h := range hosts {
+			if _, ok := seen[h.PublicKey]; ok {
+				t.Fatal("host returned twice", h.PublicKey)
+			}
+			seen[h.PublicKey] = struct{}{}
+		}
+	}
+}
+
 // TestSearchHosts is a unit test for SearchHosts.
 func TestSearchHosts(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
@@ -257,23 +346,176 @@ func TestSearchHosts(t *testing.T) {
 	hk1, hk2, hk3 := hks[0], hks[1], hks[2]
 
 	// Search by address.
-	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "1", nil, 0, -1); err != nil || len(hosts) != 1 {
+	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "1", nil, false, 0, 0, types.ZeroCurrency, 0, -1); err != nil || len(hosts) != 1 {
 		t.Fatal("unexpected", len(hosts), err)
 	}
 	// Filter by key.
-	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", []types.PublicKey{hk1, hk2}, 0, -1); err != nil || len(hosts) != 2 {
+	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", []types.PublicKey{hk1, hk2}, false, 0, 0, types.ZeroCurrency, 0, -1); err != nil || len(hosts) != 2 {
 		t.Fatal("unexpected", len(hosts), err)
 	}
 	// Filter by address and key.
-	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "1", []types.PublicKey{hk1, hk2}, 0, -1); err != nil || len(hosts) != 1 {
+	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "1", []types.PublicKey{hk1, hk2}, false, 0, 0, types.ZeroCurrency, 0, -1); err != nil || len(hosts) != 1 {
 		t.Fatal("unexpected", len(hosts), err)
 	}
 	// Filter by key and limit results
-	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "3", []types.PublicKey{hk3}, 0, -1); err != nil || len(hosts) != 1 {
+	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "3", []types.PublicKey{hk3}, false, 0, 0, types.ZeroCurrency, 0, -1); err != nil || len(hosts) != 1 {
+		t.Fatal("unexpected", len(hosts), err)
+	}
+
+	// Filter by accepting contracts, none of the hosts were scanned yet.
+	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", nil, true, 0, 0, types.ZeroCurrency, 0, -1); err != nil || len(hosts) != 0 {
+		t.Fatal("unexpected", len(hosts), err)
+	}
+
+	// Scan the hosts, only the first two are accepting contracts.
+	now := time.Now()
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{
+		newTestScan(hk1, now, rhpv2.HostSettings{AcceptingContracts: true}, true),
+		newTestScan(hk2, now, rhpv2.HostSettings{AcceptingContracts: true}, true),
+		newTestScan(hk3, now, rhpv2.HostSettings{AcceptingContracts: false}, true),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", nil, true, 0, 0, types.ZeroCurrency, 0, -1); err != nil || len(hosts) != 2 {
+		t.Fatal("unexpected", len(hosts), err)
+	}
+
+	// Failed scans don't change the column.
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{
+		newTestScan(hk1, now.Add(time.Minute), rhpv2.HostSettings{}, false),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", nil, true, 0, 0, types.ZeroCurrency, 0, -1); err != nil || len(hosts) != 2 {
+		t.Fatal("unexpected", len(hosts), err)
+	}
+
+	// A host that stops accepting contracts is filtered out.
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{
+		newTestScan(hk2, now.Add(time.Minute), rhpv2.HostSettings{AcceptingContracts: false}, true),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", nil, true, 0, 0, types.ZeroCurrency, 0, -1); err != nil || len(hosts) != 1 || hosts[0].PublicKey != hk1 {
 		t.Fatal("unexpected", len(hosts), err)
 	}
 }
 
+// TestNewHosts is a test for fetching hosts that were first announced after a
+// certain block height.
+func TestNewHosts(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	// announce 3 hosts at different heights, hk1 re-announces at a later height
+	hk1, hk2, hk3 := types.PublicKey{1}, types.PublicKey{2}, types.PublicKey{3}
+	for _, a := range []struct {
+		hk     types.PublicKey
+		height uint64
+	}{
+		{hk1, 5},
+		{hk3, 20},
+		{hk2, 10},
+		{hk1, 30},
+	} {
+		ann := newTestHostDBAnnouncement("address")
+		ann.Index.Height = a.height
+		if err := ss.insertTestAnnouncement(a.hk, ann); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert all hosts are returned ordered by their first announcement
+	hosts, err := ss.NewHosts(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != 3 {
+		t.Fatal("unexpected", len(hosts))
+	} else if hosts[0].PublicKey != hk1 || hosts[1].PublicKey != hk2 || hosts[2].PublicKey != hk3 {
+		t.Fatal("unexpected order")
+	}
+
+	// assert the re-announcement of hk1 doesn't make it a new host
+	hosts, err = ss.NewHosts(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != 2 {
+		t.Fatal("unexpected", len(hosts))
+	} else if hosts[0].PublicKey != hk2 || hosts[1].PublicKey != hk3 {
+		t.Fatal("unexpected order")
+	}
+
+	// assert no hosts are returned past the last announcement
+	hosts, err = ss.NewHosts(ctx, 21)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != 0 {
+		t.Fatal("unexpected", len(hosts))
+	}
+}
+
+func TestHostAnnouncements(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	// announce a host at different heights and net addresses, and another host
+	// in between
+	hk1, hk2 := types.PublicKey{1}, types.PublicKey{2}
+	start := time.Now().UTC().Round(time.Second)
+	for i, a := range []struct {
+		hk     types.PublicKey
+		height uint64
+		addr   string
+	}{
+		{hk1, 5, "foo.com:1000"},
+		{hk1, 30, "bar.com:1000"},
+		{hk2, 20, "baz.com:1000"},
+		{hk1, 10, "foo.com:2000"},
+	} {
+		ann := newTestHostDBAnnouncement(a.addr)
+		ann.Index = types.ChainIndex{Height: a.height, ID: types.BlockID{byte(a.height)}}
+		ann.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		if err := ss.insertTestAnnouncement(a.hk, ann); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert the announcements are returned from most to least recent
+	announcements, err := ss.HostAnnouncements(ctx, hk1, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(announcements) != 3 {
+		t.Fatal("unexpected number of announcements", len(announcements))
+	}
+	for i, expected := range []hostdb.Announcement{
+		{Index: types.ChainIndex{Height: 30, ID: types.BlockID{30}}, Timestamp: start.Add(time.Minute), NetAddress: "bar.com:1000"},
+		{Index: types.ChainIndex{Height: 10, ID: types.BlockID{10}}, Timestamp: start.Add(3 * time.Minute), NetAddress: "foo.com:2000"},
+		{Index: types.ChainIndex{Height: 5, ID: types.BlockID{5}}, Timestamp: start, NetAddress: "foo.com:1000"},
+	} {
+		if a := announcements[i]; a.Index != expected.Index || a.NetAddress != expected.NetAddress || !a.Timestamp.Equal(expected.Timestamp) {
+			t.Fatal("unexpected announcement", i, cmp.Diff(a, expected))
+		}
+	}
+
+	// assert the limit is applied
+	announcements, err = ss.HostAnnouncements(ctx, hk1, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(announcements) != 1 || announcements[0].NetAddress != "bar.com:1000" {
+		t.Fatal("unexpected announcements", announcements)
+	}
+
+	// assert unknown hosts have no announcements
+	announcements, err = ss.HostAnnouncements(ctx, types.PublicKey{3}, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(announcements) != 0 {
+		t.Fatal("unexpected announcements", announcements)
+	}
+}
+
 // TestRecordScan is a test for recording scans.
 func TestRecordScan(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
@@ -310,10 +552,17 @@ func TestRecordScan(t *testing.T) {
 
 	// Record a scan.
 	firstScanTime := time.Now().UTC()
-	settings := rhpv2.HostSettings{NetAddress: "host.com"}
+	settings := rhpv2.HostSettings{NetAddress: "host.com", MaxDuration: 144}
 	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk, firstScanTime, settings, true)}); err != nil {
 		t.Fatal(err)
 	}
+
+	// The max duration should have been updated.
+	if h, err := hostByPubKey(ss.db, hk); err != nil {
+		t.Fatal(err)
+	} else if h.MaxDuration != settings.MaxDuration {
+		t.Fatal("unexpected max duration", h.MaxDuration)
+	}
 	host, err = ss.Host(ctx, hk)
 	if err != nil {
 		t.Fatal(err)
@@ -335,65 +584,950 @@ func TestRecordScan(t *testing.T) {
 		Downtime:                downtime,
 		SuccessfulInteractions:  1,
 		FailedInteractions:      0,
+		SuccessRatio:            1,
 	}); host.Interactions != expected {
 		t.Fatal("mismatch", cmp.Diff(host.Interactions, expected))
 	}
-	if !reflect.DeepEqual(host.Settings, settings) {
-		t.Fatal("mismatch")
+	if !reflect.DeepEqual(host.Settings, settings) {
+		t.Fatal("mismatch")
+	}
+
+	// Record another scan 1 hour after the previous one.
+	secondScanTime := firstScanTime.Add(time.Hour)
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk, secondScanTime, settings, true)}); err != nil {
+		t.Fatal(err)
+	}
+	host, err = ss.Host(ctx, hk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.Interactions.LastScan.UnixNano() != secondScanTime.UnixNano() {
+		t.Fatal("wrong time")
+	}
+	host.Interactions.LastScan = time.Time{}
+	uptime += secondScanTime.Sub(firstScanTime)
+	if host.Interactions != (hostdb.Interactions{
+		TotalScans:              2,
+		LastScan:                time.Time{},
+		LastScanSuccess:         true,
+		SecondToLastScanSuccess: true,
+		Uptime:                  uptime,
+		Downtime:                downtime,
+		UptimeRatio:             1,
+		SuccessfulInteractions:  2,
+		FailedInteractions:      0,
+		SuccessRatio:            1,
+	}) {
+		t.Fatal("mismatch")
+	}
+
+	// Record another scan 2 hours after the second one. This time it fails.
+	thirdScanTime := secondScanTime.Add(2 * time.Hour)
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk, thirdScanTime, settings, false)}); err != nil {
+		t.Fatal(err)
+	}
+	host, err = ss.Host(ctx, hk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.Interactions.LastScan.UnixNano() != thirdScanTime.UnixNano() {
+		t.Fatal("wrong time")
+	}
+	host.Interactions.LastScan = time.Time{}
+	downtime += thirdScanTime.Sub(secondScanTime)
+	if host.Interactions != (hostdb.Interactions{
+		TotalScans:              3,
+		LastScan:                time.Time{},
+		LastScanSuccess:         false,
+		SecondToLastScanSuccess: true,
+		Uptime:                  uptime,
+		Downtime:                downtime,
+		UptimeRatio:             1.0 / 3,
+		SuccessfulInteractions:  2,
+		FailedInteractions:      1,
+		SuccessRatio:            2.0 / 3,
+	}) {
+		t.Fatal("mismatch")
+	}
+}
+
+func TestRecordScanBatch(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add hosts
+	var hks []types.PublicKey
+	for i := 0; i < 500; i++ {
+		hk := types.GeneratePrivateKey().PublicKey()
+		if err := ss.addCustomTestHost(hk, fmt.Sprintf("host%d.com", i)); err != nil {
+			t.Fatal(err)
+		}
+		hks = append(hks, hk)
+	}
+
+	// record a scan for every host and a second one for every other host
+	// within a single batch
+	var scans []hostdb.HostScan
+	for i, hk := range hks {
+		scans = append(scans, newTestScan(hk, time.Now(), rhpv2.HostSettings{}, true))
+		if i%2 == 0 {
+			scans = append(scans, newTestScan(hk, time.Now(), rhpv2.HostSettings{}, false))
+		}
+	}
+
+	// fail every host update after the first half of the batch was written
+	errInjected := errors.New("injected")
+	var updates int
+	if err := ss.db.Callback().Update().Before("gorm:update").Register("test:fail_batch", func(tx *gorm.DB) {
+		if tx.Statement.Table != (dbHost{}).TableName() {
+			return
+		} else if updates++; updates > len(hks)/2 {
+			tx.AddError(errInjected)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the batch fails and none of the hosts were updated, proving
+	// all updates are applied within a single transaction
+	if err := ss.RecordHostScans(context.Background(), scans); !errors.Is(err, errInjected) {
+		t.Fatal("expected injected error, got", err)
+	} else if updates <= len(hks)/2 {
+		t.Fatal("expected part of the batch to be written before failing", updates)
+	}
+	hosts, err := ss.hosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range hosts {
+		if h.TotalScans != 0 {
+			t.Fatal("expected no host to be updated")
+		}
+	}
+
+	// remove the failure and record the batch
+	if err := ss.db.Callback().Update().Remove("test:fail_batch"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RecordHostScans(context.Background(), scans); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert all hosts were updated
+	hosts, err = ss.hosts()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != len(hks) {
+		t.Fatalf("expected %d hosts, got %d", len(hks), len(hosts))
+	}
+	for _, h := range hosts {
+		if h.TotalScans == 1 && !h.LastScanSuccess {
+			t.Fatal("expected last scan to succeed")
+		} else if h.TotalScans == 2 && (h.LastScanSuccess || h.FailedInteractions != 1) {
+			t.Fatal("expected last scan to fail")
+		} else if h.TotalScans != 1 && h.TotalScans != 2 {
+			t.Fatal("unexpected number of scans", h.TotalScans)
+		} else if h.SuccessfulInteractions != 1 {
+			t.Fatal("unexpected number of successful interactions", h.SuccessfulInteractions)
+		}
+	}
+}
+
+func BenchmarkRecordHostScans(b *testing.B) {
+	ss := newTestSQLStore(b, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add hosts
+	var hks []types.PublicKey
+	for i := 0; i < 500; i++ {
+		hk := types.GeneratePrivateKey().PublicKey()
+		if err := ss.addCustomTestHost(hk, fmt.Sprintf("host%d.com", i)); err != nil {
+			b.Fatal(err)
+		}
+		hks = append(hks, hk)
+	}
+
+	// prepare a batch with a scan for every host
+	scans := make([]hostdb.HostScan, len(hks))
+	for i, hk := range hks {
+		scans[i] = newTestScan(hk, time.Now(), rhpv2.HostSettings{}, i%2 == 0)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ss.RecordHostScans(context.Background(), scans); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUptimeRatio(t *testing.T) {
+	tests := []struct {
+		uptime   time.Duration
+		downtime time.Duration
+		expected float64
+	}{
+		{0, 0, 0},
+		{time.Hour, 0, 1},
+		{0, time.Hour, 0},
+		{3 * time.Hour, time.Hour, 0.75},
+	}
+	for _, test := range tests {
+		if ratio := uptimeRatio(test.uptime, test.downtime); ratio != test.expected {
+			t.Errorf("uptimeRatio(%v, %v) = %v, expected %v", test.uptime, test.downtime, ratio, test.expected)
+		}
+	}
+}
+
+func TestRecordScanClock(t *testing.T) {
+	cfg := defaultTestSQLStoreConfig
+	clock := newTestClock(time.Unix(1700000000, 0))
+	cfg.clock = clock
+	ss := newTestSQLStore(t, cfg)
+	defer ss.Close()
+
+	// Add a host.
+	hk := types.GeneratePrivateKey().PublicKey()
+	err := ss.addCustomTestHost(hk, "host.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Record a successful scan without a timestamp, it should be recorded at
+	// the current time of the clock.
+	ctx := context.Background()
+	start := clock.Now()
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk, time.Time{}, rhpv2.HostSettings{}, true)}); err != nil {
+		t.Fatal(err)
+	}
+	host, err := ss.Host(ctx, hk)
+	if err != nil {
+		t.Fatal(err)
+	} else if !host.Interactions.LastScan.Equal(start) {
+		t.Fatalf("unexpected last scan %v, expected %v", host.Interactions.LastScan, start)
+	} else if !host.PriceTable.Expiry.Equal(start) {
+		t.Fatalf("unexpected price table expiry %v, expected %v", host.PriceTable.Expiry, start)
+	}
+
+	// Advance the clock and record a successful scan, the host should have
+	// been up for exactly that duration.
+	clock.Advance(time.Hour)
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk, time.Time{}, rhpv2.HostSettings{}, true)}); err != nil {
+		t.Fatal(err)
+	}
+	host, err = ss.Host(ctx, hk)
+	if err != nil {
+		t.Fatal(err)
+	} else if host.Interactions.Uptime != time.Hour {
+		t.Fatalf("unexpected uptime %v", host.Interactions.Uptime)
+	} else if host.Interactions.Downtime != 0 {
+		t.Fatalf("unexpected downtime %v", host.Interactions.Downtime)
+	}
+
+	// Advance the clock and record a failed scan.
+	clock.Advance(30 * time.Minute)
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk, time.Time{}, rhpv2.HostSettings{}, false)}); err != nil {
+		t.Fatal(err)
+	}
+	host, err = ss.Host(ctx, hk)
+	if err != nil {
+		t.Fatal(err)
+	} else if host.Interactions.Uptime != time.Hour {
+		t.Fatalf("unexpected uptime %v", host.Interactions.Uptime)
+	} else if host.Interactions.Downtime != 30*time.Minute {
+		t.Fatalf("unexpected downtime %v", host.Interactions.Downtime)
+	} else if !host.Interactions.LastScan.Equal(clock.Now()) {
+		t.Fatalf("unexpected last scan %v, expected %v", host.Interactions.LastScan, clock.Now())
+	}
+}
+
+func TestRecordRTT(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a host
+	hk := types.GeneratePrivateKey().PublicKey()
+	if err := ss.addCustomTestHost(hk, "host.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the host has no samples
+	ctx := context.Background()
+	host, err := ss.Host(ctx, hk)
+	if err != nil {
+		t.Fatal(err)
+	} else if host.Interactions.RTT.Count() != 0 {
+		t.Fatal("unexpected samples", host.Interactions.RTT)
+	}
+
+	// record 98 fast scans, one slow scan and a failed scan
+	var scans []hostdb.HostScan
+	for i := 0; i < 98; i++ {
+		scan := newTestScan(hk, time.Now(), rhpv2.HostSettings{}, true)
+		scan.Duration = 20 * time.Millisecond
+		scans = append(scans, scan)
+	}
+	slow := newTestScan(hk, time.Now(), rhpv2.HostSettings{}, true)
+	slow.Duration = 3 * time.Second
+	failed := newTestScan(hk, time.Now(), rhpv2.HostSettings{}, false)
+	failed.Duration = time.Minute
+	if err := ss.RecordHostScans(ctx, append(scans, slow, failed)); err != nil {
+		t.Fatal(err)
+	}
+
+	// record a price table update
+	if err := ss.RecordPriceTables(ctx, []hostdb.PriceTableUpdate{{
+		HostKey:   hk,
+		Success:   true,
+		Timestamp: time.Now(),
+		Duration:  200 * time.Millisecond,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the failed scan was ignored
+	host, err = ss.Host(ctx, hk)
+	if err != nil {
+		t.Fatal(err)
+	} else if n := host.Interactions.RTT.Count(); n != 100 {
+		t.Fatal("unexpected number of samples", n)
+	}
+
+	// assert the percentiles reveal the tail latency
+	expected := hostdb.RTTPercentiles{
+		P50: 25 * time.Millisecond,
+		P95: 25 * time.Millisecond,
+		P99: 250 * time.Millisecond,
+	}
+	if p := host.Interactions.RTT.Percentiles(); p != expected {
+		t.Fatal("unexpected percentiles", cmp.Diff(p, expected))
+	} else if p := host.Interactions.RTT.Percentile(1); p != 5*time.Second {
+		t.Fatal("unexpected percentile", p)
+	}
+}
+
+func TestRecordScanLatency(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add two hosts
+	hk1 := types.GeneratePrivateKey().PublicKey()
+	hk2 := types.GeneratePrivateKey().PublicKey()
+	if err := ss.addCustomTestHost(hk1, "host1.com"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.addCustomTestHost(hk2, "host2.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// record a successful scan for both hosts
+	ctx := context.Background()
+	scan1 := newTestScan(hk1, time.Now(), rhpv2.HostSettings{}, true)
+	scan1.Duration = 300 * time.Millisecond
+	scan2 := newTestScan(hk2, time.Now(), rhpv2.HostSettings{}, true)
+	scan2.Duration = 40 * time.Millisecond
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{scan1, scan2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the latency survived the round trip
+	host, err := ss.Host(ctx, hk1)
+	if err != nil {
+		t.Fatal(err)
+	} else if host.Interactions.LastScanLatency != scan1.Duration {
+		t.Fatal("unexpected latency", host.Interactions.LastScanLatency)
+	} else if host.Interactions.LastScanResolutionFailed {
+		t.Fatal("unexpected resolution failure")
+	}
+
+	// assert hosts can be sorted by latency
+	hosts, _, err := ss.HostsSorted(ctx, api.HostSortByLastScanLatency, true, 0, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != 2 || hosts[0].PublicKey != hk2 || hosts[1].PublicKey != hk1 {
+		t.Fatal("unexpected order", hosts)
+	}
+
+	// record a failed scan that couldn't resolve the host, the latency of the
+	// last successful scan should be kept
+	failed := newTestScan(hk1, time.Now(), rhpv2.HostSettings{}, false)
+	failed.Duration = time.Minute
+	failed.ResolutionFailed = true
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{failed}); err != nil {
+		t.Fatal(err)
+	}
+	host, err = ss.Host(ctx, hk1)
+	if err != nil {
+		t.Fatal(err)
+	} else if host.Interactions.LastScanLatency != scan1.Duration {
+		t.Fatal("unexpected latency", host.Interactions.LastScanLatency)
+	} else if !host.Interactions.LastScanResolutionFailed {
+		t.Fatal("expected resolution failure")
+	}
+
+	// assert the flag is reset by a successful scan
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk1, time.Now(), rhpv2.HostSettings{}, true)}); err != nil {
+		t.Fatal(err)
+	}
+	host, err = ss.Host(ctx, hk1)
+	if err != nil {
+		t.Fatal(err)
+	} else if host.Interactions.LastScanResolutionFailed {
+		t.Fatal("unexpected resolution failure")
+	}
+}
+
+func TestRecordHostInteractions(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a host
+	hk := types.GeneratePrivateKey().PublicKey()
+	if err := ss.addTestHost(hk); err != nil {
+		t.Fatal(err)
+	}
+
+	// record two successful and a failed download as well as an interaction
+	// with an unknown host
+	ctx := context.Background()
+	if err := ss.RecordHostInteractions(ctx, []hostdb.Interaction{
+		{HostKey: hk, Type: hostdb.InteractionTypeDownload, Success: true, Timestamp: time.Now()},
+		{HostKey: hk, Type: hostdb.InteractionTypeDownload, Success: true, Timestamp: time.Now()},
+		{HostKey: hk, Type: hostdb.InteractionTypeDownload, Success: false, Timestamp: time.Now()},
+		{HostKey: types.PublicKey{1}, Type: hostdb.InteractionTypeDownload, Success: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the interactions were recorded, downloads aren't tracked in the
+	// RTT histogram since it holds the round trip times of scans and price
+	// table updates
+	host, err := ss.Host(ctx, hk)
+	if err != nil {
+		t.Fatal(err)
+	} else if host.Interactions.SuccessfulInteractions != 2 || host.Interactions.FailedInteractions != 1 {
+		t.Fatal("unexpected interactions", host.Interactions.SuccessfulInteractions, host.Interactions.FailedInteractions)
+	} else if host.Interactions.SuccessRatio != 2.0/3.0 {
+		t.Fatal("unexpected success ratio", host.Interactions.SuccessRatio)
+	} else if host.Interactions.RTT.Count() != 0 {
+		t.Fatal("unexpected number of RTT samples", host.Interactions.RTT.Count())
+	}
+}
+
+func TestHostLastSeen(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// helper to assert a host's last seen timestamp
+	ctx := context.Background()
+	assertLastSeen := func(hk types.PublicKey, expected time.Time) {
+		t.Helper()
+		if h, err := ss.Host(ctx, hk); err != nil {
+			t.Fatal(err)
+		} else if !h.LastSeen.Equal(expected) {
+			t.Fatalf("unexpected last seen %v, expected %v", h.LastSeen, expected)
+		}
+	}
+
+	// announce two hosts
+	now := time.Now().UTC().Round(time.Second)
+	hk1, hk2 := types.PublicKey{1}, types.PublicKey{2}
+	for _, hk := range []types.PublicKey{hk1, hk2} {
+		if err := ss.insertTestAnnouncement(hk, hostdb.Announcement{Timestamp: now.Add(-time.Hour), NetAddress: "address"}); err != nil {
+			t.Fatal(err)
+		}
+		assertLastSeen(hk, now.Add(-time.Hour))
+	}
+
+	// a failed scan shouldn't update the last seen timestamp
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk1, now.Add(-time.Minute), rhpv2.HostSettings{}, false)}); err != nil {
+		t.Fatal(err)
+	}
+	assertLastSeen(hk1, now.Add(-time.Hour))
+
+	// a successful scan should
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk1, now, rhpv2.HostSettings{}, true)}); err != nil {
+		t.Fatal(err)
+	}
+	assertLastSeen(hk1, now)
+
+	// an older announcement shouldn't update it, a newer one should
+	if err := ss.insertTestAnnouncement(hk1, hostdb.Announcement{Timestamp: now.Add(-time.Minute), NetAddress: "address"}); err != nil {
+		t.Fatal(err)
+	}
+	assertLastSeen(hk1, now)
+	if err := ss.insertTestAnnouncement(hk2, hostdb.Announcement{Timestamp: now.Add(time.Minute), NetAddress: "address"}); err != nil {
+		t.Fatal(err)
+	}
+	assertLastSeen(hk2, now.Add(time.Minute))
+
+	// hosts should be sortable by last seen
+	hosts, _, err := ss.HostsSorted(ctx, api.HostSortByLastSeen, true, 0, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != 2 || hosts[0].PublicKey != hk1 || hosts[1].PublicKey != hk2 {
+		t.Fatal("unexpected order", hosts)
+	}
+
+	// reset the column and assert the backfill restores it from the last
+	// announcement and the last successful scan
+	if err := ss.db.Model(&dbHost{}).Where("1 = 1").Update("last_seen", 0).Error; err != nil {
+		t.Fatal(err)
+	} else if err := backfillHostLastSeen(ss.db); err != nil {
+		t.Fatal(err)
+	}
+	assertLastSeen(hk1, now)
+	assertLastSeen(hk2, now.Add(time.Minute))
+}
+
+func TestHostSuccessRatio(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add three hosts
+	ctx := context.Background()
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2, hk3 := hks[0], hks[1], hks[2]
+
+	// helper to assert a host's success ratio
+	assertRatio := func(hk types.PublicKey, expected float64) {
+		t.Helper()
+		if h, err := ss.Host(ctx, hk); err != nil {
+			t.Fatal(err)
+		} else if h.Interactions.SuccessRatio != expected {
+			t.Fatalf("unexpected success ratio %v, expected %v", h.Interactions.SuccessRatio, expected)
+		}
+	}
+
+	// hosts without interactions have a ratio of 0
+	assertRatio(hk1, 0)
+
+	// record interactions through both scans and price table updates
+	now := time.Now()
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{
+		newTestScan(hk1, now, rhpv2.HostSettings{}, true),
+		newTestScan(hk2, now, rhpv2.HostSettings{}, true),
+		newTestScan(hk3, now, rhpv2.HostSettings{}, false),
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RecordPriceTables(ctx, []hostdb.PriceTableUpdate{
+		{HostKey: hk1, Success: true, Timestamp: now},
+		{HostKey: hk2, Success: false, Timestamp: now},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assertRatio(hk1, 1)
+	assertRatio(hk2, 0.5)
+	assertRatio(hk3, 0)
+
+	// hosts should be searchable by a minimum success ratio
+	if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", nil, false, 0.95, 0, types.ZeroCurrency, 0, -1); err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != 1 || hosts[0].PublicKey != hk1 {
+		t.Fatal("unexpected hosts", hosts)
+	} else if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", nil, false, 0.5, 0, types.ZeroCurrency, 0, -1); err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != 2 {
+		t.Fatal("unexpected number of hosts", len(hosts))
+	}
+
+	// hosts should be sortable by success ratio
+	hosts, _, err := ss.HostsSorted(ctx, api.HostSortBySuccessRatio, false, 0, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != 3 || hosts[0].PublicKey != hk1 || hosts[1].PublicKey != hk2 || hosts[2].PublicKey != hk3 {
+		t.Fatal("unexpected order", hosts)
+	}
+}
+
+func TestSearchHostsMinRemainingStorage(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add three hosts
+	ctx := context.Background()
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2, hk3 := hks[0], hks[1], hks[2]
+
+	// scan them with varying remaining storage
+	now := time.Now()
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{
+		newTestScan(hk1, now, rhpv2.HostSettings{RemainingStorage: 1 << 20}, true),
+		newTestScan(hk2, now, rhpv2.HostSettings{RemainingStorage: 1 << 30}, true),
+		newTestScan(hk3, now, rhpv2.HostSettings{RemainingStorage: 1 << 40}, true),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// helper to assert the hosts returned for a minimum remaining storage
+	assertHosts := func(minRemainingStorage uint64, expected ...types.PublicKey) {
+		t.Helper()
+		hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", nil, false, 0, minRemainingStorage, types.ZeroCurrency, 0, -1)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(hosts) != len(expected) {
+			t.Fatalf("expected %v hosts, got %v", len(expected), len(hosts))
+		}
+		for i, h := range hosts {
+			if h.PublicKey != expected[i] {
+				t.Fatalf("unexpected host at index %d", i)
+			}
+		}
+	}
+	assertHosts(0, hk1, hk2, hk3)
+	assertHosts(1<<20, hk1, hk2, hk3)
+	assertHosts(1<<20+1, hk2, hk3)
+	assertHosts(1<<40, hk3)
+	assertHosts(1<<40 + 1)
+
+	// reset the column and assert the backfill restores it from the settings
+	if err := ss.db.Model(&dbHost{}).Where("1 = 1").Update("remaining_storage", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	assertHosts(1 << 30)
+	if err := backfillHostSortColumns(ss.db); err != nil {
+		t.Fatal(err)
+	}
+	assertHosts(1<<30, hk2, hk3)
+}
+
+func TestHostPriceColumns(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add three hosts
+	ctx := context.Background()
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2, hk3 := hks[0], hks[1], hks[2]
+
+	// scan them with varying prices
+	settings := func(price uint64) rhpv2.HostSettings {
+		return rhpv2.HostSettings{
+			StoragePrice:           types.Siacoins(1).Mul64(price),
+			ContractPrice:          types.Siacoins(2).Mul64(price),
+			UploadBandwidthPrice:   types.Siacoins(3).Mul64(price),
+			DownloadBandwidthPrice: types.Siacoins(4).Mul64(4 - price),
+		}
+	}
+	now := time.Now()
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{
+		newTestScan(hk1, now, settings(1), true),
+		newTestScan(hk2, now, settings(2), true),
+		newTestScan(hk3, now, settings(3), true),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// helper to assert the hosts returned for a maximum storage price
+	assertHosts := func(maxStoragePrice types.Currency, expected ...types.PublicKey) {
+		t.Helper()
+		hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAll, "", nil, false, 0, 0, maxStoragePrice, 0, -1)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(hosts) != len(expected) {
+			t.Fatalf("expected %v hosts, got %v", len(expected), len(hosts))
+		}
+		for i, h := range hosts {
+			if h.PublicKey != expected[i] {
+				t.Fatalf("unexpected host at index %d", i)
+			}
+		}
+	}
+	assertHosts(types.ZeroCurrency, hk1, hk2, hk3)
+	assertHosts(types.Siacoins(2), hk1, hk2)
+	assertHosts(types.Siacoins(2).Sub(types.NewCurrency64(1)), hk1)
+	assertHosts(types.NewCurrency64(1))
+
+	// helper to assert the order of the hosts when sorted by a price
+	assertOrder := func(sortBy string, expected ...types.PublicKey) {
+		t.Helper()
+		hosts, _, err := ss.HostsSorted(ctx, sortBy, true, 0, 0, -1)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(hosts) != len(expected) {
+			t.Fatalf("expected %v hosts, got %v", len(expected), len(hosts))
+		}
+		for i, h := range hosts {
+			if h.PublicKey != expected[i] {
+				t.Fatalf("unexpected host at index %d when sorting by %v", i, sortBy)
+			}
+		}
+	}
+	assertOrder(api.HostSortByContractPrice, hk1, hk2, hk3)
+	assertOrder(api.HostSortByUploadBandwidthPrice, hk1, hk2, hk3)
+	assertOrder(api.HostSortByDownloadBandwidthPrice, hk3, hk2, hk1)
+
+	// reset the columns and assert the backfill restores them from the settings
+	if err := ss.db.Model(&dbHost{}).Where("1 = 1").Updates(map[string]interface{}{
+		"host_contract_price":      bCurrency(types.ZeroCurrency),
+		"upload_bandwidth_price":   bCurrency(types.ZeroCurrency),
+		"download_bandwidth_price": bCurrency(types.ZeroCurrency),
+	}).Error; err != nil {
+		t.Fatal(err)
+	} else if err := backfillHostPriceColumns(ss.db); err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(api.HostSortByContractPrice, hk1, hk2, hk3)
+	assertOrder(api.HostSortByDownloadBandwidthPrice, hk3, hk2, hk1)
+}
+
+func TestVersionSortKey(t *testing.T) {
+	versions := []string{"", "0.1", "1.5.8", "v1.5.9", "1.5.10-beta", "1.6.0", "1.9.0", "1.10.0", "2.0.0"}
+	for i := 1; i < len(versions); i++ {
+		if versionSortKey(versions[i-1]) >= versionSortKey(versions[i]) {
+			t.Fatalf("expected %q to sort before %q", versions[i-1], versions[i])
+		}
+	}
+	if versionSortKey("v1.2.3") != versionSortKey("1.2.3+build") {
+		t.Fatal("expected prefix and suffix to be ignored")
+	}
+}
+
+func TestRecordHostScores(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a batch of hosts
+	hks, err := ss.addTestHosts(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// record a score for every host, the score decreases with the index and
+	// the score of an unknown host is ignored
+	ctx := context.Background()
+	scores := make(map[types.PublicKey]float64)
+	for i, hk := range hks {
+		scores[hk] = float64(len(hks) - i)
+	}
+	scores[types.PublicKey{1}] = 100
+	if err := ss.RecordHostScores(ctx, scores); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the hosts are returned ordered by score
+	hosts, total, err := ss.HostsSorted(ctx, api.HostSortByScore, false, 0, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if total != int64(len(hks)) || len(hosts) != len(hks) {
+		t.Fatalf("unexpected number of hosts, %v %v != %v", total, len(hosts), len(hks))
+	}
+	for i, h := range hosts {
+		if h.PublicKey != hks[i] {
+			t.Fatalf("unexpected host at index %d", i)
+		} else if h.Score != scores[hks[i]] {
+			t.Fatalf("unexpected score %v != %v", h.Score, scores[hks[i]])
+		}
+	}
+
+	// assert hosts below the min score are filtered out
+	hosts, total, err = ss.HostsSorted(ctx, api.HostSortByScore, false, 15, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if total != 6 || len(hosts) != 6 {
+		t.Fatalf("unexpected number of hosts, %v %v != 6", total, len(hosts))
+	} else if hosts[0].PublicKey != hks[0] || hosts[5].PublicKey != hks[5] {
+		t.Fatal("unexpected hosts", hosts)
+	}
+
+	// assert recording scores updates existing scores
+	if err := ss.RecordHostScores(ctx, map[types.PublicKey]float64{hks[0]: 0.5}); err != nil {
+		t.Fatal(err)
+	} else if h, err := ss.Host(ctx, hks[0]); err != nil {
+		t.Fatal(err)
+	} else if h.Score != 0.5 {
+		t.Fatalf("unexpected score %v", h.Score)
+	}
+}
+
+func TestHostsSorted(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add 3 hosts
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2, hk3 := hks[0], hks[1], hks[2]
+
+	// scan them with different settings, hk2 is scanned last
+	ctx := context.Background()
+	now := time.Now()
+	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{
+		newTestScan(hk1, now.Add(-time.Minute), rhpv2.HostSettings{StoragePrice: types.Siacoins(3), Collateral: types.Siacoins(1), RemainingStorage: 2, Version: "1.5.10"}, true),
+		newTestScan(hk2, now, rhpv2.HostSettings{StoragePrice: types.Siacoins(1), Collateral: types.Siacoins(2), RemainingStorage: 3, Version: "1.5.8"}, true),
+		newTestScan(hk3, now.Add(-2*time.Minute), rhpv2.HostSettings{StoragePrice: types.Siacoins(2), Collateral: types.Siacoins(3), RemainingStorage: 1, Version: "1.5.9"}, true),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertSorted := func(sortBy string, expected ...types.PublicKey) {
+		t.Helper()
+		hosts, total, err := ss.HostsSorted(ctx, sortBy, true, 0, 0, -1)
+		if err != nil {
+			t.Fatal(err)
+		} else if total != 3 {
+			t.Fatal("unexpected total", total)
+		} else if len(hosts) != len(expected) {
+			t.Fatal("unexpected number of hosts", len(hosts))
+		}
+		for i, h := range hosts {
+			if h.PublicKey != expected[i] {
+				t.Fatalf("unexpected host at index %d when sorting by %v", i, sortBy)
+			}
+		}
+
+		// assert the reverse order when sorting descending
+		hosts, _, err = ss.HostsSorted(ctx, sortBy, false, 0, 0, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, h := range hosts {
+			if h.PublicKey != expected[len(expected)-1-i] {
+				t.Fatalf("unexpected host at index %d when sorting by %v descending", i, sortBy)
+			}
+		}
+	}
+	assertAllSorted := func() {
+		t.Helper()
+		assertSorted(api.HostSortByPrice, hk2, hk3, hk1)
+		assertSorted(api.HostSortByCollateral, hk1, hk2, hk3)
+		assertSorted(api.HostSortByRemainingStorage, hk3, hk1, hk2)
+		assertSorted(api.HostSortByLastScan, hk3, hk1, hk2)
+		assertSorted(api.HostSortByVersion, hk2, hk3, hk1) // semver-aware
+	}
+	assertAllSorted()
+
+	// all hosts have the same uptime, so the id breaks the tie
+	assertSorted(api.HostSortByUptime, hk1, hk2, hk3)
+
+	// assert pagination
+	hosts, total, err := ss.HostsSorted(ctx, api.HostSortByPrice, true, 0, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if total != 3 {
+		t.Fatal("unexpected total", total)
+	} else if len(hosts) != 1 || hosts[0].PublicKey != hk3 {
+		t.Fatal("unexpected hosts", hosts)
+	}
+
+	// assert invalid sort fields are rejected
+	if _, _, err := ss.HostsSorted(ctx, "settings; DROP TABLE hosts", true, 0, 0, -1); !errors.Is(err, api.ErrInvalidHostSortField) {
+		t.Fatal("unexpected error", err)
+	} else if _, _, err := ss.HostsSorted(ctx, api.HostSortByPrice, true, 0, -1, -1); !errors.Is(err, ErrNegativeOffset) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// reset the columns and assert the backfill restores them
+	if err := ss.db.Exec("UPDATE hosts SET storage_price = ?, collateral = ?, remaining_storage = 0, version = '', version_sort = 0", bCurrency{}, bCurrency{}).Error; err != nil {
+		t.Fatal(err)
+	} else if err := backfillHostSortColumns(ss.db); err != nil {
+		t.Fatal(err)
+	} else if err := backfillHostVersionSort(ss.db); err != nil {
+		t.Fatal(err)
+	}
+	assertAllSorted()
+
+	// block a host and assert it's excluded
+	if err := ss.UpdateHostBlocklistEntries(ctx, []string{"host.com"}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	hk4 := types.GeneratePrivateKey().PublicKey()
+	if err := ss.addCustomTestHost(hk4, "host.com"); err != nil {
+		t.Fatal(err)
+	} else if hosts, total, err := ss.HostsSorted(ctx, api.HostSortByPrice, true, 0, 0, -1); err != nil {
+		t.Fatal(err)
+	} else if total != 3 || len(hosts) != 3 {
+		t.Fatal("unexpected hosts", total, len(hosts))
+	}
+}
+
+func TestHostNullSettingsFields(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a host and scan it
+	ctx := context.Background()
+	hk := types.GeneratePrivateKey().PublicKey()
+	settings := rhpv2.HostSettings{
+		NetAddress:   "host.com",
+		StoragePrice: types.Siacoins(1),
+		Collateral:   types.Siacoins(2),
+		MaxDuration:  144,
+	}
+	if err := ss.addCustomTestHost(hk, settings.NetAddress); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk, time.Now(), settings, true)}); err != nil {
+		t.Fatal(err)
 	}
 
-	// Record another scan 1 hour after the previous one.
-	secondScanTime := firstScanTime.Add(time.Hour)
-	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk, secondScanTime, settings, true)}); err != nil {
+	// overwrite the settings and price table with blobs containing null
+	// prices
+	nullify := func(v interface{}, fields ...string) string {
+		t.Helper()
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range fields {
+			if _, ok := m[f]; !ok {
+				t.Fatal("unknown field", f)
+			}
+			m[f] = nil
+		}
+		b, err = json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if err := ss.db.
+		Model(&dbHost{}).
+		Where("public_key", publicKey(hk)).
+		Updates(map[string]interface{}{
+			"settings":    nullify(settings, "storageprice", "collateral"),
+			"price_table": nullify(rhpv3.HostPriceTable{}, "contractprice"),
+		}).
+		Error; err != nil {
 		t.Fatal(err)
 	}
-	host, err = ss.Host(ctx, hk)
+
+	// assert the host can still be fetched, the null prices are zero
+	h, err := ss.Host(ctx, hk)
 	if err != nil {
 		t.Fatal(err)
-	}
-	if host.Interactions.LastScan.UnixNano() != secondScanTime.UnixNano() {
-		t.Fatal("wrong time")
-	}
-	host.Interactions.LastScan = time.Time{}
-	uptime += secondScanTime.Sub(firstScanTime)
-	if host.Interactions != (hostdb.Interactions{
-		TotalScans:              2,
-		LastScan:                time.Time{},
-		LastScanSuccess:         true,
-		SecondToLastScanSuccess: true,
-		Uptime:                  uptime,
-		Downtime:                downtime,
-		SuccessfulInteractions:  2,
-		FailedInteractions:      0,
-	}) {
-		t.Fatal("mismatch")
+	} else if !h.Settings.StoragePrice.IsZero() || !h.Settings.Collateral.IsZero() {
+		t.Fatal("expected null prices to be zero", h.Settings.StoragePrice, h.Settings.Collateral)
+	} else if h.Settings.MaxDuration != settings.MaxDuration {
+		t.Fatal("unexpected max duration", h.Settings.MaxDuration)
+	} else if !h.PriceTable.ContractPrice.IsZero() {
+		t.Fatal("expected null price to be zero", h.PriceTable.ContractPrice)
 	}
 
-	// Record another scan 2 hours after the second one. This time it fails.
-	thirdScanTime := secondScanTime.Add(2 * time.Hour)
-	if err := ss.RecordHostScans(ctx, []hostdb.HostScan{newTestScan(hk, thirdScanTime, settings, false)}); err != nil {
+	// assert the host doesn't break listing all hosts
+	if hosts, err := ss.Hosts(ctx, 0, -1); err != nil {
 		t.Fatal(err)
+	} else if len(hosts) != 1 {
+		t.Fatal("unexpected number of hosts", len(hosts))
 	}
-	host, err = ss.Host(ctx, hk)
-	if err != nil {
+
+	// assert settings that are malformed otherwise still fail to scan
+	if err := ss.db.Exec("UPDATE hosts SET settings = ?", `{"storageprice":"foo"}`).Error; err != nil {
 		t.Fatal(err)
-	}
-	if host.Interactions.LastScan.UnixNano() != thirdScanTime.UnixNano() {
-		t.Fatal("wrong time")
-	}
-	host.Interactions.LastScan = time.Time{}
-	downtime += thirdScanTime.Sub(secondScanTime)
-	if host.Interactions != (hostdb.Interactions{
-		TotalScans:              3,
-		LastScan:                time.Time{},
-		LastScanSuccess:         false,
-		SecondToLastScanSuccess: true,
-		Uptime:                  uptime,
-		Downtime:                downtime,
-		SuccessfulInteractions:  2,
-		FailedInteractions:      1,
-	}) {
-		t.Fatal("mismatch")
+	} else if _, err := ss.Host(ctx, hk); err == nil {
+		t.Fatal("expected error")
 	}
 }
 
@@ -496,9 +1630,214 @@ func TestRemoveHosts(t *testing.T) {
 	if _, err = hostByPubKey(ss.db, hk); err != gorm.ErrRecordNotFound {
 		t.Fatal("expected record not found error")
 	}
+
+	// assert its announcements were pruned
+	var cnt int64
+	if err := ss.db.Model(&dbAnnouncement{}).Where("host_key = ?", publicKey(hk)).Count(&cnt).Error; err != nil {
+		t.Fatal(err)
+	} else if cnt != 0 {
+		t.Fatal("expected announcements to be pruned", cnt)
+	}
+}
+
+func TestRemoveHost(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add two hosts, both are blocked and have a contract
+	ctx := context.Background()
+	hk1, hk2 := types.PublicKey{1}, types.PublicKey{2}
+	if err := ss.addCustomTestHost(hk1, "foo.com:1000"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.addCustomTestHost(hk2, "foo.com:1001"); err != nil {
+		t.Fatal(err)
+	} else if _, _, err := ss.addTestContracts([]types.PublicKey{hk1, hk2}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateHostBlocklistEntries(ctx, []string{"foo.com"}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert removing an unknown host fails
+	if err := ss.RemoveHost(ctx, types.PublicKey{3}); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// remove the first host
+	if err := ss.RemoveHost(ctx, hk1); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.Host(ctx, hk1); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("expected host to be removed", err)
+	} else if _, err := ss.Host(ctx, hk2); err != nil {
+		t.Fatal(err)
+	}
+
+	// helper to count rows
+	count := func(model interface{}, query string, args ...interface{}) (cnt int64) {
+		t.Helper()
+		if err := ss.db.Model(model).Where(query, args...).Count(&cnt).Error; err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// assert the announcements were pruned, they don't cascade
+	if cnt := count(&dbAnnouncement{}, "host_key = ?", publicKey(hk1)); cnt != 0 {
+		t.Fatal("expected announcements to be pruned", cnt)
+	} else if cnt := count(&dbAnnouncement{}, "host_key = ?", publicKey(hk2)); cnt != 1 {
+		t.Fatal("expected announcement of other host to remain", cnt)
+	}
+
+	// assert the blocklist join table cascaded but the entry remains
+	if cnt := count(&dbHostBlocklistEntryHost{}, "1 = 1"); cnt != 1 {
+		t.Fatal("expected 1 blocklist entry host, got", cnt)
+	} else if cnt := count(&dbBlocklistEntry{}, "1 = 1"); cnt != 1 {
+		t.Fatal("expected 1 blocklist entry, got", cnt)
+	}
+
+	// assert the contract was archived
+	if cnt := count(&dbContract{}, "1 = 1"); cnt != 1 {
+		t.Fatal("expected 1 contract, got", cnt)
+	} else if cnt := count(&dbArchivedContract{}, "host = ? AND reason = ?", publicKey(hk1), api.ContractArchivalReasonHostPruned); cnt != 1 {
+		t.Fatal("expected 1 archived contract, got", cnt)
+	}
+}
+
+func TestPruneHosts(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add three hosts
+	ctx := context.Background()
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2, hk3 := hks[0], hks[1], hks[2]
+
+	// helper to update the time the host was last announced and scanned, the
+	// scans are unsuccessful so the host was last seen when it announced
+	cutoff := time.Now().UTC().Add(-24 * time.Hour)
+	update := func(hk types.PublicKey, lastAnnouncement, lastScan time.Time) {
+		t.Helper()
+		if err := ss.db.Model(&dbHost{}).Where("public_key", publicKey(hk)).Updates(map[string]interface{}{
+			"last_announcement": lastAnnouncement,
+			"last_scan":         lastScan.UnixNano(),
+			"last_seen":         lastAnnouncement.UnixNano(),
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first host wasn't seen since the cutoff, the second was announced
+	// and the third was scanned after it
+	update(hk1, cutoff.Add(-time.Hour), cutoff.Add(-time.Minute))
+	update(hk2, cutoff.Add(time.Minute), cutoff.Add(-time.Hour))
+	update(hk3, cutoff.Add(-time.Hour), cutoff.Add(time.Minute))
+
+	// prune the hosts
+	if pruned, err := ss.PruneHosts(ctx, cutoff); err != nil {
+		t.Fatal(err)
+	} else if pruned != 1 {
+		t.Fatal("expected 1 pruned host, got", pruned)
+	} else if _, err := ss.Host(ctx, hk1); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("expected host to be pruned", err)
+	}
+
+	// assert pruning again is a no-op
+	if pruned, err := ss.PruneHosts(ctx, cutoff); err != nil {
+		t.Fatal(err)
+	} else if pruned != 0 {
+		t.Fatal("expected no pruned hosts, got", pruned)
+	}
+
+	// move the cutoff, the remaining hosts should be pruned
+	if pruned, err := ss.PruneHosts(ctx, cutoff.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if pruned != 2 {
+		t.Fatal("expected 2 pruned hosts, got", pruned)
+	}
+
+	// assert the announcements were pruned as well
+	var cnt int64
+	if err := ss.db.Model(&dbAnnouncement{}).Count(&cnt).Error; err != nil {
+		t.Fatal(err)
+	} else if cnt != 0 {
+		t.Fatal("expected announcements to be pruned", cnt)
+	}
+}
+
+func TestPruneHostAnnouncements(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a host
+	hk := types.GeneratePrivateKey().PublicKey()
+	if err := ss.addTestHost(hk); err != nil {
+		t.Fatal(err)
+	}
+
+	// insert two orphaned announcements
+	for i := 0; i < 2; i++ {
+		if err := ss.db.Create(&dbAnnouncement{HostKey: publicKey{byte(i + 1)}}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// prune them
+	if pruned, err := ss.PruneHostAnnouncements(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if pruned != 2 {
+		t.Fatal("expected 2 announcements to be pruned", pruned)
+	}
+
+	// assert the host's announcement is still there
+	var cnt int64
+	if err := ss.db.Model(&dbAnnouncement{}).Count(&cnt).Error; err != nil {
+		t.Fatal(err)
+	} else if cnt != 1 {
+		t.Fatal("expected 1 announcement", cnt)
+	}
 }
 
 // TestInsertAnnouncements is a test for insertAnnouncements.
+func TestHostNetAddressChanges(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// helper to announce the host and assert its net address changes
+	hk := types.GeneratePrivateKey().PublicKey()
+	announce := func(addr string, changes uint64) {
+		t.Helper()
+		if err := ss.insertTestAnnouncement(hk, newTestHostDBAnnouncement(addr)); err != nil {
+			t.Fatal(err)
+		}
+		h, err := ss.Host(context.Background(), hk)
+		if err != nil {
+			t.Fatal(err)
+		} else if h.NetAddress != addr {
+			t.Fatalf("unexpected net address %v, expected %v", h.NetAddress, addr)
+		} else if h.NetAddressChanges != changes {
+			t.Fatalf("unexpected net address changes %v, expected %v", h.NetAddressChanges, changes)
+		}
+	}
+
+	// announce the host, re-announce it with the same address and then with
+	// different addresses
+	announce("foo.com:1000", 0)
+	announce("foo.com:1000", 0)
+	announce("bar.com:1000", 1)
+	announce("foo.com:1000", 2)
+
+	// re-announce the host twice within the same batch
+	if err := insertAnnouncements(ss.db, []announcement{
+		{hostKey: publicKey(hk), announcement: newTestHostDBAnnouncement("foo.com:1000")},
+		{hostKey: publicKey(hk), announcement: newTestHostDBAnnouncement("baz.com:1000")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	announce("baz.com:1000", 3)
+}
+
 func TestInsertAnnouncements(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -531,6 +1870,10 @@ func TestInsertAnnouncements(t *testing.T) {
 		BlockHeight: 1,
 		BlockID:     types.BlockID{1}.String(),
 		NetAddress:  "foo.bar:1000",
+		Timestamp: sql.NullTime{
+			Time:  ann1.announcement.Timestamp,
+			Valid: true,
+		},
 	}
 	if ann != expectedAnn {
 		t.Fatal("mismatch")
@@ -679,21 +2022,21 @@ func TestSQLHostAllowlist(t *testing.T) {
 
 	assertSearch := func(total, allowed, blocked int) error {
 		t.Helper()
-		hosts, err := ss.SearchHosts(context.Background(), api.HostFilterModeAll, "", nil, 0, -1)
+		hosts, err := ss.SearchHosts(context.Background(), api.HostFilterModeAll, "", nil, false, 0, 0, types.ZeroCurrency, 0, -1)
 		if err != nil {
 			return err
 		}
 		if len(hosts) != total {
 			return fmt.Errorf("invalid number of hosts: %v", len(hosts))
 		}
-		hosts, err = ss.SearchHosts(context.Background(), api.HostFilterModeAllowed, "", nil, 0, -1)
+		hosts, err = ss.SearchHosts(context.Background(), api.HostFilterModeAllowed, "", nil, false, 0, 0, types.ZeroCurrency, 0, -1)
 		if err != nil {
 			return err
 		}
 		if len(hosts) != allowed {
 			return fmt.Errorf("invalid number of hosts: %v", len(hosts))
 		}
-		hosts, err = ss.SearchHosts(context.Background(), api.HostFilterModeBlocked, "", nil, 0, -1)
+		hosts, err = ss.SearchHosts(context.Background(), api.HostFilterModeBlocked, "", nil, false, 0, 0, types.ZeroCurrency, 0, -1)
 		if err != nil {
 			return err
 		}
@@ -1031,6 +2374,127 @@ func TestSQLHostBlocklistBasic(t *testing.T) {
 	}
 }
 
+func TestSQLHostBlocklistExpiry(t *testing.T) {
+	cfg := defaultTestSQLStoreConfig
+	clock := newTestClock(time.Unix(1700000000, 0))
+	cfg.clock = clock
+	ss := newTestSQLStore(t, cfg)
+	defer ss.Close()
+
+	ctx := context.Background()
+
+	// add two hosts
+	hk1, hk2 := types.GeneratePrivateKey().PublicKey(), types.GeneratePrivateKey().PublicKey()
+	if err := ss.addCustomTestHost(hk1, "foo.com:1000"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.addCustomTestHost(hk2, "bar.com:1000"); err != nil {
+		t.Fatal(err)
+	}
+
+	// block the first host temporarily and the second one permanently
+	if err := ss.AddHostBlocklistEntriesWithExpiry(ctx, []string{"foo.com"}, clock.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateHostBlocklistEntries(ctx, []string{"bar.com"}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// helper to assert the blocked state of both hosts
+	assertBlocked := func(blocked1, blocked2 bool) {
+		t.Helper()
+		if h, err := ss.Host(ctx, hk1); err != nil {
+			t.Fatal(err)
+		} else if h.Blocked != blocked1 {
+			t.Fatalf("unexpected blocked state %v for host 1", h.Blocked)
+		} else if h, err := ss.Host(ctx, hk2); err != nil {
+			t.Fatal(err)
+		} else if h.Blocked != blocked2 {
+			t.Fatalf("unexpected blocked state %v for host 2", h.Blocked)
+		}
+
+		expected := 2
+		if blocked1 {
+			expected--
+		}
+		if blocked2 {
+			expected--
+		}
+		if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeAllowed, "", nil, false, 0, 0, types.ZeroCurrency, 0, -1); err != nil {
+			t.Fatal(err)
+		} else if len(hosts) != expected {
+			t.Fatalf("expected %v allowed hosts, got %v", expected, len(hosts))
+		} else if hosts, err := ss.SearchHosts(ctx, api.HostFilterModeBlocked, "", nil, false, 0, 0, types.ZeroCurrency, 0, -1); err != nil {
+			t.Fatal(err)
+		} else if len(hosts) != 2-expected {
+			t.Fatalf("expected %v blocked hosts, got %v", 2-expected, len(hosts))
+		}
+	}
+	assertBlocked(true, true)
+
+	// assert the remaining time is returned
+	entries, err := ss.HostBlocklistEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 {
+		t.Fatal("unexpected number of entries", len(entries))
+	} else if entries[0].Entry != "foo.com" || time.Duration(entries[0].Remaining) != time.Hour {
+		t.Fatal("unexpected entry", entries[0])
+	} else if entries[1].Entry != "bar.com" || !entries[1].ExpiresAt.IsZero() || entries[1].Remaining != 0 {
+		t.Fatal("unexpected entry", entries[1])
+	}
+
+	// blocking the hosts again with an earlier expiry doesn't shorten the
+	// temporary block nor does it make the permanent block temporary
+	if err := ss.AddHostBlocklistEntriesWithExpiry(ctx, []string{"foo.com", "bar.com"}, clock.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(30 * time.Minute)
+	assertBlocked(true, true)
+	if entries, err := ss.HostBlocklistEntries(ctx); err != nil {
+		t.Fatal(err)
+	} else if time.Duration(entries[0].Remaining) != 30*time.Minute || !entries[1].ExpiresAt.IsZero() {
+		t.Fatal("unexpected entries", entries)
+	}
+
+	// advance the clock past the expiry, the first host should be unblocked
+	clock.Advance(time.Hour)
+	assertBlocked(false, true)
+	if blocklist, err := ss.HostBlocklist(ctx); err != nil {
+		t.Fatal(err)
+	} else if len(blocklist) != 1 || blocklist[0] != "bar.com" {
+		t.Fatal("unexpected blocklist", blocklist)
+	}
+
+	// prune the expired entries
+	if pruned, err := ss.PruneExpiredHostBlocklistEntries(ctx); err != nil {
+		t.Fatal(err)
+	} else if pruned != 1 {
+		t.Fatal("expected 1 pruned entry, got", pruned)
+	} else if cnt, err := tableCount(ss.db, &dbBlocklistEntry{}); err != nil {
+		t.Fatal(err)
+	} else if cnt != 1 {
+		t.Fatal("expected 1 entry, got", cnt)
+	}
+	assertBlocked(false, true)
+
+	// block the first host again
+	if err := ss.AddHostBlocklistEntriesWithExpiry(ctx, []string{"foo.com"}, clock.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	assertBlocked(true, true)
+
+	// block it permanently, the temporary entry should become permanent
+	if err := ss.UpdateHostBlocklistEntries(ctx, []string{"foo.com"}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Hour)
+	assertBlocked(true, true)
+	if entries, err := ss.HostBlocklistEntries(ctx); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 || !entries[0].ExpiresAt.IsZero() || !entries[1].ExpiresAt.IsZero() {
+		t.Fatal("unexpected entries", entries)
+	}
+}
+
 // TestAnnouncementMaxAge verifies old announcements are ignored.
 func TestAnnouncementMaxAge(t *testing.T) {
 	db := newTestSQLStore(t, defaultTestSQLStoreConfig)
@@ -1064,6 +2528,174 @@ func TestAnnouncementMaxAge(t *testing.T) {
 	}
 }
 
+func TestAnnouncementBatchSoftLimit(t *testing.T) {
+	db := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer db.Close()
+
+	// lower the soft limit
+	db.announcementBatchSoftLimit = 3
+
+	// helper to process a consensus change with a single announcement
+	processAnnouncement := func(i int) {
+		t.Helper()
+		ann, sk := newTestHostAnnouncement(modules.NetAddress(fmt.Sprintf("foo.com:%d", 1000+i)))
+		db.lastSave = time.Now() // make sure the persist interval doesn't pass
+		db.ProcessConsensusChange(modules.ConsensusChange{
+			ID:          modules.ConsensusChangeID{byte(i + 1)},
+			BlockHeight: stypes.BlockHeight(i + 1),
+			AppliedBlocks: []stypes.Block{{
+				Timestamp:    stypes.Timestamp(time.Now().Unix()),
+				Transactions: []stypes.Transaction{newTestTransaction(ann, sk)},
+			}},
+			AppliedDiffs: []modules.ConsensusChangeDiffs{{}},
+			Synced:       true,
+		})
+	}
+	assertAnnouncements := func(unapplied, applied int) {
+		t.Helper()
+		var n int64
+		if len(db.unappliedAnnouncements) != unapplied {
+			t.Fatalf("expected %d unapplied announcements, got %d", unapplied, len(db.unappliedAnnouncements))
+		} else if err := db.db.Model(&dbAnnouncement{}).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		} else if n != int64(applied) {
+			t.Fatalf("expected %d announcements in the db, got %d", applied, n)
+		}
+	}
+
+	// assert announcements are buffered until the soft limit is reached
+	processAnnouncement(0)
+	assertAnnouncements(1, 0)
+	processAnnouncement(1)
+	assertAnnouncements(2, 0)
+	processAnnouncement(2)
+	assertAnnouncements(0, 3)
+
+	// assert the buffer fills up again
+	processAnnouncement(3)
+	assertAnnouncements(1, 3)
+}
+
+func TestAnnouncementBatchHardLimit(t *testing.T) {
+	db := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer db.Close()
+
+	// lower the hard limit
+	db.announcementBatchHardLimit = 3
+
+	// helper to process a consensus change with n announcements
+	processAnnouncements := func(id byte, n int) {
+		t.Helper()
+		var blocks []stypes.Block
+		var diffs []modules.ConsensusChangeDiffs
+		for i := 0; i < n; i++ {
+			ann, sk := newTestHostAnnouncement(modules.NetAddress(fmt.Sprintf("foo.com:%d", int(id)*1000+i)))
+			blocks = append(blocks, stypes.Block{
+				Timestamp:    stypes.Timestamp(time.Now().Unix()),
+				Transactions: []stypes.Transaction{newTestTransaction(ann, sk)},
+			})
+			diffs = append(diffs, modules.ConsensusChangeDiffs{})
+		}
+		db.lastSave = time.Now() // make sure the persist interval doesn't pass
+		db.ProcessConsensusChange(modules.ConsensusChange{
+			ID:            modules.ConsensusChangeID{id},
+			BlockHeight:   stypes.BlockHeight(int(id) * 10),
+			AppliedBlocks: blocks,
+			AppliedDiffs:  diffs,
+			Synced:        true,
+		})
+	}
+	assertAnnouncements := func(unapplied, applied int) {
+		t.Helper()
+		var n int64
+		if len(db.unappliedAnnouncements) != unapplied {
+			t.Fatalf("expected %d unapplied announcements, got %d", unapplied, len(db.unappliedAnnouncements))
+		} else if err := db.db.Model(&dbAnnouncement{}).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		} else if n != int64(applied) {
+			t.Fatalf("expected %d announcements in the db, got %d", applied, n)
+		}
+	}
+
+	// assert announcements are buffered below the hard limit
+	processAnnouncements(1, 2)
+	assertAnnouncements(2, 0)
+
+	// assert they're applied once the change that exceeds the hard limit was
+	// processed, including the announcements of that change
+	processAnnouncements(2, 2)
+	assertAnnouncements(0, 4)
+
+	// assert the change's ID was persisted alongside the announcements
+	var ci dbConsensusInfo
+	if err := db.db.Where(&dbConsensusInfo{Model: Model{ID: consensusInfoID}}).Take(&ci).Error; err != nil {
+		t.Fatal(err)
+	}
+	var ccid modules.ConsensusChangeID
+	copy(ccid[:], ci.CCID)
+	if ccid != (modules.ConsensusChangeID{2}) {
+		t.Fatal("unexpected ccid", ccid)
+	}
+}
+
+func TestAnnouncementCatchUpBatchSize(t *testing.T) {
+	db := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer db.Close()
+
+	// apply announcements every 2 blocks while catching up
+	db.announcementCatchUpBatchSize = 2
+
+	// helper to process a consensus change with an announcement per block
+	processBlocks := func(id byte, n int, synced bool) {
+		t.Helper()
+		var blocks []stypes.Block
+		var diffs []modules.ConsensusChangeDiffs
+		for i := 0; i < n; i++ {
+			ann, sk := newTestHostAnnouncement(modules.NetAddress(fmt.Sprintf("foo.com:%d", int(id)*1000+i)))
+			blocks = append(blocks, stypes.Block{
+				Timestamp:    stypes.Timestamp(time.Now().Unix()),
+				Transactions: []stypes.Transaction{newTestTransaction(ann, sk)},
+			})
+			diffs = append(diffs, modules.ConsensusChangeDiffs{})
+		}
+		db.lastSave = time.Now() // make sure the persist interval doesn't pass
+		db.ProcessConsensusChange(modules.ConsensusChange{
+			ID:            modules.ConsensusChangeID{id},
+			BlockHeight:   stypes.BlockHeight(int(id) * 10),
+			AppliedBlocks: blocks,
+			AppliedDiffs:  diffs,
+			Synced:        synced,
+		})
+	}
+	assertAnnouncements := func(unapplied, applied int) {
+		t.Helper()
+		var n int64
+		if len(db.unappliedAnnouncements) != unapplied {
+			t.Fatalf("expected %d unapplied announcements, got %d", unapplied, len(db.unappliedAnnouncements))
+		} else if err := db.db.Model(&dbAnnouncement{}).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		} else if n != int64(applied) {
+			t.Fatalf("expected %d announcements in the db, got %d", applied, n)
+		}
+	}
+
+	// assert the announcements are buffered until they span enough blocks
+	processBlocks(1, 1, false)
+	assertAnnouncements(1, 0)
+
+	// assert the block count carries over to the next change
+	processBlocks(2, 1, false)
+	assertAnnouncements(0, 2)
+
+	// assert changes spanning more blocks are applied in their entirety
+	processBlocks(3, 3, false)
+	assertAnnouncements(0, 5)
+
+	// assert announcements are buffered as usual once synced
+	processBlocks(4, 3, true)
+	assertAnnouncements(3, 5)
+}
+
 // addTestHosts adds 'n' hosts to the db and returns their keys.
 func (s *SQLStore) addTestHosts(n int) (keys []types.PublicKey, err error) {
 	cnt, err := s.contractsCount()
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -32,6 +33,14 @@ const (
 	// 10/30 erasure coding and takes <1s to execute on an SSD in SQLite.
 	refreshHealthBatchSize = 10000
 
+	// recomputeObjectSizesBatchSize is the number of objects for which we
+	// recompute the size per db transaction.
+	recomputeObjectSizesBatchSize = 1000
+
+	// objectInsertionBatchSize is the number of objects UpdateObjects inserts
+	// per db transaction.
+	objectInsertionBatchSize = 100
+
 	sectorInsertionBatchSize = 500
 	sectorQueryBatchSize     = 100
 
@@ -43,6 +52,11 @@ var (
 	errInvalidNumberOfShards = errors.New("slab has invalid number of shards")
 	errShardRootChanged      = errors.New("shard root changed")
 
+	// errObjectCreatedConcurrently is returned when overwriting an object
+	// fails because a concurrent upload created it in the meantime, unlike
+	// the raw error it doesn't abort the retry
+	errObjectCreatedConcurrently = errors.New("object was created concurrently")
+
 	objectDeleteBatchSizes = []int64{10, 50, 100, 200, 500, 1000, 5000, 10000, 50000, 100000}
 )
 
@@ -75,6 +89,12 @@ type (
 		HostID uint `gorm:"index"`
 		Host   dbHost
 
+		// InitialRenterFunds are the renter's funds in the contract when it
+		// was formed or renewed, i.e. the total cost minus the contract price,
+		// the transaction fees and the siafund tax. It's zero for contracts
+		// that were added before the column was introduced.
+		InitialRenterFunds currency `gorm:"NOT NULL;default:'0'"`
+
 		ContractSets []dbContractSet `gorm:"many2many:contract_set_contracts;constraint:OnDelete:CASCADE"`
 	}
 
@@ -320,9 +340,11 @@ func (c dbArchivedContract) convert() api.ArchivedContract {
 	var revisionNumber uint64
 	_, _ = fmt.Sscan(c.RevisionNumber, &revisionNumber)
 	return api.ArchivedContract{
-		ID:        types.FileContractID(c.FCID),
-		HostKey:   types.PublicKey(c.Host),
-		RenewedTo: types.FileContractID(c.RenewedTo),
+		ID:          types.FileContractID(c.FCID),
+		HostKey:     types.PublicKey(c.Host),
+		Reason:      c.Reason,
+		RenewedFrom: types.FileContractID(c.RenewedFrom),
+		RenewedTo:   types.FileContractID(c.RenewedTo),
 
 		ProofHeight:    c.ProofHeight,
 		RevisionHeight: c.RevisionHeight,
@@ -579,6 +601,42 @@ func (s *SQLStore) ListBuckets(ctx context.Context) ([]api.Bucket, error) {
 	return resp, nil
 }
 
+// ObjectsHealth returns the objects ordered by their health, least healthy
+// first, together with the number of objects whose health is below the safe
+// threshold. The health of an object is the cached minimum health of its slabs.
+func (s *SQLStore) ObjectsHealth(ctx context.Context, offset, limit int) (api.ObjectsHealthResponse, error) {
+	if offset < 0 {
+		return api.ObjectsHealthResponse{}, ErrNegativeOffset
+	}
+
+	var resp api.ObjectsHealthResponse
+	err := s.db.
+		WithContext(ctx).
+		Model(&dbObject{}).
+		Where("health < ?", api.ObjectsHealthSafeThreshold).
+		Count(&resp.NumUnsafe).
+		Error
+	if err != nil {
+		return api.ObjectsHealthResponse{}, err
+	}
+
+	err = s.db.
+		WithContext(ctx).
+		Model(&dbObject{}).
+		Select("b.name AS Bucket, objects.object_id AS Name, objects.health AS Health, objects.size AS Size").
+		Joins("INNER JOIN buckets b ON objects.db_bucket_id = b.id").
+		Order("objects.health ASC").
+		Order("objects.id ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&resp.Objects).
+		Error
+	if err != nil {
+		return api.ObjectsHealthResponse{}, err
+	}
+	return resp, nil
+}
+
 // ObjectsStats returns some info related to the objects stored in the store. To
 // reduce locking and make sure all results are consistent, everything is done
 // within a single transaction.
@@ -799,13 +857,16 @@ func (s *SQLStore) Contracts(ctx context.Context, opts api.ContractsOpts) ([]api
 // AddRenewedContract adds a new contract which was created as the result of a renewal to the store.
 // The old contract specified as 'renewedFrom' will be deleted from the active
 // contracts and moved to the archive. Both new and old contract will be linked
-// to each other through the RenewedFrom and RenewedTo fields respectively.
+// to each other through the RenewedFrom and RenewedTo fields respectively. If
+// the new contract has the same end height as the old one it was refreshed
+// rather than renewed, which is reflected in the archival reason.
 func (s *SQLStore) AddRenewedContract(ctx context.Context, c rhpv2.ContractRevision, contractPrice, totalCost types.Currency, startHeight uint64, renewedFrom types.FileContractID, state string) (api.ContractMetadata, error) {
 	var cs contractState
 	if err := cs.LoadString(state); err != nil {
 		return api.ContractMetadata{}, err
 	}
 	var renewed dbContract
+	var reason string
 	if err := s.retryTransaction(func(tx *gorm.DB) error {
 		// Fetch contract we renew from.
 		oldContract, err := contract(tx, fileContractID(renewedFrom))
@@ -813,10 +874,16 @@ func (s *SQLStore) AddRenewedContract(ctx context.Context, c rhpv2.ContractRevis
 			return err
 		}
 
+		// Determine whether the contract was renewed or refreshed.
+		reason = api.ContractArchivalReasonRenewed
+		if c.Revision.WindowStart == oldContract.WindowStart {
+			reason = api.ContractArchivalReasonRefreshed
+		}
+
 		// Create copy in archive.
 		err = tx.Create(&dbArchivedContract{
 			Host:      publicKey(oldContract.Host.PublicKey),
-			Reason:    api.ContractArchivalReasonRenewed,
+			Reason:    reason,
 			RenewedTo: fileContractID(c.ID()),
 
 			ContractCommon: oldContract.ContractCommon,
@@ -826,7 +893,7 @@ func (s *SQLStore) AddRenewedContract(ctx context.Context, c rhpv2.ContractRevis
 		}
 
 		// Overwrite the old contract with the new one.
-		newContract := newContract(oldContract.HostID, c.ID(), renewedFrom, contractPrice, totalCost, startHeight, c.Revision.WindowStart, c.Revision.WindowEnd, oldContract.Size, cs)
+		newContract := newContract(oldContract.HostID, c, renewedFrom, contractPrice, totalCost, startHeight, oldContract.Size, cs)
 		newContract.Model = oldContract.Model
 		newContract.CreatedAt = time.Now()
 		err = tx.Save(&newContract).Error
@@ -841,6 +908,16 @@ func (s *SQLStore) AddRenewedContract(ctx context.Context, c rhpv2.ContractRevis
 		return api.ContractMetadata{}, err
 	}
 
+	// Record whether the contract was renewed or refreshed.
+	if err := s.RecordContractRenewalMetric(ctx, api.ContractRenewalMetric{
+		Timestamp:   api.TimeRFC3339(time.Now()),
+		ContractID:  c.ID(),
+		RenewedFrom: renewedFrom,
+		HostKey:     c.HostKey(),
+		Kind:        reason,
+	}); err != nil {
+		s.logger.Errorw("failed to record contract renewal metric", zap.Error(err))
+	}
 	return renewed.convert(), nil
 }
 
@@ -859,6 +936,33 @@ func (s *SQLStore) AncestorContracts(ctx context.Context, id types.FileContractI
 	return contracts, nil
 }
 
+// ArchivedContracts returns the archived contracts that match the given
+// filter, in the order they were archived.
+func (s *SQLStore) ArchivedContracts(ctx context.Context, opts api.ArchivedContractsOpts) ([]api.ArchivedContract, error) {
+	tx := s.db.
+		WithContext(ctx).
+		Model(&dbArchivedContract{})
+	if opts.HostKey != (types.PublicKey{}) {
+		tx = tx.Where("host = ?", publicKey(opts.HostKey))
+	}
+	if opts.Reason != "" {
+		tx = tx.Where("reason = ?", opts.Reason)
+	}
+
+	var archived []dbArchivedContract
+	if err := tx.
+		Order("id ASC").
+		Find(&archived).
+		Error; err != nil {
+		return nil, err
+	}
+	contracts := make([]api.ArchivedContract, len(archived))
+	for i, c := range archived {
+		contracts[i] = c.convert()
+	}
+	return contracts, nil
+}
+
 func (s *SQLStore) ArchiveContract(ctx context.Context, id types.FileContractID, reason string) error {
 	return s.ArchiveContracts(ctx, map[types.FileContractID]string{id: reason})
 }
@@ -913,6 +1017,25 @@ func (s *SQLStore) Contract(ctx context.Context, id types.FileContractID) (api.C
 	return contract.convert(), nil
 }
 
+// ContractForHost returns the active contract with the given host. If there
+// are multiple active contracts with the host, the most recent one is returned.
+func (s *SQLStore) ContractForHost(ctx context.Context, hk types.PublicKey) (api.ContractMetadata, error) {
+	var contract dbContract
+	err := s.db.
+		WithContext(ctx).
+		Joins("Host").
+		Where("Host.public_key = ?", publicKey(hk)).
+		Order("contracts.start_height DESC").
+		Take(&contract).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return api.ContractMetadata{}, api.ErrContractNotFound
+	} else if err != nil {
+		return api.ContractMetadata{}, err
+	}
+	return contract.convert(), nil
+}
+
 func (s *SQLStore) ContractRoots(ctx context.Context, id types.FileContractID) (roots []types.Hash256, err error) {
 	if !s.isKnownContract(id) {
 		return nil, api.ErrContractNotFound
@@ -944,6 +1067,53 @@ func (s *SQLStore) ContractSets(ctx context.Context) ([]string, error) {
 	return sets, err
 }
 
+// LockedFunds returns the sum of the funds that are still locked in pending
+// and active contracts. For every contract, that's the initial renter funds
+// minus whatever was spent on the contract so far. Contracts that predate the
+// initial renter funds fall back to the total cost minus the contract price,
+// which overstates their funds by the fees.
+func (s *SQLStore) LockedFunds(ctx context.Context) (types.Currency, error) {
+	// NOTE: we sum the funds in Go rather than using SUM in SQL since the
+	// currencies are stored as strings and summing them in SQL loses
+	// precision
+	var rows []struct {
+		InitialRenterFunds  currency
+		TotalCost           currency
+		ContractPrice       currency
+		UploadSpending      currency
+		FundAccountSpending currency
+		DeleteSpending      currency
+		ListSpending        currency
+	}
+	if err := s.db.
+		WithContext(ctx).
+		Model(&dbContract{}).
+		Select("initial_renter_funds, total_cost, contract_price, upload_spending, fund_account_spending, delete_spending, list_spending").
+		Where("state IN ?", []contractState{contractStatePending, contractStateActive}).
+		Scan(&rows).
+		Error; err != nil {
+		return types.ZeroCurrency, err
+	}
+
+	var locked types.Currency
+	for _, row := range rows {
+		// NOTE: downloads are paid for using ephemeral accounts, their
+		// spending is part of the fund account spending already
+		funds := types.Currency(row.InitialRenterFunds)
+		if funds.IsZero() {
+			funds, _ = types.Currency(row.TotalCost).SubWithUnderflow(types.Currency(row.ContractPrice))
+		}
+		spent := types.Currency(row.UploadSpending).
+			Add(types.Currency(row.FundAccountSpending)).
+			Add(types.Currency(row.DeleteSpending)).
+			Add(types.Currency(row.ListSpending))
+		if remaining, underflow := funds.SubWithUnderflow(spent); !underflow {
+			locked = locked.Add(remaining)
+		}
+	}
+	return locked, nil
+}
+
 func (s *SQLStore) ContractSizes(ctx context.Context) (map[types.FileContractID]api.ContractSize, error) {
 	type size struct {
 		Fcid     fileContractID `json:"fcid"`
@@ -1396,7 +1566,14 @@ func (s *SQLStore) RecordContractSpending(ctx context.Context, records []api.Con
 				DeleteSpending:      types.Currency(contract.DeleteSpending).Add(newSpending.Deletions),
 				ListSpending:        types.Currency(contract.ListSpending).Add(newSpending.SectorRoots),
 			}
-			metrics = append(metrics, m)
+
+			// spending that's paid for using an ephemeral account, like
+			// downloads, isn't tied to a revision so we only record a metric
+			// if we know the contract's latest revision
+			latest, hasRevision := latestValues[fcid]
+			if hasRevision {
+				metrics = append(metrics, m)
+			}
 
 			updates := make(map[string]interface{})
 			if !newSpending.Uploads.IsZero() {
@@ -1414,15 +1591,19 @@ func (s *SQLStore) RecordContractSpending(ctx context.Context, records []api.Con
 			if !newSpending.SectorRoots.IsZero() {
 				updates["list_spending"] = currency(m.ListSpending)
 			}
-			updates["revision_number"] = latestValues[fcid].revision
-			updates["size"] = latestValues[fcid].size
+			if hasRevision {
+				updates["revision_number"] = latest.revision
+				updates["size"] = latest.size
+			}
 			return tx.Model(&contract).Updates(updates).Error
 		})
 		if err != nil {
 			return err
 		}
 	}
-	if err := s.RecordContractMetric(ctx, metrics...); err != nil {
+	if len(metrics) == 0 {
+		return nil
+	} else if err := s.RecordContractMetric(ctx, metrics...); err != nil {
 		s.logger.Errorw("failed to record contract metrics", zap.Error(err))
 	}
 	return nil
@@ -1491,6 +1672,40 @@ func (s *SQLStore) RenameObject(ctx context.Context, bucket, keyOld, keyNew stri
 	})
 }
 
+// MoveObject moves an object from one bucket to another without touching its
+// slabs. If overwrite is set, an existing object at the destination is
+// replaced, otherwise api.ErrObjectExists is returned.
+func (s *SQLStore) MoveObject(ctx context.Context, srcBucket, srcPath, dstBucket, dstPath string, overwrite bool) error {
+	return s.retryTransaction(func(tx *gorm.DB) error {
+		var bucketID uint
+		err := tx.Table("(SELECT id from buckets WHERE buckets.name = ?) bucket_id", dstBucket).
+			Take(&bucketID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("bucket %v not found: %w", dstBucket, api.ErrBucketNotFound)
+		} else if err != nil {
+			return fmt.Errorf("failed to fetch bucket id: %w", err)
+		}
+
+		if overwrite && (srcBucket != dstBucket || srcPath != dstPath) {
+			// delete potentially existing object at destination
+			if _, err := s.deleteObject(tx, dstBucket, dstPath); err != nil {
+				return err
+			}
+		}
+		tx = tx.Exec(`UPDATE objects SET object_id = ?, db_bucket_id = ? WHERE object_id = ? AND ?`, dstPath, bucketID, srcPath, sqlWhereBucket("objects", srcBucket))
+		if tx.Error != nil &&
+			(strings.Contains(tx.Error.Error(), "UNIQUE constraint failed") || strings.Contains(tx.Error.Error(), "Duplicate entry")) {
+			return api.ErrObjectExists
+		} else if tx.Error != nil {
+			return tx.Error
+		}
+		if tx.RowsAffected == 0 {
+			return fmt.Errorf("%w: key %v", api.ErrObjectNotFound, srcPath)
+		}
+		return nil
+	})
+}
+
 func (s *SQLStore) RenameObjects(ctx context.Context, bucket, prefixOld, prefixNew string, force bool) error {
 	return s.retryTransaction(func(tx *gorm.DB) error {
 		if force {
@@ -1692,8 +1907,99 @@ func (s *SQLStore) DeleteHostSector(ctx context.Context, hk types.PublicKey, roo
 	return deletedSectors, err
 }
 
-func (s *SQLStore) UpdateObject(ctx context.Context, bucket, path, contractSet, eTag, mimeType string, metadata api.ObjectUserMetadata, o object.Object) error {
+// UpdateObject stores the given object at the given path. The mode decides
+// what happens if an object already exists at that path, see the
+// api.UploadMode constants. An empty mode overwrites the existing object.
+func (s *SQLStore) UpdateObject(ctx context.Context, bucket, path, contractSet, eTag, mimeType, mode string, metadata api.ObjectUserMetadata, o object.Object) error {
+	// Sanity check input.
+	if err := validateObject(o); err != nil {
+		return err
+	}
+
+	// UpdateObject is ACID.
+	err := s.retryTransaction(func(tx *gorm.DB) error {
+		// Fetch contract set.
+		csID, err := fetchContractSetID(tx, contractSet)
+		if err != nil {
+			return err
+		}
+
+		// Fetch the used contracts.
+		contracts, err := fetchUsedContracts(tx, o.Contracts())
+		if err != nil {
+			return fmt.Errorf("failed to fetch used contracts: %w", err)
+		}
+		return s.updateObject(tx, bucket, path, eTag, mimeType, mode, metadata, o, csID, contracts)
+	})
+	if errors.Is(err, api.ErrObjectExists) && mode == api.UploadModeSkipIfExists {
+		return nil // leave the existing object untouched
+	}
+	return err
+}
+
+// UpdateObjects adds the given objects to the store. The objects are inserted
+// in batches of objectInsertionBatchSize objects per transaction, if a batch
+// fails the objects of the previous batches remain in the store.
+func (s *SQLStore) UpdateObjects(ctx context.Context, bucket, contractSet, mode string, objs map[string]api.AddObjectsEntry) error {
 	// Sanity check input.
+	paths := make([]string, 0, len(objs))
+	for path, o := range objs {
+		if err := validateObject(o.Object); err != nil {
+			return fmt.Errorf("invalid object %v: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for len(paths) > 0 {
+		batch := paths
+		if len(batch) > objectInsertionBatchSize {
+			batch = batch[:objectInsertionBatchSize]
+		}
+		paths = paths[len(batch):]
+
+		// collect the contracts used by the objects in the batch, so we only
+		// have to fetch them once
+		usedContracts := make(map[types.PublicKey]map[types.FileContractID]struct{})
+		for _, path := range batch {
+			for hk, fcids := range objs[path].Object.Contracts() {
+				if _, exists := usedContracts[hk]; !exists {
+					usedContracts[hk] = make(map[types.FileContractID]struct{})
+				}
+				for fcid := range fcids {
+					usedContracts[hk][fcid] = struct{}{}
+				}
+			}
+		}
+
+		if err := s.retryTransaction(func(tx *gorm.DB) error {
+			csID, err := fetchContractSetID(tx, contractSet)
+			if err != nil {
+				return err
+			}
+			contracts, err := fetchUsedContracts(tx, usedContracts)
+			if err != nil {
+				return fmt.Errorf("failed to fetch used contracts: %w", err)
+			}
+			for _, path := range batch {
+				o := objs[path]
+				err := s.updateObject(tx, bucket, path, o.ETag, o.MimeType, mode, o.Metadata, o.Object, csID, contracts)
+				if errors.Is(err, api.ErrObjectExists) && mode == api.UploadModeSkipIfExists {
+					continue // leave the existing object untouched
+				} else if err != nil {
+					return fmt.Errorf("failed to add object %v: %w", path, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateObject verifies that all shards of the object have a contract.
+func validateObject(o object.Object) error {
 	for _, s := range o.Slabs {
 		for i, shard := range s.Shards {
 			// Verify that all hosts have a contract.
@@ -1702,77 +2008,94 @@ func (s *SQLStore) UpdateObject(ctx context.Context, bucket, path, contractSet,
 			}
 		}
 	}
+	return nil
+}
 
-	// collect all used contracts
-	usedContracts := o.Contracts()
+// fetchContractSetID returns the id of the contract set with given name.
+func fetchContractSetID(tx *gorm.DB, contractSet string) (uint, error) {
+	var cs dbContractSet
+	if err := tx.Take(&cs, "name = ?", contractSet).Error; err != nil {
+		return 0, fmt.Errorf("contract set %v not found: %w", contractSet, err)
+	}
+	return cs.ID, nil
+}
 
-	// UpdateObject is ACID.
-	return s.retryTransaction(func(tx *gorm.DB) error {
-		// Fetch contract set.
-		var cs dbContractSet
-		if err := tx.Take(&cs, "name = ?", contractSet).Error; err != nil {
-			return fmt.Errorf("contract set %v not found: %w", contractSet, err)
+// updateObject creates the object at the given path, the used contracts are
+// expected to be fetched by the caller using fetchUsedContracts.
+func (s *SQLStore) updateObject(tx *gorm.DB, bucket, path, eTag, mimeType, mode string, metadata api.ObjectUserMetadata, o object.Object, csID uint, contracts map[types.FileContractID]dbContract) error {
+	if mode == api.UploadModeFailIfExists || mode == api.UploadModeSkipIfExists {
+		// Check whether the object exists, if it does we either fail or
+		// leave it untouched.
+		var count int64
+		if err := tx.Model(&dbObject{}).
+			Where("object_id = ? AND ?", path, sqlWhereBucket("objects", bucket)).
+			Count(&count).
+			Error; err != nil {
+			return fmt.Errorf("failed to check whether object exists: %w", err)
+		} else if count > 0 {
+			return api.ErrObjectExists
 		}
-
-		// Try to delete. We want to get rid of the object and its slices if it
-		// exists.
+	} else {
+		// Try to delete. We want to get rid of the object and its slices
+		// if it exists.
 		//
-		// NOTE: the object's created_at is currently used as its ModTime, if we
-		// ever stop recreating the object but update it instead we need to take
-		// this into account
+		// NOTE: the object's created_at is currently used as its ModTime,
+		// if we ever stop recreating the object but update it instead we
+		// need to take this into account
 		//
-		// NOTE: the metadata is not deleted because this delete will cascade,
-		// if we stop recreating the object we have to make sure to delete the
-		// object's metadata before trying to recreate it
+		// NOTE: the metadata is not deleted because this delete will
+		// cascade, if we stop recreating the object we have to make sure
+		// to delete the object's metadata before trying to recreate it
 		_, err := s.deleteObject(tx, bucket, path)
 		if err != nil {
 			return fmt.Errorf("failed to delete object: %w", err)
 		}
+	}
 
-		// Insert a new object.
-		objKey, err := o.Key.MarshalBinary()
-		if err != nil {
-			return fmt.Errorf("failed to marshal object key: %w", err)
-		}
-		var bucketID uint
-		err = tx.Table("(SELECT id from buckets WHERE buckets.name = ?) bucket_id", bucket).
-			Take(&bucketID).Error
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("bucket %v not found: %w", bucket, api.ErrBucketNotFound)
-		} else if err != nil {
-			return fmt.Errorf("failed to fetch bucket id: %w", err)
-		}
-		obj := dbObject{
-			DBBucketID: bucketID,
-			ObjectID:   path,
-			Key:        objKey,
-			Size:       o.TotalSize(),
-			MimeType:   mimeType,
-			Etag:       eTag,
-		}
-		err = tx.Create(&obj).Error
-		if err != nil {
-			return fmt.Errorf("failed to create object: %w", err)
-		}
-
-		// Fetch the used contracts.
-		contracts, err := fetchUsedContracts(tx, usedContracts)
-		if err != nil {
-			return fmt.Errorf("failed to fetch used contracts: %w", err)
+	// Insert a new object.
+	objKey, err := o.Key.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to marshal object key: %w", err)
+	}
+	var bucketID uint
+	err = tx.Table("(SELECT id from buckets WHERE buckets.name = ?) bucket_id", bucket).
+		Take(&bucketID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("bucket %v not found: %w", bucket, api.ErrBucketNotFound)
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch bucket id: %w", err)
+	}
+	obj := dbObject{
+		DBBucketID: bucketID,
+		ObjectID:   path,
+		Key:        objKey,
+		Size:       o.TotalSize(),
+		MimeType:   mimeType,
+		Etag:       eTag,
+	}
+	err = tx.Create(&obj).Error
+	if err != nil && (strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "Duplicate entry")) {
+		// a concurrent upload created the object in the meantime, unless we
+		// overwrite we're done, otherwise the transaction is retried
+		if mode == api.UploadModeFailIfExists || mode == api.UploadModeSkipIfExists {
+			return api.ErrObjectExists
 		}
+		return errObjectCreatedConcurrently
+	} else if err != nil {
+		return fmt.Errorf("failed to create object: %w", err)
+	}
 
-		// Create all slices. This also creates any missing slabs or sectors.
-		if err := s.createSlices(tx, &obj.ID, nil, cs.ID, contracts, o.Slabs); err != nil {
-			return fmt.Errorf("failed to create slices: %w", err)
-		}
+	// Create all slices. This also creates any missing slabs or sectors.
+	if err := s.createSlices(tx, &obj.ID, nil, csID, contracts, o.Slabs); err != nil {
+		return fmt.Errorf("failed to create slices: %w", err)
+	}
 
-		// Create all user metadata.
-		if err := s.createUserMetadata(tx, obj.ID, metadata); err != nil {
-			return fmt.Errorf("failed to create user metadata: %w", err)
-		}
+	// Create all user metadata.
+	if err := s.createUserMetadata(tx, obj.ID, metadata); err != nil {
+		return fmt.Errorf("failed to create user metadata: %w", err)
+	}
 
-		return nil
-	})
+	return nil
 }
 
 func (s *SQLStore) RemoveObject(ctx context.Context, bucket, key string) error {
@@ -1791,17 +2114,21 @@ func (s *SQLStore) RemoveObject(ctx context.Context, bucket, key string) error {
 	return nil
 }
 
-func (s *SQLStore) RemoveObjects(ctx context.Context, bucket, prefix string) error {
-	var rowsAffected int64
-	var err error
-	rowsAffected, err = s.deleteObjects(bucket, prefix)
+// RemoveObjects removes all objects with the given prefix. Objects that can't
+// be removed are skipped and reported in the response while the removal of
+// the other objects is committed.
+func (s *SQLStore) RemoveObjects(ctx context.Context, bucket, prefix string) (api.ObjectsRemoveResponse, error) {
+	removed, failed, err := s.deleteObjects(bucket, prefix)
 	if err != nil {
-		return err
+		return api.ObjectsRemoveResponse{}, err
 	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("%w: prefix: %s", api.ErrObjectNotFound, prefix)
+	if removed == 0 && len(failed) == 0 {
+		return api.ObjectsRemoveResponse{}, fmt.Errorf("%w: prefix: %s", api.ErrObjectNotFound, prefix)
 	}
-	return nil
+	return api.ObjectsRemoveResponse{
+		Removed: removed,
+		Failed:  failed,
+	}, nil
 }
 
 func (s *SQLStore) Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error) {
@@ -1819,6 +2146,121 @@ func (s *SQLStore) Slab(ctx context.Context, key object.EncryptionKey) (object.S
 	return slab.convert()
 }
 
+// SlabInfo returns the slab with the given key together with its freshly
+// computed health and the objects that reference it across all buckets.
+func (s *SQLStore) SlabInfo(ctx context.Context, key object.EncryptionKey) (info api.SlabInfo, err error) {
+	info.Slab, err = s.Slab(ctx, key)
+	if err != nil {
+		return api.SlabInfo{}, err
+	}
+	k, err := key.MarshalBinary()
+	if err != nil {
+		return api.SlabInfo{}, err
+	}
+
+	// compute the health, a slab without sectors has no row in the health
+	// query, in which case it's considered to be lost
+	var healths []float64
+	if err := s.db.
+		WithContext(ctx).
+		Raw(fmt.Sprintf("SELECT health FROM (%s) h", fmt.Sprintf(slabHealthQuery, "slabs.key = ?")), k).
+		Scan(&healths).
+		Error; err != nil {
+		return api.SlabInfo{}, err
+	}
+	info.Health = -1
+	if len(healths) > 0 {
+		info.Health = healths[0]
+	}
+
+	// fetch the referencing objects
+	if err := s.db.
+		WithContext(ctx).
+		Raw(`
+SELECT DISTINCT b.name as Bucket, obj.object_id as Name
+FROM slabs sla
+INNER JOIN slices sli ON sli.db_slab_id = sla.id
+INNER JOIN objects obj ON sli.db_object_id = obj.id
+INNER JOIN buckets b ON obj.db_bucket_id = b.id
+WHERE sla.key = ?
+ORDER BY b.name, obj.object_id
+`, k).
+		Scan(&info.Objects).
+		Error; err != nil {
+		return api.SlabInfo{}, err
+	}
+	return
+}
+
+// RepointSlices updates all slices that reference the slab with key 'oldKey'
+// to reference the slab with key 'newKey' instead, preserving their offsets and
+// lengths. Both slabs need to have the same number of min shards since that
+// determines the amount of data in a slab. The old slab is pruned if it's no
+// longer referenced afterwards.
+func (s *SQLStore) RepointSlices(ctx context.Context, oldKey, newKey object.EncryptionKey) (repointed int, err error) {
+	oldK, err := oldKey.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	newK, err := newKey.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.retryTransaction(func(tx *gorm.DB) error {
+		// fetch both slabs
+		var oldSlab, newSlab dbSlab
+		if err := tx.Where(&dbSlab{Key: oldK}).Take(&oldSlab).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %v", api.ErrSlabNotFound, oldKey)
+		} else if err != nil {
+			return err
+		}
+		if err := tx.Where(&dbSlab{Key: newK}).Take(&newSlab).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %v", api.ErrSlabNotFound, newKey)
+		} else if err != nil {
+			return err
+		}
+
+		// sanity check the slabs are compatible
+		if oldSlab.ID == newSlab.ID {
+			return fmt.Errorf("%w: old and new slab are the same", api.ErrIncompatibleSlab)
+		} else if oldSlab.DBBufferedSlabID != 0 || newSlab.DBBufferedSlabID != 0 {
+			return fmt.Errorf("%w: slices of buffered slabs can't be repointed", api.ErrIncompatibleSlab)
+		} else if oldSlab.MinShards != newSlab.MinShards {
+			return fmt.Errorf("%w: min shards %d != %d", api.ErrIncompatibleSlab, oldSlab.MinShards, newSlab.MinShards)
+		}
+
+		// repoint the slices
+		res := tx.Model(&dbSlice{}).
+			Where("db_slab_id = ?", oldSlab.ID).
+			Update("db_slab_id", newSlab.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		repointed = int(res.RowsAffected)
+		if repointed == 0 {
+			return nil
+		}
+
+		// update the health of the affected objects
+		if err := tx.Exec(`
+UPDATE objects
+SET health = (
+	SELECT COALESCE(MIN(slabs.health), 1)
+	FROM slabs
+	INNER JOIN slices sli ON sli.db_slab_id = slabs.id
+	WHERE sli.db_object_id = objects.id)
+WHERE id IN (SELECT db_object_id FROM slices WHERE db_slab_id = ?)
+`, newSlab.ID).Error; err != nil {
+			return err
+		}
+
+		// prune the old slab
+		return pruneSlabs(tx)
+	})
+	return
+}
+
 func (ss *SQLStore) UpdateSlab(ctx context.Context, s object.Slab, contractSet string) error {
 	// sanity check the shards don't contain an empty root
 	for _, s := range s.Shards {
@@ -1943,20 +2385,10 @@ func (ss *SQLStore) UpdateSlab(ctx context.Context, s object.Slab, contractSet s
 	})
 }
 
-func (s *SQLStore) RefreshHealth(ctx context.Context) error {
-	var nSlabs int64
-	if err := s.db.Model(&dbSlab{}).Count(&nSlabs).Error; err != nil {
-		return err
-	}
-	if nSlabs == 0 {
-		return nil // nothing to do
-	}
-
-	// Update slab health in batches.
-	now := time.Now()
-
-	// build health query
-	healthQuery := s.db.Raw(`
+// slabHealthQuery computes the health of the slabs matching the where clause
+// that is formatted into it. A slab's health is based on the number of distinct
+// hosts in its contract set that store one of its sectors.
+const slabHealthQuery = `
 SELECT slabs.id, slabs.db_contract_set_id, CASE WHEN (slabs.min_shards = slabs.total_shards)
 THEN
     CASE WHEN (COUNT(DISTINCT(CASE WHEN cs.name IS NULL THEN NULL ELSE c.host_id END)) < slabs.min_shards)
@@ -1971,10 +2403,23 @@ LEFT JOIN contract_sectors se ON s.id = se.db_sector_id
 LEFT JOIN contracts c ON se.db_contract_id = c.id
 LEFT JOIN contract_set_contracts csc ON csc.db_contract_id = c.id AND csc.db_contract_set_id = slabs.db_contract_set_id
 LEFT JOIN contract_sets cs ON cs.id = csc.db_contract_set_id
-WHERE slabs.health_valid_until <= ?
-GROUP BY slabs.id
-LIMIT ?
-`, now.Unix(), refreshHealthBatchSize)
+WHERE %s
+GROUP BY slabs.id`
+
+func (s *SQLStore) RefreshHealth(ctx context.Context) error {
+	var nSlabs int64
+	if err := s.db.Model(&dbSlab{}).Count(&nSlabs).Error; err != nil {
+		return err
+	}
+	if nSlabs == 0 {
+		return nil // nothing to do
+	}
+
+	// Update slab health in batches.
+	now := time.Now()
+
+	// build health query
+	healthQuery := s.db.Raw(fmt.Sprintf(slabHealthQuery, "slabs.health_valid_until <= ?")+"\nLIMIT ?", now.Unix(), refreshHealthBatchSize)
 
 	for {
 		var rowsAffected int64
@@ -2015,6 +2460,68 @@ UPDATE objects SET health = (
 	}
 }
 
+// RecomputeObjectSizes recalculates the size of all objects from their slices
+// and corrects the stored size of objects where the two don't match. It returns
+// the number of objects that were corrected.
+func (s *SQLStore) RecomputeObjectSizes(ctx context.Context) (int, error) {
+	return recomputeObjectSizes(ctx, s.db, recomputeObjectSizesBatchSize, s.logger)
+}
+
+func recomputeObjectSizes(ctx context.Context, db *gorm.DB, batchSize int, logger *zap.SugaredLogger) (fixed int, _ error) {
+	var lastID uint
+	for {
+		select {
+		case <-ctx.Done():
+			return fixed, ctx.Err()
+		default:
+		}
+
+		// fetch the stored and the actual size of the next batch of objects
+		var rows []struct {
+			ID       uint
+			ObjectID string
+			Size     int64
+			Computed int64
+		}
+		if err := db.WithContext(ctx).Raw(`
+SELECT o.id, o.object_id, o.size, COALESCE(SUM(sli.length), 0) AS computed
+FROM objects o
+LEFT JOIN slices sli ON sli.db_object_id = o.id
+WHERE o.id > ?
+GROUP BY o.id
+ORDER BY o.id
+LIMIT ?`, lastID, batchSize).
+			Scan(&rows).Error; err != nil {
+			return fixed, fmt.Errorf("failed to fetch object sizes: %w", err)
+		} else if len(rows) == 0 {
+			return fixed, nil // done
+		}
+		lastID = rows[len(rows)-1].ID
+
+		// correct the sizes that drifted
+		var corrected int
+		if err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			corrected = 0
+			for _, row := range rows {
+				if row.Size == row.Computed {
+					continue
+				}
+				if err := tx.Model(&dbObject{}).
+					Where("id", row.ID).
+					Update("size", row.Computed).Error; err != nil {
+					return err
+				}
+				logger.Warnw("corrected object size", "objectID", row.ObjectID, "stored", row.Size, "computed", row.Computed)
+				corrected++
+			}
+			return nil
+		}); err != nil {
+			return fixed, fmt.Errorf("failed to update object sizes: %w", err)
+		}
+		fixed += corrected
+	}
+}
+
 // UnhealthySlabs returns up to 'limit' slabs that do not reach full redundancy
 // in the given contract set. These slabs need to be migrated to good contracts
 // so they are restored to full health.
@@ -2614,13 +3121,18 @@ func contractsForHost(tx *gorm.DB, host dbHost) (contracts []dbContract, err err
 	return
 }
 
-func newContract(hostID uint, fcid, renewedFrom types.FileContractID, contractPrice, totalCost types.Currency, startHeight, windowStart, windowEnd, size uint64, state contractState) dbContract {
+func newContract(hostID uint, c rhpv2.ContractRevision, renewedFrom types.FileContractID, contractPrice, totalCost types.Currency, startHeight, size uint64, state contractState) dbContract {
+	var renterFunds types.Currency
+	if len(c.Revision.ValidProofOutputs) > 0 {
+		renterFunds = c.Revision.ValidRenterPayout()
+	}
 	return dbContract{
-		HostID:       hostID,
-		ContractSets: nil, // new contract isn't in a set yet
+		HostID:             hostID,
+		InitialRenterFunds: currency(renterFunds),
+		ContractSets:       nil, // new contract isn't in a set yet
 
 		ContractCommon: ContractCommon{
-			FCID:        fileContractID(fcid),
+			FCID:        fileContractID(c.ID()),
 			RenewedFrom: fileContractID(renewedFrom),
 
 			ContractPrice:  currency(contractPrice),
@@ -2629,8 +3141,8 @@ func newContract(hostID uint, fcid, renewedFrom types.FileContractID, contractPr
 			RevisionNumber: "0",
 			Size:           size,
 			StartHeight:    startHeight,
-			WindowStart:    windowStart,
-			WindowEnd:      windowEnd,
+			WindowStart:    c.Revision.WindowStart,
+			WindowEnd:      c.Revision.WindowEnd,
 
 			UploadSpending:      zeroCurrency,
 			DownloadSpending:    zeroCurrency,
@@ -2643,8 +3155,6 @@ func newContract(hostID uint, fcid, renewedFrom types.FileContractID, contractPr
 
 // addContract adds a contract to the store.
 func addContract(tx *gorm.DB, c rhpv2.ContractRevision, contractPrice, totalCost types.Currency, startHeight uint64, renewedFrom types.FileContractID, state contractState) (dbContract, error) {
-	fcid := c.ID()
-
 	// Find host.
 	var host dbHost
 	err := tx.Model(&dbHost{}).Where(&dbHost{PublicKey: publicKey(c.HostKey())}).
@@ -2654,7 +3164,7 @@ func addContract(tx *gorm.DB, c rhpv2.ContractRevision, contractPrice, totalCost
 	}
 
 	// Create contract.
-	contract := newContract(host.ID, fcid, renewedFrom, contractPrice, totalCost, startHeight, c.Revision.WindowStart, c.Revision.WindowEnd, c.Revision.Filesize, state)
+	contract := newContract(host.ID, c, renewedFrom, contractPrice, totalCost, startHeight, c.Revision.Filesize, state)
 
 	// Insert contract.
 	err = tx.Create(&contract).Error
@@ -2740,7 +3250,17 @@ func (s *SQLStore) deleteObject(tx *gorm.DB, bucket string, path string) (int64,
 // deletion goes from largest to smallest. That's because the batch size is
 // dynamically increased and the smaller objects get the faster we can delete
 // them meaning it makes sense to increase the batch size over time.
-func (s *SQLStore) deleteObjects(bucket string, path string) (numDeleted int64, _ error) {
+func (s *SQLStore) deleteObjects(bucket string, path string) (numDeleted int64, failed []api.ObjectRemoveFailure, _ error) {
+	// failedIDs contains the ids of objects that couldn't be deleted, they are
+	// excluded from subsequent batches
+	var failedIDs []uint
+	whereNotFailed := func() interface{} {
+		if len(failedIDs) == 0 {
+			return gorm.Expr("TRUE")
+		}
+		return gorm.Expr("id NOT IN (?)", failedIDs)
+	}
+
 	batchSizeIdx := 0
 	for {
 		var duration time.Duration
@@ -2752,12 +3272,12 @@ func (s *SQLStore) deleteObjects(bucket string, path string) (numDeleted int64,
 			WHERE id IN (
 				SELECT id FROM (
 					SELECT id FROM objects
-					WHERE object_id LIKE ? AND SUBSTR(object_id, 1, ?) = ? AND ?
+					WHERE object_id LIKE ? AND SUBSTR(object_id, 1, ?) = ? AND ? AND ?
 					ORDER BY size DESC
 					LIMIT ?
 					) tmp
 				)`,
-				path+"%", utf8.RuneCountInString(path), path, sqlWhereBucket("objects", bucket),
+				path+"%", utf8.RuneCountInString(path), path, sqlWhereBucket("objects", bucket), whereNotFailed(),
 				objectDeleteBatchSizes[batchSizeIdx])
 			if err := res.Error; err != nil {
 				return res.Error
@@ -2770,7 +3290,21 @@ func (s *SQLStore) deleteObjects(bucket string, path string) (numDeleted int64,
 			duration = time.Since(start)
 			return nil
 		}); err != nil {
-			return 0, fmt.Errorf("failed to delete objects: %w", err)
+			// the batch failed, delete its objects one by one to figure out
+			// which ones can't be deleted
+			deleted, batchFailures, indErr := s.deleteObjectsIndividually(bucket, path, whereNotFailed(), objectDeleteBatchSizes[batchSizeIdx])
+			if indErr != nil {
+				return 0, nil, fmt.Errorf("failed to delete objects: %w", indErr)
+			} else if deleted == 0 && len(batchFailures) == 0 {
+				return 0, nil, fmt.Errorf("failed to delete objects: %w", err) // no progress
+			}
+			for _, f := range batchFailures {
+				failedIDs = append(failedIDs, f.id)
+				failed = append(failed, f.ObjectRemoveFailure)
+			}
+			numDeleted += deleted
+			batchSizeIdx = 0
+			continue
 		}
 
 		// if nothing got deleted we are done
@@ -2784,7 +3318,48 @@ func (s *SQLStore) deleteObjects(bucket string, path string) (numDeleted int64,
 			batchSizeIdx++
 		}
 	}
-	return numDeleted, nil
+	return numDeleted, failed, nil
+}
+
+type objectRemoveFailure struct {
+	api.ObjectRemoveFailure
+	id uint
+}
+
+// deleteObjectsIndividually deletes a batch of objects with the given prefix
+// one by one, objects that can't be deleted are returned.
+func (s *SQLStore) deleteObjectsIndividually(bucket, path string, whereNotFailed interface{}, limit int64) (numDeleted int64, failed []objectRemoveFailure, _ error) {
+	var objects []struct {
+		ID       uint
+		ObjectID string
+	}
+	if err := s.db.
+		Raw(`
+		SELECT id, object_id FROM objects
+		WHERE object_id LIKE ? AND SUBSTR(object_id, 1, ?) = ? AND ? AND ?
+		ORDER BY size DESC
+		LIMIT ?`,
+			path+"%", utf8.RuneCountInString(path), path, sqlWhereBucket("objects", bucket), whereNotFailed, limit).
+		Scan(&objects).
+		Error; err != nil {
+		return 0, nil, err
+	}
+
+	for _, o := range objects {
+		var deleted int64
+		if err := s.retryTransaction(func(tx *gorm.DB) (err error) {
+			deleted, err = s.deleteObject(tx, bucket, o.ObjectID)
+			return
+		}); err != nil {
+			failed = append(failed, objectRemoveFailure{
+				ObjectRemoveFailure: api.ObjectRemoveFailure{Path: o.ObjectID, Error: err.Error()},
+				id:                  o.ID,
+			})
+			continue
+		}
+		numDeleted += deleted
+	}
+	return numDeleted, failed, nil
 }
 
 func invalidateSlabHealthByFCID(ctx context.Context, tx *gorm.DB, fcids []fileContractID) error {
@@ -10,6 +10,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -767,6 +768,86 @@ func TestRenewedContract(t *testing.T) {
 	if renewedContract.RenewedFrom != fcid1Renewed {
 		t.Fatal("unexpected")
 	}
+
+	// The end height didn't change, so the contract was archived as
+	// refreshed rather than renewed.
+	err = ss.db.Model(&dbArchivedContract{}).
+		Where("fcid", fileContractID(fcid1Renewed)).
+		Take(&ac).
+		Error
+	if err != nil {
+		t.Fatal(err)
+	} else if ac.Reason != api.ContractArchivalReasonRefreshed {
+		t.Fatal("unexpected reason", ac.Reason)
+	}
+
+	// Assert a renewal metric was recorded for both renewals.
+	metrics, err := ss.ContractRenewalMetrics(ctx, time.UnixMilli(0), 1, time.Hour*24*365*100, api.ContractRenewalMetricsQueryOpts{Kind: api.ContractArchivalReasonRenewed})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %v", len(metrics))
+	} else if m := metrics[0]; m.ContractID != fcid1Renewed || m.RenewedFrom != fcid1 || m.HostKey != hk {
+		t.Fatal("unexpected metric", m)
+	}
+	metrics, err = ss.ContractRenewalMetrics(ctx, time.UnixMilli(0), 1, time.Hour*24*365*100, api.ContractRenewalMetricsQueryOpts{Kind: api.ContractArchivalReasonRefreshed})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %v", len(metrics))
+	} else if m := metrics[0]; m.ContractID != fcid3 || m.RenewedFrom != fcid1Renewed || m.HostKey != hk {
+		t.Fatal("unexpected metric", m)
+	}
+}
+
+func TestContractForHost(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add two hosts
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2 := hks[0], hks[1]
+
+	// assert the first host has no contract
+	_, err = ss.ContractForHost(context.Background(), hk1)
+	if !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("expected contract not found error", err)
+	}
+
+	// add two contracts with the first host and one with the second one
+	for i, hk := range []types.PublicKey{hk1, hk1, hk2} {
+		fcid := types.FileContractID{byte(i + 1)}
+		if _, err := ss.AddContract(context.Background(), testContractRevision(fcid, hk), types.ZeroCurrency, types.ZeroCurrency, uint64(i), api.ContractStatePending); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert we get the most recent contract for the first host
+	c, err := ss.ContractForHost(context.Background(), hk1)
+	if err != nil {
+		t.Fatal(err)
+	} else if c.ID != (types.FileContractID{2}) || c.HostKey != hk1 {
+		t.Fatal("unexpected contract", c.ID, c.HostKey)
+	}
+
+	// archive it and assert we get the other one
+	if err := ss.ArchiveContract(context.Background(), c.ID, api.ContractArchivalReasonRemoved); err != nil {
+		t.Fatal(err)
+	} else if c, err := ss.ContractForHost(context.Background(), hk1); err != nil {
+		t.Fatal(err)
+	} else if c.ID != (types.FileContractID{1}) {
+		t.Fatal("unexpected contract", c.ID)
+	}
+
+	// assert the second host's contract is returned
+	if c, err := ss.ContractForHost(context.Background(), hk2); err != nil {
+		t.Fatal(err)
+	} else if c.ID != (types.FileContractID{3}) || c.HostKey != hk2 {
+		t.Fatal("unexpected contract", c.ID, c.HostKey)
+	}
 }
 
 // TestAncestorsContracts verifies that AncestorContracts returns the right
@@ -806,6 +887,8 @@ func TestAncestorsContracts(t *testing.T) {
 		if !reflect.DeepEqual(contracts[i], api.ArchivedContract{
 			ID:          fcids[len(fcids)-2-i],
 			HostKey:     hk,
+			Reason:      api.ContractArchivalReasonRefreshed,
+			RenewedFrom: fcids[len(fcids)-3-i],
 			RenewedTo:   fcids[len(fcids)-1-i],
 			StartHeight: 2,
 			Size:        4096,
@@ -872,6 +955,65 @@ func TestArchiveContracts(t *testing.T) {
 	}
 }
 
+func TestArchivedContracts(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add 2 hosts and a contract with each of them
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// renew the first contract and archive all contracts
+	renewed := types.FileContractID{9}
+	if _, err := ss.addTestRenewedContract(renewed, fcids[0], hks[0], 1); err != nil {
+		t.Fatal(err)
+	} else if err := ss.ArchiveContract(context.Background(), renewed, api.ContractArchivalReasonHostPruned); err != nil {
+		t.Fatal(err)
+	} else if err := ss.ArchiveContract(context.Background(), fcids[1], api.ContractArchivalReasonRemoved); err != nil {
+		t.Fatal(err)
+	}
+
+	assertArchived := func(opts api.ArchivedContractsOpts, expected ...types.FileContractID) []api.ArchivedContract {
+		t.Helper()
+		contracts, err := ss.ArchivedContracts(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(contracts) != len(expected) {
+			t.Fatalf("expected %d contracts, got %d", len(expected), len(contracts))
+		}
+		for i, c := range contracts {
+			if c.ID != expected[i] {
+				t.Fatalf("expected contract %v, got %v", expected[i], c.ID)
+			}
+		}
+		return contracts
+	}
+
+	// assert filtering by host
+	assertArchived(api.ArchivedContractsOpts{}, fcids[0], renewed, fcids[1])
+	contracts := assertArchived(api.ArchivedContractsOpts{HostKey: hks[0]}, fcids[0], renewed)
+	assertArchived(api.ArchivedContractsOpts{HostKey: hks[1]}, fcids[1])
+	assertArchived(api.ArchivedContractsOpts{HostKey: types.PublicKey{9}})
+
+	// assert the renewal chain can be traced back
+	if contracts[1].RenewedFrom != fcids[0] || contracts[0].RenewedTo != renewed {
+		t.Fatal("unexpected renewal linkage", contracts)
+	} else if contracts[0].Reason != api.ContractArchivalReasonRefreshed {
+		t.Fatal("unexpected reason", contracts[0].Reason)
+	}
+
+	// assert filtering by reason
+	assertArchived(api.ArchivedContractsOpts{Reason: api.ContractArchivalReasonRemoved}, fcids[1])
+	assertArchived(api.ArchivedContractsOpts{Reason: api.ContractArchivalReasonHostPruned}, renewed)
+	assertArchived(api.ArchivedContractsOpts{HostKey: hks[1], Reason: api.ContractArchivalReasonHostPruned})
+}
+
 func testContractRevision(fcid types.FileContractID, hk types.PublicKey) rhpv2.ContractRevision {
 	uc := generateMultisigUC(1, 2, "salt")
 	uc.PublicKeys[1].Key = hk[:]
@@ -1091,6 +1233,7 @@ func TestSQLMetadataStore(t *testing.T) {
 						Host: dbHost{
 							PublicKey: publicKey(hk1),
 						},
+						InitialRenterFunds: currency(types.NewCurrency64(121)),
 
 						ContractCommon: ContractCommon{
 							FCID: fileContractID(fcid1),
@@ -1131,6 +1274,7 @@ func TestSQLMetadataStore(t *testing.T) {
 						Host: dbHost{
 							PublicKey: publicKey(hk2),
 						},
+						InitialRenterFunds: currency(types.NewCurrency64(121)),
 						ContractCommon: ContractCommon{
 							FCID: fileContractID(fcid2),
 
@@ -1168,6 +1312,7 @@ func TestSQLMetadataStore(t *testing.T) {
 		slabs[i].Shards[0].Contracts[0].Model = Model{}
 		slabs[i].Shards[0].Contracts[0].Host.Model = Model{}
 		slabs[i].Shards[0].Contracts[0].Host.LastAnnouncement = time.Time{}
+		slabs[i].Shards[0].Contracts[0].Host.LastSeen = 0
 		slabs[i].HealthValidUntil = 0
 	}
 	if !reflect.DeepEqual(slab1, expectedObjSlab1) {
@@ -2223,6 +2368,52 @@ func TestUpdateSlab(t *testing.T) {
 	}
 }
 
+func TestRecomputeObjectSizes(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a few objects, including an empty one
+	objs := map[string]object.Object{
+		"/foo": newTestObject(1),
+		"/bar": newTestObject(2),
+		"/baz": newTestObject(0),
+	}
+	for path, o := range objs {
+		if _, err := ss.addTestObject(path, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert nothing needs fixing
+	if fixed, err := ss.RecomputeObjectSizes(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if fixed != 0 {
+		t.Fatal("unexpected number of fixed objects", fixed)
+	}
+
+	// corrupt the size of all objects
+	if err := ss.db.Model(&dbObject{}).Where("1 = 1").Update("size", 1).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// recompute the sizes in batches of one object
+	if fixed, err := recomputeObjectSizes(context.Background(), ss.db, 1, ss.logger); err != nil {
+		t.Fatal(err)
+	} else if fixed != len(objs) {
+		t.Fatal("unexpected number of fixed objects", fixed)
+	}
+
+	// assert the sizes were corrected
+	for path, o := range objs {
+		obj, err := ss.Object(context.Background(), api.DefaultBucketName, path)
+		if err != nil {
+			t.Fatal(err)
+		} else if obj.Size != o.TotalSize() {
+			t.Fatalf("unexpected size for %v, %v != %v", path, obj.Size, o.TotalSize())
+		}
+	}
+}
+
 func newTestObject(slabs int) object.Object {
 	obj := object.Object{}
 
@@ -2325,6 +2516,82 @@ func TestRecordContractSpending(t *testing.T) {
 	if cm3.Spending != expectedSpending {
 		t.Fatal("invalid spending")
 	}
+
+	// assert records without a revision didn't reset the revision and size
+	if cm3.RevisionNumber != cm.RevisionNumber || cm3.Size != cm.Size {
+		t.Fatal("unexpected revision or size", cm3.RevisionNumber, cm3.Size)
+	}
+
+	// assert records without a revision didn't emit a contract metric
+	var n int64
+	if err := ss.dbMetrics.Model(&dbContractMetric{}).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatal("expected no contract metrics", n)
+	}
+}
+
+func TestLockedFunds(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// no contracts, no locked funds
+	ctx := context.Background()
+	if locked, err := ss.LockedFunds(ctx); err != nil {
+		t.Fatal(err)
+	} else if !locked.IsZero() {
+		t.Fatal("expected no locked funds", locked)
+	}
+
+	// add three hosts
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add a pending, an active and a complete contract, the renter funds
+	// exclude the contract price and 2SC worth of fees
+	states := []string{api.ContractStatePending, api.ContractStateActive, api.ContractStateComplete}
+	for i, state := range states {
+		rev := testContractRevision(types.FileContractID{byte(i + 1)}, hks[i])
+		rev.Revision.ValidProofOutputs[0].Value = types.Siacoins(7)
+		if _, err := ss.AddContract(ctx, rev, types.Siacoins(1), types.Siacoins(10), 0, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// record some spending on the active contract
+	if err := ss.RecordContractSpending(ctx, []api.ContractSpendingRecord{
+		{
+			ContractID: types.FileContractID{2},
+			ContractSpending: api.ContractSpending{
+				Uploads:     types.Siacoins(1),
+				FundAccount: types.Siacoins(1),
+				Downloads:   types.Siacoins(1),
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the complete contract is ignored, the fees are excluded and the
+	// spending is deducted, downloads are paid for by the funded account so
+	// they're not deducted twice
+	if locked, err := ss.LockedFunds(ctx); err != nil {
+		t.Fatal(err)
+	} else if !locked.Equals(types.Siacoins(12)) {
+		t.Fatalf("unexpected locked funds %v", locked)
+	}
+
+	// reset the initial renter funds and assert we fall back to the total
+	// cost minus the contract price for contracts that predate them
+	if err := ss.db.Exec("UPDATE contracts SET initial_renter_funds = '0'").Error; err != nil {
+		t.Fatal(err)
+	} else if locked, err := ss.LockedFunds(ctx); err != nil {
+		t.Fatal(err)
+	} else if !locked.Equals(types.Siacoins(16)) {
+		t.Fatalf("unexpected locked funds %v", locked)
+	}
 }
 
 // TestRenameObjects is a unit test for RenameObject and RenameObjects.
@@ -2536,6 +2803,62 @@ func TestObjectsStats(t *testing.T) {
 	}
 }
 
+func TestObjectsHealth(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add three objects and update their health
+	ctx := context.Background()
+	for i, health := range []float64{0.9, 0.1, 0.5} {
+		name := fmt.Sprintf("obj%d", i)
+		if _, err := ss.addTestObject(name, newTestObject(1)); err != nil {
+			t.Fatal(err)
+		} else if err := ss.db.
+			Model(&dbObject{}).
+			Where("object_id", name).
+			Update("health", health).
+			Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert the objects are ordered by health and the unsafe ones are counted
+	resp, err := ss.ObjectsHealth(ctx, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.NumUnsafe != 2 {
+		t.Fatal("expected 2 unsafe objects", resp.NumUnsafe)
+	} else if len(resp.Objects) != 3 {
+		t.Fatal("expected 3 objects", len(resp.Objects))
+	}
+	for i, expected := range []api.ObjectHealth{
+		{Bucket: api.DefaultBucketName, Name: "obj1", Health: 0.1},
+		{Bucket: api.DefaultBucketName, Name: "obj2", Health: 0.5},
+		{Bucket: api.DefaultBucketName, Name: "obj0", Health: 0.9},
+	} {
+		got := resp.Objects[i]
+		if got.Bucket != expected.Bucket || got.Name != expected.Name || got.Health != expected.Health {
+			t.Fatalf("unexpected object at index %d: %+v", i, got)
+		} else if got.Size == 0 {
+			t.Fatal("expected size to be set")
+		}
+	}
+
+	// assert pagination
+	if resp, err := ss.ObjectsHealth(ctx, 1, 1); err != nil {
+		t.Fatal(err)
+	} else if len(resp.Objects) != 1 || resp.Objects[0].Name != "obj2" {
+		t.Fatal("unexpected objects", resp.Objects)
+	} else if resp.NumUnsafe != 2 {
+		t.Fatal("expected 2 unsafe objects", resp.NumUnsafe)
+	}
+
+	// assert a negative offset is rejected
+	if _, err := ss.ObjectsHealth(ctx, -1, -1); !errors.Is(err, ErrNegativeOffset) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
 func TestPartialSlab(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -3026,6 +3349,63 @@ func (s *SQLStore) dbSlab(key []byte) (dbSlab, error) {
 	return slab, nil
 }
 
+func TestRemoveObjectsPartialFailure(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	if !isSQLite(ss.db) {
+		t.Skip("test relies on a SQLite trigger")
+	}
+
+	// add some objects
+	ctx := context.Background()
+	for _, path := range []string{"/foo/a", "/foo/b", "/foo/bad", "/bar"} {
+		if _, err := ss.addTestObject(path, newTestObject(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// prevent one of them from being deleted
+	if err := ss.db.Exec(`CREATE TRIGGER prevent_delete BEFORE DELETE ON objects
+WHEN OLD.object_id = '/foo/bad'
+BEGIN SELECT RAISE(ABORT, 'object is locked'); END`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// remove the objects with prefix '/foo/', the failure should be reported
+	// and the other objects should be removed
+	res, err := ss.RemoveObjects(ctx, api.DefaultBucketName, "/foo/")
+	if err != nil {
+		t.Fatal(err)
+	} else if res.Removed != 2 {
+		t.Fatal("unexpected number of removed objects", res.Removed)
+	} else if len(res.Failed) != 1 || res.Failed[0].Path != "/foo/bad" || !strings.Contains(res.Failed[0].Error, "object is locked") {
+		t.Fatal("unexpected failures", res.Failed)
+	}
+	for path, exists := range map[string]bool{"/foo/a": false, "/foo/b": false, "/foo/bad": true, "/bar": true} {
+		if _, err := ss.Object(ctx, api.DefaultBucketName, path); exists && err != nil {
+			t.Fatal(err)
+		} else if !exists && !errors.Is(err, api.ErrObjectNotFound) {
+			t.Fatalf("expected object %v to be removed, got %v", path, err)
+		}
+	}
+
+	// drop the trigger and try again
+	if err := ss.db.Exec("DROP TRIGGER prevent_delete").Error; err != nil {
+		t.Fatal(err)
+	}
+	res, err = ss.RemoveObjects(ctx, api.DefaultBucketName, "/foo/")
+	if err != nil {
+		t.Fatal(err)
+	} else if res.Removed != 1 || len(res.Failed) != 0 {
+		t.Fatal("unexpected response", res)
+	}
+
+	// nothing left to remove
+	if _, err := ss.RemoveObjects(ctx, api.DefaultBucketName, "/foo/"); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
 func TestObjectsBySlabKey(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -3088,6 +3468,174 @@ func TestObjectsBySlabKey(t *testing.T) {
 	}
 }
 
+func TestSlabInfo(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add test hosts
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add test contracts & set them as contract set
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ss.SetContractSet(context.Background(), testContractSet, fcids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create a slab
+	slab := object.Slab{
+		Key:       object.GenerateEncryptionKey(),
+		MinShards: 1,
+		Shards: []object.Sector{
+			newTestShard(hks[0], fcids[0], types.Hash256{1}),
+			newTestShard(hks[1], fcids[1], types.Hash256{2}),
+		},
+	}
+	obj := object.Object{
+		Key:   object.GenerateEncryptionKey(),
+		Slabs: []object.SlabSlice{{Slab: slab, Length: 1}},
+	}
+
+	// add an object referencing the slab to two different buckets
+	if _, err := ss.addTestObject("/foo", obj); err != nil {
+		t.Fatal(err)
+	} else if err := ss.CreateBucket(context.Background(), "other", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateObject(context.Background(), "other", "/bar", testContractSet, testETag, testMimeType, api.UploadModeOverwrite, testMetadata, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	// fetch the slab info
+	info, err := ss.SlabInfo(context.Background(), slab.Key)
+	if err != nil {
+		t.Fatal(err)
+	} else if info.Slab.MinShards != 1 || len(info.Slab.Shards) != 2 {
+		t.Fatal("unexpected slab", info.Slab)
+	} else if info.Slab.Shards[0].Root != (types.Hash256{1}) || info.Slab.Shards[1].Root != (types.Hash256{2}) {
+		t.Fatal("unexpected roots")
+	} else if _, ok := info.Slab.Shards[1].Contracts[hks[1]]; !ok {
+		t.Fatal("missing host")
+	} else if info.Health != 1 {
+		t.Fatal("unexpected health", info.Health)
+	} else if !reflect.DeepEqual(info.Objects, []api.SlabObject{
+		{Bucket: api.DefaultBucketName, Name: "/foo"},
+		{Bucket: "other", Name: "/bar"},
+	}) {
+		t.Fatal("unexpected objects", info.Objects)
+	}
+
+	// remove a contract from the set, the computed health should reflect
+	// that even though the cached health wasn't refreshed yet
+	err = ss.SetContractSet(context.Background(), testContractSet, fcids[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err = ss.SlabInfo(context.Background(), slab.Key)
+	if err != nil {
+		t.Fatal(err)
+	} else if info.Health != 0 {
+		t.Fatal("unexpected health", info.Health)
+	}
+
+	// unknown slabs return an error
+	_, err = ss.SlabInfo(context.Background(), object.GenerateEncryptionKey())
+	if !errors.Is(err, api.ErrSlabNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestRepointSlices(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create a host
+	hks, err := ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1 := hks[0]
+
+	// create a contract
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcid1 := fcids[0]
+
+	// helper to create a slab
+	newSlab := func(minShards uint8, root types.Hash256) object.Slab {
+		return object.Slab{
+			Health:    1.0,
+			Key:       object.GenerateEncryptionKey(),
+			MinShards: minShards,
+			Shards:    newTestShards(hk1, fcid1, root),
+		}
+	}
+	oldSlab, newSlab1, newSlab2 := newSlab(1, types.Hash256{1}), newSlab(1, types.Hash256{2}), newSlab(2, types.Hash256{3})
+
+	// add 2 objects that reference the old slab and one object for each of
+	// the new slabs
+	for _, o := range []struct {
+		name string
+		slab object.Slab
+	}{
+		{"obj1", oldSlab},
+		{"obj2", oldSlab},
+		{"obj3", newSlab1},
+		{"obj4", newSlab2},
+	} {
+		if _, err := ss.addTestObject(o.name, object.Object{
+			Key:   object.GenerateEncryptionKey(),
+			Slabs: []object.SlabSlice{{Slab: o.slab, Offset: 1, Length: 2}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// repointing to a slab with different min shards should fail
+	ctx := context.Background()
+	if _, err := ss.RepointSlices(ctx, oldSlab.Key, newSlab2.Key); !errors.Is(err, api.ErrIncompatibleSlab) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// repointing to an unknown slab should fail
+	if _, err := ss.RepointSlices(ctx, oldSlab.Key, object.GenerateEncryptionKey()); !errors.Is(err, api.ErrSlabNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// repoint the slices of the old slab
+	if n, err := ss.RepointSlices(ctx, oldSlab.Key, newSlab1.Key); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatal("unexpected number of repointed slices", n)
+	}
+
+	// assert the old slab was pruned
+	if _, err := ss.Slab(ctx, oldSlab.Key); !errors.Is(err, api.ErrSlabNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// assert the objects now reference the new slab and the slices are intact
+	for _, name := range []string{"obj1", "obj2", "obj3"} {
+		obj, err := ss.Object(ctx, api.DefaultBucketName, name)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(obj.Slabs) != 1 {
+			t.Fatal("unexpected number of slabs", len(obj.Slabs))
+		} else if obj.Slabs[0].Key.String() != newSlab1.Key.String() {
+			t.Fatal("object doesn't reference the new slab", name)
+		} else if obj.Slabs[0].Offset != 1 || obj.Slabs[0].Length != 2 {
+			t.Fatal("unexpected slice", obj.Slabs[0].Offset, obj.Slabs[0].Length)
+		}
+	}
+}
+
 func TestBuckets(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -3128,6 +3676,24 @@ func TestBuckets(t *testing.T) {
 	} else if err := ss.DeleteBucket(context.Background(), "foo"); !errors.Is(err, api.ErrBucketNotFound) {
 		t.Fatal("expected ErrBucketNotFound", err)
 	}
+
+	// Update the policy of a bucket with overrides and assert they are
+	// persisted.
+	policy := api.BucketPolicy{
+		Redundancy: &api.RedundancySettings{MinShards: 1, TotalShards: 3},
+		Gouging:    &api.GougingSettings{MaxRPCPrice: types.NewCurrency64(1)},
+	}
+	if err := ss.UpdateBucketPolicy(context.Background(), b1, policy); err != nil {
+		t.Fatal(err)
+	} else if bucket, err := ss.Bucket(context.Background(), b1); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(bucket.Policy, policy) {
+		t.Fatal("unexpected policy", bucket.Policy)
+	} else if bucket, err := ss.Bucket(context.Background(), b2); err != nil {
+		t.Fatal(err)
+	} else if bucket.Policy.Redundancy != nil || bucket.Policy.Gouging != nil {
+		t.Fatal("unexpected overrides", bucket.Policy)
+	}
 }
 
 func TestBucketObjects(t *testing.T) {
@@ -3136,7 +3702,7 @@ func TestBucketObjects(t *testing.T) {
 
 	// Adding an object to a bucket that doesn't exist shouldn't work.
 	obj := newTestObject(1)
-	err := ss.UpdateObject(context.Background(), "unknown-bucket", "foo", testContractSet, testETag, testMimeType, testMetadata, obj)
+	err := ss.UpdateObject(context.Background(), "unknown-bucket", "foo", testContractSet, testETag, testMimeType, api.UploadModeOverwrite, testMetadata, obj)
 	if !errors.Is(err, api.ErrBucketNotFound) {
 		t.Fatal("expected ErrBucketNotFound", err)
 	}
@@ -3167,7 +3733,7 @@ func TestBucketObjects(t *testing.T) {
 		obj := newTestObject(frand.Intn(9) + 1)
 		obj.Slabs = obj.Slabs[:1]
 		obj.Slabs[0].Length = uint32(o.size)
-		err := ss.UpdateObject(ctx, o.bucket, o.path, testContractSet, testETag, testMimeType, testMetadata, obj)
+		err := ss.UpdateObject(ctx, o.bucket, o.path, testContractSet, testETag, testMimeType, api.UploadModeOverwrite, testMetadata, obj)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -3262,7 +3828,7 @@ func TestBucketObjects(t *testing.T) {
 		t.Fatal(err)
 	} else if len(entries) != 2 {
 		t.Fatal("expected 2 entries", len(entries))
-	} else if err := ss.RemoveObjects(context.Background(), b2, "/"); err != nil {
+	} else if _, err := ss.RemoveObjects(context.Background(), b2, "/"); err != nil {
 		t.Fatal(err)
 	} else if entries, _, err := ss.ObjectEntries(context.Background(), b2, "/", "", "", "", "", 0, -1); err != nil {
 		t.Fatal(err)
@@ -3314,7 +3880,7 @@ func TestCopyObject(t *testing.T) {
 
 	// Create one object.
 	obj := newTestObject(1)
-	err := ss.UpdateObject(ctx, "src", "/foo", testContractSet, testETag, testMimeType, testMetadata, obj)
+	err := ss.UpdateObject(ctx, "src", "/foo", testContractSet, testETag, testMimeType, api.UploadModeOverwrite, testMetadata, obj)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3346,6 +3912,309 @@ func TestCopyObject(t *testing.T) {
 	}
 }
 
+// TestCopyObjectRemoveSource asserts that removing the source of a copy
+// doesn't prune the slabs the copy still references.
+func TestCopyObjectRemoveSource(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create an object and copy it
+	ctx := context.Background()
+	src, err := ss.addTestObject("/foo", newTestObject(2))
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.CopyObject(ctx, api.DefaultBucketName, api.DefaultBucketName, "/foo", "/bar", "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the copy should share the slabs of the source
+	var numSlabs int64
+	if err := ss.db.Model(&dbSlab{}).Count(&numSlabs).Error; err != nil {
+		t.Fatal(err)
+	} else if numSlabs != 2 {
+		t.Fatalf("expected 2 slabs, got %d", numSlabs)
+	}
+
+	// remove the source
+	if err := ss.RemoveObject(ctx, api.DefaultBucketName, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.db.Model(&dbSlab{}).Count(&numSlabs).Error; err != nil {
+		t.Fatal(err)
+	} else if numSlabs != 2 {
+		t.Fatalf("expected 2 slabs after removing the source, got %d", numSlabs)
+	}
+
+	// the copy should still be complete
+	dst, err := ss.Object(ctx, api.DefaultBucketName, "/bar")
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(dst.Object, src.Object) {
+		t.Fatal("copy doesn't match the source", dst.Object, src.Object)
+	}
+
+	// removing the copy prunes the slabs
+	if err := ss.RemoveObject(ctx, api.DefaultBucketName, "/bar"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.db.Model(&dbSlab{}).Count(&numSlabs).Error; err != nil {
+		t.Fatal(err)
+	} else if numSlabs != 0 {
+		t.Fatalf("expected 0 slabs, got %d", numSlabs)
+	}
+}
+
+func TestUpdateObjects(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add 2 hosts with a contract each
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// helper to create objects with a single sector stored on one of the
+	// contracts
+	newObjects := func(prefix string, n int) map[string]api.AddObjectsEntry {
+		objs := make(map[string]api.AddObjectsEntry, n)
+		for i := 0; i < n; i++ {
+			objs[fmt.Sprintf("%s/%d", prefix, i)] = api.AddObjectsEntry{
+				ETag:     testETag,
+				MimeType: testMimeType,
+				Metadata: testMetadata,
+				Object: object.Object{
+					Key: object.GenerateEncryptionKey(),
+					Slabs: []object.SlabSlice{{
+						Slab: object.Slab{
+							Health:    1,
+							Key:       object.GenerateEncryptionKey(),
+							MinShards: 1,
+							Shards:    newTestShards(hks[i%2], fcids[i%2], frand.Entropy256()),
+						},
+						Length: uint32(i + 1),
+					}},
+				},
+			}
+		}
+		return objs
+	}
+
+	// add objects one by one
+	ctx := context.Background()
+	const n = 1000
+	start := time.Now()
+	for path, o := range newObjects("/loop", n) {
+		if err := ss.UpdateObject(ctx, api.DefaultBucketName, path, testContractSet, o.ETag, o.MimeType, "", o.Metadata, o.Object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loopDuration := time.Since(start)
+
+	// add objects in batches
+	objs := newObjects("/batch", n)
+	start = time.Now()
+	if err := ss.UpdateObjects(ctx, api.DefaultBucketName, testContractSet, "", objs); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("adding %d objects took %v one by one and %v in batches", n, loopDuration, time.Since(start))
+
+	// assert all objects were added and their sectors are linked to the
+	// contracts they were stored on
+	var numObjects, numContractSectors int64
+	if err := ss.db.Model(&dbObject{}).Count(&numObjects).Error; err != nil {
+		t.Fatal(err)
+	} else if numObjects != 2*n {
+		t.Fatalf("expected %d objects, got %d", 2*n, numObjects)
+	} else if err := ss.db.Model(&dbContractSector{}).Count(&numContractSectors).Error; err != nil {
+		t.Fatal(err)
+	} else if numContractSectors != 2*n {
+		t.Fatalf("expected %d contract sectors, got %d", 2*n, numContractSectors)
+	}
+	for _, path := range []string{"/batch/0", "/batch/1", fmt.Sprintf("/batch/%d", n-1)} {
+		obj, err := ss.Object(ctx, api.DefaultBucketName, path)
+		if err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(*obj.Object, objs[path].Object) {
+			t.Fatalf("unexpected object %v", path)
+		} else if obj.ETag != testETag || obj.MimeType != testMimeType || !reflect.DeepEqual(obj.Metadata, testMetadata) {
+			t.Fatalf("unexpected object metadata %v", path)
+		}
+	}
+
+	// assert the upload modes are respected
+	existing := map[string]api.AddObjectsEntry{"/batch/0": {Object: newTestObject(1)}}
+	if err := ss.UpdateObjects(ctx, api.DefaultBucketName, testContractSet, api.UploadModeFailIfExists, existing); !errors.Is(err, api.ErrObjectExists) {
+		t.Fatal("expected ErrObjectExists, got", err)
+	} else if err := ss.UpdateObjects(ctx, api.DefaultBucketName, testContractSet, api.UploadModeSkipIfExists, existing); err != nil {
+		t.Fatal(err)
+	} else if obj, err := ss.Object(ctx, api.DefaultBucketName, "/batch/0"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(*obj.Object, objs["/batch/0"].Object) {
+		t.Fatal("object was overwritten")
+	}
+}
+
+func TestMoveObject(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// Create the buckets.
+	ctx := context.Background()
+	if err := ss.CreateBucket(ctx, "src", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.CreateBucket(ctx, "dst", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create two objects in the source and one in the destination bucket.
+	for _, o := range []struct{ bucket, path string }{
+		{"src", "/foo"},
+		{"src", "/bar"},
+		{"dst", "/bar"},
+	} {
+		if err := ss.UpdateObject(ctx, o.bucket, o.path, testContractSet, testETag, testMimeType, api.UploadModeOverwrite, testMetadata, newTestObject(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Count the slabs.
+	var nSlabs int64
+	if err := ss.db.Model(&dbSlab{}).Count(&nSlabs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Move an object to another bucket.
+	if err := ss.MoveObject(ctx, "src", "/foo", "dst", "/foo", false); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.Object(ctx, "src", "/foo"); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("expected object to be gone from the source bucket", err)
+	} else if obj, err := ss.Object(ctx, "dst", "/foo"); err != nil {
+		t.Fatal(err)
+	} else if obj.MimeType != testMimeType || !reflect.DeepEqual(obj.Metadata, testMetadata) {
+		t.Fatal("unexpected object", obj.MimeType, obj.Metadata)
+	}
+
+	// Moving onto an existing object should fail unless overwrite is set.
+	if err := ss.MoveObject(ctx, "src", "/bar", "dst", "/bar", false); !errors.Is(err, api.ErrObjectExists) {
+		t.Fatal("unexpected error", err)
+	} else if err := ss.MoveObject(ctx, "src", "/bar", "dst", "/bar", true); err != nil {
+		t.Fatal(err)
+	} else if entries, _, err := ss.ObjectEntries(ctx, "dst", "/", "", "", "", "", 0, -1); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 {
+		t.Fatal("expected 2 entries", len(entries))
+	}
+
+	// The overwritten object's slab should have been pruned.
+	var nSlabsAfter int64
+	if err := ss.db.Model(&dbSlab{}).Count(&nSlabsAfter).Error; err != nil {
+		t.Fatal(err)
+	} else if nSlabsAfter != nSlabs-1 {
+		t.Fatal("unexpected number of slabs", nSlabsAfter, nSlabs)
+	}
+
+	// Moving unknown objects or into unknown buckets should fail.
+	if err := ss.MoveObject(ctx, "src", "/baz", "dst", "/baz", false); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if err := ss.MoveObject(ctx, "dst", "/foo", "unknown", "/foo", false); !errors.Is(err, api.ErrBucketNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestUpdateObjectUploadMode(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// helper to upload an object with a given mode and etag
+	ctx := context.Background()
+	upload := func(mode, eTag string) error {
+		return ss.UpdateObject(ctx, api.DefaultBucketName, "/foo", testContractSet, eTag, testMimeType, mode, testMetadata, newTestObject(1))
+	}
+
+	// helper to assert the object's etag
+	assertETag := func(eTag string) {
+		t.Helper()
+		if obj, err := ss.Object(ctx, api.DefaultBucketName, "/foo"); err != nil {
+			t.Fatal(err)
+		} else if obj.ETag != eTag {
+			t.Fatalf("unexpected etag %v, expected %v", obj.ETag, eTag)
+		}
+	}
+
+	// all modes should create the object if it doesn't exist
+	for _, mode := range []string{api.UploadModeFailIfExists, api.UploadModeSkipIfExists, api.UploadModeOverwrite} {
+		if err := upload(mode, mode); err != nil {
+			t.Fatal(err)
+		}
+		assertETag(mode)
+		if err := ss.RemoveObject(ctx, api.DefaultBucketName, "/foo"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// upload the object
+	if err := upload(api.UploadModeOverwrite, "original"); err != nil {
+		t.Fatal(err)
+	}
+
+	// count the slabs
+	var nSlabs int64
+	if err := ss.db.Model(&dbSlab{}).Count(&nSlabs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// fail mode should return an error and leave the object untouched
+	if err := upload(api.UploadModeFailIfExists, "fail"); !errors.Is(err, api.ErrObjectExists) {
+		t.Fatal("unexpected error", err)
+	}
+	assertETag("original")
+
+	// skip mode should succeed but leave the object untouched
+	if err := upload(api.UploadModeSkipIfExists, "skip"); err != nil {
+		t.Fatal(err)
+	}
+	assertETag("original")
+
+	// no slabs should have been added
+	var nSlabsAfter int64
+	if err := ss.db.Model(&dbSlab{}).Count(&nSlabsAfter).Error; err != nil {
+		t.Fatal(err)
+	} else if nSlabsAfter != nSlabs {
+		t.Fatal("unexpected number of slabs", nSlabsAfter, nSlabs)
+	}
+
+	// overwrite mode should replace the object, as should an empty mode
+	if err := upload(api.UploadModeOverwrite, "overwrite"); err != nil {
+		t.Fatal(err)
+	}
+	assertETag("overwrite")
+	if err := upload("", "default"); err != nil {
+		t.Fatal(err)
+	}
+	assertETag("default")
+
+	// concurrent overwrites should all succeed
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- upload(api.UploadModeOverwrite, fmt.Sprint(i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestMarkSlabUploadedAfterRenew(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 
@@ -3779,7 +4648,7 @@ func TestSlabHealthInvalidation(t *testing.T) {
 
 	// prepare a slab with pieces on h3 and h4
 	s2 := object.GenerateEncryptionKey()
-	err = ss.UpdateObject(context.Background(), api.DefaultBucketName, "o2", testContractSet, testETag, testMimeType, testMetadata, object.Object{
+	err = ss.UpdateObject(context.Background(), api.DefaultBucketName, "o2", testContractSet, testETag, testMimeType, api.UploadModeOverwrite, testMetadata, object.Object{
 		Key: object.GenerateEncryptionKey(),
 		Slabs: []object.SlabSlice{{Slab: object.Slab{
 			Key: s2,
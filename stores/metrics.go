@@ -65,6 +65,20 @@ type (
 		Duration  time.Duration `gorm:"index;NOT NULL"`
 	}
 
+	// dbContractRenewalMetric tracks contract renewals and whether the
+	// contract was renewed or refreshed. Recorded by the store when the
+	// renewed contract is added.
+	dbContractRenewalMetric struct {
+		Model
+
+		Timestamp unixTimeMS `gorm:"index;NOT NULL"`
+
+		FCID        fileContractID `gorm:"index;size:32;NOT NULL;column:fcid"`
+		RenewedFrom fileContractID `gorm:"index;size:32;NOT NULL"`
+		Host        publicKey      `gorm:"index;size:32;NOT NULL"`
+		Kind        string         `gorm:"index;NOT NULL"`
+	}
+
 	// dbContractSetMetric tracks information about a specific contract set.
 	// Such as the number of contracts it contains. Intended to be reported by
 	// the bus every time the set is updated.
@@ -87,6 +101,8 @@ type (
 		FCID      fileContractID `gorm:"index;size:32;NOT NULL"`
 		Direction string         `gorm:"index;NOT NULL"` // "added" or "removed"
 		Reason    string         `gorm:"index;NOT NULL"`
+		Host      publicKey      `gorm:"index;size:32;NOT NULL"`
+		Period    unsigned64     `gorm:"index;NOT NULL"`
 	}
 
 	// dbPerformanceMetric is a generic metric used to track the performance of
@@ -116,8 +132,21 @@ type (
 	}
 )
 
+func (m dbContractSetChurnMetric) convert() api.ContractSetChurnMetric {
+	return api.ContractSetChurnMetric{
+		Direction:  m.Direction,
+		ContractID: types.FileContractID(m.FCID),
+		HostKey:    types.PublicKey(m.Host),
+		Name:       m.Name,
+		Period:     uint64(m.Period),
+		Reason:     m.Reason,
+		Timestamp:  api.TimeRFC3339(time.Time(m.Timestamp).UTC()),
+	}
+}
+
 func (dbContractMetric) TableName() string         { return "contracts" }
 func (dbContractPruneMetric) TableName() string    { return "contract_prunes" }
+func (dbContractRenewalMetric) TableName() string  { return "contract_renewals" }
 func (dbContractSetMetric) TableName() string      { return "contract_sets" }
 func (dbContractSetChurnMetric) TableName() string { return "contract_sets_churn" }
 func (dbPerformanceMetric) TableName() string      { return "performance" }
@@ -173,6 +202,26 @@ func (s *SQLStore) ContractPruneMetrics(ctx context.Context, start time.Time, n
 	return resp, nil
 }
 
+func (s *SQLStore) ContractRenewalMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractRenewalMetricsQueryOpts) ([]api.ContractRenewalMetric, error) {
+	metrics, err := s.contractRenewalMetrics(ctx, start, n, interval, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]api.ContractRenewalMetric, len(metrics))
+	for i := range resp {
+		resp[i] = api.ContractRenewalMetric{
+			Timestamp: api.TimeRFC3339(time.Time(metrics[i].Timestamp).UTC()),
+
+			ContractID:  types.FileContractID(metrics[i].FCID),
+			RenewedFrom: types.FileContractID(metrics[i].RenewedFrom),
+			HostKey:     types.PublicKey(metrics[i].Host),
+			Kind:        metrics[i].Kind,
+		}
+	}
+	return resp, nil
+}
+
 func (s *SQLStore) ContractSetChurnMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractSetChurnMetricsQueryOpts) ([]api.ContractSetChurnMetric, error) {
 	metrics, err := s.contractSetChurnMetrics(ctx, start, n, interval, opts)
 	if err != nil {
@@ -180,13 +229,29 @@ func (s *SQLStore) ContractSetChurnMetrics(ctx context.Context, start time.Time,
 	}
 	resp := make([]api.ContractSetChurnMetric, len(metrics))
 	for i := range resp {
-		resp[i] = api.ContractSetChurnMetric{
-			Direction:  metrics[i].Direction,
-			ContractID: types.FileContractID(metrics[i].FCID),
-			Name:       metrics[i].Name,
-			Reason:     metrics[i].Reason,
-			Timestamp:  api.TimeRFC3339(time.Time(metrics[i].Timestamp).UTC()),
-		}
+		resp[i] = metrics[i].convert()
+	}
+	return resp, nil
+}
+
+// ContractSetChurnHistory returns all churn metrics recorded for the given
+// contract set up until and including the given period, in the order in which
+// they were recorded. Unlike ContractSetChurnMetrics the metrics aren't
+// aggregated, which allows for replaying the changes to the set.
+func (s *SQLStore) ContractSetChurnHistory(ctx context.Context, name string, maxPeriod uint64) ([]api.ContractSetChurnMetric, error) {
+	var metrics []dbContractSetChurnMetric
+	err := s.dbMetrics.
+		WithContext(ctx).
+		Where("name = ? AND period <= ?", name, unsigned64(maxPeriod)).
+		Order("id ASC").
+		Find(&metrics).
+		Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract set churn history: %w", err)
+	}
+	resp := make([]api.ContractSetChurnMetric, len(metrics))
+	for i := range resp {
+		resp[i] = metrics[i].convert()
 	}
 	return resp, nil
 }
@@ -289,13 +354,32 @@ func (s *SQLStore) RecordContractPruneMetric(ctx context.Context, metrics ...api
 	})
 }
 
+func (s *SQLStore) RecordContractRenewalMetric(ctx context.Context, metrics ...api.ContractRenewalMetric) error {
+	dbMetrics := make([]dbContractRenewalMetric, len(metrics))
+	for i, metric := range metrics {
+		dbMetrics[i] = dbContractRenewalMetric{
+			Timestamp: unixTimeMS(metric.Timestamp),
+
+			FCID:        fileContractID(metric.ContractID),
+			RenewedFrom: fileContractID(metric.RenewedFrom),
+			Host:        publicKey(metric.HostKey),
+			Kind:        metric.Kind,
+		}
+	}
+	return s.dbMetrics.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&dbMetrics).Error
+	})
+}
+
 func (s *SQLStore) RecordContractSetChurnMetric(ctx context.Context, metrics ...api.ContractSetChurnMetric) error {
 	dbMetrics := make([]dbContractSetChurnMetric, len(metrics))
 	for i, metric := range metrics {
 		dbMetrics[i] = dbContractSetChurnMetric{
 			Direction: string(metric.Direction),
 			FCID:      fileContractID(metric.ContractID),
+			Host:      publicKey(metric.HostKey),
 			Name:      metric.Name,
+			Period:    unsigned64(metric.Period),
 			Reason:    metric.Reason,
 			Timestamp: unixTimeMS(metric.Timestamp),
 		}
@@ -417,6 +501,8 @@ func (s *SQLStore) PruneMetrics(ctx context.Context, metric string, cutoff time.
 	switch metric {
 	case api.MetricContractPrune:
 		model = &dbContractPruneMetric{}
+	case api.MetricContractRenewal:
+		model = &dbContractRenewalMetric{}
 	case api.MetricContractSet:
 		model = &dbContractSetMetric{}
 	case api.MetricContractSetChurn:
@@ -486,6 +572,26 @@ func (s *SQLStore) contractPruneMetrics(ctx context.Context, start time.Time, n
 	return metrics, nil
 }
 
+func (s *SQLStore) contractRenewalMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractRenewalMetricsQueryOpts) ([]dbContractRenewalMetric, error) {
+	whereExpr := gorm.Expr("TRUE")
+	if opts.HostKey != (types.PublicKey{}) {
+		whereExpr = gorm.Expr("? AND host = ?", whereExpr, publicKey(opts.HostKey))
+	}
+	if opts.Kind != "" {
+		whereExpr = gorm.Expr("? AND kind = ?", whereExpr, opts.Kind)
+	}
+
+	var metrics []dbContractRenewalMetric
+	err := s.findPeriods(dbContractRenewalMetric{}.TableName(), &metrics, start, n, interval, whereExpr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract renewal metrics: %w", err)
+	}
+	for i, m := range metrics {
+		metrics[i].Timestamp = normaliseTimestamp(start, interval, m.Timestamp)
+	}
+	return metrics, nil
+}
+
 func (s *SQLStore) contractSetChurnMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractSetChurnMetricsQueryOpts) ([]dbContractSetChurnMetric, error) {
 	whereExpr := gorm.Expr("TRUE")
 	if opts.Name != "" {
@@ -295,6 +295,50 @@ func TestContractChurnSetMetrics(t *testing.T) {
 	}
 }
 
+func TestContractSetChurnHistory(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// record churn metrics for two sets over three periods
+	var recorded []api.ContractSetChurnMetric
+	for _, period := range []uint64{30, 10, 20} {
+		for _, set := range []string{"foo", "bar"} {
+			m := api.ContractSetChurnMetric{
+				Direction:  api.ChurnDirAdded,
+				ContractID: types.FileContractID{byte(period)},
+				HostKey:    types.PublicKey{byte(period)},
+				Name:       set,
+				Period:     period,
+				Timestamp:  api.TimeRFC3339(time.UnixMilli(int64(period))),
+			}
+			if err := ss.RecordContractSetChurnMetric(context.Background(), m); err != nil {
+				t.Fatal(err)
+			}
+			if set == "foo" {
+				recorded = append(recorded, m)
+			}
+		}
+	}
+
+	// assert the history is returned in the order it was recorded
+	history, err := ss.ContractSetChurnHistory(context.Background(), "foo", 30)
+	if err != nil {
+		t.Fatal(err)
+	} else if !cmp.Equal(history, recorded, cmp.Comparer(api.CompareTimeRFC3339)) {
+		t.Fatal("unexpected history", cmp.Diff(history, recorded, cmp.Comparer(api.CompareTimeRFC3339)))
+	}
+
+	// assert the history is capped by period
+	history, err = ss.ContractSetChurnHistory(context.Background(), "foo", 20)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(history) != 2 {
+		t.Fatalf("expected 2 metrics, got %v", len(history))
+	} else if history[0].Period != 10 || history[1].Period != 20 {
+		t.Fatalf("unexpected periods %v and %v", history[0].Period, history[1].Period)
+	}
+}
+
 func TestPerformanceMetrics(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
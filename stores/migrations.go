@@ -1,6 +1,7 @@
 package stores
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -56,6 +57,132 @@ func performMigrations(db *gorm.DB, logger *zap.SugaredLogger) error {
 				return performMigration(tx, dbIdentifier, "00005_zero_size_object_health", logger)
 			},
 		},
+		{
+			ID: "00006_idx_foreign_keys",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00006_idx_foreign_keys", logger)
+			},
+		},
+		{
+			ID: "00007_host_accepting_contracts",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00007_host_accepting_contracts", logger)
+			},
+		},
+		{
+			ID: "00008_host_rtt_histogram",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00008_host_rtt_histogram", logger)
+			},
+		},
+		{
+			ID: "00009_recompute_object_sizes",
+			Migrate: func(tx *gorm.DB) error {
+				logger.Infof("performing %s migration '00009_recompute_object_sizes'", dbIdentifier)
+				fixed, err := recomputeObjectSizes(context.Background(), tx, recomputeObjectSizesBatchSize, logger)
+				if err != nil {
+					return fmt.Errorf("migration '00009_recompute_object_sizes' failed: %w", err)
+				}
+				logger.Infof("migration '00009_recompute_object_sizes' complete, corrected the size of %d objects", fixed)
+				return nil
+			},
+		},
+		{
+			ID: "00010_host_max_duration",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00010_host_max_duration", logger)
+			},
+		},
+		{
+			ID: "00011_host_sort_columns",
+			Migrate: func(tx *gorm.DB) error {
+				if err := performMigration(tx, dbIdentifier, "00011_host_sort_columns", logger); err != nil {
+					return err
+				}
+				return backfillHostSortColumns(tx)
+			},
+		},
+		{
+			ID: "00012_host_last_seen",
+			Migrate: func(tx *gorm.DB) error {
+				if err := performMigration(tx, dbIdentifier, "00012_host_last_seen", logger); err != nil {
+					return err
+				}
+				return backfillHostLastSeen(tx)
+			},
+		},
+		{
+			ID: "00013_host_success_ratio",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00013_host_success_ratio", logger)
+			},
+		},
+		{
+			ID: "00014_host_score",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00014_host_score", logger)
+			},
+		},
+		{
+			ID: "00015_blocklist_entry_expiry",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00015_blocklist_entry_expiry", logger)
+			},
+		},
+		{
+			ID: "00016_host_price_columns",
+			Migrate: func(tx *gorm.DB) error {
+				if err := performMigration(tx, dbIdentifier, "00016_host_price_columns", logger); err != nil {
+					return err
+				}
+				return backfillHostPriceColumns(tx)
+			},
+		},
+		{
+			ID: "00017_host_last_scan_latency",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00017_host_last_scan_latency", logger)
+			},
+		},
+		{
+			ID: "00018_host_announcement_timestamp",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00018_host_announcement_timestamp", logger)
+			},
+		},
+		{
+			ID: "00019_host_net_address_changes",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00019_host_net_address_changes", logger)
+			},
+		},
+		{
+			ID: "00020_autopilot_config_history",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00020_autopilot_config_history", logger)
+			},
+		},
+		{
+			ID: "00021_autopilot_disabled",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00021_autopilot_disabled", logger)
+			},
+		},
+		{
+			ID: "00022_host_version_sort",
+			Migrate: func(tx *gorm.DB) error {
+				if err := performMigration(tx, dbIdentifier, "00022_host_version_sort", logger); err != nil {
+					return err
+				}
+				return backfillHostVersionSort(tx)
+			},
+		},
+		{
+			ID: "00023_contract_initial_renter_funds",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00023_contract_initial_renter_funds", logger)
+			},
+		},
 	}
 
 	// Create migrator.
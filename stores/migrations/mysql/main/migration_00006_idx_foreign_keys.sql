@@ -0,0 +1,8 @@
+-- NOTE: InnoDB already indexes the multipart upload foreign key of the object
+-- user metadata
+
+-- index the host key of host announcements
+CREATE INDEX `idx_host_announcements_host_key` ON `host_announcements`(`host_key`(32));
+
+-- index the slices of an object in the order they are fetched
+CREATE INDEX `idx_slices_db_object_id_object_index` ON `slices`(`db_object_id`,`object_index`);
@@ -0,0 +1,6 @@
+-- add the accepting_contracts column to hosts
+ALTER TABLE `hosts` ADD COLUMN `accepting_contracts` tinyint(1) NOT NULL DEFAULT 0;
+CREATE INDEX `idx_hosts_accepting_contracts` ON `hosts`(`accepting_contracts`);
+
+-- backfill it from the settings of existing hosts
+UPDATE `hosts` SET `accepting_contracts` = (JSON_UNQUOTE(JSON_EXTRACT(`settings`, '$.acceptingcontracts')) = 'true') WHERE JSON_VALID(`settings`);
@@ -0,0 +1,2 @@
+-- add the rtt_histogram column to hosts
+ALTER TABLE `hosts` ADD COLUMN `rtt_histogram` longtext;
@@ -0,0 +1,4 @@
+-- add the last seen column, it's backfilled from the last announcement and
+-- the last successful scan of existing hosts after the migration
+ALTER TABLE `hosts` ADD COLUMN `last_seen` bigint NOT NULL DEFAULT 0;
+CREATE INDEX `idx_hosts_last_seen` ON `hosts`(`last_seen`);
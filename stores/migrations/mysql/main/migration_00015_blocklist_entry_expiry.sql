@@ -0,0 +1,3 @@
+-- add the expiry column, entries without an expiry never lapse
+ALTER TABLE `host_blocklist_entries` ADD COLUMN `expires_at` datetime(3) DEFAULT NULL;
+CREATE INDEX `idx_host_blocklist_entries_expires_at` ON `host_blocklist_entries`(`expires_at`);
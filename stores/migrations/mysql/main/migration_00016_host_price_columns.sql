@@ -0,0 +1,8 @@
+-- add the remaining price columns, they are backfilled from the settings of
+-- existing hosts after the migration
+ALTER TABLE `hosts` ADD COLUMN `host_contract_price` varbinary(16) NOT NULL DEFAULT 0x00000000000000000000000000000000;
+ALTER TABLE `hosts` ADD COLUMN `upload_bandwidth_price` varbinary(16) NOT NULL DEFAULT 0x00000000000000000000000000000000;
+ALTER TABLE `hosts` ADD COLUMN `download_bandwidth_price` varbinary(16) NOT NULL DEFAULT 0x00000000000000000000000000000000;
+CREATE INDEX `idx_hosts_host_contract_price` ON `hosts`(`host_contract_price`);
+CREATE INDEX `idx_hosts_upload_bandwidth_price` ON `hosts`(`upload_bandwidth_price`);
+CREATE INDEX `idx_hosts_download_bandwidth_price` ON `hosts`(`download_bandwidth_price`);
@@ -0,0 +1,3 @@
+-- add the timestamp of the block an announcement was found in, it can't be
+-- backfilled for existing announcements
+ALTER TABLE `host_announcements` ADD COLUMN `timestamp` datetime(3) DEFAULT NULL;
@@ -0,0 +1,3 @@
+-- add the number of times a host re-announced itself with a different net
+-- address, it's not backfilled for existing hosts
+ALTER TABLE `hosts` ADD COLUMN `net_address_changes` bigint unsigned NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- keep track of autopilot config changes
+CREATE TABLE `autopilot_config_history` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_autopilot_id` bigint unsigned NOT NULL,
+  `config` longtext,
+  `current_period` bigint unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  KEY `idx_autopilot_config_history_db_autopilot_id` (`db_autopilot_id`),
+  CONSTRAINT `fk_autopilot_config_history_autopilot` FOREIGN KEY (`db_autopilot_id`) REFERENCES `autopilots` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,2 @@
+-- allow disabling autopilots, existing autopilots remain enabled
+ALTER TABLE `autopilots` ADD COLUMN `disabled` tinyint(1) NOT NULL DEFAULT 0;
@@ -43,10 +43,23 @@ CREATE TABLE `autopilots` (
   `identifier` varchar(191) NOT NULL,
   `config` longtext,
   `current_period` bigint unsigned DEFAULT '0',
+  `disabled` tinyint(1) NOT NULL DEFAULT 0,
   PRIMARY KEY (`id`),
   UNIQUE KEY `identifier` (`identifier`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+-- dbAutopilotConfigHistory
+CREATE TABLE `autopilot_config_history` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_autopilot_id` bigint unsigned NOT NULL,
+  `config` longtext,
+  `current_period` bigint unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  KEY `idx_autopilot_config_history_db_autopilot_id` (`db_autopilot_id`),
+  CONSTRAINT `fk_autopilot_config_history_autopilot` FOREIGN KEY (`db_autopilot_id`) REFERENCES `autopilots` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 -- dbBucket
 CREATE TABLE `buckets` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
@@ -98,6 +111,23 @@ CREATE TABLE `hosts` (
   `lost_sectors` bigint unsigned DEFAULT NULL,
   `last_announcement` datetime(3) DEFAULT NULL,
   `net_address` varchar(191) DEFAULT NULL,
+  `accepting_contracts` tinyint(1) NOT NULL DEFAULT 0,
+  `rtt_histogram` longtext,
+  `max_duration` bigint unsigned NOT NULL DEFAULT 0,
+  `storage_price` varbinary(16) NOT NULL DEFAULT 0x00000000000000000000000000000000,
+  `collateral` varbinary(16) NOT NULL DEFAULT 0x00000000000000000000000000000000,
+  `remaining_storage` bigint unsigned NOT NULL DEFAULT 0,
+  `version` varchar(191) NOT NULL DEFAULT '',
+  `version_sort` bigint unsigned NOT NULL DEFAULT 0,
+  `last_seen` bigint NOT NULL DEFAULT 0,
+  `success_ratio` double NOT NULL DEFAULT 0,
+  `score` double NOT NULL DEFAULT 0,
+  `host_contract_price` varbinary(16) NOT NULL DEFAULT 0x00000000000000000000000000000000,
+  `upload_bandwidth_price` varbinary(16) NOT NULL DEFAULT 0x00000000000000000000000000000000,
+  `download_bandwidth_price` varbinary(16) NOT NULL DEFAULT 0x00000000000000000000000000000000,
+  `last_scan_latency` bigint NOT NULL DEFAULT 0,
+  `last_scan_resolution_failed` tinyint(1) NOT NULL DEFAULT 0,
+  `net_address_changes` bigint unsigned NOT NULL DEFAULT 0,
   PRIMARY KEY (`id`),
   UNIQUE KEY `public_key` (`public_key`),
   KEY `idx_hosts_public_key` (`public_key`),
@@ -105,7 +135,22 @@ CREATE TABLE `hosts` (
   KEY `idx_hosts_scanned` (`scanned`),
   KEY `idx_hosts_recent_downtime` (`recent_downtime`),
   KEY `idx_hosts_recent_scan_failures` (`recent_scan_failures`),
-  KEY `idx_hosts_net_address` (`net_address`)
+  KEY `idx_hosts_net_address` (`net_address`),
+  KEY `idx_hosts_accepting_contracts` (`accepting_contracts`),
+  KEY `idx_hosts_max_duration` (`max_duration`),
+  KEY `idx_hosts_storage_price` (`storage_price`),
+  KEY `idx_hosts_collateral` (`collateral`),
+  KEY `idx_hosts_remaining_storage` (`remaining_storage`),
+  KEY `idx_hosts_version` (`version`),
+  KEY `idx_hosts_version_sort` (`version_sort`),
+  KEY `idx_hosts_uptime` (`uptime`),
+  KEY `idx_hosts_last_seen` (`last_seen`),
+  KEY `idx_hosts_success_ratio` (`success_ratio`),
+  KEY `idx_hosts_score` (`score`),
+  KEY `idx_hosts_host_contract_price` (`host_contract_price`),
+  KEY `idx_hosts_upload_bandwidth_price` (`upload_bandwidth_price`),
+  KEY `idx_hosts_download_bandwidth_price` (`download_bandwidth_price`),
+  KEY `idx_hosts_last_scan_latency` (`last_scan_latency`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 -- dbContract
@@ -130,6 +175,7 @@ CREATE TABLE `contracts` (
   `delete_spending` longtext,
   `list_spending` longtext,
   `host_id` bigint unsigned DEFAULT NULL,
+  `initial_renter_funds` varchar(191) NOT NULL DEFAULT '0',
   PRIMARY KEY (`id`),
   UNIQUE KEY `fcid` (`fcid`),
   KEY `idx_contracts_window_end` (`window_end`),
@@ -258,7 +304,9 @@ CREATE TABLE `host_announcements` (
   `block_height` bigint unsigned DEFAULT NULL,
   `block_id` longtext,
   `net_address` longtext,
-  PRIMARY KEY (`id`)
+  `timestamp` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_host_announcements_host_key` (`host_key`(32))
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 -- dbBlocklistEntry
@@ -266,9 +314,11 @@ CREATE TABLE `host_blocklist_entries` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
   `created_at` datetime(3) DEFAULT NULL,
   `entry` varchar(191) NOT NULL,
+  `expires_at` datetime(3) DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `entry` (`entry`),
-  KEY `idx_host_blocklist_entries_entry` (`entry`)
+  KEY `idx_host_blocklist_entries_entry` (`entry`),
+  KEY `idx_host_blocklist_entries_expires_at` (`expires_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 -- dbBlocklistEntry <-> dbHost
@@ -374,6 +424,7 @@ CREATE TABLE `slices` (
   KEY `idx_slices_object_index` (`object_index`),
   KEY `idx_slices_db_multipart_part_id` (`db_multipart_part_id`),
   KEY `idx_slices_db_slab_id` (`db_slab_id`),
+  KEY `idx_slices_db_object_id_object_index` (`db_object_id`,`object_index`),
   CONSTRAINT `fk_multipart_parts_slabs` FOREIGN KEY (`db_multipart_part_id`) REFERENCES `multipart_parts` (`id`) ON DELETE CASCADE,
   CONSTRAINT `fk_objects_slabs` FOREIGN KEY (`db_object_id`) REFERENCES `objects` (`id`) ON DELETE CASCADE,
   CONSTRAINT `fk_slabs_slices` FOREIGN KEY (`db_slab_id`) REFERENCES `slabs` (`id`)
@@ -0,0 +1,17 @@
+-- add a table to track contract renewals and whether the contract was renewed
+-- or refreshed
+CREATE TABLE `contract_renewals` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `timestamp` bigint NOT NULL,
+  `fcid` varbinary(32) NOT NULL,
+  `renewed_from` varbinary(32) NOT NULL,
+  `host` varbinary(32) NOT NULL,
+  `kind` varchar(191) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_renewals_timestamp` (`timestamp`),
+  KEY `idx_contract_renewals_fc_id` (`fcid`),
+  KEY `idx_contract_renewals_renewed_from` (`renewed_from`),
+  KEY `idx_contract_renewals_host` (`host`),
+  KEY `idx_contract_renewals_kind` (`kind`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
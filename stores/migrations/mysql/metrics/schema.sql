@@ -19,6 +19,23 @@ CREATE TABLE `contract_prunes` (
   KEY `idx_contract_prunes_duration` (`duration`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+-- dbContractRenewalMetric
+CREATE TABLE `contract_renewals` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `timestamp` bigint NOT NULL,
+  `fcid` varbinary(32) NOT NULL,
+  `renewed_from` varbinary(32) NOT NULL,
+  `host` varbinary(32) NOT NULL,
+  `kind` varchar(191) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_renewals_timestamp` (`timestamp`),
+  KEY `idx_contract_renewals_fc_id` (`fcid`),
+  KEY `idx_contract_renewals_renewed_from` (`renewed_from`),
+  KEY `idx_contract_renewals_host` (`host`),
+  KEY `idx_contract_renewals_kind` (`kind`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 -- dbContractSetMetric
 CREATE TABLE `contract_sets` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
@@ -41,12 +58,16 @@ CREATE TABLE `contract_sets_churn` (
   `fc_id` varbinary(32) NOT NULL,
   `direction` varchar(191) NOT NULL,
   `reason` varchar(191) NOT NULL,
+  `host` varbinary(32) NOT NULL,
+  `period` bigint NOT NULL,
   PRIMARY KEY (`id`),
   KEY `idx_contract_sets_churn_timestamp` (`timestamp`),
   KEY `idx_contract_sets_churn_name` (`name`),
   KEY `idx_contract_sets_churn_fc_id` (`fc_id`),
   KEY `idx_contract_sets_churn_direction` (`direction`),
-  KEY `idx_contract_sets_churn_reason` (`reason`)
+  KEY `idx_contract_sets_churn_reason` (`reason`),
+  KEY `idx_contract_sets_churn_host` (`host`),
+  KEY `idx_contract_sets_churn_period` (`period`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 -- dbContractMetric
@@ -0,0 +1,8 @@
+-- index the multipart upload foreign key of the object user metadata
+CREATE INDEX `idx_object_user_metadata_db_multipart_upload_id` ON `object_user_metadata`(`db_multipart_upload_id`);
+
+-- index the host key of host announcements
+CREATE INDEX `idx_host_announcements_host_key` ON `host_announcements`(`host_key`);
+
+-- index the slices of an object in the order they are fetched
+CREATE INDEX `idx_slices_db_object_id_object_index` ON `slices`(`db_object_id`,`object_index`);
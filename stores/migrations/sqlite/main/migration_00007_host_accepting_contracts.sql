@@ -0,0 +1,6 @@
+-- add the accepting_contracts column to hosts
+ALTER TABLE `hosts` ADD COLUMN `accepting_contracts` numeric NOT NULL DEFAULT false;
+CREATE INDEX `idx_hosts_accepting_contracts` ON `hosts`(`accepting_contracts`);
+
+-- backfill it from the settings of existing hosts
+UPDATE `hosts` SET `accepting_contracts` = COALESCE(json_extract(`settings`, '$.acceptingcontracts'), false) WHERE json_valid(`settings`);
@@ -0,0 +1,6 @@
+-- add the max_duration column to hosts
+ALTER TABLE `hosts` ADD COLUMN `max_duration` integer NOT NULL DEFAULT 0;
+CREATE INDEX `idx_hosts_max_duration` ON `hosts`(`max_duration`);
+
+-- backfill it from the settings of existing hosts
+UPDATE `hosts` SET `max_duration` = COALESCE(json_extract(`settings`, '$.maxduration'), 0) WHERE json_valid(`settings`);
@@ -0,0 +1,11 @@
+-- add the columns hosts can be sorted by, they are backfilled from the
+-- settings of existing hosts after the migration
+ALTER TABLE `hosts` ADD COLUMN `storage_price` blob NOT NULL DEFAULT X'00000000000000000000000000000000';
+ALTER TABLE `hosts` ADD COLUMN `collateral` blob NOT NULL DEFAULT X'00000000000000000000000000000000';
+ALTER TABLE `hosts` ADD COLUMN `remaining_storage` integer NOT NULL DEFAULT 0;
+ALTER TABLE `hosts` ADD COLUMN `version` text NOT NULL DEFAULT '';
+CREATE INDEX `idx_hosts_storage_price` ON `hosts`(`storage_price`);
+CREATE INDEX `idx_hosts_collateral` ON `hosts`(`collateral`);
+CREATE INDEX `idx_hosts_remaining_storage` ON `hosts`(`remaining_storage`);
+CREATE INDEX `idx_hosts_version` ON `hosts`(`version`);
+CREATE INDEX `idx_hosts_uptime` ON `hosts`(`uptime`);
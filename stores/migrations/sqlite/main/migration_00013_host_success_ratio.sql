@@ -0,0 +1,5 @@
+-- add the success ratio column and backfill it from the interactions of
+-- existing hosts
+ALTER TABLE `hosts` ADD COLUMN `success_ratio` real NOT NULL DEFAULT 0;
+CREATE INDEX `idx_hosts_success_ratio` ON `hosts`(`success_ratio`);
+UPDATE `hosts` SET `success_ratio` = `successful_interactions` / (`successful_interactions` + `failed_interactions`) WHERE `successful_interactions` + `failed_interactions` > 0;
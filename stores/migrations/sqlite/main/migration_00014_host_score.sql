@@ -0,0 +1,3 @@
+-- add the score column, scores are computed and recorded by the autopilot
+ALTER TABLE `hosts` ADD COLUMN `score` real NOT NULL DEFAULT 0;
+CREATE INDEX `idx_hosts_score` ON `hosts`(`score`);
@@ -0,0 +1,5 @@
+-- add the latency of the last successful scan and whether the last scan
+-- failed to resolve the host's net address
+ALTER TABLE `hosts` ADD COLUMN `last_scan_latency` integer NOT NULL DEFAULT 0;
+ALTER TABLE `hosts` ADD COLUMN `last_scan_resolution_failed` numeric NOT NULL DEFAULT false;
+CREATE INDEX `idx_hosts_last_scan_latency` ON `hosts`(`last_scan_latency`);
@@ -0,0 +1,3 @@
+-- keep track of autopilot config changes
+CREATE TABLE `autopilot_config_history` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_autopilot_id` integer NOT NULL,`config` text,`current_period` integer DEFAULT 0,CONSTRAINT `fk_autopilot_config_history_autopilot` FOREIGN KEY (`db_autopilot_id`) REFERENCES `autopilots`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_autopilot_config_history_db_autopilot_id` ON `autopilot_config_history`(`db_autopilot_id`);
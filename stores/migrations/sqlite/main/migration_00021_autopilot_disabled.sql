@@ -0,0 +1,2 @@
+-- allow disabling autopilots, existing autopilots remain enabled
+ALTER TABLE `autopilots` ADD COLUMN `disabled` numeric NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+-- add a column to sort hosts by their semantic version, sorting by the version
+-- string would put "1.10.0" before "1.9.0"
+ALTER TABLE `hosts` ADD COLUMN `version_sort` integer NOT NULL DEFAULT 0;
+CREATE INDEX `idx_hosts_version_sort` ON `hosts`(`version_sort`);
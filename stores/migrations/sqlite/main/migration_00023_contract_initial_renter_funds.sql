@@ -0,0 +1,2 @@
+-- add the renter's initial funds to contracts, existing contracts default to 0
+ALTER TABLE `contracts` ADD COLUMN `initial_renter_funds` text NOT NULL DEFAULT '0';
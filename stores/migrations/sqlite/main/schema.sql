@@ -12,7 +12,22 @@ CREATE INDEX `idx_archived_contracts_state` ON `archived_contracts`(`state`);
 CREATE INDEX `idx_archived_contracts_renewed_from` ON `archived_contracts`(`renewed_from`);
 
 -- dbHost
-CREATE TABLE `hosts` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`public_key` blob NOT NULL UNIQUE,`settings` text,`price_table` text,`price_table_expiry` datetime,`total_scans` integer,`last_scan` integer,`last_scan_success` numeric,`second_to_last_scan_success` numeric,`scanned` numeric,`uptime` integer,`downtime` integer,`recent_downtime` integer,`recent_scan_failures` integer,`successful_interactions` real,`failed_interactions` real,`lost_sectors` integer,`last_announcement` datetime,`net_address` text);
+CREATE TABLE `hosts` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`public_key` blob NOT NULL UNIQUE,`settings` text,`price_table` text,`price_table_expiry` datetime,`total_scans` integer,`last_scan` integer,`last_scan_success` numeric,`second_to_last_scan_success` numeric,`scanned` numeric,`uptime` integer,`downtime` integer,`recent_downtime` integer,`recent_scan_failures` integer,`successful_interactions` real,`failed_interactions` real,`lost_sectors` integer,`last_announcement` datetime,`net_address` text,`accepting_contracts` numeric NOT NULL DEFAULT false,`rtt_histogram` text,`max_duration` integer NOT NULL DEFAULT 0,`storage_price` blob NOT NULL DEFAULT X'00000000000000000000000000000000',`collateral` blob NOT NULL DEFAULT X'00000000000000000000000000000000',`remaining_storage` integer NOT NULL DEFAULT 0,`version` text NOT NULL DEFAULT '',`version_sort` integer NOT NULL DEFAULT 0,`last_seen` integer NOT NULL DEFAULT 0,`success_ratio` real NOT NULL DEFAULT 0,`score` real NOT NULL DEFAULT 0,`host_contract_price` blob NOT NULL DEFAULT X'00000000000000000000000000000000',`upload_bandwidth_price` blob NOT NULL DEFAULT X'00000000000000000000000000000000',`download_bandwidth_price` blob NOT NULL DEFAULT X'00000000000000000000000000000000',`last_scan_latency` integer NOT NULL DEFAULT 0,`last_scan_resolution_failed` numeric NOT NULL DEFAULT false,`net_address_changes` integer NOT NULL DEFAULT 0);
+CREATE INDEX `idx_hosts_accepting_contracts` ON `hosts`(`accepting_contracts`);
+CREATE INDEX `idx_hosts_max_duration` ON `hosts`(`max_duration`);
+CREATE INDEX `idx_hosts_storage_price` ON `hosts`(`storage_price`);
+CREATE INDEX `idx_hosts_collateral` ON `hosts`(`collateral`);
+CREATE INDEX `idx_hosts_remaining_storage` ON `hosts`(`remaining_storage`);
+CREATE INDEX `idx_hosts_version_sort` ON `hosts`(`version_sort`);
+CREATE INDEX `idx_hosts_version` ON `hosts`(`version`);
+CREATE INDEX `idx_hosts_uptime` ON `hosts`(`uptime`);
+CREATE INDEX `idx_hosts_last_seen` ON `hosts`(`last_seen`);
+CREATE INDEX `idx_hosts_success_ratio` ON `hosts`(`success_ratio`);
+CREATE INDEX `idx_hosts_score` ON `hosts`(`score`);
+CREATE INDEX `idx_hosts_host_contract_price` ON `hosts`(`host_contract_price`);
+CREATE INDEX `idx_hosts_upload_bandwidth_price` ON `hosts`(`upload_bandwidth_price`);
+CREATE INDEX `idx_hosts_download_bandwidth_price` ON `hosts`(`download_bandwidth_price`);
+CREATE INDEX `idx_hosts_last_scan_latency` ON `hosts`(`last_scan_latency`);
 CREATE INDEX `idx_hosts_recent_scan_failures` ON `hosts`(`recent_scan_failures`);
 CREATE INDEX `idx_hosts_recent_downtime` ON `hosts`(`recent_downtime`);
 CREATE INDEX `idx_hosts_scanned` ON `hosts`(`scanned`);
@@ -21,7 +36,7 @@ CREATE INDEX `idx_hosts_public_key` ON `hosts`(`public_key`);
 CREATE INDEX `idx_hosts_net_address` ON `hosts`(`net_address`);
 
 -- dbContract
-CREATE TABLE `contracts` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`fcid` blob NOT NULL UNIQUE,`renewed_from` blob,`contract_price` text,`state` integer NOT NULL DEFAULT 0,`total_cost` text,`proof_height` integer DEFAULT 0,`revision_height` integer DEFAULT 0,`revision_number` text NOT NULL DEFAULT "0",`size` integer,`start_height` integer NOT NULL,`window_start` integer NOT NULL DEFAULT 0,`window_end` integer NOT NULL DEFAULT 0,`upload_spending` text,`download_spending` text,`fund_account_spending` text,`delete_spending` text,`list_spending` text,`host_id` integer,CONSTRAINT `fk_contracts_host` FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`));
+CREATE TABLE `contracts` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`fcid` blob NOT NULL UNIQUE,`renewed_from` blob,`contract_price` text,`state` integer NOT NULL DEFAULT 0,`total_cost` text,`proof_height` integer DEFAULT 0,`revision_height` integer DEFAULT 0,`revision_number` text NOT NULL DEFAULT "0",`size` integer,`start_height` integer NOT NULL,`window_start` integer NOT NULL DEFAULT 0,`window_end` integer NOT NULL DEFAULT 0,`upload_spending` text,`download_spending` text,`fund_account_spending` text,`delete_spending` text,`list_spending` text,`host_id` integer,`initial_renter_funds` text NOT NULL DEFAULT "0",CONSTRAINT `fk_contracts_host` FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`));
 CREATE INDEX `idx_contracts_proof_height` ON `contracts`(`proof_height`);
 CREATE INDEX `idx_contracts_state` ON `contracts`(`state`);
 CREATE INDEX `idx_contracts_renewed_from` ON `contracts`(`renewed_from`);
@@ -96,16 +111,19 @@ CREATE INDEX `idx_slices_object_index` ON `slices`(`object_index`);
 CREATE INDEX `idx_slices_db_object_id` ON `slices`(`db_object_id`);
 CREATE INDEX `idx_slices_db_slab_id` ON `slices`(`db_slab_id`);
 CREATE INDEX `idx_slices_db_multipart_part_id` ON `slices`(`db_multipart_part_id`);
+CREATE INDEX `idx_slices_db_object_id_object_index` ON `slices`(`db_object_id`,`object_index`);
 
 -- dbHostAnnouncement
-CREATE TABLE `host_announcements` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`host_key` blob NOT NULL,`block_height` integer,`block_id` text,`net_address` text);
+CREATE TABLE `host_announcements` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`host_key` blob NOT NULL,`block_height` integer,`block_id` text,`net_address` text,`timestamp` datetime);
+CREATE INDEX `idx_host_announcements_host_key` ON `host_announcements`(`host_key`);
 
 -- dbConsensusInfo
 CREATE TABLE `consensus_infos` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`cc_id` blob,`height` integer,`block_id` blob);
 
 -- dbBlocklistEntry
-CREATE TABLE `host_blocklist_entries` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`entry` text NOT NULL UNIQUE);
+CREATE TABLE `host_blocklist_entries` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`entry` text NOT NULL UNIQUE,`expires_at` datetime);
 CREATE INDEX `idx_host_blocklist_entries_entry` ON `host_blocklist_entries`(`entry`);
+CREATE INDEX `idx_host_blocklist_entries_expires_at` ON `host_blocklist_entries`(`expires_at`);
 
 -- dbBlocklistEntry <-> dbHost
 CREATE TABLE `host_blocklist_entry_hosts` (`db_blocklist_entry_id` integer,`db_host_id` integer,PRIMARY KEY (`db_blocklist_entry_id`,`db_host_id`),CONSTRAINT `fk_host_blocklist_entry_hosts_db_blocklist_entry` FOREIGN KEY (`db_blocklist_entry_id`) REFERENCES `host_blocklist_entries`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_host_blocklist_entry_hosts_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
@@ -138,7 +156,11 @@ CREATE TABLE `ephemeral_accounts` (`id` integer PRIMARY KEY AUTOINCREMENT,`creat
 CREATE INDEX `idx_ephemeral_accounts_requires_sync` ON `ephemeral_accounts`(`requires_sync`);
 
 -- dbAutopilot
-CREATE TABLE `autopilots` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`identifier` text NOT NULL UNIQUE,`config` text,`current_period` integer DEFAULT 0);
+CREATE TABLE `autopilots` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`identifier` text NOT NULL UNIQUE,`config` text,`current_period` integer DEFAULT 0,`disabled` numeric NOT NULL DEFAULT false);
+
+-- dbAutopilotConfigHistory
+CREATE TABLE `autopilot_config_history` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_autopilot_id` integer NOT NULL,`config` text,`current_period` integer DEFAULT 0,CONSTRAINT `fk_autopilot_config_history_autopilot` FOREIGN KEY (`db_autopilot_id`) REFERENCES `autopilots`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_autopilot_config_history_db_autopilot_id` ON `autopilot_config_history`(`db_autopilot_id`);
 
 -- dbWebhook
 CREATE TABLE `webhooks` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`module` text NOT NULL,`event` text NOT NULL,`url` text NOT NULL);
@@ -147,6 +169,7 @@ CREATE UNIQUE INDEX `idx_module_event_url` ON `webhooks`(`module`,`event`,`url`)
 -- dbObjectUserMetadata
 CREATE TABLE `object_user_metadata` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_object_id` integer DEFAULT NULL,`db_multipart_upload_id` integer DEFAULT NULL,`key` text NOT NULL,`value` text, CONSTRAINT `fk_object_user_metadata` FOREIGN KEY (`db_object_id`) REFERENCES `objects` (`id`) ON DELETE CASCADE, CONSTRAINT `fk_multipart_upload_user_metadata` FOREIGN KEY (`db_multipart_upload_id`) REFERENCES `multipart_uploads` (`id`) ON DELETE SET NULL);
 CREATE UNIQUE INDEX `idx_object_user_metadata_key` ON `object_user_metadata`(`db_object_id`,`db_multipart_upload_id`,`key`);
+CREATE INDEX `idx_object_user_metadata_db_multipart_upload_id` ON `object_user_metadata`(`db_multipart_upload_id`);
 
 -- create default bucket
 INSERT INTO buckets (created_at, name) VALUES (CURRENT_TIMESTAMP, 'default');
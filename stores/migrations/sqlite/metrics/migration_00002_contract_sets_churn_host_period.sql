@@ -0,0 +1,6 @@
+-- add the host and period columns to the contract set churn metrics, existing
+-- metrics default to the zero host key and period
+ALTER TABLE `contract_sets_churn` ADD COLUMN `host` blob NOT NULL DEFAULT x'0000000000000000000000000000000000000000000000000000000000000000';
+ALTER TABLE `contract_sets_churn` ADD COLUMN `period` BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX `idx_contract_sets_churn_host` ON `contract_sets_churn`(`host`);
+CREATE INDEX `idx_contract_sets_churn_period` ON `contract_sets_churn`(`period`);
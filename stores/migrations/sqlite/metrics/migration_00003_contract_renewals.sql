@@ -0,0 +1,8 @@
+-- add a table to track contract renewals and whether the contract was renewed
+-- or refreshed
+CREATE TABLE `contract_renewals` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`timestamp` BIGINT NOT NULL,`fcid` blob NOT NULL,`renewed_from` blob NOT NULL,`host` blob NOT NULL,`kind` text NOT NULL);
+CREATE INDEX `idx_contract_renewals_kind` ON `contract_renewals`(`kind`);
+CREATE INDEX `idx_contract_renewals_host` ON `contract_renewals`(`host`);
+CREATE INDEX `idx_contract_renewals_renewed_from` ON `contract_renewals`(`renewed_from`);
+CREATE INDEX `idx_contract_renewals_fc_id` ON `contract_renewals`(`fcid`);
+CREATE INDEX `idx_contract_renewals_timestamp` ON `contract_renewals`(`timestamp`);
@@ -23,6 +23,14 @@ CREATE INDEX `idx_contract_prunes_host` ON `contract_prunes`(`host`);
 CREATE INDEX `idx_contract_prunes_fc_id` ON `contract_prunes`(`fcid`);
 CREATE INDEX `idx_contract_prunes_timestamp` ON `contract_prunes`(`timestamp`);
 
+-- dbContractRenewalMetric
+CREATE TABLE `contract_renewals` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`timestamp` BIGINT NOT NULL,`fcid` blob NOT NULL,`renewed_from` blob NOT NULL,`host` blob NOT NULL,`kind` text NOT NULL);
+CREATE INDEX `idx_contract_renewals_kind` ON `contract_renewals`(`kind`);
+CREATE INDEX `idx_contract_renewals_host` ON `contract_renewals`(`host`);
+CREATE INDEX `idx_contract_renewals_renewed_from` ON `contract_renewals`(`renewed_from`);
+CREATE INDEX `idx_contract_renewals_fc_id` ON `contract_renewals`(`fcid`);
+CREATE INDEX `idx_contract_renewals_timestamp` ON `contract_renewals`(`timestamp`);
+
 -- dbContractSetMetric
 CREATE TABLE `contract_sets` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`timestamp` BIGINT NOT NULL,`name` text NOT NULL,`contracts` integer NOT NULL);
 CREATE INDEX `idx_contract_sets_timestamp` ON `contract_sets`(`timestamp`);
@@ -30,7 +38,9 @@ CREATE INDEX `idx_contract_sets_contracts` ON `contract_sets`(`contracts`);
 CREATE INDEX `idx_contract_sets_name` ON `contract_sets`(`name`);
 
 -- dbContractSetChurnMetric
-CREATE TABLE `contract_sets_churn` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`timestamp` BIGINT NOT NULL,`name` text NOT NULL,`fc_id` blob NOT NULL,`direction` text NOT NULL,`reason` text NOT NULL);
+CREATE TABLE `contract_sets_churn` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`timestamp` BIGINT NOT NULL,`name` text NOT NULL,`fc_id` blob NOT NULL,`direction` text NOT NULL,`reason` text NOT NULL,`host` blob NOT NULL,`period` BIGINT NOT NULL);
+CREATE INDEX `idx_contract_sets_churn_period` ON `contract_sets_churn`(`period`);
+CREATE INDEX `idx_contract_sets_churn_host` ON `contract_sets_churn`(`host`);
 CREATE INDEX `idx_contract_sets_churn_reason` ON `contract_sets_churn`(`reason`);
 CREATE INDEX `idx_contract_sets_churn_direction` ON `contract_sets_churn`(`direction`);
 CREATE INDEX `idx_contract_sets_churn_fc_id` ON `contract_sets_churn`(`fc_id`);
@@ -21,6 +21,18 @@ func performMetricsMigrations(db *gorm.DB, logger *zap.SugaredLogger) error {
 				return performMigration(tx, dbIdentifier, "00001_idx_contracts_fcid_timestamp", logger)
 			},
 		},
+		{
+			ID: "00002_contract_sets_churn_host_period",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00002_contract_sets_churn_host_period", logger)
+			},
+		},
+		{
+			ID: "00003_contract_renewals",
+			Migrate: func(tx *gorm.DB) error {
+				return performMigration(tx, dbIdentifier, "00003_contract_renewals", logger)
+			},
+		},
 	}
 
 	// Create migrator.
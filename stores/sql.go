@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.sia.tech/core/types"
@@ -27,6 +28,19 @@ const (
 	// number matches the sqlite default of 32766 rounded down to the nearest
 	// 1000. This is also lower than the mysql default of 65535.
 	maxSQLVars = 32000
+
+	// consensusSubscriberTimeout is the maximum amount of time we wait for a
+	// consensus subscriber to process a consensus change before moving on.
+	consensusSubscriberTimeout = 30 * time.Second
+
+	// consensusSubscriberQueueSize is the maximum number of consensus changes
+	// that are queued for a subscriber that fails to keep up, a subscriber
+	// is disconnected if its queue is full.
+	consensusSubscriberQueueSize = 100
+
+	// databaseMetricsCacheInterval is the amount of time the result of
+	// Metrics is cached for.
+	databaseMetricsCacheInterval = 10 * time.Second
 )
 
 //go:embed all:migrations/*
@@ -34,6 +48,10 @@ var migrations embed.FS
 
 var (
 	exprTRUE = gorm.Expr("TRUE")
+
+	// ErrBackupUnsupported is returned by Backup if the store is not backed
+	// by SQLite.
+	ErrBackupUnsupported = errors.New("backups are only supported for SQLite databases")
 )
 
 type (
@@ -52,17 +70,37 @@ type (
 		PartialSlabDir                string
 		Migrate                       bool
 		AnnouncementMaxAge            time.Duration
+		AnnouncementBatchSoftLimit    int
+		AnnouncementBatchHardLimit    int
+		AnnouncementCatchUpBatchSize  int
 		PersistInterval               time.Duration
 		WalletAddress                 types.Address
 		SlabBufferCompletionThreshold int64
 		Logger                        *zap.SugaredLogger
 		GormLogger                    glogger.Interface
 		RetryTransactionIntervals     []time.Duration
+
+		// MaxOpenConns, MaxIdleConns and ConnMaxLifetime configure the
+		// connection pools of both databases, zero values leave the defaults
+		// of database/sql untouched.
+		MaxOpenConns    int
+		MaxIdleConns    int
+		ConnMaxLifetime time.Duration
+
+		// Clock is used to determine the current time when recording host
+		// interactions, if not set the system clock is used.
+		Clock Clock
+	}
+
+	// Clock returns the current time.
+	Clock interface {
+		Now() time.Time
 	}
 
 	// SQLStore is a helper type for interacting with a SQL-based backend.
 	SQLStore struct {
 		alerts    alerts.Alerter
+		clock     Clock
 		db        *gorm.DB
 		dbMetrics *gorm.DB
 		logger    *zap.SugaredLogger
@@ -72,20 +110,24 @@ type (
 		retryTransactionIntervals []time.Duration
 
 		// Persistence buffer - related fields.
-		lastSave               time.Time
-		persistInterval        time.Duration
-		persistMu              sync.Mutex
-		persistTimer           *time.Timer
-		unappliedAnnouncements []announcement
-		unappliedContractState map[types.FileContractID]contractState
-		unappliedHostKeys      map[types.PublicKey]struct{}
-		unappliedRevisions     map[types.FileContractID]revisionUpdate
-		unappliedProofs        map[types.FileContractID]uint64
-		unappliedOutputChanges []outputChange
-		unappliedTxnChanges    []txnChange
+		lastSave                    time.Time
+		persistInterval             time.Duration
+		persistMu                   sync.Mutex
+		persistTimer                *time.Timer
+		unappliedAnnouncements      []announcement
+		unappliedAnnouncementBlocks int
+		unappliedContractState      map[types.FileContractID]contractState
+		unappliedHostKeys           map[types.PublicKey]struct{}
+		unappliedRevisions          map[types.FileContractID]revisionUpdate
+		unappliedProofs             map[types.FileContractID]uint64
+		unappliedOutputChanges      []outputChange
+		unappliedTxnChanges         []txnChange
 
 		// HostDB related fields
-		announcementMaxAge time.Duration
+		announcementMaxAge           time.Duration
+		announcementBatchSoftLimit   int
+		announcementBatchHardLimit   int
+		announcementCatchUpBatchSize int
 
 		// SettingsDB related fields.
 		settingsMu sync.Mutex
@@ -98,6 +140,10 @@ type (
 		ccid       modules.ConsensusChangeID
 		chainIndex types.ChainIndex
 
+		ccSubscribersMu     sync.Mutex
+		ccSubscribers       []*ccSubscriber
+		ccSubscriberTimeout time.Duration
+
 		shutdownCtx       context.Context
 		shutdownCtxCancel context.CancelFunc
 
@@ -110,6 +156,24 @@ type (
 		closed       bool
 
 		knownContracts map[types.FileContractID]struct{}
+
+		metricsMu       sync.Mutex
+		metricsCache    DatabaseMetrics
+		metricsCachedAt time.Time
+	}
+
+	// DatabaseMetrics contains the row counts of the largest tables of the
+	// store. PageCount and PageSize are only set for SQLite databases.
+	DatabaseMetrics struct {
+		Hosts             int64 `json:"hosts"`
+		HostAnnouncements int64 `json:"hostAnnouncements"`
+		Contracts         int64 `json:"contracts"`
+		Objects           int64 `json:"objects"`
+		Slabs             int64 `json:"slabs"`
+		Sectors           int64 `json:"sectors"`
+
+		PageCount uint64 `json:"pageCount,omitempty"`
+		PageSize  uint64 `json:"pageSize,omitempty"`
 	}
 
 	revisionUpdate struct {
@@ -131,24 +195,84 @@ func NewEphemeralSQLiteConnection(name string) gorm.Dialector {
 	return sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared&_foreign_keys=1", name))
 }
 
+// SQLiteOption overrides one of the default connection options of a SQLite
+// database.
+type SQLiteOption func(*sqliteOptions)
+
+type sqliteOptions struct {
+	busyTimeout time.Duration
+	foreignKeys bool
+	journalMode string
+	synchronous string
+}
+
+// WithBusyTimeout sets the amount of time a transaction waits for the
+// database to be unlocked before failing.
+func WithBusyTimeout(timeout time.Duration) SQLiteOption {
+	return func(opts *sqliteOptions) {
+		opts.busyTimeout = timeout
+	}
+}
+
+// WithForeignKeys sets whether foreign key relations are enforced.
+func WithForeignKeys(enabled bool) SQLiteOption {
+	return func(opts *sqliteOptions) {
+		opts.foreignKeys = enabled
+	}
+}
+
+// WithJournalMode sets the journal mode of the database, e.g. TRUNCATE or
+// DELETE for databases on network drives which don't support WAL.
+func WithJournalMode(mode string) SQLiteOption {
+	return func(opts *sqliteOptions) {
+		opts.journalMode = mode
+	}
+}
+
 // NewSQLiteConnection opens a sqlite db at the given path.
 //
 //	_busy_timeout: set to prevent concurrent transactions from failing and
 //	  instead have them block
 //	_foreign_keys: enforce foreign_key relations
 //	_journal_mode: set to WAL instead of delete since it's usually the fastest.
-//	  Only downside is that the db won't work on network drives. In that case this
-//	  should be made configurable and set to TRUNCATE or any of the other options.
+//	  Only downside is that the db won't work on network drives, in that case
+//	  use WithJournalMode to set it to TRUNCATE or any of the other options.
 //	  For reference see https://github.com/mattn/go-sqlite3#connection-string.
-func NewSQLiteConnection(path string) gorm.Dialector {
-	return sqlite.Open(fmt.Sprintf("file:%s?_busy_timeout=30000&_foreign_keys=1&_journal_mode=WAL", path))
+func NewSQLiteConnection(path string, opts ...SQLiteOption) gorm.Dialector {
+	return newSQLiteConnection(path, sqliteOptions{
+		busyTimeout: 30 * time.Second,
+		foreignKeys: true,
+		journalMode: "WAL",
+	}, opts...)
 }
 
 // NewMetricsSQLiteConnection opens a sqlite db at the given path similarly to
 // NewSQLiteConnection but with weaker consistency guarantees since it's
 // optimised for recording metrics.
-func NewMetricsSQLiteConnection(path string) gorm.Dialector {
-	return sqlite.Open(fmt.Sprintf("file:%s?_busy_timeout=30000&_foreign_keys=1&_journal_mode=WAL&_synchronous=NORMAL", path))
+func NewMetricsSQLiteConnection(path string, opts ...SQLiteOption) gorm.Dialector {
+	return newSQLiteConnection(path, sqliteOptions{
+		busyTimeout: 30 * time.Second,
+		foreignKeys: true,
+		journalMode: "WAL",
+		synchronous: "NORMAL",
+	}, opts...)
+}
+
+func newSQLiteConnection(path string, defaults sqliteOptions, opts ...SQLiteOption) gorm.Dialector {
+	o := defaults
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var foreignKeys int
+	if o.foreignKeys {
+		foreignKeys = 1
+	}
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=%d&_foreign_keys=%d&_journal_mode=%s", path, o.busyTimeout.Milliseconds(), foreignKeys, o.journalMode)
+	if o.synchronous != "" {
+		dsn += "&_synchronous=" + o.synchronous
+	}
+	return sqlite.Open(dsn)
 }
 
 // NewMySQLConnection creates a connection to a MySQL database.
@@ -173,6 +297,26 @@ func NewSQLStore(cfg Config) (*SQLStore, modules.ConsensusChangeID, error) {
 		return nil, modules.ConsensusChangeID{}, errors.New("announcementMaxAge must be non-zero")
 	}
 
+	// Sanity check announcement batch limits, falling back to the defaults.
+	softLimit, hardLimit := cfg.AnnouncementBatchSoftLimit, cfg.AnnouncementBatchHardLimit
+	if softLimit == 0 {
+		softLimit = defaultAnnouncementBatchSoftLimit
+	}
+	if hardLimit == 0 {
+		hardLimit = defaultAnnouncementBatchHardLimit
+	}
+	if softLimit < 0 {
+		return nil, modules.ConsensusChangeID{}, errors.New("announcementBatchSoftLimit must be positive")
+	} else if hardLimit < softLimit {
+		return nil, modules.ConsensusChangeID{}, fmt.Errorf("announcementBatchHardLimit must be at least announcementBatchSoftLimit (%d)", softLimit)
+	}
+	catchUpBatchSize := cfg.AnnouncementCatchUpBatchSize
+	if catchUpBatchSize == 0 {
+		catchUpBatchSize = defaultAnnouncementCatchUpBatchSize
+	} else if catchUpBatchSize < 0 {
+		return nil, modules.ConsensusChangeID{}, errors.New("announcementCatchUpBatchSize must be positive")
+	}
+
 	if err := os.MkdirAll(cfg.PartialSlabDir, 0700); err != nil {
 		return nil, modules.ConsensusChangeID{}, fmt.Errorf("failed to create partial slab dir: %v", err)
 	}
@@ -190,6 +334,11 @@ func NewSQLStore(cfg Config) (*SQLStore, modules.ConsensusChangeID, error) {
 	if err != nil {
 		return nil, modules.ConsensusChangeID{}, fmt.Errorf("failed to open metrics db")
 	}
+	for _, gdb := range []*gorm.DB{db, dbMetrics} {
+		if err := configureConnPool(gdb, cfg); err != nil {
+			return nil, modules.ConsensusChangeID{}, fmt.Errorf("failed to configure connection pool: %w", err)
+		}
+	}
 	l := cfg.Logger.Named("sql")
 
 	// Print SQLite version
@@ -251,8 +400,14 @@ func NewSQLStore(cfg Config) (*SQLStore, modules.ConsensusChangeID, error) {
 	}
 
 	shutdownCtx, shutdownCtxCancel := context.WithCancel(context.Background())
+	clock := cfg.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
 	ss := &SQLStore{
 		alerts:                 cfg.Alerts,
+		clock:                  clock,
 		db:                     db,
 		dbMetrics:              dbMetrics,
 		logger:                 l,
@@ -268,13 +423,17 @@ func NewSQLStore(cfg Config) (*SQLStore, modules.ConsensusChangeID, error) {
 		unappliedRevisions:     make(map[types.FileContractID]revisionUpdate),
 		unappliedProofs:        make(map[types.FileContractID]uint64),
 
-		announcementMaxAge: cfg.AnnouncementMaxAge,
+		announcementMaxAge:           cfg.AnnouncementMaxAge,
+		announcementBatchSoftLimit:   softLimit,
+		announcementBatchHardLimit:   hardLimit,
+		announcementCatchUpBatchSize: catchUpBatchSize,
 
 		walletAddress: cfg.WalletAddress,
 		chainIndex: types.ChainIndex{
 			Height: ci.Height,
 			ID:     types.BlockID(ci.BlockID),
 		},
+		ccSubscriberTimeout: consensusSubscriberTimeout,
 
 		retryTransactionIntervals: cfg.RetryTransactionIntervals,
 
@@ -289,6 +448,30 @@ func NewSQLStore(cfg Config) (*SQLStore, modules.ConsensusChangeID, error) {
 	return ss, ccid, nil
 }
 
+// configureConnPool applies the connection pool settings of the config to the
+// underlying sql.DB of the given gorm.DB.
+func configureConnPool(db *gorm.DB, cfg Config) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	return nil
+}
+
+// systemClock is the default Clock of the SQLStore.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
 func isSQLite(db *gorm.DB) bool {
 	switch db.Dialector.(type) {
 	case *sqlite.Dialector:
@@ -337,6 +520,60 @@ func tableCount(db *gorm.DB, model interface{}) (cnt int64, err error) {
 	return
 }
 
+// Metrics returns the row counts of the largest tables of the main database.
+// The result is cached for a short interval to avoid running the count
+// queries too often.
+func (s *SQLStore) Metrics(ctx context.Context) (DatabaseMetrics, error) {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	if !s.metricsCachedAt.IsZero() && s.clock.Now().Sub(s.metricsCachedAt) < databaseMetricsCacheInterval {
+		return s.metricsCache, nil
+	}
+
+	var m DatabaseMetrics
+	db := s.db.WithContext(ctx)
+	for _, count := range []struct {
+		model interface{}
+		dst   *int64
+	}{
+		{&dbHost{}, &m.Hosts},
+		{&dbAnnouncement{}, &m.HostAnnouncements},
+		{&dbContract{}, &m.Contracts},
+		{&dbObject{}, &m.Objects},
+		{&dbSlab{}, &m.Slabs},
+		{&dbSector{}, &m.Sectors},
+	} {
+		cnt, err := tableCount(db, count.model)
+		if err != nil {
+			return DatabaseMetrics{}, err
+		}
+		*count.dst = cnt
+	}
+	if isSQLite(s.db) {
+		if err := db.Raw("PRAGMA page_count").Scan(&m.PageCount).Error; err != nil {
+			return DatabaseMetrics{}, fmt.Errorf("failed to fetch page count: %w", err)
+		} else if err := db.Raw("PRAGMA page_size").Scan(&m.PageSize).Error; err != nil {
+			return DatabaseMetrics{}, fmt.Errorf("failed to fetch page size: %w", err)
+		}
+	}
+
+	s.metricsCache = m
+	s.metricsCachedAt = s.clock.Now()
+	return m, nil
+}
+
+// Backup writes a consistent snapshot of the main database to destPath, which
+// must not exist yet. Since the snapshot is taken using VACUUM INTO within a
+// single read transaction, writers are not blocked while the backup is in
+// progress when the database is in WAL mode. Consensus changes that are still
+// buffered in memory are not part of the backup.
+func (s *SQLStore) Backup(ctx context.Context, destPath string) error {
+	if !isSQLite(s.db) {
+		return ErrBackupUnsupported
+	}
+	return s.db.WithContext(ctx).Exec("VACUUM INTO ?", destPath).Error
+}
+
 // Close closes the underlying database connection of the store.
 func (s *SQLStore) Close() error {
 	s.shutdownCtxCancel()
@@ -373,6 +610,13 @@ func (s *SQLStore) Close() error {
 
 // ProcessConsensusChange implements consensus.Subscriber.
 func (ss *SQLStore) ProcessConsensusChange(cc modules.ConsensusChange) {
+	ss.processConsensusChange(cc)
+
+	// Notify subscribers after releasing the persistMu.
+	ss.notifyConsensusSubscribers(cc)
+}
+
+func (ss *SQLStore) processConsensusChange(cc modules.ConsensusChange) {
 	ss.persistMu.Lock()
 	defer ss.persistMu.Unlock()
 
@@ -387,9 +631,10 @@ func (ss *SQLStore) ProcessConsensusChange(cc modules.ConsensusChange) {
 		ID:     types.BlockID(cc.AppliedBlocks[len(cc.AppliedBlocks)-1].ID()),
 	}
 
-	// Try to apply the updates.
-	if err := ss.applyUpdates(false); err != nil {
-		ss.logger.Error(fmt.Sprintf("failed to apply updates, err: %v", err))
+	// Try to apply the updates, force it if too many announcements are
+	// buffered.
+	if err := ss.applyUpdates(ss.announcementBatchLimitReached(cc.Synced)); err != nil {
+		ss.logApplyUpdatesError(err)
 	}
 
 	// Force a persist if no block has been received for some time.
@@ -411,16 +656,129 @@ func (ss *SQLStore) ProcessConsensusChange(cc modules.ConsensusChange) {
 		ss.persistMu.Lock()
 		defer ss.persistMu.Unlock()
 		if err := ss.applyUpdates(true); err != nil {
-			ss.logger.Error(fmt.Sprintf("failed to apply updates, err: %v", err))
+			ss.logApplyUpdatesError(err)
 		}
 	})
 }
 
+// logApplyUpdatesError logs an error that occurred when applying the unapplied
+// updates, they are retried the next time updates are applied. The caller is
+// expected to hold the persistMu.
+func (ss *SQLStore) logApplyUpdatesError(err error) {
+	ss.logger.Errorw("failed to apply updates",
+		zap.Error(err),
+		"ccid", ss.ccid,
+		"height", ss.chainIndex.Height,
+		"announcements", len(ss.unappliedAnnouncements),
+		"revisions", len(ss.unappliedRevisions),
+		"proofs", len(ss.unappliedProofs))
+}
+
+// ccSubscriber passes consensus changes to a subscriber one at a time, in the
+// order they were queued.
+type ccSubscriber struct {
+	s       modules.ConsensusSetSubscriber
+	changes chan ccNotification
+	stop    chan struct{}
+
+	// pending is the number of changes that are queued for the subscriber or
+	// are being processed by it.
+	pending atomic.Int64
+}
+
+// ccNotification is a consensus change that is queued for a subscriber, done
+// is closed once the subscriber processed it.
+type ccNotification struct {
+	cc   modules.ConsensusChange
+	done chan struct{}
+}
+
+// AddConsensusSubscriber registers a subscriber that is passed every consensus
+// change after the store processed it. Changes are always passed to a
+// subscriber in order and the store waits for a subscriber to process a change
+// until the timeout expires. A subscriber that fails to keep up has its changes
+// queued without being waited for, if its queue fills up the subscriber is
+// disconnected and no longer receives any changes.
+func (ss *SQLStore) AddConsensusSubscriber(s modules.ConsensusSetSubscriber) {
+	sub := &ccSubscriber{
+		s:       s,
+		changes: make(chan ccNotification, consensusSubscriberQueueSize),
+		stop:    make(chan struct{}),
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ss.shutdownCtx.Done():
+				return
+			case <-sub.stop:
+				return
+			case n := <-sub.changes:
+				sub.s.ProcessConsensusChange(n.cc)
+				sub.pending.Add(-1)
+				close(n.done)
+			}
+		}
+	}()
+
+	ss.ccSubscribersMu.Lock()
+	defer ss.ccSubscribersMu.Unlock()
+	ss.ccSubscribers = append(ss.ccSubscribers, sub)
+}
+
+func (ss *SQLStore) notifyConsensusSubscribers(cc modules.ConsensusChange) {
+	ss.ccSubscribersMu.Lock()
+	subscribers := append([]*ccSubscriber(nil), ss.ccSubscribers...)
+	ss.ccSubscribersMu.Unlock()
+
+	for i, sub := range subscribers {
+		// queue the change, a subscriber with a full queue is disconnected
+		// since it would otherwise miss changes
+		n := ccNotification{cc: cc, done: make(chan struct{})}
+		backlog := sub.pending.Add(1) > 1
+		select {
+		case sub.changes <- n:
+		default:
+			ss.removeConsensusSubscriber(sub)
+			ss.logger.Errorw("consensus subscriber queue is full, disconnecting subscriber",
+				"subscriber", i,
+				"ccid", cc.ID)
+			continue
+		}
+
+		// don't wait for a subscriber that is still processing earlier changes
+		if backlog {
+			continue
+		}
+
+		select {
+		case <-n.done:
+		case <-time.After(ss.ccSubscriberTimeout):
+			ss.logger.Warnw("consensus subscriber timed out processing consensus change",
+				"subscriber", i,
+				"ccid", cc.ID,
+				"timeout", ss.ccSubscriberTimeout)
+		}
+	}
+}
+
+func (ss *SQLStore) removeConsensusSubscriber(sub *ccSubscriber) {
+	ss.ccSubscribersMu.Lock()
+	defer ss.ccSubscribersMu.Unlock()
+	for i, s := range ss.ccSubscribers {
+		if s == sub {
+			ss.ccSubscribers = append(ss.ccSubscribers[:i], ss.ccSubscribers[i+1:]...)
+			close(sub.stop)
+			return
+		}
+	}
+}
+
 // applyUpdates applies all unapplied updates to the database.
 func (ss *SQLStore) applyUpdates(force bool) error {
 	// Check if we need to apply changes
 	persistIntervalPassed := time.Since(ss.lastSave) > ss.persistInterval                           // enough time has passed since last persist
-	softLimitReached := len(ss.unappliedAnnouncements) >= announcementBatchSoftLimit                // enough announcements have accumulated
+	softLimitReached := len(ss.unappliedAnnouncements) >= ss.announcementBatchSoftLimit             // enough announcements have accumulated
 	unappliedRevisionsOrProofs := len(ss.unappliedRevisions) > 0 || len(ss.unappliedProofs) > 0     // enough revisions/proofs have accumulated
 	unappliedOutputsOrTxns := len(ss.unappliedOutputChanges) > 0 || len(ss.unappliedTxnChanges) > 0 // enough outputs/txns have accumualted
 	unappliedContractState := len(ss.unappliedContractState) > 0                                    // the chain state of a contract changed
@@ -508,6 +866,7 @@ func (ss *SQLStore) applyUpdates(force bool) error {
 	ss.unappliedRevisions = make(map[types.FileContractID]revisionUpdate)
 	ss.unappliedHostKeys = make(map[types.PublicKey]struct{})
 	ss.unappliedAnnouncements = ss.unappliedAnnouncements[:0]
+	ss.unappliedAnnouncementBlocks = 0
 	ss.lastSave = time.Now()
 	ss.unappliedOutputChanges = nil
 	ss.unappliedTxnChanges = nil
@@ -532,7 +891,8 @@ func (s *SQLStore) retryTransaction(fc func(tx *gorm.DB) error, opts ...*sql.TxO
 			strings.Contains(err.Error(), "no such table") ||
 			strings.Contains(err.Error(), "Duplicate entry") ||
 			errors.Is(err, api.ErrPartNotFound) ||
-			errors.Is(err, api.ErrSlabNotFound) {
+			errors.Is(err, api.ErrSlabNotFound) ||
+			errors.Is(err, api.ErrIncompatibleSlab) {
 			return true
 		}
 		return false
@@ -566,30 +926,46 @@ func initConsensusInfo(db *gorm.DB) (dbConsensusInfo, modules.ConsensusChangeID,
 	return ci, ccid, nil
 }
 
+// ResetConsensusSubscription resets the store's consensus state to the
+// beginning of the chain. It should be called when the consensus set rejects
+// the store's consensus change ID, e.g. because the consensus set was reset,
+// before resubscribing from modules.ConsensusChangeBeginning.
 func (s *SQLStore) ResetConsensusSubscription() error {
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+
 	// empty tables and reinit consensus_infos
 	var ci dbConsensusInfo
-	err := s.retryTransaction(func(tx *gorm.DB) error {
-		if err := s.db.Exec("DELETE FROM consensus_infos").Error; err != nil {
-			return err
-		} else if err := s.db.Exec("DELETE FROM siacoin_elements").Error; err != nil {
+	var ccid modules.ConsensusChangeID
+	err := s.retryTransaction(func(tx *gorm.DB) (err error) {
+		if err := tx.Exec("DELETE FROM consensus_infos").Error; err != nil {
 			return err
-		} else if err := s.db.Exec("DELETE FROM transactions").Error; err != nil {
+		} else if err := tx.Exec("DELETE FROM siacoin_elements").Error; err != nil {
 			return err
-		} else if ci, _, err = initConsensusInfo(tx); err != nil {
+		} else if err := tx.Exec("DELETE FROM transactions").Error; err != nil {
 			return err
 		}
-		return nil
+		ci, ccid, err = initConsensusInfo(tx)
+		return err
 	})
 	if err != nil {
 		return err
 	}
-	// reset in-memory state.
-	s.persistMu.Lock()
+
+	// reset in-memory state, unapplied changes belong to the chain we are
+	// resyncing from scratch so they are dropped
+	s.ccid = ccid
 	s.chainIndex = types.ChainIndex{
 		Height: ci.Height,
 		ID:     types.BlockID(ci.BlockID),
 	}
-	s.persistMu.Unlock()
+	s.unappliedAnnouncements = s.unappliedAnnouncements[:0]
+	s.unappliedAnnouncementBlocks = 0
+	s.unappliedContractState = make(map[types.FileContractID]contractState)
+	s.unappliedHostKeys = make(map[types.PublicKey]struct{})
+	s.unappliedRevisions = make(map[types.FileContractID]revisionUpdate)
+	s.unappliedProofs = make(map[types.FileContractID]uint64)
+	s.unappliedOutputChanges = nil
+	s.unappliedTxnChanges = nil
 	return nil
 }
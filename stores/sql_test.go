@@ -4,20 +4,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/alerts"
 	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/hostdb"
 	"go.sia.tech/renterd/object"
 	"go.sia.tech/siad/modules"
+	stypes "go.sia.tech/siad/types"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"lukechampine.com/frand"
@@ -38,7 +43,7 @@ var (
 )
 
 type testSQLStore struct {
-	t *testing.T
+	t testing.TB
 	*SQLStore
 
 	dbName        string
@@ -57,12 +62,36 @@ type testSQLStoreConfig struct {
 	persistent      bool
 	skipMigrate     bool
 	skipContractSet bool
+	maxOpenConns    int
+	clock           Clock
+}
+
+// testClock is a Clock that only advances when told to.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newTestClock(now time.Time) *testClock {
+	return &testClock{now: now}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
 }
 
 var defaultTestSQLStoreConfig = testSQLStoreConfig{}
 
 // newTestSQLStore creates a new SQLStore for testing.
-func newTestSQLStore(t *testing.T, cfg testSQLStoreConfig) *testSQLStore {
+func newTestSQLStore(t testing.TB, cfg testSQLStoreConfig) *testSQLStore {
 	t.Helper()
 	dir := cfg.dir
 	if dir == "" {
@@ -129,6 +158,8 @@ func newTestSQLStore(t *testing.T, cfg testSQLStoreConfig) *testSQLStore {
 		Logger:                        zap.NewNop().Sugar(),
 		GormLogger:                    newTestLogger(),
 		RetryTransactionIntervals:     []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond},
+		MaxOpenConns:                  cfg.maxOpenConns,
+		Clock:                         cfg.clock,
 	})
 	if err != nil {
 		t.Fatal("failed to create SQLStore", err)
@@ -214,7 +245,7 @@ func newTestLogger() logger.Interface {
 }
 
 func (s *testSQLStore) addTestObject(path string, o object.Object) (api.Object, error) {
-	if err := s.UpdateObject(context.Background(), api.DefaultBucketName, path, testContractSet, testETag, testMimeType, testMetadata, o); err != nil {
+	if err := s.UpdateObject(context.Background(), api.DefaultBucketName, path, testContractSet, testETag, testMimeType, api.UploadModeOverwrite, testMetadata, o); err != nil {
 		return api.Object{}, err
 	} else if obj, err := s.Object(context.Background(), api.DefaultBucketName, path); err != nil {
 		return api.Object{}, err
@@ -271,6 +302,144 @@ func (s *SQLStore) overrideSlabHealth(objectID string, health float64) (err erro
 	return
 }
 
+func TestSQLiteConnectionOptions(t *testing.T) {
+	dir := t.TempDir()
+
+	// helper to open a connection and assert its settings
+	assertPragmas := func(name, journalMode string, busyTimeout, foreignKeys int, opts ...SQLiteOption) {
+		t.Helper()
+		db, err := gorm.Open(NewSQLiteConnection(filepath.Join(dir, name), opts...), &gorm.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sqlDB.Close()
+
+		var mode string
+		var timeout, fks int
+		if err := db.Raw("PRAGMA journal_mode").Scan(&mode).Error; err != nil {
+			t.Fatal(err)
+		} else if err := db.Raw("PRAGMA busy_timeout").Scan(&timeout).Error; err != nil {
+			t.Fatal(err)
+		} else if err := db.Raw("PRAGMA foreign_keys").Scan(&fks).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !strings.EqualFold(mode, journalMode) {
+			t.Fatalf("unexpected journal mode %v, expected %v", mode, journalMode)
+		} else if timeout != busyTimeout {
+			t.Fatalf("unexpected busy timeout %v, expected %v", timeout, busyTimeout)
+		} else if fks != foreignKeys {
+			t.Fatalf("unexpected foreign keys %v, expected %v", fks, foreignKeys)
+		}
+
+		// assert we can write to the db
+		if err := db.Exec("CREATE TABLE test (id INTEGER PRIMARY KEY)").Error; err != nil {
+			t.Fatal(err)
+		} else if err := db.Exec("INSERT INTO test (id) VALUES (1)").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert the defaults
+	assertPragmas("default.sqlite", "wal", 30000, 1)
+
+	// assert the options override the defaults
+	assertPragmas("truncate.sqlite", "truncate", 30000, 1, WithJournalMode("TRUNCATE"))
+	assertPragmas("custom.sqlite", "delete", 5000, 0, WithJournalMode("DELETE"), WithBusyTimeout(5*time.Second), WithForeignKeys(false))
+}
+
+// TestDatabaseMetrics asserts the row counts returned by Metrics match the
+// number of inserted rows and are cached.
+func TestDatabaseMetrics(t *testing.T) {
+	clock := newTestClock(time.Unix(1700000000, 0))
+	ss := newTestSQLStore(t, testSQLStoreConfig{clock: clock})
+	defer ss.Close()
+
+	// add 3 hosts and 2 contracts
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, _, err := ss.addTestContracts(hks[:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMetrics := func(hosts, contracts int64) {
+		t.Helper()
+		m, err := ss.Metrics(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Hosts != hosts {
+			t.Fatalf("expected %d hosts, got %d", hosts, m.Hosts)
+		} else if m.HostAnnouncements != hosts {
+			t.Fatalf("expected %d announcements, got %d", hosts, m.HostAnnouncements)
+		} else if m.Contracts != contracts {
+			t.Fatalf("expected %d contracts, got %d", contracts, m.Contracts)
+		} else if m.Objects != 0 || m.Slabs != 0 || m.Sectors != 0 {
+			t.Fatalf("expected no objects, slabs or sectors, got %+v", m)
+		}
+		if isSQLite(ss.db) && (m.PageCount == 0 || m.PageSize == 0) {
+			t.Fatalf("expected page count and size to be set, got %+v", m)
+		}
+	}
+	assertMetrics(3, 2)
+
+	// add another host, the metrics should be cached
+	if err := ss.addTestHost(types.PublicKey{4}); err != nil {
+		t.Fatal(err)
+	}
+	assertMetrics(3, 2)
+
+	// advance the clock past the cache interval
+	clock.Advance(databaseMetricsCacheInterval)
+	assertMetrics(4, 2)
+}
+
+// TestBackup asserts a backup of the store can be opened as a new store.
+func TestBackup(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add some hosts
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// MySQL is not supported
+	dir := t.TempDir()
+	err = ss.Backup(context.Background(), filepath.Join(dir, "db.sqlite"))
+	if !isSQLite(ss.db) {
+		if !errors.Is(err, ErrBackupUnsupported) {
+			t.Fatalf("expected ErrBackupUnsupported, got %v", err)
+		}
+		return
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	// backing up to an existing file should fail
+	if err := ss.Backup(context.Background(), filepath.Join(dir, "db.sqlite")); err == nil {
+		t.Fatal("expected backup to an existing file to fail")
+	}
+
+	// open the backup and assert the hosts are present
+	backup := newTestSQLStore(t, testSQLStoreConfig{
+		dir:             dir,
+		persistent:      true,
+		skipContractSet: true,
+	})
+	defer backup.Close()
+	for _, hk := range hks {
+		if _, err := backup.Host(context.Background(), hk); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 // TestConsensusReset is a unit test for ResetConsensusSubscription.
 func TestConsensusReset(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
@@ -291,11 +460,41 @@ func TestConsensusReset(t *testing.T) {
 		TransactionID: hash256{3},
 	})
 
+	// Simulate the store having processed changes the consensus set no longer
+	// knows about, the consensus set rejects ccid2 and the store still has
+	// unapplied changes from the old chain.
+	ss.SQLStore.ccid = ccid2
+	ss.chainIndex = types.ChainIndex{Height: 10, ID: types.BlockID{4}}
+	ss.unappliedAnnouncements = []announcement{{hostKey: publicKey{7}, announcement: hostdb.Announcement{NetAddress: "foo.bar:1000"}}}
+	ss.unappliedAnnouncementBlocks = 1
+	ss.unappliedHostKeys[types.PublicKey{7}] = struct{}{}
+	ss.unappliedContractState[types.FileContractID{8}] = contractStateActive
+	ss.unappliedRevisions[types.FileContractID{8}] = revisionUpdate{height: 10, number: 1}
+	ss.unappliedProofs[types.FileContractID{8}] = 10
+	ss.unappliedOutputChanges = []outputChange{{addition: true, oid: hash256{5}}}
+	ss.unappliedTxnChanges = []txnChange{{addition: true, txnID: hash256{6}}}
+
 	// Reset the consensus.
 	if err := ss.ResetConsensusSubscription(); err != nil {
 		t.Fatal(err)
 	}
 
+	// Check the in-memory state was reset without reopening the store.
+	if ss.SQLStore.ccid != modules.ConsensusChangeBeginning {
+		t.Fatal("wrong ccid", ss.SQLStore.ccid, modules.ConsensusChangeBeginning)
+	} else if ss.chainIndex != (types.ChainIndex{}) {
+		t.Fatal("wrong chain index", ss.chainIndex)
+	} else if len(ss.unappliedOutputChanges) != 0 || len(ss.unappliedTxnChanges) != 0 {
+		t.Fatal("unapplied wallet changes weren't dropped")
+	} else if len(ss.unappliedAnnouncements) != 0 || ss.unappliedAnnouncementBlocks != 0 || len(ss.unappliedHostKeys) != 0 {
+		t.Fatal("unapplied host changes weren't dropped")
+	} else if len(ss.unappliedContractState) != 0 || len(ss.unappliedRevisions) != 0 || len(ss.unappliedProofs) != 0 {
+		t.Fatal("unapplied contract changes weren't dropped")
+	}
+	if err := ss.applyUpdates(true); err != nil {
+		t.Fatal(err)
+	}
+
 	// Reopen the SQLStore.
 	ss = ss.Reopen()
 	defer ss.Close()
@@ -328,6 +527,25 @@ func TestConsensusReset(t *testing.T) {
 	}
 }
 
+// TestConnPoolConfig asserts the connection pool settings passed to
+// NewSQLStore are applied to the underlying databases.
+func TestConnPoolConfig(t *testing.T) {
+	if dbURI, _, _, _ := DBConfigFromEnv(); dbURI == "" {
+		t.Skip("requires MySQL")
+	}
+	ss := newTestSQLStore(t, testSQLStoreConfig{maxOpenConns: 3})
+	defer ss.Close()
+
+	for _, gdb := range []*gorm.DB{ss.db, ss.dbMetrics} {
+		sqlDB, err := gdb.DB()
+		if err != nil {
+			t.Fatal(err)
+		} else if stats := sqlDB.Stats(); stats.MaxOpenConnections != 3 {
+			t.Fatalf("expected max open connections to be 3, got %d", stats.MaxOpenConnections)
+		}
+	}
+}
+
 type sqliteQueryPlan struct {
 	Detail string `json:"detail"`
 }
@@ -337,6 +555,10 @@ func (p sqliteQueryPlan) usesIndex() bool {
 	return strings.Contains(d, "using index") || strings.Contains(d, "using covering index")
 }
 
+func (p sqliteQueryPlan) usesTempBTree() bool {
+	return strings.Contains(strings.ToLower(p.Detail), "temp b-tree")
+}
+
 //nolint:tagliatelle
 type mysqlQueryPlan struct {
 	Extra        string `json:"Extra"`
@@ -375,19 +597,42 @@ func TestQueryPlan(t *testing.T) {
 		"SELECT * FROM slabs WHERE health > 0",
 		"SELECT * FROM slabs WHERE db_buffered_slab_id = 1",
 
+		// hosts
+		"SELECT * FROM hosts WHERE accepting_contracts = 1",
+
 		// objects
 		"SELECT * FROM objects WHERE db_bucket_id = 1",
 		"SELECT * FROM objects WHERE etag = ''",
+
+		// slices
+		"SELECT * FROM slices WHERE db_slab_id = 1",
+		"SELECT * FROM slices WHERE db_object_id = 1 ORDER BY object_index",
+		"SELECT * FROM slices WHERE db_multipart_part_id = 1",
+
+		// sectors
+		"SELECT * FROM sectors WHERE db_slab_id = 1",
+
+		// object_user_metadata
+		"SELECT * FROM object_user_metadata WHERE db_object_id = 1",
+		"SELECT * FROM object_user_metadata WHERE db_multipart_upload_id = 1",
+
+		// host_announcements
+		"SELECT * FROM host_announcements WHERE host_key = ''",
 	}
 
 	for _, query := range queries {
 		if isSQLite(ss.db) {
-			var explain sqliteQueryPlan
+			var explain []sqliteQueryPlan
 			if err := ss.db.Raw(fmt.Sprintf("EXPLAIN QUERY PLAN %s;", query)).Scan(&explain).Error; err != nil {
 				t.Fatal(err)
-			} else if !explain.usesIndex() {
+			} else if len(explain) == 0 || !explain[0].usesIndex() {
 				t.Fatalf("query '%s' should use an index, instead the plan was %+v", query, explain)
 			}
+			for _, step := range explain {
+				if step.usesTempBTree() {
+					t.Fatalf("query '%s' shouldn't require sorting, instead the plan was %+v", query, explain)
+				}
+			}
 		} else {
 			var explain mysqlQueryPlan
 			if err := ss.db.Raw(fmt.Sprintf("EXPLAIN %s;", query)).Scan(&explain).Error; err != nil {
@@ -420,3 +665,163 @@ func TestApplyUpdatesErr(t *testing.T) {
 		t.Fatal("lastSave should not have changed")
 	}
 }
+
+func TestConsensusChangeApplyError(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// observe the store's logs
+	core, logs := observer.New(zap.ErrorLevel)
+	ss.logger = zap.New(core).Sugar()
+
+	// drop the consensus info table so applying updates fails
+	if err := ss.db.Migrator().DropTable(&dbConsensusInfo{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// apply a consensus change and force the updates to be applied
+	ccid := modules.ConsensusChangeID{1, 2, 3}
+	ss.lastSave = time.Time{}
+	ss.unappliedAnnouncements = append(ss.unappliedAnnouncements, announcement{
+		hostKey:      publicKey(types.GeneratePrivateKey().PublicKey()),
+		announcement: newTestHostDBAnnouncement("host.com"),
+	})
+	ss.ProcessConsensusChange(modules.ConsensusChange{
+		ID:            ccid,
+		BlockHeight:   1,
+		AppliedBlocks: []stypes.Block{{}},
+		AppliedDiffs:  []modules.ConsensusChangeDiffs{{}},
+	})
+
+	// assert the error was logged with the ccid and the number of
+	// announcements
+	entries := logs.FilterMessage("failed to apply updates").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["ccid"] != ccid.String() {
+		t.Fatal("unexpected ccid", fields["ccid"])
+	} else if fields["announcements"] != int64(1) {
+		t.Fatal("unexpected number of announcements", fields["announcements"])
+	} else if fields["error"] == nil {
+		t.Fatal("expected error to be logged")
+	}
+
+	// the updates should have been kept to be retried
+	if len(ss.unappliedAnnouncements) != 1 {
+		t.Fatal("expected announcement to be kept", len(ss.unappliedAnnouncements))
+	}
+}
+
+type ccSubscriberFn func(modules.ConsensusChange)
+
+func (fn ccSubscriberFn) ProcessConsensusChange(cc modules.ConsensusChange) { fn(cc) }
+
+func TestConsensusSubscribers(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ss.ccSubscriberTimeout = 100 * time.Millisecond
+
+	// add a slow subscriber followed by one that records the changes
+	block := make(chan struct{})
+	slowReceived := make(chan modules.ConsensusChangeID, 2)
+	var received []modules.ConsensusChangeID
+	ss.AddConsensusSubscriber(ccSubscriberFn(func(cc modules.ConsensusChange) { <-block; slowReceived <- cc.ID }))
+	var locked bool
+	ss.AddConsensusSubscriber(ccSubscriberFn(func(cc modules.ConsensusChange) {
+		received = append(received, cc.ID)
+		if ss.persistMu.TryLock() {
+			ss.persistMu.Unlock()
+		} else {
+			locked = true
+		}
+	}))
+
+	// apply two consensus changes
+	ccids := []modules.ConsensusChangeID{{1, 2, 3}, {4, 5, 6}}
+	start := time.Now()
+	for _, ccid := range ccids {
+		ss.ProcessConsensusChange(modules.ConsensusChange{
+			ID:            ccid,
+			AppliedBlocks: []stypes.Block{{}},
+			AppliedDiffs:  []modules.ConsensusChangeDiffs{{}},
+		})
+	}
+
+	// the slow subscriber shouldn't stall the store indefinitely
+	if time.Since(start) > time.Second {
+		t.Fatal("slow subscriber stalled the consensus change")
+	}
+
+	// the store should have processed the changes before the subscribers
+	// were notified and the second subscriber should have received them
+	if ss.SQLStore.ccid != ccids[1] {
+		t.Fatal("unexpected ccid", ss.SQLStore.ccid)
+	} else if len(received) != 2 || received[0] != ccids[0] || received[1] != ccids[1] {
+		t.Fatal("unexpected consensus changes", received)
+	} else if locked {
+		t.Fatal("subscribers should be notified after releasing the persistMu")
+	}
+
+	// unblock the slow subscriber and assert it received the changes in order
+	close(block)
+	for _, ccid := range ccids {
+		select {
+		case id := <-slowReceived:
+			if id != ccid {
+				t.Fatal("unexpected consensus change", id, ccid)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("slow subscriber didn't receive the consensus change")
+		}
+	}
+	if len(slowReceived) != 0 {
+		t.Fatal("slow subscriber received unexpected consensus changes")
+	}
+}
+
+func TestConsensusSubscriberDisconnect(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ss.ccSubscriberTimeout = 100 * time.Millisecond
+
+	// add a subscriber that blocks until we unblock it
+	block := make(chan struct{})
+	defer close(block)
+	ss.AddConsensusSubscriber(ccSubscriberFn(func(cc modules.ConsensusChange) { <-block }))
+
+	// apply enough consensus changes to fill up the subscriber's queue, one
+	// change is being processed so the queue is full after one more change
+	// than its size
+	cc := modules.ConsensusChange{
+		AppliedBlocks: []stypes.Block{{}},
+		AppliedDiffs:  []modules.ConsensusChangeDiffs{{}},
+	}
+	start := time.Now()
+	for i := 0; i < consensusSubscriberQueueSize+1; i++ {
+		ss.ProcessConsensusChange(cc)
+	}
+
+	// only the first change should have been waited for
+	if time.Since(start) > time.Second {
+		t.Fatal("store waited for a subscriber with a backlog")
+	}
+
+	// the subscriber should still be connected
+	ss.ccSubscribersMu.Lock()
+	n := len(ss.ccSubscribers)
+	ss.ccSubscribersMu.Unlock()
+	if n != 1 {
+		t.Fatal("expected subscriber to be connected", n)
+	}
+
+	// the next change doesn't fit the queue and disconnects the subscriber
+	ss.ProcessConsensusChange(cc)
+	ss.ccSubscribersMu.Lock()
+	n = len(ss.ccSubscribers)
+	ss.ccSubscribersMu.Unlock()
+	if n != 0 {
+		t.Fatal("expected subscriber to be disconnected", n)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	rhpv2 "go.sia.tech/core/rhp/v2"
 	rhpv3 "go.sia.tech/core/rhp/v3"
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/hostdb"
 )
 
 const (
@@ -32,6 +33,7 @@ type (
 	publicKey      types.PublicKey
 	hostSettings   rhpv2.HostSettings
 	hostPriceTable rhpv3.HostPriceTable
+	rttHistogram   hostdb.RTTHistogram
 	balance        big.Int
 	unsigned64     uint64 // used for storing large uint64 values in sqlite
 	secretKey      []byte
@@ -167,11 +169,19 @@ func (hostSettings) GormDataType() string {
 
 // Scan scan value into hostSettings, implements sql.Scanner interface.
 func (hs *hostSettings) Scan(value interface{}) error {
-	bytes, ok := value.([]byte)
-	if !ok {
+	var bytes []byte
+	switch value := value.(type) {
+	case nil:
+		*hs = hostSettings{}
+		return nil
+	case string:
+		bytes = []byte(value)
+	case []byte:
+		bytes = value
+	default:
 		return errors.New(fmt.Sprint("failed to unmarshal hostSettings value:", value))
 	}
-	return json.Unmarshal(bytes, hs)
+	return unmarshalIgnoringNulls(bytes, hs)
 }
 
 // Value returns a hostSettings value, implements driver.Valuer interface.
@@ -185,11 +195,19 @@ func (hs hostPriceTable) GormDataType() string {
 
 // Scan scan value into hostPriceTable, implements sql.Scanner interface.
 func (hpt *hostPriceTable) Scan(value interface{}) error {
-	bytes, ok := value.([]byte)
-	if !ok {
+	var bytes []byte
+	switch value := value.(type) {
+	case nil:
+		*hpt = hostPriceTable{}
+		return nil
+	case string:
+		bytes = []byte(value)
+	case []byte:
+		bytes = value
+	default:
 		return errors.New(fmt.Sprint("failed to unmarshal hostPriceTable value:", value))
 	}
-	return json.Unmarshal(bytes, hpt)
+	return unmarshalIgnoringNulls(bytes, hpt)
 }
 
 // Value returns a hostPriceTable value, implements driver.Valuer interface.
@@ -197,6 +215,64 @@ func (hs hostPriceTable) Value() (driver.Value, error) {
 	return json.Marshal(hs)
 }
 
+// unmarshalIgnoringNulls unmarshals the given JSON object into v. If that
+// fails, it retries after dropping all null fields, leaving them at their zero
+// value. This prevents a single host with malformed settings from failing every
+// query that loads it, since types.Currency can't be unmarshaled from null.
+func unmarshalIgnoringNulls(b []byte, v interface{}) error {
+	err := json.Unmarshal(b, v)
+	if err == nil {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(b, &fields) != nil {
+		return err
+	}
+	var dropped bool
+	for k, f := range fields {
+		if string(f) == "null" {
+			delete(fields, k)
+			dropped = true
+		}
+	}
+	if !dropped {
+		return err
+	}
+
+	b, err = json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (rttHistogram) GormDataType() string {
+	return "string"
+}
+
+// Scan scan value into rttHistogram, implements sql.Scanner interface.
+func (h *rttHistogram) Scan(value interface{}) error {
+	var bytes []byte
+	switch value := value.(type) {
+	case nil:
+		*h = rttHistogram{} // hosts without samples have no histogram
+		return nil
+	case string:
+		bytes = []byte(value)
+	case []byte:
+		bytes = value
+	default:
+		return errors.New(fmt.Sprint("failed to unmarshal rttHistogram value:", value))
+	}
+	return json.Unmarshal(bytes, h)
+}
+
+// Value returns a rttHistogram value, implements driver.Valuer interface.
+func (h rttHistogram) Value() (driver.Value, error) {
+	return json.Marshal(h)
+}
+
 func (balance) GormDataType() string {
 	return "string"
 }
@@ -27,8 +27,53 @@ type (
 	Broadcaster interface {
 		BroadcastAction(ctx context.Context, action Event) error
 	}
+
+	// Subscriber allows for subscribing to the events of a module from within
+	// the same process.
+	Subscriber interface {
+		Subscribe(module string, fn func(Event))
+	}
+)
+
+// Relay relays the events that are broadcast to it to its in-process
+// subscribers. It allows subscribing to the events of a Manager before the
+// Manager is created, e.g. when a worker subscribes to the events of a bus
+// running in the same process. Subscribers are called synchronously and should
+// return quickly.
+type Relay struct {
+	mu          sync.Mutex
+	subscribers map[string][]func(Event) // module -> subscribers
+}
+
+var (
+	_ Broadcaster = (*Relay)(nil)
+	_ Subscriber  = (*Relay)(nil)
 )
 
+// NewRelay returns a new Relay.
+func NewRelay() *Relay {
+	return &Relay{subscribers: make(map[string][]func(Event))}
+}
+
+// BroadcastAction implements Broadcaster.
+func (r *Relay) BroadcastAction(_ context.Context, event Event) error {
+	r.mu.Lock()
+	subscribers := append([]func(Event){}, r.subscribers[event.Module]...)
+	r.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(event)
+	}
+	return nil
+}
+
+// Subscribe implements Subscriber.
+func (r *Relay) Subscribe(module string, fn func(Event)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers[module] = append(r.subscribers[module], fn)
+}
+
 type NoopBroadcaster struct{}
 
 func (NoopBroadcaster) BroadcastAction(_ context.Context, _ Event) error { return nil }
@@ -66,6 +111,8 @@ type Manager struct {
 	shutdownCtx       context.Context
 	shutdownCtxCancel context.CancelFunc
 
+	relay *Relay
+
 	mu       sync.Mutex
 	queues   map[string]*eventQueue // URL -> queue
 	webhooks map[string]Webhook
@@ -81,9 +128,16 @@ type eventQueue struct {
 	events       []Event
 }
 
-func (m *Manager) BroadcastAction(_ context.Context, event Event) error {
+func (m *Manager) BroadcastAction(ctx context.Context, event Event) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.broadcastToWebhooks(event)
+	m.mu.Unlock()
+
+	// Notify in-process subscribers.
+	return m.relay.BroadcastAction(ctx, event)
+}
+
+func (m *Manager) broadcastToWebhooks(event Event) {
 	for _, hook := range m.webhooks {
 		if !hook.Matches(event) {
 			continue
@@ -113,7 +167,6 @@ func (m *Manager) BroadcastAction(_ context.Context, event Event) error {
 		}
 		queue.mu.Unlock()
 	}
-	return nil
 }
 
 func (m *Manager) Close() error {
@@ -179,6 +232,12 @@ func (m *Manager) Register(wh Webhook) error {
 	return nil
 }
 
+// Subscribe registers a function that is called with every event of the given
+// module. Subscribers are called synchronously and should return quickly.
+func (m *Manager) Subscribe(module string, fn func(Event)) {
+	m.relay.Subscribe(module, fn)
+}
+
 func (a Event) String() string {
 	return a.Module + "." + a.Event
 }
@@ -227,6 +286,8 @@ func NewManager(logger *zap.SugaredLogger, store WebhookStore) (*Manager, error)
 		shutdownCtx:       shutdownCtx,
 		shutdownCtxCancel: shutdownCtxCancel,
 
+		relay: NewRelay(),
+
 		queues:   make(map[string]*eventQueue),
 		webhooks: make(map[string]Webhook),
 	}
@@ -3,6 +3,7 @@ package worker
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"testing"
 
@@ -99,3 +100,29 @@ func BenchmarkUploaderMultiObject(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkUploaderConcurrency benchmarks uploading a single object with
+// varying limits on the number of sectors that are uploaded concurrently.
+func BenchmarkUploaderConcurrency(b *testing.B) {
+	for _, concurrency := range []int{1, 10, 30} {
+		b.Run(fmt.Sprint(concurrency), func(b *testing.B) {
+			w := newTestWorker(b)
+			w.uploadManager.uploadSlots = make(chan struct{}, concurrency)
+
+			up := testParameters(b.TempDir())
+			up.rs.MinShards = 10
+			up.rs.TotalShards = 30
+			up.packing = false
+			w.AddHosts(up.rs.TotalShards)
+
+			data := io.LimitReader(&zeroReader{}, int64(b.N*rhpv2.SectorSize*up.rs.MinShards))
+			b.SetBytes(int64(rhpv2.SectorSize * up.rs.MinShards))
+			b.ResetTimer()
+
+			_, _, err := w.uploadManager.Upload(context.Background(), data, w.Contracts(), up, lockingPriorityUpload)
+			if err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
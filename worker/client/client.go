@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -71,6 +73,47 @@ func (c *Client) DownloadObject(ctx context.Context, w io.Writer, bucket, path s
 	return err
 }
 
+// DownloadToFile downloads the object at the given path to localPath and
+// returns the number of bytes written. The object is streamed to a temporary
+// file which is only moved into place if the download succeeded and its size
+// matches the object's, so an interrupted download never leaves a partial file
+// behind.
+func (c *Client) DownloadToFile(ctx context.Context, bucket, path, localPath string) (n int64, err error) {
+	res, err := c.GetObject(ctx, bucket, path, api.DownloadObjectOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer res.Content.Close()
+
+	// create the temporary file next to the destination to ensure the rename
+	// is atomic
+	f, err := os.CreateTemp(filepath.Dir(localPath), fmt.Sprintf(".%s-*.tmp", filepath.Base(localPath)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	// download the object and verify its integrity
+	n, err = io.Copy(f, res.Content)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download object: %w", err)
+	} else if n != res.Size {
+		return 0, fmt.Errorf("failed to download object: size mismatch, %d != %d", n, res.Size)
+	} else if err = f.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync temporary file: %w", err)
+	} else if err = f.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close temporary file: %w", err)
+	} else if err = os.Rename(f.Name(), localPath); err != nil {
+		return 0, fmt.Errorf("failed to move temporary file into place: %w", err)
+	}
+	return n, nil
+}
+
 // DownloadStats returns download statistics.
 func (c *Client) DownloadStats() (resp api.DownloadStatsResponse, err error) {
 	err = c.c.GET("/stats/downloads", &resp)
@@ -217,8 +260,7 @@ func (c *Client) UploadMultipartUploadPart(ctx context.Context, r io.Reader, buc
 	defer io.Copy(io.Discard, resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		err, _ := io.ReadAll(resp.Body)
-		return nil, errors.New(string(err))
+		return nil, uploadError(resp)
 	}
 	return &api.UploadMultipartUploadPartResponse{ETag: resp.Header.Get("ETag")}, nil
 }
@@ -254,10 +296,16 @@ func (c *Client) UploadObject(ctx context.Context, r io.Reader, bucket, path str
 	defer io.Copy(io.Discard, resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		err, _ := io.ReadAll(resp.Body)
-		return nil, errors.New(string(err))
+		return nil, uploadError(resp)
 	}
-	return &api.UploadObjectResponse{ETag: resp.Header.Get("ETag")}, nil
+
+	// decode the response, older workers only set the ETag header
+	var uor api.UploadObjectResponse
+	if err := json.NewDecoder(resp.Body).Decode(&uor); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	uor.ETag = resp.Header.Get("ETag")
+	return &uor, nil
 }
 
 // UploadStats returns the upload stats.
@@ -332,6 +380,19 @@ func parseObjectResponseHeaders(header http.Header) (api.HeadObjectResponse, err
 	}, nil
 }
 
+// uploadError returns the error of a failed upload request, an upload that
+// failed due to insufficient contracts returns an
+// api.InsufficientContractsError.
+func uploadError(resp *http.Response) error {
+	msg, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		if ice, ok := api.ParseInsufficientContractsError(string(msg)); ok {
+			return ice
+		}
+	}
+	return errors.New(string(msg))
+}
+
 func sizeFromSeeker(r io.Reader) (int64, error) {
 	s, ok := r.(io.Seeker)
 	if !ok {
@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/webhooks"
+)
+
+const (
+	// contractCacheTTL is the maximum amount of time the contracts of a set
+	// are cached, it serves as a fallback for changes that aren't broadcast,
+	// e.g. contracts being archived when they expire
+	contractCacheTTL = time.Minute
+)
+
+type (
+	// contractCache caches the contracts of a contract set, it is invalidated
+	// whenever the bus broadcasts the set was updated or removed or that
+	// contracts were renewed or archived.
+	contractCache struct {
+		bus Bus
+
+		mu   sync.Mutex
+		gen  uint64
+		sets map[string]cachedContracts
+	}
+
+	cachedContracts struct {
+		contracts []api.ContractMetadata
+		expiry    time.Time
+	}
+)
+
+func newContractCache(b Bus, s webhooks.Subscriber) *contractCache {
+	cc := &contractCache{
+		bus:  b,
+		sets: make(map[string]cachedContracts),
+	}
+	s.Subscribe(api.WebhookModuleContractSet, cc.handleEvent)
+	s.Subscribe(api.WebhookModuleContract, cc.handleEvent)
+	return cc
+}
+
+// Contracts returns the contracts in the given set, they are fetched from the
+// bus if they aren't cached or if the cache expired.
+func (cc *contractCache) Contracts(ctx context.Context, set string) ([]api.ContractMetadata, error) {
+	cc.mu.Lock()
+	entry, ok := cc.sets[set]
+	gen := cc.gen
+	cc.mu.Unlock()
+	if ok && time.Now().Before(entry.expiry) {
+		return entry.contracts, nil
+	}
+
+	contracts, err := cc.bus.Contracts(ctx, api.ContractsOpts{ContractSet: set})
+	if err != nil {
+		return nil, err
+	}
+
+	// only cache the contracts if the cache wasn't invalidated while we were
+	// fetching them, otherwise we might cache an outdated set
+	cc.mu.Lock()
+	if cc.gen == gen {
+		cc.sets[set] = cachedContracts{
+			contracts: contracts,
+			expiry:    time.Now().Add(contractCacheTTL),
+		}
+	}
+	cc.mu.Unlock()
+	return contracts, nil
+}
+
+// Invalidate removes the given set from the cache, if no set is given the
+// entire cache is cleared.
+func (cc *contractCache) Invalidate(set string) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.gen++
+	if set == "" {
+		cc.sets = make(map[string]cachedContracts)
+	} else {
+		delete(cc.sets, set)
+	}
+}
+
+func (cc *contractCache) handleEvent(e webhooks.Event) {
+	switch payload := e.Payload.(type) {
+	case api.EventContractSetUpdate:
+		cc.Invalidate(payload.Name)
+	case api.EventContractSetDelete:
+		cc.Invalidate(payload.Name)
+	default:
+		// renewals and archivals affect the sets the contracts are in
+		cc.Invalidate("")
+	}
+}
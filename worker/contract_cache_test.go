@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/webhooks"
+)
+
+func TestContractCache(t *testing.T) {
+	// create a contract store with a single contract
+	cs := newContractStoreMock()
+	cs.addContract(types.PublicKey{1})
+
+	// create the cache
+	s := newWebhookSubscriberMock()
+	cc := newContractCache(newBusMock(cs, newHostStoreMock(), newObjectStoreMock("")), s)
+
+	// fetch the contracts
+	contracts, err := cc.Contracts(context.Background(), "set")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 1 {
+		t.Fatalf("expected 1 contract, got %v", len(contracts))
+	}
+
+	// add a contract, the cache shouldn't pick it up
+	cs.addContract(types.PublicKey{2})
+	contracts, err = cc.Contracts(context.Background(), "set")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 1 {
+		t.Fatalf("expected 1 contract, got %v", len(contracts))
+	}
+
+	// broadcast an update for another set, the cache should be unaffected
+	s.broadcast(webhooks.Event{
+		Module:  api.WebhookModuleContractSet,
+		Event:   api.WebhookEventContractSetUpdate,
+		Payload: api.EventContractSetUpdate{Name: "other"},
+	})
+	contracts, err = cc.Contracts(context.Background(), "set")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 1 {
+		t.Fatalf("expected 1 contract, got %v", len(contracts))
+	}
+
+	// broadcast an update for the set, the cache should be invalidated
+	s.broadcast(webhooks.Event{
+		Module:  api.WebhookModuleContractSet,
+		Event:   api.WebhookEventContractSetUpdate,
+		Payload: api.EventContractSetUpdate{Name: "set"},
+	})
+	contracts, err = cc.Contracts(context.Background(), "set")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 2 {
+		t.Fatalf("expected 2 contracts, got %v", len(contracts))
+	}
+
+	// add another contract and broadcast a renewal, the cache should be
+	// invalidated since renewals swap contracts in their sets
+	cs.addContract(types.PublicKey{3})
+	s.broadcast(webhooks.Event{
+		Module:  api.WebhookModuleContract,
+		Event:   api.WebhookEventContractRenew,
+		Payload: api.EventContractRenew{},
+	})
+	contracts, err = cc.Contracts(context.Background(), "set")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 3 {
+		t.Fatalf("expected 3 contracts, got %v", len(contracts))
+	}
+
+	// same goes for archivals
+	cs.addContract(types.PublicKey{4})
+	s.broadcast(webhooks.Event{
+		Module:  api.WebhookModuleContract,
+		Event:   api.WebhookEventContractArchive,
+		Payload: api.EventContractArchive{},
+	})
+	contracts, err = cc.Contracts(context.Background(), "set")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 4 {
+		t.Fatalf("expected 4 contracts, got %v", len(contracts))
+	}
+}
@@ -478,14 +478,19 @@ func (mgr *downloadManager) refreshDownloaders(contracts []api.ContractMetadata)
 	}
 
 	// prune downloaders
-	for hk := range mgr.downloaders {
-		_, wanted := want[hk]
+	for hk, d := range mgr.downloaders {
+		c, wanted := want[hk]
 		if !wanted {
-			mgr.downloaders[hk].Stop()
+			d.Stop()
 			delete(mgr.downloaders, hk)
 			continue
 		}
 
+		// refresh the downloader if the contract was renewed
+		if d.ContractID() != c.ID {
+			d.Refresh(mgr.hm.Host(c.HostKey, c.ID, c.SiamuxAddr), c.ID)
+		}
+
 		delete(want, hk) // remove from want so remainging ones are the missing ones
 	}
 
@@ -493,7 +498,7 @@ func (mgr *downloadManager) refreshDownloaders(contracts []api.ContractMetadata)
 	for _, c := range want {
 		// create a host
 		host := mgr.hm.Host(c.HostKey, c.ID, c.SiamuxAddr)
-		downloader := newDownloader(mgr.shutdownCtx, host)
+		downloader := newDownloader(mgr.shutdownCtx, host, c.ID)
 		mgr.downloaders[c.HostKey] = downloader
 		go downloader.processQueue(mgr.hm)
 	}
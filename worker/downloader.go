@@ -24,7 +24,7 @@ var (
 
 type (
 	downloader struct {
-		host Host
+		hk types.PublicKey
 
 		statsDownloadSpeedBytesPerMS    *stats.DataPoints // keep track of this separately for stats (no decay is applied)
 		statsSectorDownloadEstimateInMS *stats.DataPoints
@@ -33,6 +33,8 @@ type (
 		shutdownCtx    context.Context
 
 		mu                  sync.Mutex
+		fcid                types.FileContractID
+		host                Host
 		consecutiveFailures uint64
 		numDownloads        uint64
 		queue               []*sectorDownloadReq
@@ -40,8 +42,10 @@ type (
 	}
 )
 
-func newDownloader(ctx context.Context, host Host) *downloader {
+func newDownloader(ctx context.Context, host Host, fcid types.FileContractID) *downloader {
 	return &downloader{
+		hk:   host.PublicKey(),
+		fcid: fcid,
 		host: host,
 
 		statsSectorDownloadEstimateInMS: stats.Default(),
@@ -54,8 +58,24 @@ func newDownloader(ctx context.Context, host Host) *downloader {
 	}
 }
 
+func (d *downloader) ContractID() types.FileContractID {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.fcid
+}
+
 func (d *downloader) PublicKey() types.PublicKey {
-	return d.host.PublicKey()
+	return d.hk
+}
+
+// Refresh updates the host the downloader downloads from, this is necessary
+// when the host's contract was renewed so spending is attributed to the
+// renewed contract.
+func (d *downloader) Refresh(host Host, fcid types.FileContractID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fcid = fcid
+	d.host = host
 }
 
 func (d *downloader) Stop() {
@@ -127,8 +147,12 @@ func (d *downloader) estimate() float64 {
 
 func (d *downloader) execute(req *sectorDownloadReq) (err error) {
 	// download the sector
+	d.mu.Lock()
+	host := d.host
+	d.mu.Unlock()
+
 	buf := bytes.NewBuffer(make([]byte, 0, req.length))
-	err = d.host.DownloadSector(req.ctx, buf, req.root, req.offset, req.length, req.overpay)
+	err = host.DownloadSector(req.ctx, buf, req.root, req.offset, req.length, req.overpay)
 	if err != nil {
 		req.fail(err)
 		return err
@@ -34,3 +34,27 @@ func TestDownloaderStopped(t *testing.T) {
 		t.Fatal("no response")
 	}
 }
+
+func TestRefreshDownloaders(t *testing.T) {
+	w := newTestWorker(t)
+	hosts := w.AddHosts(1)
+
+	// convenience variables
+	dm := w.downloadManager
+	h := hosts[0]
+
+	// add the downloader
+	dm.refreshDownloaders(w.Contracts())
+	dl := dm.downloaders[h.PublicKey()]
+
+	// renew the contract and refresh the downloaders
+	renewal := w.RenewContract(h.PublicKey())
+	dm.refreshDownloaders(w.Contracts())
+
+	// assert the downloader was refreshed rather than replaced
+	if dm.downloaders[h.PublicKey()] != dl {
+		t.Fatal("expected downloader to be reused")
+	} else if dl.ContractID() != renewal.rev.ParentID {
+		t.Fatal("expected downloader to use the renewed contract", dl.ContractID(), renewal.rev.ParentID)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/hostdb"
+	"go.sia.tech/renterd/internal/gouging"
 	"go.uber.org/zap"
 )
 
@@ -48,6 +49,7 @@ type (
 		acc                      *account
 		bus                      Bus
 		contractSpendingRecorder ContractSpendingRecorder
+		interactionRecorder      HostInteractionRecorder
 		logger                   *zap.SugaredLogger
 		transportPool            *transportPoolV3
 		priceTables              *priceTables
@@ -65,6 +67,7 @@ func (w *worker) Host(hk types.PublicKey, fcid types.FileContractID, siamuxAddr
 		acc:                      w.accounts.ForHost(hk),
 		bus:                      w.bus,
 		contractSpendingRecorder: w.contractSpendingRecorder,
+		interactionRecorder:      w.hostInteractionRecorder,
 		logger:                   w.logger.Named(hk.String()[:4]),
 		fcid:                     fcid,
 		siamuxAddr:               siamuxAddr,
@@ -90,7 +93,7 @@ func (h *host) DownloadSector(ctx context.Context, w io.Writer, root types.Hash2
 		return err
 	}
 	if breakdown := gc.Check(nil, &hpt); breakdown.Gouging() {
-		return fmt.Errorf("%w: %v", errPriceTableGouging, breakdown)
+		return fmt.Errorf("%w: %v", gouging.ErrPriceTableGouging, breakdown)
 	}
 
 	// return errBalanceInsufficient if balance insufficient
@@ -113,6 +116,23 @@ func (h *host) DownloadSector(ctx context.Context, w io.Writer, root types.Hash2
 			amount = cost.Sub(refund)
 			return err
 		})
+
+		// record the interaction, unless the download was cancelled which
+		// happens when we overdrive
+		if ctx.Err() == nil {
+			h.interactionRecorder.RecordHostInteraction(hostdb.Interaction{
+				HostKey:   h.hk,
+				Type:      hostdb.InteractionTypeDownload,
+				Success:   isSuccessfulInteraction(err),
+				Timestamp: time.Now(),
+			})
+		}
+
+		// record spending, the account is funded by the host's contract so
+		// we attribute the download to it
+		if err == nil && h.fcid != (types.FileContractID{}) {
+			h.contractSpendingRecorder.Record(types.FileContractRevision{ParentID: h.fcid}, api.ContractSpending{Downloads: amount})
+		}
 		return
 	})
 }
@@ -191,14 +211,14 @@ func (h *host) FetchPriceTable(ctx context.Context, rev *types.FileContractRevis
 	// fetchPT is a helper function that performs the RPC given a payment function
 	fetchPT := func(paymentFn PriceTablePaymentFunc) (hpt hostdb.HostPriceTable, err error) {
 		err = h.transportPool.withTransportV3(ctx, h.hk, h.siamuxAddr, func(ctx context.Context, t *transportV3) (err error) {
+			start := time.Now()
 			hpt, err = RPCPriceTable(ctx, t, paymentFn)
-			h.bus.RecordPriceTables(ctx, []hostdb.PriceTableUpdate{
-				{
-					HostKey:    h.hk,
-					Success:    isSuccessfulInteraction(err),
-					Timestamp:  time.Now(),
-					PriceTable: hpt,
-				},
+			h.interactionRecorder.RecordPriceTableUpdate(hostdb.PriceTableUpdate{
+				HostKey:    h.hk,
+				Success:    isSuccessfulInteraction(err),
+				Timestamp:  time.Now(),
+				Duration:   time.Since(start),
+				PriceTable: hpt,
 			})
 			return
 		})
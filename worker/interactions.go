@@ -1,16 +1,295 @@
 package worker
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
 	"go.sia.tech/renterd/hostdb"
+	"go.uber.org/zap"
+)
+
+const (
+	// interactionsMaxBufferFactor determines the maximum number of buffered
+	// interactions as a multiple of the flush size, if the bus is unable to
+	// keep up or is unreachable we drop the oldest interactions rather than
+	// buffering them indefinitely
+	interactionsMaxBufferFactor = 10
+
+	// interactionsMaxBufferSize is the maximum number of buffered interactions
+	// when flushing is not triggered by size
+	interactionsMaxBufferSize = 100_000
+
+	// interactionsMaxBackoffShift caps the exponential backoff that's applied
+	// to the flush interval after consecutive failed flushes
+	interactionsMaxBackoffShift = 6
 )
 
 type (
 	HostInteractionRecorder interface {
-		RecordHostScan(...hostdb.HostScan)
+		RecordHostInteraction(...hostdb.Interaction)
 		RecordPriceTableUpdate(...hostdb.PriceTableUpdate)
+		Flush(context.Context)
+		Stop(context.Context)
 	}
+
+	hostInteractionRecorder struct {
+		flushInterval time.Duration
+		flushSize     int
+		maxBufferSize int
+
+		bus    Bus
+		logger *zap.SugaredLogger
+
+		mu                sync.Mutex
+		interactions      []hostdb.Interaction
+		priceTableUpdates []hostdb.PriceTableUpdate
+
+		failures   int
+		flushing   chan struct{}
+		stopped    bool
+		flushCtx   context.Context
+		flushTimer *time.Timer
+	}
+
+	// interactionsBatch is a batch of buffered interactions that is being
+	// flushed to the bus.
+	interactionsBatch struct {
+		interactions      []hostdb.Interaction
+		priceTableUpdates []hostdb.PriceTableUpdate
+	}
+)
+
+var (
+	_ HostInteractionRecorder = (*hostInteractionRecorder)(nil)
 )
 
+func (w *worker) initHostInteractionRecorder(flushInterval time.Duration, flushSize int) {
+	if w.hostInteractionRecorder != nil {
+		panic("HostInteractionRecorder already initialized") // developer error
+	}
+	w.hostInteractionRecorder = newHostInteractionRecorder(w.bus, w.logger, w.shutdownCtx, flushInterval, flushSize)
+}
+
+func newHostInteractionRecorder(b Bus, l *zap.SugaredLogger, flushCtx context.Context, flushInterval time.Duration, flushSize int) *hostInteractionRecorder {
+	maxBufferSize := interactionsMaxBufferSize
+	if flushSize > 0 {
+		maxBufferSize = flushSize * interactionsMaxBufferFactor
+	}
+	return &hostInteractionRecorder{
+		bus:    b,
+		logger: l,
+
+		flushCtx:      flushCtx,
+		flushInterval: flushInterval,
+		flushSize:     flushSize,
+		maxBufferSize: maxBufferSize,
+	}
+}
+
+// RecordHostInteraction buffers the given interactions until they get flushed
+// to the bus.
+func (r *hostInteractionRecorder) RecordHostInteraction(interactions ...hostdb.Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interactions...)
+	r.scheduleFlush()
+}
+
+// RecordPriceTableUpdate buffers the given price table updates until they get
+// flushed to the bus.
+func (r *hostInteractionRecorder) RecordPriceTableUpdate(ptUpdates ...hostdb.PriceTableUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.priceTableUpdates = append(r.priceTableUpdates, ptUpdates...)
+	r.scheduleFlush()
+}
+
+// Flush flushes all buffered interactions to the bus using the given context,
+// if a flush is already in progress it waits for it to finish first.
+func (r *hostInteractionRecorder) Flush(ctx context.Context) {
+	for {
+		r.mu.Lock()
+		if r.flushing == nil {
+			break
+		}
+		flushing := r.flushing
+		r.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-flushing:
+		}
+	}
+	batch := r.startFlush()
+	r.mu.Unlock()
+
+	r.flushBatch(ctx, batch)
+}
+
+// Stop stops the flush timer and flushes one last time.
+func (r *hostInteractionRecorder) Stop(ctx context.Context) {
+	// stop the flush timer
+	r.mu.Lock()
+	if r.flushTimer != nil {
+		r.flushTimer.Stop()
+		r.flushTimer = nil
+	}
+	r.flushCtx = ctx
+	r.stopped = true
+	r.mu.Unlock()
+
+	// flush all interactions
+	r.Flush(ctx)
+
+	// log if we weren't able to flush them
+	r.mu.Lock()
+	if r.buffered() > 0 {
+		r.logger.Errorw(fmt.Sprintf("failed to record %d interactions and %d price table updates on worker shutdown", len(r.interactions), len(r.priceTableUpdates)))
+	}
+	r.mu.Unlock()
+}
+
+// buffered returns the number of buffered interactions. Must be called with
+// the mutex held.
+func (r *hostInteractionRecorder) buffered() int {
+	return len(r.interactions) + len(r.priceTableUpdates)
+}
+
+// scheduleFlush flushes the buffer in the background if it reached the flush
+// size, otherwise it makes sure a flush is scheduled. Flushing by size is
+// skipped while a flush is in progress or after a failed flush, in which case
+// we fall back to the (backed off) flush timer. Must be called with the mutex
+// held.
+func (r *hostInteractionRecorder) scheduleFlush() {
+	r.dropExcess()
+	if r.flushSize > 0 && r.buffered() >= r.flushSize && r.flushing == nil && r.failures == 0 && !r.stopped {
+		if r.flushTimer != nil {
+			r.flushTimer.Stop()
+			r.flushTimer = nil
+		}
+		batch, ctx := r.startFlush(), r.flushCtx
+		go r.flushBatch(ctx, batch)
+		return
+	}
+	if r.flushTimer == nil && !r.stopped {
+		r.flushTimer = time.AfterFunc(r.backoff(), r.flush)
+	}
+}
+
+// backoff returns the interval after which the next flush is scheduled, which
+// grows exponentially with the number of consecutive failed flushes. Must be
+// called with the mutex held.
+func (r *hostInteractionRecorder) backoff() time.Duration {
+	shift := r.failures
+	if shift > interactionsMaxBackoffShift {
+		shift = interactionsMaxBackoffShift
+	}
+	return r.flushInterval << shift
+}
+
+// dropExcess drops the oldest buffered interactions if the buffer exceeds its
+// maximum size. Must be called with the mutex held.
+func (r *hostInteractionRecorder) dropExcess() {
+	excess := r.buffered() - r.maxBufferSize
+	if excess <= 0 {
+		return
+	}
+	r.logger.Warnw(fmt.Sprintf("dropping %d buffered interactions, the buffer exceeds its max size of %d", excess, r.maxBufferSize))
+
+	drop := func(n int) int {
+		if n > excess {
+			n = excess
+		}
+		excess -= n
+		return n
+	}
+	r.priceTableUpdates = r.priceTableUpdates[drop(len(r.priceTableUpdates)):]
+	r.interactions = r.interactions[drop(len(r.interactions)):]
+}
+
+func (r *hostInteractionRecorder) flush() {
+	r.mu.Lock()
+	r.flushTimer = nil
+	if r.flushing != nil {
+		r.mu.Unlock()
+		return // the ongoing flush reschedules if necessary
+	}
+	batch, ctx := r.startFlush(), r.flushCtx
+	r.mu.Unlock()
+
+	r.flushBatch(ctx, batch)
+}
+
+// startFlush swaps out the buffered interactions and marks the recorder as
+// flushing. Must be called with the mutex held and no other flush in progress.
+func (r *hostInteractionRecorder) startFlush() interactionsBatch {
+	batch := interactionsBatch{
+		interactions:      r.interactions,
+		priceTableUpdates: r.priceTableUpdates,
+	}
+	r.interactions = nil
+	r.priceTableUpdates = nil
+	r.flushing = make(chan struct{})
+	return batch
+}
+
+// flushBatch records the given batch on the bus, interactions are recorded in
+// batches in the order they were buffered which preserves the order of
+// interactions per host. Only one batch is flushed at a time, anything that
+// failed to get recorded is put back in front of the buffer. Must be called
+// without the mutex held.
+func (r *hostInteractionRecorder) flushBatch(ctx context.Context, batch interactionsBatch) {
+	// NOTE: don't bother flushing if the context is cancelled, we can safely
+	// ignore the buffered interactions since we'll flush on shutdown and log
+	// in case we weren't able to flush all interactions to the bus
+	var failed bool
+	select {
+	case <-ctx.Done():
+		failed = true
+	default:
+		if len(batch.interactions) > 0 {
+			if err := r.bus.RecordHostInteractions(ctx, batch.interactions); err != nil {
+				r.logger.Errorw(fmt.Sprintf("failed to record interactions: %v", err))
+				failed = true
+			} else {
+				batch.interactions = nil
+			}
+		}
+		if len(batch.priceTableUpdates) > 0 {
+			if err := r.bus.RecordPriceTables(ctx, batch.priceTableUpdates); err != nil {
+				r.logger.Errorw(fmt.Sprintf("failed to record price table updates: %v", err))
+				failed = true
+			} else {
+				batch.priceTableUpdates = nil
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// put back whatever we failed to record
+	r.interactions = append(batch.interactions, r.interactions...)
+	r.priceTableUpdates = append(batch.priceTableUpdates, r.priceTableUpdates...)
+	r.dropExcess()
+
+	if failed {
+		r.failures++
+	} else {
+		r.failures = 0
+	}
+	close(r.flushing)
+	r.flushing = nil
+
+	// make sure the remainder gets flushed eventually
+	if r.buffered() > 0 && r.flushTimer == nil && !r.stopped {
+		r.flushTimer = time.AfterFunc(r.backoff(), r.flush)
+	}
+}
+
 func isSuccessfulInteraction(err error) bool {
 	// No error always means success.
 	if err == nil {
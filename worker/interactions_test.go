@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/hostdb"
+	"go.uber.org/zap"
+)
+
+type interactionsBusMock struct {
+	*busMock
+
+	mu       sync.Mutex
+	block    chan struct{}
+	err      error
+	calls    int
+	recorded []hostdb.Interaction
+}
+
+func (b *interactionsBusMock) RecordHostInteractions(ctx context.Context, interactions []hostdb.Interaction) error {
+	b.mu.Lock()
+	block, err := b.block, b.err
+	b.calls++
+	b.mu.Unlock()
+
+	if block != nil {
+		<-block
+	}
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.recorded = append(b.recorded, interactions...)
+	b.mu.Unlock()
+	return nil
+}
+
+func TestHostInteractionRecorder(t *testing.T) {
+	b := &interactionsBusMock{
+		busMock: newBusMock(newContractStoreMock(), newHostStoreMock(), newObjectStoreMock(testBucket)),
+		block:   make(chan struct{}),
+	}
+	r := newHostInteractionRecorder(b, zap.NewNop().Sugar(), context.Background(), time.Hour, 2)
+
+	// record enough interactions to trigger a flush, the bus blocks so assert
+	// recording doesn't block while the flush is in progress
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			r.RecordHostInteraction(hostdb.Interaction{Type: "test"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("recording interactions blocked on the bus")
+	}
+
+	// unblock the bus and flush, Flush waits for the flush in progress so
+	// assert only one flush was started while the bus was blocked and all
+	// interactions got recorded
+	close(b.block)
+	r.Flush(context.Background())
+	b.mu.Lock()
+	if b.calls != 2 {
+		t.Fatalf("expected 2 calls, got %v", b.calls)
+	} else if len(b.recorded) != 10 {
+		t.Fatalf("expected 10 recorded interactions, got %v", len(b.recorded))
+	}
+	b.block = nil
+	b.err = errors.New("bus unreachable")
+	b.mu.Unlock()
+
+	// make the bus fail and assert the buffer is capped
+	for i := 0; i < 100; i++ {
+		r.RecordHostInteraction(hostdb.Interaction{Type: "test"})
+	}
+	r.Flush(context.Background())
+	r.mu.Lock()
+	if r.buffered() != r.maxBufferSize {
+		t.Fatalf("expected %v buffered interactions, got %v", r.maxBufferSize, r.buffered())
+	} else if r.failures == 0 {
+		t.Fatal("expected failures to be tracked")
+	}
+	r.mu.Unlock()
+
+	// assert failed flushes back off, size no longer triggers a flush
+	b.mu.Lock()
+	calls := b.calls
+	b.mu.Unlock()
+	for i := 0; i < 10; i++ {
+		r.RecordHostInteraction(hostdb.Interaction{Type: "test"})
+	}
+	r.mu.Lock()
+	if r.flushing != nil {
+		t.Fatal("expected no flush to be in progress")
+	}
+	r.mu.Unlock()
+	b.mu.Lock()
+	if b.calls != calls {
+		t.Fatalf("expected no additional calls, got %v", b.calls-calls)
+	}
+	b.err = nil
+	b.mu.Unlock()
+
+	// assert the buffer is recorded once the bus recovers
+	r.Stop(context.Background())
+	r.mu.Lock()
+	if r.buffered() != 0 {
+		t.Fatalf("expected no buffered interactions, got %v", r.buffered())
+	}
+	r.mu.Unlock()
+}
@@ -293,6 +293,10 @@ func (hs *hostStoreMock) Host(ctx context.Context, hostKey types.PublicKey) (hos
 	return h.hi, nil
 }
 
+func (hs *hostStoreMock) RecordHostInteractions(ctx context.Context, interactions []hostdb.Interaction) error {
+	return nil
+}
+
 func (hs *hostStoreMock) RecordHostScans(ctx context.Context, scans []hostdb.HostScan) error {
 	return nil
 }
@@ -649,3 +653,23 @@ type webhookBroadcasterMock struct{}
 func (*webhookBroadcasterMock) BroadcastAction(context.Context, webhooks.Event) error {
 	return nil
 }
+
+var _ webhooks.Subscriber = (*webhookSubscriberMock)(nil)
+
+type webhookSubscriberMock struct {
+	subscribers map[string][]func(webhooks.Event)
+}
+
+func newWebhookSubscriberMock() *webhookSubscriberMock {
+	return &webhookSubscriberMock{subscribers: make(map[string][]func(webhooks.Event))}
+}
+
+func (s *webhookSubscriberMock) Subscribe(module string, fn func(webhooks.Event)) {
+	s.subscribers[module] = append(s.subscribers[module], fn)
+}
+
+func (s *webhookSubscriberMock) broadcast(e webhooks.Event) {
+	for _, fn := range s.subscribers[e.Module] {
+		fn(e)
+	}
+}
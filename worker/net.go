@@ -4,6 +4,28 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
+
+	"go.sia.tech/core/types"
+)
+
+type (
+	// connTracker keeps track of the connections the worker opened to hosts,
+	// it allows for waiting on in-flight RPCs to finish and for forcibly
+	// closing the connections of RPCs that don't.
+	connTracker struct {
+		mu    sync.Mutex
+		conns map[*trackedConn]struct{}
+		idle  chan struct{} // closed once the last connection is closed
+	}
+
+	// trackedConn wraps a net.Conn and stops tracking it once it's closed.
+	trackedConn struct {
+		net.Conn
+		hostKey types.PublicKey
+		tracker *connTracker
+		once    sync.Once
+	}
 )
 
 var privateSubnets []*net.IPNet
@@ -40,3 +62,77 @@ func dial(ctx context.Context, hostIP string) (net.Conn, error) {
 	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", hostIP)
 	return conn, err
 }
+
+func newConnTracker() *connTracker {
+	return &connTracker{
+		conns: make(map[*trackedConn]struct{}),
+	}
+}
+
+// Dial dials the host and tracks the connection until it's closed.
+func (ct *connTracker) Dial(ctx context.Context, hostKey types.PublicKey, hostIP string) (net.Conn, error) {
+	conn, err := dial(ctx, hostIP)
+	if err != nil || ct == nil {
+		return conn, err
+	}
+	tc := &trackedConn{Conn: conn, hostKey: hostKey, tracker: ct}
+	ct.mu.Lock()
+	ct.conns[tc] = struct{}{}
+	ct.mu.Unlock()
+	return tc, nil
+}
+
+// Wait blocks until all tracked connections are closed or until the context
+// is done.
+func (ct *connTracker) Wait(ctx context.Context) error {
+	ct.mu.Lock()
+	if len(ct.conns) == 0 {
+		ct.mu.Unlock()
+		return nil
+	}
+	if ct.idle == nil {
+		ct.idle = make(chan struct{})
+	}
+	idle := ct.idle
+	ct.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CloseAll forcibly closes all tracked connections and returns a description
+// of the hosts they were connected to.
+func (ct *connTracker) CloseAll() (hosts []string) {
+	ct.mu.Lock()
+	conns := make([]*trackedConn, 0, len(ct.conns))
+	for tc := range ct.conns {
+		conns = append(conns, tc)
+	}
+	ct.mu.Unlock()
+
+	for _, tc := range conns {
+		hosts = append(hosts, fmt.Sprintf("%v (%v)", tc.hostKey, tc.RemoteAddr()))
+		_ = tc.Close()
+	}
+	return
+}
+
+func (ct *connTracker) release(tc *trackedConn) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	delete(ct.conns, tc)
+	if len(ct.conns) == 0 && ct.idle != nil {
+		close(ct.idle)
+		ct.idle = nil
+	}
+}
+
+// Close closes the connection and stops tracking it.
+func (tc *trackedConn) Close() error {
+	tc.once.Do(func() { tc.tracker.release(tc) })
+	return tc.Conn.Close()
+}
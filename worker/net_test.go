@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	rhpv2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/hostdb"
+)
+
+func TestShutdownHungHost(t *testing.T) {
+	w := newTestWorker(t)
+
+	// create a host that accepts connections but never responds
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, c := range conns {
+				c.Close()
+			}
+		}()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	// start an RPC that hangs on the handshake, it doesn't use a context with
+	// a deadline so it only returns once its connection is closed
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- w.withTransportV2(context.Background(), types.PublicKey{1}, l.Addr().String(), func(*rhpv2.Transport) error {
+			return nil
+		})
+	}()
+
+	// wait until the connection is tracked
+	w.tt.Retry(100, 10*time.Millisecond, func() error {
+		w.rhpConns.mu.Lock()
+		defer w.rhpConns.mu.Unlock()
+		if len(w.rhpConns.conns) != 1 {
+			return errors.New("connection not tracked yet")
+		}
+		return nil
+	})
+
+	// record a host interaction, it should get flushed on shutdown
+	w.hostInteractionRecorder.RecordHostInteraction(hostdb.Interaction{HostKey: types.PublicKey{1}})
+
+	// assert shutdown returns within the deadline
+	deadline := 100 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+	start := time.Now()
+	if err := w.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	} else if elapsed := time.Since(start); elapsed > deadline+time.Second {
+		t.Fatalf("shutdown took %v, expected it to return within %v", elapsed, deadline)
+	}
+
+	// assert the recorders were flushed after draining the connections
+	r := w.hostInteractionRecorder.(*hostInteractionRecorder)
+	r.mu.Lock()
+	if n := r.buffered(); n != 0 {
+		t.Fatalf("expected no buffered interactions, got %v", n)
+	}
+	r.mu.Unlock()
+
+	// assert the RPC was aborted
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("expected the RPC to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("RPC wasn't aborted")
+	}
+
+	// assert no connections are tracked anymore
+	if err := w.rhpConns.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
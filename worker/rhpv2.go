@@ -13,6 +13,7 @@ import (
 	rhpv2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/gouging"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 )
@@ -60,7 +61,7 @@ type HostErrorSet map[types.PublicKey]error
 // NumGouging returns numbers of host that errored out due to price gouging.
 func (hes HostErrorSet) NumGouging() (n int) {
 	for _, he := range hes {
-		if errors.Is(he, errPriceTableGouging) {
+		if errors.Is(he, gouging.ErrPriceTableGouging) {
 			n++
 		}
 	}
@@ -615,7 +616,7 @@ func (w *worker) fetchContractRoots(t *rhpv2.Transport, rev *rhpv2.ContractRevis
 }
 
 func (w *worker) withTransportV2(ctx context.Context, hostKey types.PublicKey, hostIP string, fn func(*rhpv2.Transport) error) (err error) {
-	conn, err := dial(ctx, hostIP)
+	conn, err := w.rhpConns.Dial(ctx, hostKey, hostIP)
 	if err != nil {
 		return err
 	}
@@ -635,6 +636,7 @@ func (w *worker) withTransportV2(ctx context.Context, hostKey types.PublicKey, h
 	}()
 	t, err := rhpv2.NewRenterTransport(conn, hostKey)
 	if err != nil {
+		conn.Close()
 		return err
 	}
 	defer t.Close()
@@ -20,6 +20,7 @@ import (
 	"go.sia.tech/mux/v1"
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/hostdb"
+	"go.sia.tech/renterd/internal/gouging"
 	"go.sia.tech/siad/crypto"
 	"go.uber.org/zap"
 )
@@ -90,7 +91,7 @@ func isClosedStream(err error) bool {
 }
 func isInsufficientFunds(err error) bool  { return isError(err, ErrInsufficientFunds) }
 func isPriceTableExpired(err error) bool  { return isError(err, errPriceTableExpired) }
-func isPriceTableGouging(err error) bool  { return isError(err, errPriceTableGouging) }
+func isPriceTableGouging(err error) bool  { return isError(err, gouging.ErrPriceTableGouging) }
 func isPriceTableNotFound(err error) bool { return isError(err, errPriceTableNotFound) }
 func isSectorNotFound(err error) bool {
 	return isError(err, errSectorNotFound) || isError(err, errSectorNotFoundOld)
@@ -115,6 +116,7 @@ type transportV3 struct {
 	refCount uint64 // locked by pool
 
 	mu         sync.Mutex
+	conns      *connTracker
 	hostKey    types.PublicKey
 	siamuxAddr string
 	t          *rhpv3.Transport
@@ -136,7 +138,7 @@ func (t *transportV3) DialStream(ctx context.Context) (*streamV3, error) {
 	t.mu.Lock()
 	if t.t == nil {
 		start := time.Now()
-		newTransport, err := dialTransport(ctx, t.siamuxAddr, t.hostKey)
+		newTransport, err := dialTransport(ctx, t.conns, t.siamuxAddr, t.hostKey)
 		if err != nil {
 			t.mu.Unlock()
 			return nil, fmt.Errorf("DialStream: could not dial transport: %w (%v)", err, time.Since(start))
@@ -173,19 +175,22 @@ func (t *transportV3) DialStream(ctx context.Context) (*streamV3, error) {
 
 // transportPoolV3 is a pool of rhpv3.Transports which allows for reusing them.
 type transportPoolV3 struct {
+	conns *connTracker
+
 	mu   sync.Mutex
 	pool map[string]*transportV3
 }
 
 func newTransportPoolV3(w *worker) *transportPoolV3 {
 	return &transportPoolV3{
-		pool: make(map[string]*transportV3),
+		conns: w.rhpConns,
+		pool:  make(map[string]*transportV3),
 	}
 }
 
-func dialTransport(ctx context.Context, siamuxAddr string, hostKey types.PublicKey) (*rhpv3.Transport, error) {
+func dialTransport(ctx context.Context, conns *connTracker, siamuxAddr string, hostKey types.PublicKey) (*rhpv3.Transport, error) {
 	// Dial host.
-	conn, err := dial(ctx, siamuxAddr)
+	conn, err := conns.Dial(ctx, hostKey, siamuxAddr)
 	if err != nil {
 		return nil, err
 	}
@@ -203,6 +208,9 @@ func dialTransport(ctx context.Context, siamuxAddr string, hostKey types.PublicK
 		<-done
 		return nil, ctx.Err()
 	case <-done:
+		if err != nil {
+			conn.Close()
+		}
 		return t, err
 	}
 }
@@ -213,6 +221,7 @@ func (p *transportPoolV3) withTransportV3(ctx context.Context, hostKey types.Pub
 	t, found := p.pool[siamuxAddr]
 	if !found {
 		t = &transportV3{
+			conns:      p.conns,
 			hostKey:    hostKey,
 			siamuxAddr: siamuxAddr,
 		}
@@ -498,7 +507,7 @@ func (h *host) priceTable(ctx context.Context, rev *types.FileContractRevision)
 		return rhpv3.HostPriceTable{}, err
 	}
 	if breakdown := gc.Check(nil, &pt.HostPriceTable); breakdown.Gouging() {
-		return rhpv3.HostPriceTable{}, fmt.Errorf("%w: %v", errPriceTableGouging, breakdown)
+		return rhpv3.HostPriceTable{}, fmt.Errorf("%w: %v", gouging.ErrPriceTableGouging, breakdown)
 	}
 	return pt.HostPriceTable, nil
 }
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gotd/contrib/http_range"
+	"go.sia.tech/renterd/api"
+	"lukechampine.com/frand"
+)
+
+func TestServeContentRange(t *testing.T) {
+	data := frand.Bytes(1000)
+	obj := api.Object{
+		ObjectMetadata: api.ObjectMetadata{
+			ETag:    "d34db33f",
+			ModTime: api.TimeRFC3339(time.Now().UTC().Truncate(time.Second)),
+			Name:    "foo",
+			Size:    int64(len(data)),
+		},
+	}
+
+	// downloadFn writes the requested range of data
+	downloadFn := func(w io.Writer, offset, length int64) error {
+		_, err := w.Write(data[offset : offset+length])
+		return err
+	}
+
+	serve := func(rangeHdr string) (*httptest.ResponseRecorder, int, error) {
+		req := httptest.NewRequest(http.MethodGet, "/objects/foo", nil)
+		if rangeHdr != "" {
+			req.Header.Set("Range", rangeHdr)
+		}
+		rec := httptest.NewRecorder()
+		status, err := serveContent(rec, req, obj, downloadFn)
+		return rec, status, err
+	}
+
+	tests := []struct {
+		rangeHdr     string
+		status       int
+		contentRange string
+		offset       int64
+		length       int64
+	}{
+		{"", http.StatusOK, "", 0, 1000},
+		{"bytes=0-0", http.StatusPartialContent, "bytes 0-0/1000", 0, 1},
+		{"bytes=10-19", http.StatusPartialContent, "bytes 10-19/1000", 10, 10},
+		{"bytes=123-876", http.StatusPartialContent, "bytes 123-876/1000", 123, 754},
+		{"bytes=990-", http.StatusPartialContent, "bytes 990-999/1000", 990, 10},
+		{"bytes=-5", http.StatusPartialContent, "bytes 995-999/1000", 995, 5},
+	}
+	for _, test := range tests {
+		rec, _, err := serve(test.rangeHdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := rec.Result()
+		if res.StatusCode != test.status {
+			t.Fatalf("%q: expected status %d, got %d", test.rangeHdr, test.status, res.StatusCode)
+		} else if cr := res.Header.Get("Content-Range"); cr != test.contentRange {
+			t.Fatalf("%q: expected Content-Range %q, got %q", test.rangeHdr, test.contentRange, cr)
+		} else if ar := res.Header.Get("Accept-Ranges"); ar != "bytes" {
+			t.Fatalf("%q: expected Accept-Ranges bytes, got %q", test.rangeHdr, ar)
+		} else if cl := res.Header.Get("Content-Length"); cl != fmt.Sprint(test.length) {
+			t.Fatalf("%q: expected Content-Length %d, got %q", test.rangeHdr, test.length, cl)
+		} else if !bytes.Equal(rec.Body.Bytes(), data[test.offset:test.offset+test.length]) {
+			t.Fatalf("%q: unexpected body", test.rangeHdr)
+		}
+	}
+
+	// assert invalid and multipart ranges are rejected
+	if _, status, err := serve("bytes=1000-1010"); !errors.Is(err, http_range.ErrNoOverlap) || status != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("unexpected status %d and error %v", status, err)
+	} else if _, _, err := serve("bytes=0-1,5-6"); !errors.Is(err, errMultiRangeNotSupported) {
+		t.Fatal("unexpected error", err)
+	} else if _, _, err := serve("foo=0-1"); !errors.Is(err, http_range.ErrInvalid) {
+		t.Fatal("unexpected error", err)
+	}
+}
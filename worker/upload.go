@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -25,12 +26,22 @@ const (
 
 	defaultPackedSlabsLockDuration  = 10 * time.Minute
 	defaultPackedSlabsUploadTimeout = 10 * time.Minute
+
+	// autopilotTriggerPollInterval is the interval at which we check whether
+	// the autopilot formed enough contracts after it was triggered by an
+	// upload with insufficient contracts.
+	autopilotTriggerPollInterval = 2 * time.Second
+
+	// autopilotTriggerTimeout is the maximum amount of time an upload waits
+	// for the autopilot to form enough contracts, uploads that find too few
+	// contracts while the autopilot was triggered within this timeout wait
+	// for that same trigger.
+	autopilotTriggerTimeout = 30 * time.Second
 )
 
 var (
 	errContractExpired     = errors.New("contract expired")
 	errNoCandidateUploader = errors.New("no candidate uploader found")
-	errNotEnoughContracts  = errors.New("not enough contracts to support requested redundancy")
 	errUploadInterrupted   = errors.New("upload was interrupted")
 )
 
@@ -48,6 +59,10 @@ type (
 		maxOverdrive     uint64
 		overdriveTimeout time.Duration
 
+		// uploadSlots caps the number of sectors that are being uploaded
+		// concurrently across all uploaders
+		uploadSlots chan struct{}
+
 		statsOverdrivePct              *stats.DataPoints
 		statsSlabUploadSpeedBytesPerMS *stats.DataPoints
 
@@ -70,6 +85,7 @@ type (
 		id api.UploadID
 
 		allowed map[types.PublicKey]struct{}
+		seed    types.Hash256
 
 		contractLockPriority int
 		contractLockDuration time.Duration
@@ -143,16 +159,88 @@ type (
 	}
 )
 
-func (w *worker) initUploadManager(maxMemory, maxOverdrive uint64, overdriveTimeout time.Duration, logger *zap.SugaredLogger) {
+func (w *worker) initUploadManager(maxMemory, maxOverdrive, maxConcurrency uint64, overdriveTimeout time.Duration, logger *zap.SugaredLogger) {
 	if w.uploadManager != nil {
 		panic("upload manager already initialized") // developer error
 	}
 
 	mm := newMemoryManager(logger.Named("memorymanager"), maxMemory)
-	w.uploadManager = newUploadManager(w.shutdownCtx, w, mm, w.bus, w.bus, w.bus, maxOverdrive, overdriveTimeout, w.contractLockingDuration, logger)
+	w.uploadManager = newUploadManager(w.shutdownCtx, w, mm, w.bus, w.bus, w.bus, maxOverdrive, maxConcurrency, overdriveTimeout, w.contractLockingDuration, logger)
+}
+
+// uploadContracts fetches the contracts in the given set. If the set holds
+// fewer contracts than required to upload with the given redundancy and the
+// worker was configured with an autopilot trigger, it triggers the autopilot
+// and waits for it to form the missing contracts. If the worker has a
+// contract cache, the contracts are served from the cache.
+func (w *worker) uploadContracts(ctx context.Context, set string, totalShards int) ([]api.ContractMetadata, error) {
+	var contracts []api.ContractMetadata
+	var err error
+	if w.contractCache != nil {
+		contracts, err = w.contractCache.Contracts(ctx, set)
+	} else {
+		contracts, err = w.bus.Contracts(ctx, api.ContractsOpts{ContractSet: set})
+	}
+	if err != nil || len(contracts) >= totalShards || w.autopilotTrigger == nil {
+		return contracts, err
+	}
+
+	// trigger the autopilot and wait for the contracts to be formed
+	triggered := w.triggerAutopilot(len(contracts), totalShards)
+	for len(contracts) < totalShards {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-w.shutdownCtx.Done():
+			return nil, ErrShuttingDown
+		case <-triggered:
+			return contracts, nil
+		case <-time.After(autopilotTriggerPollInterval):
+		}
+
+		contracts, err = w.bus.Contracts(ctx, api.ContractsOpts{ContractSet: set})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return contracts, nil
+}
+
+// triggerAutopilot triggers the autopilot unless it was already triggered
+// within the last autopilotTriggerTimeout. The returned channel is closed once
+// the timeout expires or if the trigger failed.
+func (w *worker) triggerAutopilot(contracts, totalShards int) <-chan struct{} {
+	w.autopilotTriggerMu.Lock()
+	defer w.autopilotTriggerMu.Unlock()
+	if w.autopilotTriggered != nil {
+		return w.autopilotTriggered
+	}
+
+	triggered := make(chan struct{})
+	w.autopilotTriggered = triggered
+	go func() {
+		defer func() {
+			w.autopilotTriggerMu.Lock()
+			w.autopilotTriggered = nil
+			w.autopilotTriggerMu.Unlock()
+			close(triggered)
+		}()
+
+		if _, err := w.autopilotTrigger.Trigger(false); err != nil {
+			w.logger.Errorf("failed to trigger autopilot, err: %v", err)
+			return
+		}
+		w.logger.Infow("triggered autopilot due to insufficient contracts", "contracts", contracts, "totalShards", totalShards)
+
+		select {
+		case <-w.shutdownCtx.Done():
+		case <-time.After(autopilotTriggerTimeout):
+		}
+	}()
+	return triggered
 }
 
-func (w *worker) upload(ctx context.Context, r io.Reader, contracts []api.ContractMetadata, up uploadParameters, opts ...UploadOption) (_ string, err error) {
+func (w *worker) upload(ctx context.Context, r io.Reader, contracts []api.ContractMetadata, up uploadParameters, opts ...UploadOption) (_ api.UploadObjectResponse, err error) {
 	// apply the options
 	for _, opt := range opts {
 		opt(&up)
@@ -172,15 +260,28 @@ func (w *worker) upload(ctx context.Context, r io.Reader, contracts []api.Contra
 	}
 
 	// perform the upload
-	bufferSizeLimitReached, eTag, err := w.uploadManager.Upload(ctx, r, contracts, up, lockingPriorityUpload)
+	bufferSizeLimitReached, o, eTag, err := w.uploadManager.upload(ctx, r, contracts, up, lockingPriorityUpload)
 	if err != nil {
-		return "", err
+		return api.UploadObjectResponse{}, err
+	}
+
+	// build the response
+	slabKeys := make([]object.EncryptionKey, 0, len(o.Slabs))
+	for _, ss := range o.Slabs {
+		slabKeys = append(slabKeys, ss.Key)
+	}
+	resp := api.UploadObjectResponse{
+		ETag:       eTag,
+		Path:       up.path,
+		Size:       o.TotalSize(),
+		Redundancy: up.rs,
+		SlabKeys:   slabKeys,
 	}
 
 	// return early if worker was shut down or if we don't have to consider
 	// packed uploads
 	if w.isStopped() || !up.packing {
-		return eTag, nil
+		return resp, nil
 	}
 
 	// try and upload one slab synchronously
@@ -192,7 +293,7 @@ func (w *worker) upload(ctx context.Context, r io.Reader, contracts []api.Contra
 			// fetch packed slab to upload
 			packedSlabs, err := w.bus.PackedSlabsForUpload(ctx, defaultPackedSlabsLockDuration, uint8(up.rs.MinShards), uint8(up.rs.TotalShards), up.contractSet, 1)
 			if err != nil {
-				return "", fmt.Errorf("couldn't fetch packed slabs from bus: %v", err)
+				return api.UploadObjectResponse{}, fmt.Errorf("couldn't fetch packed slabs from bus: %v", err)
 			}
 
 			// upload packed slab
@@ -207,7 +308,7 @@ func (w *worker) upload(ctx context.Context, r io.Reader, contracts []api.Contra
 		go w.threadedUploadPackedSlabs(up.rs, up.contractSet, lockingPriorityBackgroundUpload)
 	}
 
-	return eTag, nil
+	return resp, nil
 }
 
 func (w *worker) threadedUploadPackedSlabs(rs api.RedundancySettings, contractSet string, lockPriority int) {
@@ -302,7 +403,7 @@ func (w *worker) tryUploadPackedSlab(ctx context.Context, mem Memory, ps api.Pac
 	return nil
 }
 
-func newUploadManager(ctx context.Context, hm HostManager, mm MemoryManager, os ObjectStore, cl ContractLocker, cs ContractStore, maxOverdrive uint64, overdriveTimeout time.Duration, contractLockDuration time.Duration, logger *zap.SugaredLogger) *uploadManager {
+func newUploadManager(ctx context.Context, hm HostManager, mm MemoryManager, os ObjectStore, cl ContractLocker, cs ContractStore, maxOverdrive, maxConcurrency uint64, overdriveTimeout time.Duration, contractLockDuration time.Duration, logger *zap.SugaredLogger) *uploadManager {
 	return &uploadManager{
 		hm:     hm,
 		mm:     mm,
@@ -316,6 +417,8 @@ func newUploadManager(ctx context.Context, hm HostManager, mm MemoryManager, os
 		maxOverdrive:     maxOverdrive,
 		overdriveTimeout: overdriveTimeout,
 
+		uploadSlots: make(chan struct{}, maxConcurrency),
+
 		statsOverdrivePct:              stats.NoDecay(),
 		statsSlabUploadSpeedBytesPerMS: stats.NoDecay(),
 
@@ -338,6 +441,7 @@ func (mgr *uploadManager) newUploader(os ObjectStore, cl ContractLocker, cs Cont
 		siamuxAddr:      c.SiamuxAddr,
 		shutdownCtx:     mgr.shutdownCtx,
 		signalNewUpload: make(chan struct{}, 1),
+		uploadSlots:     mgr.uploadSlots,
 
 		// stats
 		statsSectorUploadEstimateInMS:    stats.Default(),
@@ -383,28 +487,33 @@ func (mgr *uploadManager) Stop() {
 }
 
 func (mgr *uploadManager) Upload(ctx context.Context, r io.Reader, contracts []api.ContractMetadata, up uploadParameters, lockPriority int) (bufferSizeLimitReached bool, eTag string, err error) {
+	bufferSizeLimitReached, _, eTag, err = mgr.upload(ctx, r, contracts, up, lockPriority)
+	return
+}
+
+func (mgr *uploadManager) upload(ctx context.Context, r io.Reader, contracts []api.ContractMetadata, up uploadParameters, lockPriority int) (bufferSizeLimitReached bool, o object.Object, eTag string, err error) {
 	// cancel all in-flight requests when the upload is done
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	// create the object
-	o := object.NewObject(up.ec)
+	o = object.NewObject(up.ec)
 
 	// create the cipher reader
 	cr, err := o.Encrypt(r, up.encryptionOffset)
 	if err != nil {
-		return false, "", err
+		return false, object.Object{}, "", err
 	}
 
 	// create the upload
-	upload, err := mgr.newUpload(ctx, up.rs.TotalShards, contracts, up.bh, lockPriority)
+	upload, err := mgr.newUpload(ctx, up.rs.TotalShards, contracts, up.bh, uploadSeed(up.ec, up.bh), lockPriority)
 	if err != nil {
-		return false, "", err
+		return false, object.Object{}, "", err
 	}
 
 	// track the upload in the bus
 	if err := mgr.os.TrackUpload(ctx, upload.id); err != nil {
-		return false, "", fmt.Errorf("failed to track upload '%v', err: %w", upload.id, err)
+		return false, object.Object{}, "", fmt.Errorf("failed to track upload '%v', err: %w", upload.id, err)
 	}
 
 	// defer a function that finishes the upload
@@ -476,7 +585,7 @@ func (mgr *uploadManager) Upload(ctx context.Context, r io.Reader, contracts []a
 			} else {
 				// regular upload
 				go func(rs api.RedundancySettings, data []byte, length, slabIndex int) {
-					uploadSpeed, overdrivePct := upload.uploadSlab(ctx, rs, data, length, slabIndex, respChan, mgr.candidates(upload.allowed), mem, mgr.maxOverdrive, mgr.overdriveTimeout)
+					uploadSpeed, overdrivePct := upload.uploadSlab(ctx, rs, data, length, slabIndex, respChan, mgr.candidates(upload.allowed, upload.seed), mem, mgr.maxOverdrive, mgr.overdriveTimeout)
 
 					// track stats
 					mgr.statsSlabUploadSpeedBytesPerMS.Track(float64(uploadSpeed))
@@ -493,19 +602,26 @@ func (mgr *uploadManager) Upload(ctx context.Context, r io.Reader, contracts []a
 
 	// collect responses
 	var responses []slabUploadResponse
+	var uploaded uint64
 	numSlabs := math.MaxInt32
 	for len(responses) < numSlabs {
 		select {
 		case <-mgr.shutdownCtx.Done():
-			return false, "", ErrShuttingDown
+			return false, object.Object{}, "", ErrShuttingDown
 		case <-ctx.Done():
-			return false, "", errUploadInterrupted
+			return false, object.Object{}, "", errUploadInterrupted
 		case numSlabs = <-numSlabsChan:
 		case res := <-respChan:
 			if res.err != nil {
-				return false, "", res.err
+				return false, object.Object{}, "", res.err
 			}
 			responses = append(responses, res)
+
+			// report progress
+			if up.progressFn != nil {
+				uploaded += uint64(res.slab.Length)
+				up.progressFn(uploaded, up.progressTotal)
+			}
 		}
 	}
 
@@ -527,22 +643,28 @@ func (mgr *uploadManager) Upload(ctx context.Context, r io.Reader, contracts []a
 		var pss []object.SlabSlice
 		pss, bufferSizeLimitReached, err = mgr.os.AddPartialSlab(ctx, partialSlab, uint8(up.rs.MinShards), uint8(up.rs.TotalShards), up.contractSet)
 		if err != nil {
-			return false, "", err
+			return false, object.Object{}, "", err
 		}
 		o.Slabs = append(o.Slabs, pss...)
+
+		// report progress, the partial slab is buffered by the bus
+		if up.progressFn != nil {
+			uploaded += uint64(len(partialSlab))
+			up.progressFn(uploaded, up.progressTotal)
+		}
 	}
 
 	if up.multipart {
 		// persist the part
 		err = mgr.os.AddMultipartPart(ctx, up.bucket, up.path, up.contractSet, eTag, up.uploadID, up.partNumber, o.Slabs)
 		if err != nil {
-			return bufferSizeLimitReached, "", fmt.Errorf("couldn't add multi part: %w", err)
+			return bufferSizeLimitReached, object.Object{}, "", fmt.Errorf("couldn't add multi part: %w", err)
 		}
 	} else {
 		// persist the object
-		err = mgr.os.AddObject(ctx, up.bucket, up.path, up.contractSet, o, api.AddObjectOptions{MimeType: up.mimeType, ETag: eTag, Metadata: up.metadata})
+		err = mgr.os.AddObject(ctx, up.bucket, up.path, up.contractSet, o, api.AddObjectOptions{MimeType: up.mimeType, ETag: eTag, Metadata: up.metadata, Mode: up.mode})
 		if err != nil {
-			return bufferSizeLimitReached, "", fmt.Errorf("couldn't add object: %w", err)
+			return bufferSizeLimitReached, object.Object{}, "", fmt.Errorf("couldn't add object: %w", err)
 		}
 	}
 
@@ -558,7 +680,7 @@ func (mgr *uploadManager) UploadPackedSlab(ctx context.Context, rs api.Redundanc
 	shards := encryptPartialSlab(ps.Data, ps.Key, uint8(rs.MinShards), uint8(rs.TotalShards))
 
 	// create the upload
-	upload, err := mgr.newUpload(ctx, len(shards), contracts, bh, lockPriority)
+	upload, err := mgr.newUpload(ctx, len(shards), contracts, bh, uploadSeed(ps.Key, bh), lockPriority)
 	if err != nil {
 		return err
 	}
@@ -578,7 +700,7 @@ func (mgr *uploadManager) UploadPackedSlab(ctx context.Context, rs api.Redundanc
 	}()
 
 	// upload the shards
-	sectors, uploadSpeed, overdrivePct, err := upload.uploadShards(ctx, shards, mgr.candidates(upload.allowed), mem, mgr.maxOverdrive, mgr.overdriveTimeout)
+	sectors, uploadSpeed, overdrivePct, err := upload.uploadShards(ctx, shards, mgr.candidates(upload.allowed, upload.seed), mem, mgr.maxOverdrive, mgr.overdriveTimeout)
 	if err != nil {
 		return err
 	}
@@ -603,7 +725,7 @@ func (mgr *uploadManager) UploadShards(ctx context.Context, s *object.Slab, shar
 	defer cancel()
 
 	// create the upload
-	upload, err := mgr.newUpload(ctx, len(shards), contracts, bh, lockPriority)
+	upload, err := mgr.newUpload(ctx, len(shards), contracts, bh, uploadSeed(s.Key, bh), lockPriority)
 	if err != nil {
 		return err
 	}
@@ -623,7 +745,7 @@ func (mgr *uploadManager) UploadShards(ctx context.Context, s *object.Slab, shar
 	}()
 
 	// upload the shards
-	uploaded, uploadSpeed, overdrivePct, err := upload.uploadShards(ctx, shards, mgr.candidates(upload.allowed), mem, mgr.maxOverdrive, mgr.overdriveTimeout)
+	uploaded, uploadSpeed, overdrivePct, err := upload.uploadShards(ctx, shards, mgr.candidates(upload.allowed, upload.seed), mem, mgr.maxOverdrive, mgr.overdriveTimeout)
 	if err != nil {
 		return err
 	}
@@ -658,24 +780,37 @@ func (mgr *uploadManager) UploadShards(ctx context.Context, s *object.Slab, shar
 	return mgr.os.UpdateSlab(ctx, *s, contractSet)
 }
 
-func (mgr *uploadManager) candidates(allowed map[types.PublicKey]struct{}) (candidates []*uploader) {
+// candidates returns the uploaders for the allowed hosts, sorted by their
+// upload estimate. Uploaders with an equal estimate are ordered using the given
+// seed, which makes host selection reproducible for the same inputs. The seed
+// is only used to break ties, it never overrides the estimate.
+func (mgr *uploadManager) candidates(allowed map[types.PublicKey]struct{}, seed types.Hash256) (candidates []*uploader) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 
+	estimates := make(map[types.PublicKey]float64)
+	tiebreakers := make(map[types.PublicKey]types.Hash256)
 	for _, u := range mgr.uploaders {
 		if _, allowed := allowed[u.hk]; allowed {
 			candidates = append(candidates, u)
+			estimates[u.hk] = u.estimate()
+			tiebreakers[u.hk] = types.HashBytes(append(seed[:], u.hk[:]...))
 		}
 	}
 
 	// sort candidates by upload estimate
 	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].estimate() < candidates[j].estimate()
+		ei, ej := estimates[candidates[i].hk], estimates[candidates[j].hk]
+		if ei != ej {
+			return ei < ej
+		}
+		ti, tj := tiebreakers[candidates[i].hk], tiebreakers[candidates[j].hk]
+		return bytes.Compare(ti[:], tj[:]) < 0
 	})
 	return
 }
 
-func (mgr *uploadManager) newUpload(ctx context.Context, totalShards int, contracts []api.ContractMetadata, bh uint64, lockPriority int) (*upload, error) {
+func (mgr *uploadManager) newUpload(ctx context.Context, totalShards int, contracts []api.ContractMetadata, bh uint64, seed types.Hash256, lockPriority int) (*upload, error) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 
@@ -684,7 +819,7 @@ func (mgr *uploadManager) newUpload(ctx context.Context, totalShards int, contra
 
 	// check if we have enough contracts
 	if len(contracts) < totalShards {
-		return nil, fmt.Errorf("%v < %v: %w", len(contracts), totalShards, errNotEnoughContracts)
+		return nil, &api.InsufficientContractsError{Contracts: len(contracts), TotalShards: totalShards}
 	}
 
 	// create allowed map
@@ -697,6 +832,7 @@ func (mgr *uploadManager) newUpload(ctx context.Context, totalShards int, contra
 	return &upload{
 		id:                   api.NewUploadID(),
 		allowed:              allowed,
+		seed:                 seed,
 		contractLockDuration: mgr.contractLockDuration,
 		contractLockPriority: lockPriority,
 		shutdownCtx:          mgr.shutdownCtx,
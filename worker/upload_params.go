@@ -22,8 +22,12 @@ type uploadParameters struct {
 	contractSet string
 	packing     bool
 	mimeType    string
+	mode        string
 
 	metadata api.ObjectUserMetadata
+
+	progressFn    UploadProgressFunc
+	progressTotal uint64
 }
 
 func defaultParameters(bucket, path string) uploadParameters {
@@ -54,7 +58,13 @@ func multipartParameters(bucket, path, uploadID string, partNumber int) uploadPa
 	}
 }
 
-type UploadOption func(*uploadParameters)
+type (
+	UploadOption func(*uploadParameters)
+
+	// UploadProgressFunc is called with the number of bytes uploaded so far
+	// and the total number of bytes to upload, the latter is 0 if unknown.
+	UploadProgressFunc func(uploaded, total uint64)
+)
 
 func WithBlockHeight(bh uint64) UploadOption {
 	return func(up *uploadParameters) {
@@ -98,8 +108,24 @@ func WithRedundancySettings(rs api.RedundancySettings) UploadOption {
 	}
 }
 
+func WithUploadMode(mode string) UploadOption {
+	return func(up *uploadParameters) {
+		up.mode = mode
+	}
+}
+
 func WithObjectUserMetadata(metadata api.ObjectUserMetadata) UploadOption {
 	return func(up *uploadParameters) {
 		up.metadata = metadata
 	}
 }
+
+// WithUploadProgress registers a function that is called every time a slab
+// was fully uploaded. The function is called synchronously from the upload
+// loop so it should return quickly to avoid stalling the upload.
+func WithUploadProgress(total uint64, fn UploadProgressFunc) UploadOption {
+	return func(up *uploadParameters) {
+		up.progressFn = fn
+		up.progressTotal = total
+	}
+}
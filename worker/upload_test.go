@@ -5,6 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -122,6 +125,161 @@ func TestUpload(t *testing.T) {
 	if err == nil || !errors.Is(err, errUploadInterrupted) {
 		t.Fatal(err)
 	}
+
+	// upload data with too few contracts - assert the error contains the counts
+	params.bucket = testBucket
+	_, _, err = ul.Upload(context.Background(), bytes.NewReader(data), w.Contracts()[:2], params, lockingPriorityUpload)
+	var ice *api.InsufficientContractsError
+	if !errors.Is(err, api.ErrInsufficientContracts) {
+		t.Fatal("expected insufficient contracts error", err)
+	} else if !errors.As(err, &ice) {
+		t.Fatal("expected typed error", err)
+	} else if ice.Contracts != 2 || ice.TotalShards != int(params.rs.TotalShards) {
+		t.Fatal("unexpected counts", ice.Contracts, ice.TotalShards)
+	}
+}
+
+func TestUploadRedundancyOverride(t *testing.T) {
+	// create test worker
+	w := newTestWorker(t)
+
+	// add hosts to worker
+	w.AddHosts(testRedundancySettings.TotalShards * 2)
+
+	// convenience variables
+	os := w.os
+	dl := w.downloadManager
+	ul := w.uploadManager
+
+	// upload two objects, one at a higher and one at a lower redundancy than
+	// the default
+	for _, rs := range []api.RedundancySettings{
+		{MinShards: 2, TotalShards: 10},
+		{MinShards: 1, TotalShards: 2},
+	} {
+		data := frand.Bytes(128)
+		params := testParameters(fmt.Sprintf("%s_%d_%d", t.Name(), rs.MinShards, rs.TotalShards))
+		params.rs = rs
+		if _, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.Contracts(), params, lockingPriorityUpload); err != nil {
+			t.Fatal(err)
+		}
+
+		// assert the slab was stored using the overridden settings
+		o, err := os.Object(context.Background(), testBucket, params.path, api.GetObjectOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		slab := o.Object.Object.Slabs[0]
+		if int(slab.MinShards) != rs.MinShards || len(slab.Shards) != rs.TotalShards {
+			t.Fatalf("unexpected shards, %d-of-%d != %d-of-%d", slab.MinShards, len(slab.Shards), rs.MinShards, rs.TotalShards)
+		}
+
+		// download the data and assert it matches
+		var buf bytes.Buffer
+		err = dl.DownloadObject(context.Background(), &buf, *o.Object.Object, 0, uint64(o.Object.Size), w.Contracts())
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(data, buf.Bytes()) {
+			t.Fatal("data mismatch")
+		}
+	}
+
+	// assert we can't exceed the number of contracted hosts
+	params := testParameters(t.Name())
+	params.rs = api.RedundancySettings{MinShards: 2, TotalShards: len(w.Contracts()) + 1}
+	_, _, err := ul.Upload(context.Background(), bytes.NewReader(frand.Bytes(128)), w.Contracts(), params, lockingPriorityUpload)
+	if !errors.Is(err, api.ErrInsufficientContracts) {
+		t.Fatal("expected insufficient contracts error", err)
+	}
+}
+
+type autopilotTriggerMock struct {
+	triggered atomic.Int64
+}
+
+func (m *autopilotTriggerMock) Trigger(bool) (bool, error) {
+	m.triggered.Add(1)
+	return true, nil
+}
+
+func TestUploadContractsTriggerAutopilot(t *testing.T) {
+	// create test worker with an autopilot trigger
+	w := newTestWorker(t)
+	w.AddHosts(2)
+	apt := &autopilotTriggerMock{}
+	w.autopilotTrigger = apt
+
+	// fetch the contracts for uploads that require more contracts than we
+	// have concurrently, the uploads time out waiting for the autopilot
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := w.uploadContracts(ctx, testContractSet, 3); !errors.Is(err, context.DeadlineExceeded) {
+				t.Error("unexpected error", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// assert the autopilot was only triggered once
+	if n := apt.triggered.Load(); n != 1 {
+		t.Fatalf("expected the autopilot to be triggered once, got %v", n)
+	}
+}
+
+func TestParseInsufficientContractsError(t *testing.T) {
+	err := fmt.Errorf("couldn't upload object: %w", &api.InsufficientContractsError{Contracts: 2, TotalShards: 3})
+	ice, ok := api.ParseInsufficientContractsError(err.Error())
+	if !ok {
+		t.Fatal("failed to parse error")
+	} else if ice.Contracts != 2 || ice.TotalShards != 3 {
+		t.Fatal("unexpected counts", ice.Contracts, ice.TotalShards)
+	} else if !errors.Is(ice, api.ErrInsufficientContracts) {
+		t.Fatal("expected insufficient contracts error")
+	}
+	if _, ok := api.ParseInsufficientContractsError(api.ErrObjectNotFound.Error()); ok {
+		t.Fatal("unexpected insufficient contracts error")
+	}
+}
+
+func TestUploadProgress(t *testing.T) {
+	// create test worker
+	w := newTestWorker(t)
+
+	// add hosts to worker
+	w.AddHosts(testRedundancySettings.TotalShards)
+
+	// create test data that spans multiple slabs
+	params := testParameters(t.Name())
+	data := frand.Bytes(int(params.rs.SlabSizeNoRedundancy())*2 + 128)
+	size := uint64(len(data))
+
+	// upload the data and track the progress
+	var calls int
+	var last uint64
+	_, err := w.upload(context.Background(), bytes.NewReader(data), w.Contracts(), params, WithUploadProgress(size, func(uploaded, total uint64) {
+		if uploaded <= last {
+			t.Errorf("progress didn't increase, %d <= %d", uploaded, last)
+		} else if total != size {
+			t.Errorf("unexpected total, %d != %d", total, size)
+		}
+		last = uploaded
+		calls++
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the callback was called once per slab and reported the full size
+	if calls != 3 {
+		t.Fatalf("unexpected number of calls, %d != 3", calls)
+	} else if last != size {
+		t.Fatalf("unexpected uploaded bytes, %d != %d", last, size)
+	}
 }
 
 func TestUploadPackedSlab(t *testing.T) {
@@ -478,6 +636,67 @@ func TestRefreshUploaders(t *testing.T) {
 	}
 }
 
+func TestUploadCandidatesTiebreaker(t *testing.T) {
+	// create test worker
+	w := newTestWorker(t)
+
+	// add hosts to worker
+	w.AddHosts(testRedundancySettings.TotalShards * 2)
+
+	// convenience variables
+	ul := w.uploadManager
+	contracts := w.Contracts()
+
+	// create an upload to initialise the uploaders
+	seed := uploadSeed(object.GenerateEncryptionKey(), 1)
+	upload, err := ul.newUpload(context.Background(), len(contracts), contracts, 1, seed, lockingPriorityUpload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// helper to fetch the ordered candidate host keys
+	candidates := func(seed types.Hash256) (hks []types.PublicKey) {
+		for _, c := range ul.candidates(upload.allowed, seed) {
+			hks = append(hks, c.hk)
+		}
+		return
+	}
+
+	// all uploaders are new so they have an equal estimate, assert the
+	// candidates are ordered the same way for the same seed
+	order := candidates(seed)
+	if len(order) != len(contracts) {
+		t.Fatalf("unexpected number of candidates, %v != %v", len(order), len(contracts))
+	}
+	for i := 0; i < 10; i++ {
+		if !reflect.DeepEqual(candidates(seed), order) {
+			t.Fatal("expected candidates to be ordered deterministically")
+		}
+	}
+
+	// assert a different seed results in a different order
+	if reflect.DeepEqual(candidates(uploadSeed(object.GenerateEncryptionKey(), 1)), order) {
+		t.Fatal("expected candidates to be ordered differently for a different seed")
+	}
+
+	// queue a request on the first candidate's uploader
+	for _, u := range ul.uploaders {
+		if u.hk == order[0] {
+			u.mu.Lock()
+			u.queue = append(u.queue, &sectorUploadReq{})
+			u.mu.Unlock()
+			break
+		}
+	}
+
+	// assert the seed doesn't override the estimate
+	if updated := candidates(seed); updated[len(updated)-1] != order[0] {
+		t.Fatal("expected the busiest uploader to be the last candidate")
+	} else if !reflect.DeepEqual(updated[:len(updated)-1], order[1:]) {
+		t.Fatal("expected the other candidates to keep their order")
+	}
+}
+
 func TestUploadRegression(t *testing.T) {
 	// create test worker
 	w := newTestWorker(t)
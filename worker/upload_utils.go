@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/gabriel-vasile/mimetype"
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/object"
 )
 
@@ -26,3 +27,14 @@ func newMimeReader(r io.Reader) (mimeType string, recycled io.Reader, err error)
 	recycled = io.MultiReader(buf, r)
 	return mtype.String(), recycled, err
 }
+
+// uploadSeed derives the seed used to break ties between equally fast upload
+// candidates from the key of the object or slab that is being uploaded and the
+// block height at which it's uploaded.
+func uploadSeed(key object.EncryptionKey, bh uint64) types.Hash256 {
+	h := types.NewHasher()
+	b, _ := key.MarshalBinary()
+	h.E.Write(b)
+	h.E.WriteUint64(bh)
+	return h.Sum()
+}
@@ -35,6 +35,7 @@ type (
 		siamuxAddr      string
 		signalNewUpload chan struct{}
 		shutdownCtx     context.Context
+		uploadSlots     chan struct{}
 
 		mu        sync.Mutex
 		endHeight uint64
@@ -114,8 +115,18 @@ outer:
 				panic("lock duration and priority can't be 0") // developer error
 			}
 
+			// wait for an upload slot
+			select {
+			case u.uploadSlots <- struct{}{}:
+			case <-req.sector.ctx.Done():
+				continue
+			case <-u.shutdownCtx.Done():
+				return
+			}
+
 			// execute it
 			elapsed, err := u.execute(req)
+			<-u.uploadSlots
 
 			// the uploader's contract got renewed, requeue the request
 			if errors.Is(err, errMaxRevisionReached) {
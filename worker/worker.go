@@ -107,6 +107,7 @@ type (
 	}
 
 	HostStore interface {
+		RecordHostInteractions(ctx context.Context, interactions []hostdb.Interaction) error
 		RecordHostScans(ctx context.Context, scans []hostdb.HostScan) error
 		RecordPriceTables(ctx context.Context, priceTableUpdate []hostdb.PriceTableUpdate) error
 		RecordContractSpending(ctx context.Context, records []api.ContractSpendingRecord) error
@@ -159,6 +160,11 @@ type (
 	ConsensusState interface {
 		ConsensusState(ctx context.Context) (api.ConsensusState, error)
 	}
+
+	// AutopilotTrigger triggers an iteration of the autopilot's main loop.
+	AutopilotTrigger interface {
+		Trigger(forceScan bool) (bool, error)
+	}
 )
 
 // deriveSubKey can be used to derive a sub-masterkey from the worker's
@@ -205,6 +211,16 @@ type worker struct {
 	masterKey       [32]byte
 	startTime       time.Time
 
+	// autopilotTrigger is optional, if set the worker triggers the autopilot
+	// when an upload is started with insufficient contracts
+	autopilotTrigger   AutopilotTrigger
+	autopilotTriggerMu sync.Mutex
+	autopilotTriggered chan struct{}
+
+	// contractCache is optional, it's only set if the worker runs in the same
+	// process as the bus and can subscribe to contract set changes
+	contractCache *contractCache
+
 	downloadManager *downloadManager
 	uploadManager   *uploadManager
 
@@ -212,10 +228,17 @@ type worker struct {
 	priceTables     *priceTables
 	transportPoolV3 *transportPoolV3
 
+	// rhpConns tracks the connections to hosts, on shutdown the worker waits
+	// for them to be closed for at most shutdownTimeout before they are
+	// forcibly closed
+	rhpConns        *connTracker
+	shutdownTimeout time.Duration
+
 	uploadsMu            sync.Mutex
 	uploadingPackedSlabs map[string]struct{}
 
 	contractSpendingRecorder ContractSpendingRecorder
+	hostInteractionRecorder  HostInteractionRecorder
 	contractLockingDuration  time.Duration
 
 	shutdownCtx       context.Context
@@ -385,14 +408,14 @@ func (w *worker) rhpPriceTableHandler(jc jape.Context) {
 	// defer interaction recording
 	var err error
 	var hpt hostdb.HostPriceTable
+	start := time.Now()
 	defer func() {
-		w.bus.RecordPriceTables(ctx, []hostdb.PriceTableUpdate{
-			{
-				HostKey:    rptr.HostKey,
-				Success:    isSuccessfulInteraction(err),
-				Timestamp:  time.Now(),
-				PriceTable: hpt,
-			},
+		w.hostInteractionRecorder.RecordPriceTableUpdate(hostdb.PriceTableUpdate{
+			HostKey:    rptr.HostKey,
+			Success:    isSuccessfulInteraction(err),
+			Timestamp:  time.Now(),
+			Duration:   time.Since(start),
+			PriceTable: hpt,
 		})
 	}()
 
@@ -659,6 +682,19 @@ func (w *worker) rhpRenewHandler(jc jape.Context) {
 		return
 	}
 
+	// check the contract exists, renewed contracts are archived so they are
+	// not found either
+	c, err := w.bus.Contract(ctx, rrr.ContractID)
+	if errors.Is(err, api.ErrContractNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't fetch contract", err) != nil {
+		return
+	} else if c.HostKey != rrr.HostKey {
+		jc.Error(fmt.Errorf("contract %v does not belong to host %v", rrr.ContractID, rrr.HostKey), http.StatusBadRequest)
+		return
+	}
+
 	// attach gouging checker
 	gp, err := w.bus.GougingParams(ctx)
 	if jc.Check("could not get gouging parameters", err) != nil {
@@ -1030,7 +1066,7 @@ func (w *worker) objectsHandlerGET(jc jape.Context) {
 }
 
 func (w *worker) objectsHandlerPUT(jc jape.Context) {
-	jc.Custom((*[]byte)(nil), nil)
+	jc.Custom((*[]byte)(nil), api.UploadObjectResponse{})
 	ctx := jc.Request.Context()
 
 	// grab the path
@@ -1062,13 +1098,27 @@ func (w *worker) objectsHandlerPUT(jc jape.Context) {
 		return
 	}
 
+	// decode the upload mode from the query string
+	var mode string
+	if jc.DecodeForm("mode", &mode) != nil {
+		return
+	} else if err := api.ValidateUploadMode(mode); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+
 	// return early if the bucket does not exist
-	_, err = w.bus.Bucket(ctx, bucket)
+	b, err := w.bus.Bucket(ctx, bucket)
 	if err != nil && strings.Contains(err.Error(), api.ErrBucketNotFound.Error()) {
 		jc.Error(fmt.Errorf("bucket '%s' not found; %w", bucket, err), http.StatusNotFound)
 		return
+	} else if jc.Check("couldn't fetch bucket from bus", err) != nil {
+		return
 	}
 
+	// apply the bucket's overrides
+	up = up.ApplyBucketPolicy(b.Policy)
+
 	// cancel the upload if no contract set is specified
 	if up.ContractSet == "" {
 		jc.Error(api.ErrContractSetNotSpecified, http.StatusBadRequest)
@@ -1102,29 +1152,54 @@ func (w *worker) objectsHandlerPUT(jc jape.Context) {
 		}
 	}
 
+	// avoid uploading the data if the mode doesn't allow overwriting an
+	// existing object, the bus enforces the mode when adding the object
+	if mode == api.UploadModeFailIfExists || mode == api.UploadModeSkipIfExists {
+		res, err := w.bus.Object(ctx, bucket, path, api.GetObjectOptions{})
+		if err != nil && !strings.Contains(err.Error(), api.ErrObjectNotFound.Error()) {
+			jc.Check("couldn't check whether object exists", err)
+			return
+		} else if err == nil && res.Object != nil {
+			if mode == api.UploadModeFailIfExists {
+				jc.Error(api.ErrObjectExists, http.StatusConflict)
+				return
+			}
+			jc.ResponseWriter.Header().Set("ETag", api.FormatETag(res.Object.ETag))
+			jc.Encode(api.UploadObjectResponse{ETag: res.Object.ETag, Path: path, Size: res.Object.Size})
+			return
+		}
+	}
+
 	// build options
 	opts := []UploadOption{
 		WithBlockHeight(up.CurrentHeight),
 		WithContractSet(up.ContractSet),
 		WithMimeType(mimeType),
 		WithPacking(up.UploadPacking),
-		WithRedundancySettings(up.RedundancySettings),
+		WithRedundancySettings(rs),
 		WithObjectUserMetadata(metadata),
+		WithUploadMode(mode),
 	}
 
 	// attach gouging checker to the context
 	ctx = WithGougingChecker(ctx, w.bus, up.GougingParams)
 
 	// fetch contracts
-	contracts, err := w.bus.Contracts(ctx, api.ContractsOpts{ContractSet: up.ContractSet})
+	contracts, err := w.uploadContracts(ctx, up.ContractSet, rs.TotalShards)
 	if jc.Check("couldn't fetch contracts from bus", err) != nil {
 		return
 	}
 
 	// upload the object
 	params := defaultParameters(bucket, path)
-	eTag, err := w.upload(ctx, jc.Request.Body, contracts, params, opts...)
-	if err := jc.Check("couldn't upload object", err); err != nil {
+	resp, err := w.upload(ctx, jc.Request.Body, contracts, params, opts...)
+	if err != nil && strings.Contains(err.Error(), api.ErrObjectExists.Error()) {
+		jc.Error(err, http.StatusConflict)
+		return
+	} else if errors.Is(err, api.ErrInsufficientContracts) {
+		jc.Error(err, http.StatusServiceUnavailable)
+		return
+	} else if err := jc.Check("couldn't upload object", err); err != nil {
 		if err != nil {
 			w.logger.Error(err)
 			if !errors.Is(err, ErrShuttingDown) && !errors.Is(err, errUploadInterrupted) {
@@ -1134,8 +1209,20 @@ func (w *worker) objectsHandlerPUT(jc jape.Context) {
 		return
 	}
 
+	// if the object was skipped because a concurrent upload created it in the
+	// meantime, report the existing object rather than the one we uploaded
+	if mode == api.UploadModeSkipIfExists {
+		res, err := w.bus.Object(ctx, bucket, path, api.GetObjectOptions{})
+		if jc.Check("couldn't fetch object", err) != nil {
+			return
+		} else if res.Object != nil && res.Object.ETag != resp.ETag {
+			resp = api.UploadObjectResponse{ETag: res.Object.ETag, Path: path, Size: res.Object.Size}
+		}
+	}
+
 	// set etag header
-	jc.ResponseWriter.Header().Set("ETag", api.FormatETag(eTag))
+	jc.ResponseWriter.Header().Set("ETag", api.FormatETag(resp.ETag))
+	jc.Encode(resp)
 }
 
 func (w *worker) multipartUploadHandlerPUT(jc jape.Context) {
@@ -1182,12 +1269,17 @@ func (w *worker) multipartUploadHandlerPUT(jc jape.Context) {
 	}
 
 	// return early if the bucket does not exist
-	_, err = w.bus.Bucket(ctx, bucket)
+	b, err := w.bus.Bucket(ctx, bucket)
 	if err != nil && strings.Contains(err.Error(), api.ErrBucketNotFound.Error()) {
 		jc.Error(fmt.Errorf("bucket '%s' not found; %w", bucket, err), http.StatusNotFound)
 		return
+	} else if jc.Check("couldn't fetch bucket from bus", err) != nil {
+		return
 	}
 
+	// apply the bucket's overrides
+	up = up.ApplyBucketPolicy(b.Policy)
+
 	// decode the upload id
 	var uploadID string
 	if jc.DecodeForm("uploadid", &uploadID) != nil {
@@ -1238,7 +1330,7 @@ func (w *worker) multipartUploadHandlerPUT(jc jape.Context) {
 		WithBlockHeight(up.CurrentHeight),
 		WithContractSet(up.ContractSet),
 		WithPacking(up.UploadPacking),
-		WithRedundancySettings(up.RedundancySettings),
+		WithRedundancySettings(rs),
 		WithCustomKey(upload.Key),
 	}
 
@@ -1254,15 +1346,18 @@ func (w *worker) multipartUploadHandlerPUT(jc jape.Context) {
 	ctx = WithGougingChecker(ctx, w.bus, up.GougingParams)
 
 	// fetch contracts
-	contracts, err := w.bus.Contracts(ctx, api.ContractsOpts{ContractSet: up.ContractSet})
+	contracts, err := w.uploadContracts(ctx, up.ContractSet, rs.TotalShards)
 	if jc.Check("couldn't fetch contracts from bus", err) != nil {
 		return
 	}
 
 	// upload the multipart
 	params := multipartParameters(bucket, path, uploadID, partNumber)
-	eTag, err := w.upload(ctx, jc.Request.Body, contracts, params, opts...)
-	if jc.Check("couldn't upload object", err) != nil {
+	resp, err := w.upload(ctx, jc.Request.Body, contracts, params, opts...)
+	if errors.Is(err, api.ErrInsufficientContracts) {
+		jc.Error(err, http.StatusServiceUnavailable)
+		return
+	} else if jc.Check("couldn't upload object", err) != nil {
 		if err != nil {
 			w.logger.Error(err)
 			if !errors.Is(err, ErrShuttingDown) && !errors.Is(err, errUploadInterrupted) {
@@ -1273,7 +1368,7 @@ func (w *worker) multipartUploadHandlerPUT(jc jape.Context) {
 	}
 
 	// set etag header
-	jc.ResponseWriter.Header().Set("ETag", api.FormatETag(eTag))
+	jc.ResponseWriter.Header().Set("ETag", api.FormatETag(resp.ETag))
 }
 
 func (w *worker) objectsHandlerDELETE(jc jape.Context) {
@@ -1360,7 +1455,7 @@ func (w *worker) stateHandlerGET(jc jape.Context) {
 }
 
 // New returns an HTTP handler that serves the worker API.
-func New(masterKey [32]byte, id string, b Bus, contractLockingDuration, busFlushInterval, downloadOverdriveTimeout, uploadOverdriveTimeout time.Duration, downloadMaxOverdrive, uploadMaxOverdrive, downloadMaxMemory, uploadMaxMemory uint64, allowPrivateIPs bool, l *zap.Logger) (*worker, error) {
+func New(masterKey [32]byte, id string, b Bus, apt AutopilotTrigger, contractLockingDuration, busFlushInterval, downloadOverdriveTimeout, uploadOverdriveTimeout, shutdownTimeout time.Duration, downloadMaxOverdrive, uploadMaxOverdrive, downloadMaxMemory, uploadMaxMemory, uploadMaxConcurrency, interactionsFlushSize uint64, allowPrivateIPs bool, l *zap.Logger) (*worker, error) {
 	if contractLockingDuration == 0 {
 		return nil, errors.New("contract lock duration must be positive")
 	}
@@ -1373,23 +1468,32 @@ func New(masterKey [32]byte, id string, b Bus, contractLockingDuration, busFlush
 	if uploadOverdriveTimeout == 0 {
 		return nil, errors.New("upload overdrive timeout must be positive")
 	}
+	if shutdownTimeout < 0 {
+		return nil, errors.New("shutdown timeout can not be negative")
+	}
 	if downloadMaxMemory == 0 {
 		return nil, errors.New("downloadMaxMemory cannot be 0")
 	}
 	if uploadMaxMemory == 0 {
 		return nil, errors.New("uploadMaxMemory cannot be 0")
 	}
+	if uploadMaxConcurrency == 0 {
+		return nil, errors.New("uploadMaxConcurrency cannot be 0")
+	}
 
 	l = l.Named("worker").Named(id)
 	ctx, cancel := context.WithCancel(context.Background())
 	w := &worker{
 		alerts:                  alerts.WithOrigin(b, fmt.Sprintf("worker.%s", id)),
 		allowPrivateIPs:         allowPrivateIPs,
+		autopilotTrigger:        apt,
 		contractLockingDuration: contractLockingDuration,
 		id:                      id,
 		bus:                     b,
 		masterKey:               masterKey,
 		logger:                  l.Sugar(),
+		rhpConns:                newConnTracker(),
+		shutdownTimeout:         shutdownTimeout,
 		startTime:               time.Now(),
 		uploadingPackedSlabs:    make(map[string]struct{}),
 		shutdownCtx:             ctx,
@@ -1401,12 +1505,21 @@ func New(masterKey [32]byte, id string, b Bus, contractLockingDuration, busFlush
 	w.initTransportPool()
 
 	w.initDownloadManager(downloadMaxMemory, downloadMaxOverdrive, downloadOverdriveTimeout, l.Named("downloadmanager").Sugar())
-	w.initUploadManager(uploadMaxMemory, uploadMaxOverdrive, uploadOverdriveTimeout, l.Named("uploadmanager").Sugar())
+	w.initUploadManager(uploadMaxMemory, uploadMaxOverdrive, uploadMaxConcurrency, uploadOverdriveTimeout, l.Named("uploadmanager").Sugar())
 
 	w.initContractSpendingRecorder(busFlushInterval)
+	w.initHostInteractionRecorder(busFlushInterval, int(interactionsFlushSize))
 	return w, nil
 }
 
+// SubscribeToContractChanges enables caching the contracts used for uploads,
+// the cache is invalidated by the contract events of the given subscriber. It
+// should only be called if the worker runs in the same process as the bus and
+// before the worker's handler is served.
+func (w *worker) SubscribeToContractChanges(s webhooks.Subscriber) {
+	w.contractCache = newContractCache(w.bus, s)
+}
+
 // Handler returns an HTTP handler that serves the worker API.
 func (w *worker) Handler() http.Handler {
 	return jape.Mux(map[string]jape.Handler{
@@ -1450,11 +1563,39 @@ func (w *worker) Shutdown(ctx context.Context) error {
 	w.downloadManager.Stop()
 	w.uploadManager.Stop()
 
+	// wait for in-flight RHP calls to finish, hosts might not respect the
+	// cancellation so we forcibly close the connections of all calls that
+	// didn't finish in time
+	drainCtx := ctx
+	if timeout := w.rhpDrainTimeout(ctx); timeout > 0 {
+		var cancel context.CancelFunc
+		drainCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := w.rhpConns.Wait(drainCtx); err != nil {
+		inflight := w.rhpConns.CloseAll()
+		w.logger.Warnf("forcibly closed %d connections of RHP calls that were still in flight on shutdown: %v", len(inflight), inflight)
+	}
+
 	// stop recorders
 	w.contractSpendingRecorder.Stop(ctx)
+	w.hostInteractionRecorder.Stop(ctx)
 	return nil
 }
 
+// rhpDrainTimeout returns how long to wait for in-flight RHP calls to finish
+// on shutdown. If the given context has a deadline, at most half of the
+// remaining time is used so there's time left to flush the recorders.
+func (w *worker) rhpDrainTimeout(ctx context.Context) time.Duration {
+	timeout := w.shutdownTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline) / 2; timeout == 0 || remaining < timeout {
+			timeout = remaining
+		}
+	}
+	return timeout
+}
+
 func (w *worker) scanHost(ctx context.Context, timeout time.Duration, hostKey types.PublicKey, hostIP string) (rhpv2.HostSettings, rhpv3.HostPriceTable, time.Duration, error) {
 	logger := w.logger.With("host", hostKey).With("hostIP", hostIP).With("timeout", timeout)
 	// prepare a helper for scanning
@@ -1552,8 +1693,11 @@ func (w *worker) scanHost(ctx context.Context, timeout time.Duration, hostKey ty
 			HostKey:    hostKey,
 			Success:    isSuccessfulInteraction(err),
 			Timestamp:  time.Now(),
+			Duration:   duration,
 			Settings:   settings,
 			PriceTable: pt,
+
+			ResolutionFailed: isErrHostNotResolved(err),
 		},
 	})
 	if scanErr != nil {
@@ -1585,6 +1729,13 @@ func isErrHostUnreachable(err error) bool {
 		isError(err, errors.New("cannot assign requested address"))
 }
 
+// isErrHostNotResolved returns true if the error indicates the host's net
+// address couldn't be resolved.
+func isErrHostNotResolved(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) || isError(err, errors.New("no such host"))
+}
+
 func isErrDuplicateTransactionSet(err error) bool {
 	return err != nil && strings.Contains(err.Error(), modules.ErrDuplicateTransactionSet.Error())
 }